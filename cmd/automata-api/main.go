@@ -13,6 +13,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/shaiso/Automata/internal/api"
+	"github.com/shaiso/Automata/internal/config"
 	"github.com/shaiso/Automata/internal/mq"
 	"github.com/shaiso/Automata/internal/repo"
 	"github.com/shaiso/Automata/internal/telemetry"
@@ -66,17 +67,38 @@ func main() {
 	runRepo := repo.NewRunRepo(pool)
 	taskRepo := repo.NewTaskRepo(pool)
 	scheduleRepo := repo.NewScheduleRepo(pool)
+	triggerRepo := repo.NewTriggerRepo(pool)
+	notificationRepo := repo.NewNotificationRepo(pool)
 	proposalRepo := repo.NewProposalRepo(pool)
+	resourceRepo := repo.NewResourceRepo(pool)
+	descriptorSetRepo := repo.NewDescriptorSetRepo(pool)
+	wasmModuleRepo := repo.NewWasmModuleRepo(pool)
+
+	// Секрет подписи callback URL (общий с orchestrator)
+	callbackSecret, generated, err := config.LoadCallbackSecret(os.Getenv)
+	if err != nil {
+		logger.Error("failed to load callback secret", "error", err)
+		os.Exit(1)
+	}
+	if generated {
+		logger.Warn("CALLBACK_SECRET not set, using random secret (insecure dev mode): callbacks signed by other services will be rejected")
+	}
 
 	// Создаём API handler
 	handler := api.NewHandler(api.Config{
-		FlowRepo:     flowRepo,
-		RunRepo:      runRepo,
-		TaskRepo:     taskRepo,
-		ScheduleRepo: scheduleRepo,
-		ProposalRepo: proposalRepo,
-		Publisher:    publisher,
-		Logger:       logger,
+		FlowRepo:          flowRepo,
+		RunRepo:           runRepo,
+		TaskRepo:          taskRepo,
+		ScheduleRepo:      scheduleRepo,
+		TriggerRepo:       triggerRepo,
+		NotificationRepo:  notificationRepo,
+		ProposalRepo:      proposalRepo,
+		ResourceRepo:      resourceRepo,
+		DescriptorSetRepo: descriptorSetRepo,
+		WasmModuleRepo:    wasmModuleRepo,
+		Publisher:         publisher,
+		Logger:            logger,
+		CallbackSecret:    callbackSecret,
 	})
 
 	mux := http.NewServeMux()
@@ -1,5 +1,5 @@
 // Automata CLI — инструмент командной строки для управления
-// flows, runs и schedules через HTTP API.
+// flows, runs, schedules и triggers через HTTP API.
 //
 // Использование:
 //
@@ -10,6 +10,8 @@
 //	flow      Управление flows
 //	run       Управление runs
 //	schedule  Управление schedules
+//	trigger   Управление triggers
+//	notify    Управление уведомлениями о завершении runs
 package main
 
 import (
@@ -46,6 +48,11 @@ func main() {
 		cli.NewFlowCmd(clientFn, outputFn),
 		cli.NewRunCmd(clientFn, outputFn),
 		cli.NewScheduleCmd(clientFn, outputFn),
+		cli.NewTriggerCmd(clientFn, outputFn),
+		cli.NewNotifyCmd(clientFn, outputFn),
+		cli.NewResourceCmd(clientFn, outputFn),
+		cli.NewDescriptorSetCmd(clientFn, outputFn),
+		cli.NewWasmCmd(clientFn, outputFn),
 		cli.NewProposalCmd(clientFn, outputFn),
 	)
 
@@ -5,6 +5,7 @@
 //   - Парсит flow spec и строит DAG
 //   - Создаёт tasks и отправляет их workers
 //   - Отслеживает прогресс и финализирует runs
+//   - Отправляет уведомления о завершении runs (webhook, email)
 package main
 
 import (
@@ -16,8 +17,12 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/shaiso/Automata/internal/config"
+	"github.com/shaiso/Automata/internal/engine"
 	"github.com/shaiso/Automata/internal/mq"
+	"github.com/shaiso/Automata/internal/notify"
 	"github.com/shaiso/Automata/internal/orchestrator"
+	"github.com/shaiso/Automata/internal/plugin"
 	"github.com/shaiso/Automata/internal/repo"
 	"github.com/shaiso/Automata/internal/telemetry"
 )
@@ -44,6 +49,8 @@ func main() {
 	runRepo := repo.NewRunRepo(pool)
 	taskRepo := repo.NewTaskRepo(pool)
 	flowRepo := repo.NewFlowRepo(pool)
+	notificationRepo := repo.NewNotificationRepo(pool)
+	resourceRepo := repo.NewResourceRepo(pool)
 
 	// RabbitMQ
 	var publisher *mq.Publisher
@@ -68,14 +75,65 @@ func main() {
 		publisher = mq.NewPublisher(mqConn, logger)
 	}
 
+	// Callback URL для шагов wait_for_callback
+	callbackBaseURL := os.Getenv("CALLBACK_BASE_URL")
+	if callbackBaseURL == "" {
+		callbackBaseURL = "http://localhost:8080"
+	}
+	callbackSecret, generated, err := config.LoadCallbackSecret(os.Getenv)
+	if err != nil {
+		logger.Error("failed to load callback secret", "error", err)
+		os.Exit(1)
+	}
+	if generated {
+		logger.Warn("CALLBACK_SECRET not set, using random secret (insecure dev mode): callbacks signed by other services will be rejected")
+	}
+
+	// Типы шагов плагинов воркера (манифесты из AUTOMATA_PLUGIN_DIR)
+	plugins, err := plugin.Discover(os.Getenv(plugin.DirEnv))
+	if err != nil {
+		logger.Error("failed to load plugins", "error", err)
+		os.Exit(1)
+	}
+	if err := engine.RegisterStepTypes(plugin.StepTypes(plugins)...); err != nil {
+		logger.Error("failed to register plugin step types", "error", err)
+		os.Exit(1)
+	}
+	if len(plugins) > 0 {
+		logger.Info("plugin step types registered", "types", plugin.StepTypes(plugins))
+	}
+
+	// Уведомления о завершении runs (email — если задан SMTP_ADDR)
+	notifier := notify.New(notify.Config{
+		NotificationRepo: notificationRepo,
+		RunRepo:          runRepo,
+		FlowRepo:         flowRepo,
+		SMTP: notify.SMTPConfig{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			From:     os.Getenv("SMTP_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		},
+		Logger: logger,
+	})
+	if os.Getenv("SMTP_ADDR") == "" {
+		logger.Warn("SMTP_ADDR not set, email notifications disabled")
+	}
+
 	// Создаём orchestrator
 	orch := orchestrator.New(orchestrator.Config{
-		RunRepo:  runRepo,
-		TaskRepo: taskRepo,
-		FlowRepo: flowRepo,
-		Publisher: publisher,
-		Conn:     mqConn,
-		Logger:   logger,
+		RunRepo:      runRepo,
+		TaskRepo:     taskRepo,
+		FlowRepo:     flowRepo,
+		ResourceRepo: resourceRepo,
+		Publisher:    publisher,
+		Conn:         mqConn,
+		Logger:       logger,
+
+		CallbackBaseURL: callbackBaseURL,
+		CallbackSecret:  callbackSecret,
+
+		Notifier: notifier,
 	})
 
 	// Запускаем orchestrator
@@ -111,4 +169,4 @@ func main() {
 	// Останавливаем orchestrator
 	orch.Stop()
 	logger.Info("automata-orchestrator stopped")
-}
\ No newline at end of file
+}
@@ -2,7 +2,8 @@
 //
 // Worker:
 //   - Получает tasks из RabbitMQ
-//   - Выполняет в зависимости от типа (http, delay, transform)
+//   - Выполняет в зависимости от типа (http, delay, transform, poll, sql, amqp_publish, email, grpc,
+//     script, wasm и типы плагинов)
 //   - Реализует retry с exponential backoff
 //   - Отправляет результат обратно
 //
@@ -18,7 +19,10 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/shaiso/Automata/internal/config"
+	"github.com/shaiso/Automata/internal/engine"
 	"github.com/shaiso/Automata/internal/mq"
+	"github.com/shaiso/Automata/internal/plugin"
 	"github.com/shaiso/Automata/internal/repo"
 	"github.com/shaiso/Automata/internal/telemetry"
 	"github.com/shaiso/Automata/internal/worker"
@@ -46,6 +50,8 @@ func main() {
 	taskRepo := repo.NewTaskRepo(pool)
 	runRepo := repo.NewRunRepo(pool)
 	flowRepo := repo.NewFlowRepo(pool)
+	descriptorSetRepo := repo.NewDescriptorSetRepo(pool)
+	wasmModuleRepo := repo.NewWasmModuleRepo(pool)
 
 	// RabbitMQ
 	var publisher *mq.Publisher
@@ -70,14 +76,43 @@ func main() {
 		publisher = mq.NewPublisher(mqConn, logger)
 	}
 
+	// Именованные подключения для шагов (AUTOMATA_CONN_<NAME>)
+	connections, err := config.LoadConnections(os.Environ())
+	if err != nil {
+		logger.Error("failed to load connections", "error", err)
+		os.Exit(1)
+	}
+	if names := connections.Names(); len(names) > 0 {
+		logger.Info("connections loaded", "names", names)
+	}
+
+	// Плагины с дополнительными типами шагов (AUTOMATA_PLUGIN_DIR)
+	plugins, err := plugin.Discover(os.Getenv(plugin.DirEnv))
+	if err != nil {
+		logger.Error("failed to load plugins", "error", err)
+		os.Exit(1)
+	}
+	// Типы плагинов не должны переопределять встроенные
+	if err := engine.RegisterStepTypes(plugin.StepTypes(plugins)...); err != nil {
+		logger.Error("failed to register plugin step types", "error", err)
+		os.Exit(1)
+	}
+	for _, m := range plugins {
+		logger.Info("plugin loaded", "plugin", m.Name, "version", m.Version, "step_types", m.StepTypes)
+	}
+
 	// Создаём worker
 	w := worker.New(worker.Config{
-		TaskRepo:  taskRepo,
-		RunRepo:   runRepo,
-		FlowRepo:  flowRepo,
-		Publisher: publisher,
-		Conn:      mqConn,
-		Logger:    logger,
+		TaskRepo:          taskRepo,
+		RunRepo:           runRepo,
+		FlowRepo:          flowRepo,
+		Publisher:         publisher,
+		Conn:              mqConn,
+		Connections:       connections,
+		DescriptorSetRepo: descriptorSetRepo,
+		WasmModuleRepo:    wasmModuleRepo,
+		Plugins:           plugins,
+		Logger:            logger,
 	})
 
 	// Запускаем worker
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/mq"
+)
+
+// maxCallbackBodySize — максимальный размер тела callback (1 MB).
+const maxCallbackBodySize = 1 << 20
+
+// HandleCallback принимает callback от внешней системы для шага wait_for_callback.
+// Тело и заголовки запроса становятся outputs шага, run продолжает выполнение.
+// POST /api/v1/callbacks/{run_id}/{step}?token=...
+func (h *Handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	runID, err := uuid.Parse(r.PathValue("run_id"))
+	if err != nil {
+		BadRequest(w, "invalid run id")
+		return
+	}
+	stepID := r.PathValue("step")
+
+	if !h.callbacks.Verify(runID, stepID, r.URL.Query().Get("token")) {
+		Forbidden(w, "invalid callback token")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			BadRequest(w, "callback body too large")
+			return
+		}
+		BadRequest(w, "failed to read callback body")
+		return
+	}
+
+	run, err := h.runRepo.GetByID(r.Context(), runID)
+	if HandleRepoError(w, h.logger, err, "run not found") {
+		return
+	}
+
+	if run.Status != domain.RunStatusRunning {
+		InvalidState(w, "run is not running")
+		return
+	}
+
+	task, err := h.taskRepo.GetByRunAndStepID(r.Context(), runID, stepID)
+	if HandleRepoError(w, h.logger, err, "step is not waiting for a callback") {
+		return
+	}
+
+	if !task.IsWaiting() || task.Type != engine.StepTypeWaitForCallback {
+		InvalidState(w, "step is not waiting for a callback")
+		return
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	engine.ApplyCallback(task, engine.CallbackRequest{
+		Method:  r.Method,
+		Headers: headers,
+		Body:    body,
+	})
+
+	// ErrInvalidState — callback уже получен или истёк timeout
+	if HandleRepoError(w, h.logger, h.taskRepo.CompleteWaiting(r.Context(), task), "") {
+		return
+	}
+
+	// Уведомляем Orchestrator — шаг завершён
+	if h.publisher != nil {
+		payload := mq.TaskCompletedPayload{
+			TaskID:  task.ID,
+			RunID:   task.RunID,
+			StepID:  task.StepID,
+			Status:  string(task.Status),
+			Attempt: task.Attempt,
+		}
+		if err := h.publisher.PublishTaskCompleted(r.Context(), payload); err != nil {
+			h.logger.Warn("failed to publish task.completed", "task_id", task.ID, "error", err)
+		}
+	}
+
+	h.logger.Info("callback received", "run_id", runID, "step_id", stepID)
+
+	Success(w, map[string]any{"run_id": runID, "step_id": stepID, "status": "accepted"})
+}
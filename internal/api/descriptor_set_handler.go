@@ -0,0 +1,100 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/grpcschema"
+)
+
+// maxDescriptorSetSize — ограничение размера тела загрузки descriptor set.
+const maxDescriptorSetSize = 8 << 20
+
+// ListDescriptorSets возвращает загруженные descriptor sets.
+// GET /api/v1/descriptor-sets
+func (h *Handler) ListDescriptorSets(w http.ResponseWriter, r *http.Request) {
+	sets, err := h.descriptorSetRepo.List(r.Context())
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	result := make([]DescriptorSetResponse, len(sets))
+	for i := range sets {
+		result[i] = DescriptorSetFromDomain(&sets[i])
+	}
+
+	List(w, result, len(result))
+}
+
+// PutDescriptorSet загружает FileDescriptorSet под именем name (повторная
+// загрузка заменяет набор). Набор разбирается при загрузке: все
+// зависимости должны быть в нём (protoc --include_imports) или быть
+// well-known types.
+// PUT /api/v1/descriptor-sets/{name}
+func (h *Handler) PutDescriptorSet(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var req UploadDescriptorSetRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDescriptorSetSize)).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+	if len(req.Data) == 0 {
+		BadRequest(w, "data is required")
+		return
+	}
+
+	schema, err := grpcschema.Parse(req.Data)
+	if err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+
+	sum := sha256.Sum256(req.Data)
+	now := time.Now()
+	set := &domain.DescriptorSet{
+		Name:      name,
+		Data:      req.Data,
+		SHA256:    hex.EncodeToString(sum[:]),
+		Services:  schema.Services(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.descriptorSetRepo.Put(r.Context(), set); err != nil {
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	Success(w, DescriptorSetFromDomain(set))
+}
+
+// GetDescriptorSet возвращает descriptor set (сервисы и дайджест).
+// GET /api/v1/descriptor-sets/{name}
+func (h *Handler) GetDescriptorSet(w http.ResponseWriter, r *http.Request) {
+	set, err := h.descriptorSetRepo.GetByName(r.Context(), r.PathValue("name"))
+	if HandleRepoError(w, h.logger, err, "descriptor set not found") {
+		return
+	}
+
+	Success(w, DescriptorSetFromDomain(set))
+}
+
+// DeleteDescriptorSet удаляет descriptor set. Шаги, ссылающиеся на него,
+// будут завершаться ошибкой.
+// DELETE /api/v1/descriptor-sets/{name}
+func (h *Handler) DeleteDescriptorSet(w http.ResponseWriter, r *http.Request) {
+	if err := h.descriptorSetRepo.Delete(r.Context(), r.PathValue("name")); err != nil {
+		if HandleRepoError(w, h.logger, err, "descriptor set not found") {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	NoContent(w)
+}
@@ -7,9 +7,17 @@
 //   - response.go         — унифицированные JSON-ответы и обработка ошибок
 //   - dto.go              — Data Transfer Objects (request/response)
 //   - flow_handler.go     — обработчики для /flows
-//   - run_handler.go      — обработчики для /runs
+//   - run_handler.go      — обработчики для /runs (включая сигналы шагам и retry)
+//   - callback_handler.go — приём callbacks для шагов wait_for_callback
 //   - schedule_handler.go — обработчики для /schedules
+//   - trigger_handler.go  — обработчики для /triggers
+//   - hook_handler.go     — приём webhooks (/hooks/{trigger_id})
+//   - notification_handler.go — обработчики для /notification-rules
+//   - resource_handler.go — обработчики для /resources (мьютексы / семафоры шагов)
+//   - descriptor_set_handler.go — обработчики для /descriptor-sets (схемы gRPC для шага grpc)
+//   - wasm_module_handler.go — обработчики для /wasm-modules (модули шага wasm)
+//   - external_task_handler.go — fetch-and-lock / complete / fail для шага external
 //   - proposal_handler.go — обработчики для /proposals (PR-workflow + sandbox)
 //
-// API предоставляет REST endpoints для управления flows, runs, schedules и proposals.
+// API предоставляет REST endpoints для управления flows, runs, schedules, triggers и proposals.
 package api
@@ -5,6 +5,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/trigger"
 )
 
 // Flow DTOs
@@ -73,6 +75,12 @@ type CreateRunRequest struct {
 	IsSandbox      bool           `json:"is_sandbox,omitempty"`
 }
 
+// RetryRunRequest — запрос на повторный запуск run.
+// Без FromStep повторяются только упавшие и не выполненные шаги.
+type RetryRunRequest struct {
+	FromStep string `json:"from_step,omitempty"`
+}
+
 // RunResponse — ответ с run.
 type RunResponse struct {
 	ID             uuid.UUID      `json:"id"`
@@ -83,8 +91,16 @@ type RunResponse struct {
 	StartedAt      *time.Time     `json:"started_at,omitempty"`
 	FinishedAt     *time.Time     `json:"finished_at,omitempty"`
 	Error          string         `json:"error,omitempty"`
+	Outputs        map[string]any `json:"outputs,omitempty"`
+	DeadlineAt     *time.Time     `json:"deadline_at,omitempty"`
+	SLAAt          *time.Time     `json:"sla_at,omitempty"`
+	SLABreachedAt  *time.Time     `json:"sla_breached_at,omitempty"`
+	ConcurrencyKey string         `json:"concurrency_key,omitempty"`
+	WaitingReason  string         `json:"waiting_reason,omitempty"`
 	IdempotencyKey string         `json:"idempotency_key,omitempty"`
 	IsSandbox      bool           `json:"is_sandbox"`
+	RetryOf        *uuid.UUID     `json:"retry_of,omitempty"`
+	RetryFromStep  string         `json:"retry_from_step,omitempty"`
 	CreatedAt      time.Time      `json:"created_at"`
 }
 
@@ -99,8 +115,16 @@ func RunFromDomain(r domain.Run) RunResponse {
 		StartedAt:      r.StartedAt,
 		FinishedAt:     r.FinishedAt,
 		Error:          r.Error,
+		Outputs:        r.Outputs,
+		DeadlineAt:     r.DeadlineAt,
+		SLAAt:          r.SLAAt,
+		SLABreachedAt:  r.SLABreachedAt,
+		ConcurrencyKey: r.ConcurrencyKey,
+		WaitingReason:  r.WaitingReason,
 		IdempotencyKey: r.IdempotencyKey,
 		IsSandbox:      r.IsSandbox,
+		RetryOf:        r.RetryOf,
+		RetryFromStep:  r.RetryFromStep,
 		CreatedAt:      r.CreatedAt,
 	}
 }
@@ -143,6 +167,40 @@ func TaskFromDomain(t domain.Task) TaskResponse {
 	}
 }
 
+// Signal DTOs
+
+// SignalRequest — сигнал для шага approval / wait_for_signal.
+type SignalRequest struct {
+	Decision string         `json:"decision"`
+	Payload  map[string]any `json:"payload,omitempty"`
+}
+
+// WaitingStepResponse — шаг, ожидающий сигнала.
+type WaitingStepResponse struct {
+	TaskID       uuid.UUID      `json:"task_id"`
+	RunID        uuid.UUID      `json:"run_id"`
+	StepID       string         `json:"step_id"`
+	Name         string         `json:"name"`
+	Type         string         `json:"type"`
+	Config       map[string]any `json:"config,omitempty"`
+	WaitingSince *time.Time     `json:"waiting_since,omitempty"`
+	DeadlineAt   *time.Time     `json:"deadline_at,omitempty"`
+}
+
+// WaitingStepFromDomain конвертирует WAITING domain.Task в WaitingStepResponse.
+func WaitingStepFromDomain(t domain.Task) WaitingStepResponse {
+	return WaitingStepResponse{
+		TaskID:       t.ID,
+		RunID:        t.RunID,
+		StepID:       t.StepID,
+		Name:         t.Name,
+		Type:         t.Type,
+		Config:       t.Payload,
+		WaitingSince: t.StartedAt,
+		DeadlineAt:   t.NextAttemptAt,
+	}
+}
+
 // Proposal DTOs
 
 // CreateProposalRequest — запрос на создание proposal.
@@ -274,3 +332,362 @@ func ScheduleFromDomain(s *domain.Schedule) ScheduleResponse {
 		UpdatedAt:   s.UpdatedAt,
 	}
 }
+
+// Trigger DTOs
+
+// CreateTriggerRequest — запрос на создание trigger.
+type CreateTriggerRequest struct {
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	Config       domain.TriggerConfig `json:"config"`
+	Filter       string               `json:"filter,omitempty"`
+	InputMapping map[string]string    `json:"input_mapping,omitempty"`
+	Enabled      *bool                `json:"enabled,omitempty"`
+}
+
+// UpdateTriggerRequest — запрос на обновление trigger.
+type UpdateTriggerRequest struct {
+	Name         *string               `json:"name,omitempty"`
+	Config       *domain.TriggerConfig `json:"config,omitempty"`
+	Filter       *string               `json:"filter,omitempty"`
+	InputMapping *map[string]string    `json:"input_mapping,omitempty"`
+}
+
+// TriggerResponse — ответ с trigger.
+type TriggerResponse struct {
+	ID           uuid.UUID            `json:"id"`
+	FlowID       uuid.UUID            `json:"flow_id"`
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	Config       domain.TriggerConfig `json:"config"`
+	Filter       string               `json:"filter,omitempty"`
+	InputMapping map[string]string    `json:"input_mapping,omitempty"`
+	Enabled      bool                 `json:"enabled"`
+	HookURL      string               `json:"hook_url,omitempty"`
+	LastFiredAt  *time.Time           `json:"last_fired_at,omitempty"`
+	LastRunID    *uuid.UUID           `json:"last_run_id,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+// TriggerFromDomain конвертирует domain.Trigger в TriggerResponse.
+func TriggerFromDomain(t *domain.Trigger) TriggerResponse {
+	if t == nil {
+		return TriggerResponse{}
+	}
+	var hookURL string
+	if t.Type == domain.TriggerTypeWebhook {
+		hookURL = trigger.HookPathPrefix + t.ID.String()
+	}
+	return TriggerResponse{
+		ID:           t.ID,
+		FlowID:       t.FlowID,
+		Name:         t.Name,
+		Type:         string(t.Type),
+		Config:       maskTriggerSecret(t.Config),
+		Filter:       t.Filter,
+		InputMapping: t.InputMapping,
+		Enabled:      t.Enabled,
+		HookURL:      hookURL,
+		LastFiredAt:  t.LastFiredAt,
+		LastRunID:    t.LastRunID,
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+	}
+}
+
+// maskedSecret — значение секрета триггера в ответах API.
+const maskedSecret = "********"
+
+// maskTriggerSecret скрывает секрет подписи webhook.
+func maskTriggerSecret(cfg domain.TriggerConfig) domain.TriggerConfig {
+	if cfg.Secret != "" {
+		cfg.Secret = maskedSecret
+	}
+	return cfg
+}
+
+// HookResponse — ответ на webhook запрос.
+type HookResponse struct {
+	RunID     *uuid.UUID     `json:"run_id,omitempty"`
+	Status    string         `json:"status"`
+	Duplicate bool           `json:"duplicate,omitempty"`
+	Outputs   map[string]any `json:"outputs,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// Notification DTOs
+
+// CreateNotificationRuleRequest — запрос на создание правила уведомления.
+type CreateNotificationRuleRequest struct {
+	Name      string                    `json:"name"`
+	On        string                    `json:"on"`
+	Threshold int                       `json:"threshold,omitempty"`
+	Channel   string                    `json:"channel"`
+	Config    domain.NotificationConfig `json:"config"`
+	Enabled   *bool                     `json:"enabled,omitempty"`
+}
+
+// UpdateNotificationRuleRequest — запрос на обновление правила уведомления.
+type UpdateNotificationRuleRequest struct {
+	Name      *string                    `json:"name,omitempty"`
+	On        *string                    `json:"on,omitempty"`
+	Threshold *int                       `json:"threshold,omitempty"`
+	Channel   *string                    `json:"channel,omitempty"`
+	Config    *domain.NotificationConfig `json:"config,omitempty"`
+}
+
+// NotificationRuleResponse — ответ с правилом уведомления.
+type NotificationRuleResponse struct {
+	ID        uuid.UUID                 `json:"id"`
+	FlowID    uuid.UUID                 `json:"flow_id"`
+	Name      string                    `json:"name"`
+	On        string                    `json:"on"`
+	Threshold int                       `json:"threshold,omitempty"`
+	Channel   string                    `json:"channel"`
+	Config    domain.NotificationConfig `json:"config"`
+	Enabled   bool                      `json:"enabled"`
+	CreatedAt time.Time                 `json:"created_at"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+}
+
+// NotificationRuleFromDomain конвертирует domain.NotificationRule в NotificationRuleResponse.
+// Значения заголовков webhook (токены) маскируются.
+func NotificationRuleFromDomain(rule *domain.NotificationRule) NotificationRuleResponse {
+	if rule == nil {
+		return NotificationRuleResponse{}
+	}
+
+	cfg := rule.Config
+	if len(cfg.Headers) > 0 {
+		cfg.Headers = make(map[string]string, len(rule.Config.Headers))
+		for k := range rule.Config.Headers {
+			cfg.Headers[k] = maskedSecret
+		}
+	}
+
+	return NotificationRuleResponse{
+		ID:        rule.ID,
+		FlowID:    rule.FlowID,
+		Name:      rule.Name,
+		On:        string(rule.On),
+		Threshold: rule.Threshold,
+		Channel:   string(rule.Channel),
+		Config:    cfg,
+		Enabled:   rule.Enabled,
+		CreatedAt: rule.CreatedAt,
+		UpdatedAt: rule.UpdatedAt,
+	}
+}
+
+// NotificationDeliveryResponse — запись истории доставки уведомления.
+type NotificationDeliveryResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	RuleID        uuid.UUID  `json:"rule_id"`
+	RunID         uuid.UUID  `json:"run_id"`
+	Status        string     `json:"status"`
+	Subject       string     `json:"subject,omitempty"`
+	Body          string     `json:"body"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// NotificationDeliveryFromDomain конвертирует domain.NotificationDelivery в NotificationDeliveryResponse.
+func NotificationDeliveryFromDomain(d domain.NotificationDelivery) NotificationDeliveryResponse {
+	return NotificationDeliveryResponse{
+		ID:            d.ID,
+		RuleID:        d.RuleID,
+		RunID:         d.RunID,
+		Status:        string(d.Status),
+		Subject:       d.Subject,
+		Body:          d.Body,
+		Attempts:      d.Attempts,
+		LastError:     d.LastError,
+		NextAttemptAt: d.NextAttemptAt,
+		SentAt:        d.SentAt,
+		CreatedAt:     d.CreatedAt,
+	}
+}
+
+// Resource DTOs
+
+// CreateResourceRequest — запрос на создание ресурса.
+type CreateResourceRequest struct {
+	Name        string `json:"name"`
+	Capacity    int    `json:"capacity"`
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateResourceRequest — запрос на обновление ресурса.
+type UpdateResourceRequest struct {
+	Capacity    *int    `json:"capacity,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// ResourceResponse — ответ с ресурсом.
+// Holders и Waiters заполняются только для GET /resources/{name}.
+type ResourceResponse struct {
+	Name        string                  `json:"name"`
+	Capacity    int                     `json:"capacity"`
+	Description string                  `json:"description,omitempty"`
+	InUse       int                     `json:"in_use"`
+	Waiting     int                     `json:"waiting"`
+	Holders     []domain.ResourceLease  `json:"holders,omitempty"`
+	Waiters     []domain.ResourceWaiter `json:"waiters,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// ResourceFromDomain конвертирует domain.Resource в ResourceResponse.
+func ResourceFromDomain(res *domain.Resource) ResourceResponse {
+	if res == nil {
+		return ResourceResponse{}
+	}
+	return ResourceResponse{
+		Name:        res.Name,
+		Capacity:    res.Capacity,
+		Description: res.Description,
+		InUse:       res.InUse,
+		Waiting:     res.Waiting,
+		CreatedAt:   res.CreatedAt,
+		UpdatedAt:   res.UpdatedAt,
+	}
+}
+
+// Descriptor set DTOs
+
+// UploadDescriptorSetRequest — запрос на загрузку descriptor set.
+// Data — сериализованный FileDescriptorSet (в JSON — base64).
+type UploadDescriptorSetRequest struct {
+	Data []byte `json:"data"`
+}
+
+// DescriptorSetResponse — ответ с descriptor set (без данных).
+type DescriptorSetResponse struct {
+	Name      string    `json:"name"`
+	SHA256    string    `json:"sha256"`
+	Services  []string  `json:"services"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DescriptorSetFromDomain конвертирует domain.DescriptorSet в DescriptorSetResponse.
+func DescriptorSetFromDomain(set *domain.DescriptorSet) DescriptorSetResponse {
+	if set == nil {
+		return DescriptorSetResponse{}
+	}
+	return DescriptorSetResponse{
+		Name:      set.Name,
+		SHA256:    set.SHA256,
+		Services:  set.Services,
+		CreatedAt: set.CreatedAt,
+		UpdatedAt: set.UpdatedAt,
+	}
+}
+
+// Wasm module DTOs
+
+// UploadWasmModuleRequest — запрос на загрузку WebAssembly-модуля.
+// Data — содержимое модуля (в JSON — base64).
+type UploadWasmModuleRequest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Data    []byte `json:"data"`
+}
+
+// WasmModuleResponse — ответ с модулем (без содержимого).
+type WasmModuleResponse struct {
+	Digest    string    `json:"digest"`
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WasmModuleFromDomain конвертирует domain.WasmModule в WasmModuleResponse.
+func WasmModuleFromDomain(mod *domain.WasmModule) WasmModuleResponse {
+	if mod == nil {
+		return WasmModuleResponse{}
+	}
+	return WasmModuleResponse{
+		Digest:    mod.Digest,
+		Name:      mod.Name,
+		Version:   mod.Version,
+		Size:      mod.Size,
+		CreatedAt: mod.CreatedAt,
+	}
+}
+
+// External task DTOs
+
+// FetchAndLockRequest — запрос внешнего воркера на получение tasks.
+type FetchAndLockRequest struct {
+	WorkerID       string `json:"worker_id"`
+	Topic          string `json:"topic"`
+	MaxTasks       int    `json:"max_tasks,omitempty"`
+	LockDurationMs int64  `json:"lock_duration_ms"`
+	WaitMs         int64  `json:"wait_ms,omitempty"`
+}
+
+// CompleteExternalTaskRequest — успешное завершение task.
+type CompleteExternalTaskRequest struct {
+	WorkerID string         `json:"worker_id"`
+	Outputs  map[string]any `json:"outputs,omitempty"`
+}
+
+// FailExternalTaskRequest — неудачная попытка выполнения task.
+// Permanent отключает повторы по RetryPolicy шага.
+type FailExternalTaskRequest struct {
+	WorkerID  string `json:"worker_id"`
+	Error     string `json:"error"`
+	Permanent bool   `json:"permanent,omitempty"`
+}
+
+// ExtendExternalLockRequest — продление блокировки task.
+type ExtendExternalLockRequest struct {
+	WorkerID       string `json:"worker_id"`
+	LockDurationMs int64  `json:"lock_duration_ms"`
+}
+
+// FailExternalTaskResponse — результат fail: task поставлен на повтор
+// (QUEUED, RetryAt) или завершён (FAILED).
+type FailExternalTaskResponse struct {
+	TaskID  uuid.UUID  `json:"task_id"`
+	Status  string     `json:"status"`
+	Attempt int        `json:"attempt"`
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+// ExternalTaskResponse — task, заблокированный за внешним воркером.
+type ExternalTaskResponse struct {
+	ID            uuid.UUID      `json:"id"`
+	RunID         uuid.UUID      `json:"run_id"`
+	StepID        string         `json:"step_id"`
+	Topic         string         `json:"topic"`
+	Attempt       int            `json:"attempt"`
+	Config        map[string]any `json:"config"`
+	Context       any            `json:"context,omitempty"`
+	LockExpiresAt *time.Time     `json:"lock_expires_at,omitempty"`
+}
+
+// ExternalTaskFromDomain конвертирует заблокированный domain.Task в ExternalTaskResponse.
+// Config — переменные шага (config без topic), Context — контекст run.
+func ExternalTaskFromDomain(t domain.Task) ExternalTaskResponse {
+	resp := ExternalTaskResponse{
+		ID:            t.ID,
+		RunID:         t.RunID,
+		StepID:        t.StepID,
+		Attempt:       t.Attempt,
+		Config:        map[string]any{},
+		Context:       t.Payload[engine.StepGlobalsKey],
+		LockExpiresAt: t.NextAttemptAt,
+	}
+	if cfg, err := engine.ParseExternalConfig(t.Payload); err == nil {
+		resp.Topic = cfg.Topic
+		resp.Config = cfg.Variables
+	}
+	return resp
+}
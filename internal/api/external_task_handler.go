@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/mq"
+	"github.com/shaiso/Automata/internal/repo"
+)
+
+const (
+	// maxExternalTasks — сколько tasks можно забрать за один fetch-and-lock.
+	maxExternalTasks = 100
+
+	// maxExternalLock — максимальная длительность блокировки task.
+	maxExternalLock = time.Hour
+
+	// maxExternalWait — максимальное время long-poll.
+	maxExternalWait = time.Minute
+
+	// externalPollInterval — период проверки очереди во время long-poll.
+	externalPollInterval = time.Second
+)
+
+// FetchAndLockExternalTasks блокирует за внешним воркером tasks external
+// шагов темы topic. Если tasks нет, запрос ждёт их до wait_ms (long-poll).
+// POST /api/v1/external-tasks/fetch-and-lock
+func (h *Handler) FetchAndLockExternalTasks(w http.ResponseWriter, r *http.Request) {
+	var req FetchAndLockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	if req.WorkerID == "" {
+		BadRequest(w, "worker_id is required")
+		return
+	}
+	if req.Topic == "" {
+		BadRequest(w, "topic is required")
+		return
+	}
+	if req.MaxTasks == 0 {
+		req.MaxTasks = 1
+	}
+	if req.MaxTasks < 0 || req.MaxTasks > maxExternalTasks {
+		BadRequest(w, fmt.Sprintf("max_tasks must be between 1 and %d", maxExternalTasks))
+		return
+	}
+	lockDuration, ok := externalLockDuration(w, req.LockDurationMs)
+	if !ok {
+		return
+	}
+	wait := time.Duration(req.WaitMs) * time.Millisecond
+	if wait < 0 || wait > maxExternalWait {
+		BadRequest(w, fmt.Sprintf("wait_ms must be between 0 and %d", maxExternalWait.Milliseconds()))
+		return
+	}
+
+	tasks, err := h.pollExternalTasks(r.Context(), req, lockDuration, wait)
+	if err != nil {
+		// Клиент закрыл соединение — отвечать некому
+		if r.Context().Err() != nil {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	resp := make([]ExternalTaskResponse, len(tasks))
+	for i, t := range tasks {
+		resp[i] = ExternalTaskFromDomain(t)
+	}
+
+	if len(tasks) > 0 {
+		h.logger.Info("external tasks locked",
+			"worker_id", req.WorkerID,
+			"topic", req.Topic,
+			"count", len(tasks),
+		)
+	}
+
+	List(w, resp, len(resp))
+}
+
+// pollExternalTasks забирает tasks, пока они не появятся или не истечёт wait.
+func (h *Handler) pollExternalTasks(ctx context.Context, req FetchAndLockRequest, lockDuration, wait time.Duration) ([]domain.Task, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		tasks, err := h.taskRepo.FetchAndLockExternal(ctx, req.Topic, req.WorkerID, lockDuration, req.MaxTasks)
+		if err != nil {
+			return nil, err
+		}
+		remaining := time.Until(deadline)
+		if len(tasks) > 0 || remaining <= 0 {
+			return tasks, nil
+		}
+
+		select {
+		case <-time.After(min(externalPollInterval, remaining)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// CompleteExternalTask завершает task external шага успешно: outputs
+// становятся outputs шага, run продолжает выполнение.
+// POST /api/v1/external-tasks/{id}/complete
+func (h *Handler) CompleteExternalTask(w http.ResponseWriter, r *http.Request) {
+	var req CompleteExternalTaskRequest
+	task, ok := h.lockedExternalTask(w, r, &req, &req.WorkerID)
+	if !ok {
+		return
+	}
+
+	task.MarkSucceeded(req.Outputs)
+	task.NextAttemptAt = nil
+	if !h.releaseExternalTask(w, r, task, req.WorkerID) {
+		return
+	}
+
+	h.logger.Info("external task completed",
+		"task_id", task.ID,
+		"run_id", task.RunID,
+		"step_id", task.StepID,
+		"worker_id", req.WorkerID,
+	)
+
+	Success(w, TaskFromDomain(*task))
+}
+
+// FailExternalTask сообщает о неудачной попытке task external шага.
+// Если RetryPolicy шага допускает повтор (и ошибка не permanent), task
+// возвращается в очередь с backoff, иначе шаг завершается с ошибкой.
+// POST /api/v1/external-tasks/{id}/fail
+func (h *Handler) FailExternalTask(w http.ResponseWriter, r *http.Request) {
+	var req FailExternalTaskRequest
+	task, ok := h.lockedExternalTask(w, r, &req, &req.WorkerID)
+	if !ok {
+		return
+	}
+
+	if req.Error == "" {
+		BadRequest(w, "error is required")
+		return
+	}
+
+	policy, err := h.stepRetryPolicy(r.Context(), task)
+	if err != nil {
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	retried := engine.ApplyExternalFailure(task, policy, req.Error, req.Permanent, time.Now())
+	if retried {
+		// Повтор — шаг продолжается, Orchestrator не уведомляется
+		if HandleRepoError(w, h.logger, h.taskRepo.ReleaseExternal(r.Context(), task, req.WorkerID), "") {
+			return
+		}
+	} else if !h.releaseExternalTask(w, r, task, req.WorkerID) {
+		return
+	}
+
+	h.logger.Warn("external task failed",
+		"task_id", task.ID,
+		"run_id", task.RunID,
+		"step_id", task.StepID,
+		"worker_id", req.WorkerID,
+		"attempt", task.Attempt,
+		"retry", retried,
+		"error", req.Error,
+	)
+
+	resp := FailExternalTaskResponse{
+		TaskID:  task.ID,
+		Status:  string(task.Status),
+		Attempt: task.Attempt,
+	}
+	if retried {
+		resp.RetryAt = task.NextAttemptAt
+	}
+	Success(w, resp)
+}
+
+// ExtendExternalTaskLock продлевает блокировку task на lock_duration_ms
+// от текущего момента.
+// POST /api/v1/external-tasks/{id}/extend-lock
+func (h *Handler) ExtendExternalTaskLock(w http.ResponseWriter, r *http.Request) {
+	var req ExtendExternalLockRequest
+	task, ok := h.lockedExternalTask(w, r, &req, &req.WorkerID)
+	if !ok {
+		return
+	}
+
+	lockDuration, ok := externalLockDuration(w, req.LockDurationMs)
+	if !ok {
+		return
+	}
+
+	until := time.Now().Add(lockDuration)
+	err := h.taskRepo.ExtendExternalLock(r.Context(), task.ID, req.WorkerID, until)
+	if errors.Is(err, repo.ErrInvalidState) {
+		InvalidState(w, "task is not locked by this worker")
+		return
+	}
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	Success(w, map[string]any{"task_id": task.ID, "lock_expires_at": until})
+}
+
+// lockedExternalTask разбирает тело запроса в req и загружает RUNNING
+// task external шага из пути запроса. workerID — поле worker_id в req.
+func (h *Handler) lockedExternalTask(w http.ResponseWriter, r *http.Request, req any, workerID *string) (*domain.Task, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid task id")
+		return nil, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		BadRequest(w, "invalid request body")
+		return nil, false
+	}
+	if *workerID == "" {
+		BadRequest(w, "worker_id is required")
+		return nil, false
+	}
+
+	task, err := h.taskRepo.GetByID(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "task not found") {
+		return nil, false
+	}
+
+	if task.Type != engine.StepTypeExternal {
+		InvalidState(w, "task is not an external task")
+		return nil, false
+	}
+	if task.Status != domain.TaskStatusRunning {
+		InvalidState(w, "task is not locked by this worker")
+		return nil, false
+	}
+	return task, true
+}
+
+// releaseExternalTask сохраняет завершённый task, снимает блокировку
+// и уведомляет Orchestrator.
+func (h *Handler) releaseExternalTask(w http.ResponseWriter, r *http.Request, task *domain.Task, workerID string) bool {
+	// ErrInvalidState — блокировка истекла или task забрал другой воркер
+	err := h.taskRepo.ReleaseExternal(r.Context(), task, workerID)
+	if errors.Is(err, repo.ErrInvalidState) {
+		InvalidState(w, "task is not locked by this worker")
+		return false
+	}
+	if HandleRepoError(w, h.logger, err, "") {
+		return false
+	}
+
+	// Уведомляем Orchestrator — шаг завершён
+	if h.publisher != nil {
+		payload := mq.TaskCompletedPayload{
+			TaskID:  task.ID,
+			RunID:   task.RunID,
+			StepID:  task.StepID,
+			Status:  string(task.Status),
+			Error:   task.Error,
+			Attempt: task.Attempt,
+		}
+		if err := h.publisher.PublishTaskCompleted(r.Context(), payload); err != nil {
+			h.logger.Warn("failed to publish task.completed", "task_id", task.ID, "error", err)
+		}
+	}
+	return true
+}
+
+// stepRetryPolicy возвращает RetryPolicy шага task по spec его run
+// (для sandbox runs — по проверяемой spec).
+func (h *Handler) stepRetryPolicy(ctx context.Context, task *domain.Task) (*domain.RetryPolicy, error) {
+	run, err := h.runRepo.GetByID(ctx, task.RunID)
+	if err != nil {
+		return nil, fmt.Errorf("get run: %w", err)
+	}
+	if run.SpecOverride != nil {
+		return engine.StepRetryPolicy(run.SpecOverride, task.StepID), nil
+	}
+	version, err := h.flowRepo.GetVersion(ctx, run.FlowID, run.Version)
+	if err != nil {
+		return nil, fmt.Errorf("get flow version: %w", err)
+	}
+	return engine.StepRetryPolicy(&version.Spec, task.StepID), nil
+}
+
+// externalLockDuration проверяет lock_duration_ms.
+func externalLockDuration(w http.ResponseWriter, ms int64) (time.Duration, bool) {
+	d := time.Duration(ms) * time.Millisecond
+	if d <= 0 || d > maxExternalLock {
+		BadRequest(w, fmt.Sprintf("lock_duration_ms must be between 1 and %d", maxExternalLock.Milliseconds()))
+		return 0, false
+	}
+	return d, true
+}
@@ -3,44 +3,74 @@ package api
 import (
 	"log/slog"
 
+	"github.com/shaiso/Automata/internal/engine"
 	"github.com/shaiso/Automata/internal/mq"
 	"github.com/shaiso/Automata/internal/repo"
 	"github.com/shaiso/Automata/internal/sandbox"
+	"github.com/shaiso/Automata/internal/trigger"
 )
 
 // Handler — главный обработчик API с зависимостями.
 type Handler struct {
-	flowRepo         *repo.FlowRepo
-	runRepo          *repo.RunRepo
-	taskRepo         *repo.TaskRepo
-	scheduleRepo     *repo.ScheduleRepo
-	proposalRepo     *repo.ProposalRepo
-	publisher        *mq.Publisher
-	sandboxCollector *sandbox.Collector
-	logger           *slog.Logger
+	flowRepo          *repo.FlowRepo
+	runRepo           *repo.RunRepo
+	taskRepo          *repo.TaskRepo
+	scheduleRepo      *repo.ScheduleRepo
+	triggerRepo       *repo.TriggerRepo
+	notificationRepo  *repo.NotificationRepo
+	proposalRepo      *repo.ProposalRepo
+	resourceRepo      *repo.ResourceRepo
+	descriptorSetRepo *repo.DescriptorSetRepo
+	wasmModuleRepo    *repo.WasmModuleRepo
+	publisher         *mq.Publisher
+	sandboxCollector  *sandbox.Collector
+	callbacks         *engine.CallbackSigner
+	launcher          *trigger.Launcher
+	logger            *slog.Logger
 }
 
 // Config — конфигурация для создания Handler.
 type Config struct {
-	FlowRepo     *repo.FlowRepo
-	RunRepo      *repo.RunRepo
-	TaskRepo     *repo.TaskRepo
-	ScheduleRepo *repo.ScheduleRepo
-	ProposalRepo *repo.ProposalRepo
-	Publisher    *mq.Publisher
-	Logger       *slog.Logger
+	FlowRepo          *repo.FlowRepo
+	RunRepo           *repo.RunRepo
+	TaskRepo          *repo.TaskRepo
+	ScheduleRepo      *repo.ScheduleRepo
+	TriggerRepo       *repo.TriggerRepo
+	NotificationRepo  *repo.NotificationRepo
+	ProposalRepo      *repo.ProposalRepo
+	ResourceRepo      *repo.ResourceRepo
+	DescriptorSetRepo *repo.DescriptorSetRepo
+	WasmModuleRepo    *repo.WasmModuleRepo
+	Publisher         *mq.Publisher
+	Logger            *slog.Logger
+
+	// CallbackSecret — секрет подписи callback URL (общий с Orchestrator).
+	CallbackSecret string
 }
 
 // NewHandler создаёт новый Handler.
 func NewHandler(cfg Config) *Handler {
 	return &Handler{
-		flowRepo:         cfg.FlowRepo,
-		runRepo:          cfg.RunRepo,
-		taskRepo:         cfg.TaskRepo,
-		scheduleRepo:     cfg.ScheduleRepo,
-		proposalRepo:     cfg.ProposalRepo,
-		publisher:        cfg.Publisher,
-		sandboxCollector: sandbox.NewCollector(cfg.RunRepo, cfg.TaskRepo),
-		logger:           cfg.Logger,
+		flowRepo:          cfg.FlowRepo,
+		runRepo:           cfg.RunRepo,
+		taskRepo:          cfg.TaskRepo,
+		scheduleRepo:      cfg.ScheduleRepo,
+		triggerRepo:       cfg.TriggerRepo,
+		notificationRepo:  cfg.NotificationRepo,
+		proposalRepo:      cfg.ProposalRepo,
+		resourceRepo:      cfg.ResourceRepo,
+		descriptorSetRepo: cfg.DescriptorSetRepo,
+		wasmModuleRepo:    cfg.WasmModuleRepo,
+		publisher:         cfg.Publisher,
+		sandboxCollector:  sandbox.NewCollector(cfg.RunRepo, cfg.TaskRepo),
+		callbacks:         engine.NewCallbackSigner("", cfg.CallbackSecret),
+		launcher: trigger.NewLauncher(trigger.LauncherConfig{
+			TriggerRepo: cfg.TriggerRepo,
+			RunRepo:     cfg.RunRepo,
+			FlowRepo:    cfg.FlowRepo,
+			Publisher:   cfg.Publisher,
+			Logger:      cfg.Logger,
+		}),
+		logger: cfg.Logger,
 	}
 }
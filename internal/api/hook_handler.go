@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/trigger"
+)
+
+// maxHookBodySize — максимальный размер тела webhook запроса (1 MB).
+const maxHookBodySize = 1 << 20
+
+// hookPollInterval — интервал опроса run в синхронном режиме.
+const hookPollInterval = 500 * time.Millisecond
+
+// HandleHook принимает webhook и запускает flow триггера.
+// POST /api/v1/hooks/{trigger_id}
+//
+// Ответы:
+//   - 202 — run создан (или уже был создан для того же dedupe ключа)
+//   - 200 — синхронный режим: run завершён, в ответе его результат
+//   - 200 со статусом "ignored" — событие не прошло filter
+func (h *Handler) HandleHook(w http.ResponseWriter, r *http.Request) {
+	triggerID, err := uuid.Parse(r.PathValue("trigger_id"))
+	if err != nil {
+		NotFound(w, "hook not found")
+		return
+	}
+
+	t, err := h.triggerRepo.GetByID(r.Context(), triggerID)
+	if HandleRepoError(w, h.logger, err, "hook not found") {
+		return
+	}
+
+	if t.Type != domain.TriggerTypeWebhook {
+		NotFound(w, "hook not found")
+		return
+	}
+
+	if !t.Enabled {
+		InvalidState(w, "hook is disabled")
+		return
+	}
+
+	if !trigger.IPAllowed(&t.Config, r.RemoteAddr) {
+		Forbidden(w, "address is not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			BadRequest(w, "hook body too large")
+			return
+		}
+		BadRequest(w, "failed to read hook body")
+		return
+	}
+
+	if err := trigger.VerifySignature(&t.Config, r.Header, body, time.Now()); err != nil {
+		Unauthorized(w, err.Error())
+		return
+	}
+
+	ev := trigger.EventFromRequest(&t.Config, r, body)
+
+	run, created, err := h.launcher.Fire(r.Context(), t, &ev)
+	if err != nil {
+		if errors.Is(err, trigger.ErrEventRejected) {
+			InvalidState(w, err.Error())
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	if run == nil {
+		Success(w, HookResponse{Status: "ignored"})
+		return
+	}
+
+	resp := HookResponse{
+		RunID:     &run.ID,
+		Status:    string(run.Status),
+		Duplicate: !created,
+	}
+
+	if !t.Config.Sync {
+		JSON(w, http.StatusAccepted, DataResponse{Data: resp})
+		return
+	}
+
+	// Синхронный режим: ждём завершения run
+	ctx, cancel := context.WithTimeout(r.Context(), trigger.SyncTimeout(&t.Config))
+	defer cancel()
+
+	finished, err := h.waitRun(ctx, run.ID)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Timeout — клиент может узнать результат по run_id
+			JSON(w, http.StatusAccepted, DataResponse{Data: resp})
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	Success(w, hookResult(resp, finished))
+}
+
+// hookResult дополняет ответ синхронного webhook результатом завершённого
+// run: статус, ошибка и outputs flow (FlowSpec.outputs). Outputs шагов
+// (ответы внутренних HTTP и SQL шагов) вызывающей стороне не отдаются.
+func hookResult(resp HookResponse, run *domain.Run) HookResponse {
+	resp.Status = string(run.Status)
+	resp.Error = run.Error
+	resp.Outputs = run.Outputs
+	return resp
+}
+
+// waitRun ожидает перехода run в терминальный статус.
+func (h *Handler) waitRun(ctx context.Context, runID uuid.UUID) (*domain.Run, error) {
+	ticker := time.NewTicker(hookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		run, err := h.runRepo.GetByID(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+		if run.Status.IsTerminal() {
+			return run, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+func TestHookResult(t *testing.T) {
+	runID := uuid.New()
+	run := &domain.Run{
+		ID:      runID,
+		Status:  domain.RunStatusSucceeded,
+		Outputs: map[string]any{"order_id": "42"},
+	}
+
+	resp := hookResult(HookResponse{RunID: &runID, Status: string(domain.RunStatusPending)}, run)
+
+	// Только outputs flow — без outputs отдельных шагов
+	expected := HookResponse{
+		RunID:   &runID,
+		Status:  string(domain.RunStatusSucceeded),
+		Outputs: map[string]any{"order_id": "42"},
+	}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("expected %+v, got %+v", expected, resp)
+	}
+
+	failed := &domain.Run{ID: runID, Status: domain.RunStatusFailed, Error: "step fetch failed"}
+	resp = hookResult(HookResponse{RunID: &runID}, failed)
+	if resp.Status != string(domain.RunStatusFailed) || resp.Error != "step fetch failed" || resp.Outputs != nil {
+		t.Errorf("unexpected response for failed run: %+v", resp)
+	}
+}
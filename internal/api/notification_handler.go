@@ -0,0 +1,280 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/notify"
+	"github.com/shaiso/Automata/internal/repo"
+)
+
+// ListNotificationRules возвращает список правил уведомлений.
+// GET /api/v1/notification-rules?flow_id=...&enabled=...&limit=...&offset=...
+func (h *Handler) ListNotificationRules(w http.ResponseWriter, r *http.Request) {
+	filter := repo.NotificationRuleFilter{}
+
+	// Парсим query параметры
+	if flowIDStr := r.URL.Query().Get("flow_id"); flowIDStr != "" {
+		flowID, err := uuid.Parse(flowIDStr)
+		if err != nil {
+			BadRequest(w, "invalid flow_id")
+			return
+		}
+		filter.FlowID = &flowID
+	}
+
+	if enabledStr := r.URL.Query().Get("enabled"); enabledStr != "" {
+		enabled := enabledStr == "true"
+		filter.Enabled = &enabled
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		filter.Limit = int(mustParseInt(limitStr, 50))
+	} else {
+		filter.Limit = 50
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		filter.Offset = int(mustParseInt(offsetStr, 0))
+	}
+
+	rules, err := h.notificationRepo.ListRules(r.Context(), filter)
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	result := make([]NotificationRuleResponse, len(rules))
+	for i := range rules {
+		result[i] = NotificationRuleFromDomain(&rules[i])
+	}
+
+	List(w, result, len(result))
+}
+
+// CreateNotificationRule создаёт правило уведомления для flow.
+// POST /api/v1/flows/{id}/notification-rules
+func (h *Handler) CreateNotificationRule(w http.ResponseWriter, r *http.Request) {
+	flowID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid flow id")
+		return
+	}
+
+	var req CreateNotificationRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	// Проверяем, что flow существует
+	_, err = h.flowRepo.GetByID(r.Context(), flowID)
+	if HandleRepoError(w, h.logger, err, "flow not found") {
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	now := time.Now()
+	rule := &domain.NotificationRule{
+		ID:        uuid.New(),
+		FlowID:    flowID,
+		Name:      req.Name,
+		On:        domain.NotificationEvent(req.On),
+		Threshold: req.Threshold,
+		Channel:   domain.NotificationChannel(req.Channel),
+		Config:    req.Config,
+		Enabled:   enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := notify.ValidateRule(rule); err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+
+	if err := h.notificationRepo.CreateRule(r.Context(), rule); err != nil {
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	Created(w, NotificationRuleFromDomain(rule))
+}
+
+// GetNotificationRule возвращает правило уведомления по ID.
+// GET /api/v1/notification-rules/{id}
+func (h *Handler) GetNotificationRule(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid notification rule id")
+		return
+	}
+
+	rule, err := h.notificationRepo.GetRule(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "notification rule not found") {
+		return
+	}
+
+	Success(w, NotificationRuleFromDomain(rule))
+}
+
+// UpdateNotificationRule обновляет правило уведомления.
+// PUT /api/v1/notification-rules/{id}
+func (h *Handler) UpdateNotificationRule(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid notification rule id")
+		return
+	}
+
+	var req UpdateNotificationRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	rule, err := h.notificationRepo.GetRule(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "notification rule not found") {
+		return
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.On != nil {
+		rule.On = domain.NotificationEvent(*req.On)
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.Channel != nil {
+		rule.Channel = domain.NotificationChannel(*req.Channel)
+	}
+	if req.Config != nil {
+		// Замаскированные заголовки (из ответа API) означают «не менять»
+		for k, v := range req.Config.Headers {
+			if v == maskedSecret {
+				req.Config.Headers[k] = rule.Config.Headers[k]
+			}
+		}
+		rule.Config = *req.Config
+	}
+
+	if err := notify.ValidateRule(rule); err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+
+	rule.UpdatedAt = time.Now()
+	if err := h.notificationRepo.UpdateRule(r.Context(), rule); err != nil {
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	Success(w, NotificationRuleFromDomain(rule))
+}
+
+// DeleteNotificationRule удаляет правило уведомления вместе с историей доставки.
+// DELETE /api/v1/notification-rules/{id}
+func (h *Handler) DeleteNotificationRule(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid notification rule id")
+		return
+	}
+
+	if err := h.notificationRepo.DeleteRule(r.Context(), id); err != nil {
+		if HandleRepoError(w, h.logger, err, "notification rule not found") {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	NoContent(w)
+}
+
+// SetNotificationRuleEnabled включает или выключает правило уведомления.
+// PUT /api/v1/notification-rules/{id}/enabled
+func (h *Handler) SetNotificationRuleEnabled(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid notification rule id")
+		return
+	}
+
+	var req SetEnabledRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	if err := h.notificationRepo.SetRuleEnabled(r.Context(), id, req.Enabled); err != nil {
+		if HandleRepoError(w, h.logger, err, "notification rule not found") {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	// Возвращаем обновлённое правило
+	rule, err := h.notificationRepo.GetRule(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "notification rule not found") {
+		return
+	}
+
+	Success(w, NotificationRuleFromDomain(rule))
+}
+
+// ListNotificationDeliveries возвращает историю доставки уведомлений правила.
+// GET /api/v1/notification-rules/{id}/deliveries?run_id=...&limit=...&offset=...
+func (h *Handler) ListNotificationDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid notification rule id")
+		return
+	}
+
+	// Проверяем, что правило существует
+	_, err = h.notificationRepo.GetRule(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "notification rule not found") {
+		return
+	}
+
+	filter := repo.DeliveryFilter{RuleID: &id, Limit: 50}
+
+	if runIDStr := r.URL.Query().Get("run_id"); runIDStr != "" {
+		runID, err := uuid.Parse(runIDStr)
+		if err != nil {
+			BadRequest(w, "invalid run_id")
+			return
+		}
+		filter.RunID = &runID
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		filter.Limit = int(mustParseInt(limitStr, 50))
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		filter.Offset = int(mustParseInt(offsetStr, 0))
+	}
+
+	deliveries, err := h.notificationRepo.ListDeliveries(r.Context(), filter)
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	result := make([]NotificationDeliveryResponse, len(deliveries))
+	for i := range deliveries {
+		result[i] = NotificationDeliveryFromDomain(deliveries[i])
+	}
+
+	List(w, result, len(result))
+}
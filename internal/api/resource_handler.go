@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/repo"
+)
+
+// ListResources возвращает список ресурсов с количеством занятых слотов
+// и ожидающих шагов.
+// GET /api/v1/resources
+func (h *Handler) ListResources(w http.ResponseWriter, r *http.Request) {
+	resources, err := h.resourceRepo.List(r.Context())
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	result := make([]ResourceResponse, len(resources))
+	for i := range resources {
+		result[i] = ResourceFromDomain(&resources[i])
+	}
+
+	List(w, result, len(result))
+}
+
+// CreateResource создаёт именованный ресурс.
+// POST /api/v1/resources
+func (h *Handler) CreateResource(w http.ResponseWriter, r *http.Request) {
+	var req CreateResourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		BadRequest(w, "name is required")
+		return
+	}
+	if req.Capacity < 1 {
+		BadRequest(w, "capacity must be at least 1")
+		return
+	}
+
+	now := time.Now()
+	res := &domain.Resource{
+		Name:        req.Name,
+		Capacity:    req.Capacity,
+		Description: req.Description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := h.resourceRepo.Create(r.Context(), res); err != nil {
+		if errors.Is(err, repo.ErrAlreadyExists) {
+			Conflict(w, "resource already exists")
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	Created(w, ResourceFromDomain(res))
+}
+
+// GetResource возвращает ресурс с текущими держателями слотов
+// и очередью ожидающих шагов (первый в очереди получит слот следующим).
+// GET /api/v1/resources/{name}
+func (h *Handler) GetResource(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	res, err := h.resourceRepo.GetByName(r.Context(), name)
+	if HandleRepoError(w, h.logger, err, "resource not found") {
+		return
+	}
+
+	holders, err := h.resourceRepo.ListLeases(r.Context(), name)
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	waiters, err := h.resourceRepo.ListWaiters(r.Context(), name)
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	resp := ResourceFromDomain(res)
+	resp.Holders = holders
+	resp.Waiters = waiters
+	Success(w, resp)
+}
+
+// UpdateResource обновляет ёмкость или описание ресурса.
+// Увеличение ёмкости запускает ожидающие шаги при следующем poll Orchestrator.
+// PUT /api/v1/resources/{name}
+func (h *Handler) UpdateResource(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var req UpdateResourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	res, err := h.resourceRepo.GetByName(r.Context(), name)
+	if HandleRepoError(w, h.logger, err, "resource not found") {
+		return
+	}
+
+	if req.Capacity != nil {
+		if *req.Capacity < 1 {
+			BadRequest(w, "capacity must be at least 1")
+			return
+		}
+		res.Capacity = *req.Capacity
+	}
+	if req.Description != nil {
+		res.Description = *req.Description
+	}
+
+	res.UpdatedAt = time.Now()
+	if err := h.resourceRepo.Update(r.Context(), res); err != nil {
+		if HandleRepoError(w, h.logger, err, "resource not found") {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	Success(w, ResourceFromDomain(res))
+}
+
+// DeleteResource удаляет ресурс. Ресурс с занятыми слотами удалить нельзя.
+// DELETE /api/v1/resources/{name}
+func (h *Handler) DeleteResource(w http.ResponseWriter, r *http.Request) {
+	if err := h.resourceRepo.Delete(r.Context(), r.PathValue("name")); err != nil {
+		if HandleRepoError(w, h.logger, err, "resource not found") {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	NoContent(w)
+}
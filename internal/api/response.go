@@ -15,6 +15,8 @@ type ErrorCode string
 const (
 	ErrCodeBadRequest     ErrorCode = "BAD_REQUEST"
 	ErrCodeNotFound       ErrorCode = "NOT_FOUND"
+	ErrCodeUnauthorized   ErrorCode = "UNAUTHORIZED"
+	ErrCodeForbidden      ErrorCode = "FORBIDDEN"
 	ErrCodeConflict       ErrorCode = "CONFLICT"
 	ErrCodeInvalidState   ErrorCode = "INVALID_STATE"
 	ErrCodeInternalError  ErrorCode = "INTERNAL_ERROR"
@@ -90,6 +92,16 @@ func NotFound(w http.ResponseWriter, message string) {
 	Error(w, http.StatusNotFound, ErrCodeNotFound, message)
 }
 
+// Unauthorized отправляет ошибку 401.
+func Unauthorized(w http.ResponseWriter, message string) {
+	Error(w, http.StatusUnauthorized, ErrCodeUnauthorized, message)
+}
+
+// Forbidden отправляет ошибку 403.
+func Forbidden(w http.ResponseWriter, message string) {
+	Error(w, http.StatusForbidden, ErrCodeForbidden, message)
+}
+
 // Conflict отправляет ошибку 409.
 func Conflict(w http.ResponseWriter, message string) {
 	Error(w, http.StatusConflict, ErrCodeConflict, message)
@@ -29,7 +29,13 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("POST /api/v1/flows/{id}/runs", chain(http.HandlerFunc(h.CreateRun)))
 	mux.Handle("GET /api/v1/runs/{id}", chain(http.HandlerFunc(h.GetRun)))
 	mux.Handle("POST /api/v1/runs/{id}/cancel", chain(http.HandlerFunc(h.CancelRun)))
+	mux.Handle("POST /api/v1/runs/{id}/retry", chain(http.HandlerFunc(h.RetryRun)))
 	mux.Handle("GET /api/v1/runs/{id}/tasks", chain(http.HandlerFunc(h.ListRunTasks)))
+	mux.Handle("GET /api/v1/runs/waiting", chain(http.HandlerFunc(h.ListWaitingSteps)))
+	mux.Handle("POST /api/v1/runs/{id}/steps/{step}/signal", chain(http.HandlerFunc(h.SignalStep)))
+
+	// Callbacks (wait_for_callback)
+	mux.Handle("POST /api/v1/callbacks/{run_id}/{step}", chain(http.HandlerFunc(h.HandleCallback)))
 
 	// Schedules
 	mux.Handle("GET /api/v1/schedules", chain(http.HandlerFunc(h.ListSchedules)))
@@ -39,6 +45,51 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("DELETE /api/v1/schedules/{id}", chain(http.HandlerFunc(h.DeleteSchedule)))
 	mux.Handle("PUT /api/v1/schedules/{id}/enabled", chain(http.HandlerFunc(h.SetScheduleEnabled)))
 
+	// Triggers
+	mux.Handle("GET /api/v1/triggers", chain(http.HandlerFunc(h.ListTriggers)))
+	mux.Handle("POST /api/v1/flows/{id}/triggers", chain(http.HandlerFunc(h.CreateTrigger)))
+	mux.Handle("GET /api/v1/triggers/{id}", chain(http.HandlerFunc(h.GetTrigger)))
+	mux.Handle("PUT /api/v1/triggers/{id}", chain(http.HandlerFunc(h.UpdateTrigger)))
+	mux.Handle("DELETE /api/v1/triggers/{id}", chain(http.HandlerFunc(h.DeleteTrigger)))
+	mux.Handle("PUT /api/v1/triggers/{id}/enabled", chain(http.HandlerFunc(h.SetTriggerEnabled)))
+
+	// Webhooks (webhook triggers)
+	mux.Handle("POST /api/v1/hooks/{trigger_id}", chain(http.HandlerFunc(h.HandleHook)))
+
+	// Notifications
+	mux.Handle("GET /api/v1/notification-rules", chain(http.HandlerFunc(h.ListNotificationRules)))
+	mux.Handle("POST /api/v1/flows/{id}/notification-rules", chain(http.HandlerFunc(h.CreateNotificationRule)))
+	mux.Handle("GET /api/v1/notification-rules/{id}", chain(http.HandlerFunc(h.GetNotificationRule)))
+	mux.Handle("PUT /api/v1/notification-rules/{id}", chain(http.HandlerFunc(h.UpdateNotificationRule)))
+	mux.Handle("DELETE /api/v1/notification-rules/{id}", chain(http.HandlerFunc(h.DeleteNotificationRule)))
+	mux.Handle("PUT /api/v1/notification-rules/{id}/enabled", chain(http.HandlerFunc(h.SetNotificationRuleEnabled)))
+	mux.Handle("GET /api/v1/notification-rules/{id}/deliveries", chain(http.HandlerFunc(h.ListNotificationDeliveries)))
+
+	// Resources (мьютексы / семафоры шагов)
+	mux.Handle("GET /api/v1/resources", chain(http.HandlerFunc(h.ListResources)))
+	mux.Handle("POST /api/v1/resources", chain(http.HandlerFunc(h.CreateResource)))
+	mux.Handle("GET /api/v1/resources/{name}", chain(http.HandlerFunc(h.GetResource)))
+	mux.Handle("PUT /api/v1/resources/{name}", chain(http.HandlerFunc(h.UpdateResource)))
+	mux.Handle("DELETE /api/v1/resources/{name}", chain(http.HandlerFunc(h.DeleteResource)))
+
+	// Descriptor sets (схемы gRPC-сервисов для шага grpc)
+	mux.Handle("GET /api/v1/descriptor-sets", chain(http.HandlerFunc(h.ListDescriptorSets)))
+	mux.Handle("GET /api/v1/descriptor-sets/{name}", chain(http.HandlerFunc(h.GetDescriptorSet)))
+	mux.Handle("PUT /api/v1/descriptor-sets/{name}", chain(http.HandlerFunc(h.PutDescriptorSet)))
+	mux.Handle("DELETE /api/v1/descriptor-sets/{name}", chain(http.HandlerFunc(h.DeleteDescriptorSet)))
+
+	// WebAssembly-модули (для шага wasm, адресуются по дайджесту)
+	mux.Handle("GET /api/v1/wasm-modules", chain(http.HandlerFunc(h.ListWasmModules)))
+	mux.Handle("POST /api/v1/wasm-modules", chain(http.HandlerFunc(h.UploadWasmModule)))
+	mux.Handle("GET /api/v1/wasm-modules/{digest}", chain(http.HandlerFunc(h.GetWasmModule)))
+	mux.Handle("DELETE /api/v1/wasm-modules/{digest}", chain(http.HandlerFunc(h.DeleteWasmModule)))
+
+	// External tasks (шаг external, выполняемый внешними воркерами)
+	mux.Handle("POST /api/v1/external-tasks/fetch-and-lock", chain(http.HandlerFunc(h.FetchAndLockExternalTasks)))
+	mux.Handle("POST /api/v1/external-tasks/{id}/complete", chain(http.HandlerFunc(h.CompleteExternalTask)))
+	mux.Handle("POST /api/v1/external-tasks/{id}/fail", chain(http.HandlerFunc(h.FailExternalTask)))
+	mux.Handle("POST /api/v1/external-tasks/{id}/extend-lock", chain(http.HandlerFunc(h.ExtendExternalTaskLock)))
+
 	// Proposals
 	mux.Handle("GET /api/v1/proposals", chain(http.HandlerFunc(h.ListProposals)))
 	mux.Handle("POST /api/v1/flows/{id}/proposals", chain(http.HandlerFunc(h.CreateProposal)))
@@ -2,15 +2,20 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/mq"
 	"github.com/shaiso/Automata/internal/repo"
 )
 
 // ListRuns возвращает список runs с фильтрацией.
-// GET /api/v1/runs?flow_id=...&status=...&limit=...&offset=...
+// GET /api/v1/runs?flow_id=...&status=...&retry_of=...&limit=...&offset=...
 func (h *Handler) ListRuns(w http.ResponseWriter, r *http.Request) {
 	filter := repo.RunFilter{}
 
@@ -28,6 +33,15 @@ func (h *Handler) ListRuns(w http.ResponseWriter, r *http.Request) {
 		filter.Status = domain.RunStatus(status)
 	}
 
+	if retryOfStr := r.URL.Query().Get("retry_of"); retryOfStr != "" {
+		retryOf, err := uuid.Parse(retryOfStr)
+		if err != nil {
+			BadRequest(w, "invalid retry_of")
+			return
+		}
+		filter.RetryOf = &retryOf
+	}
+
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		var limit int
 		if _, err := json.Number(limitStr).Int64(); err == nil {
@@ -172,9 +186,143 @@ func (h *Handler) CancelRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Слоты ресурсов отменённого run свободны; ожидающие шаги других runs
+	// Orchestrator запустит при следующем poll
+	if h.resourceRepo != nil {
+		if _, err := h.resourceRepo.ReleaseRun(r.Context(), run.ID); err != nil {
+			h.logger.Warn("failed to release run resources", "run_id", run.ID, "error", err)
+		}
+	}
+
+	// Уведомляем подписчиков automata.events (flow триггеры)
+	if h.publisher != nil {
+		payload := mq.RunCompletedPayload{
+			RunID:      run.ID,
+			FlowID:     run.FlowID,
+			Version:    run.Version,
+			Status:     string(run.Status),
+			Inputs:     run.Inputs,
+			IsSandbox:  run.IsSandbox,
+			StartedAt:  run.StartedAt,
+			FinishedAt: run.FinishedAt,
+		}
+		if err := h.publisher.PublishRunCompleted(r.Context(), payload); err != nil {
+			h.logger.Warn("failed to publish run.completed", "run_id", run.ID, "error", err)
+		}
+	}
+
 	Success(w, RunFromDomain(*run))
 }
 
+// RetryRun создаёт повторный запуск завершённого run.
+//
+// Новый run выполняет ту же версию flow с теми же inputs и переиспользует
+// outputs успешных шагов исходного run: их tasks копируются в новый run,
+// Orchestrator восстанавливает их через RestoreFromTasks. С from_step
+// заново выполняются этот шаг и все зависящие от него.
+// POST /api/v1/runs/{id}/retry
+func (h *Handler) RetryRun(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid run id")
+		return
+	}
+
+	// Тело необязательно: пустой запрос — повтор упавших шагов
+	var req RetryRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	run, err := h.runRepo.GetByID(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "run not found") {
+		return
+	}
+
+	if !run.IsFinished() {
+		InvalidState(w, "run is not finished")
+		return
+	}
+	if run.Status == domain.RunStatusSucceeded && req.FromStep == "" {
+		InvalidState(w, "run succeeded, use from_step to rerun steps")
+		return
+	}
+
+	// Спека исходного run: spec_override для sandbox или версия flow
+	spec := run.SpecOverride
+	if spec == nil {
+		version, err := h.flowRepo.GetVersion(r.Context(), run.FlowID, run.Version)
+		if HandleRepoError(w, h.logger, err, "flow version not found") {
+			return
+		}
+		spec = &version.Spec
+	}
+
+	dag, err := engine.BuildDAG(spec)
+	if err != nil {
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	tasks, err := h.taskRepo.ListByRunID(r.Context(), run.ID)
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	reused, err := engine.RetryTasks(dag, tasks, req.FromStep)
+	if err != nil {
+		if errors.Is(err, engine.ErrRetryStepNotFound) {
+			BadRequest(w, err.Error())
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	retry := &domain.Run{
+		ID:            uuid.New(),
+		FlowID:        run.FlowID,
+		Version:       run.Version,
+		Status:        domain.RunStatusPending,
+		Inputs:        run.Inputs,
+		IsSandbox:     run.IsSandbox,
+		SpecOverride:  run.SpecOverride,
+		RetryOf:       &run.ID,
+		RetryFromStep: req.FromStep,
+		CreatedAt:     time.Now(),
+	}
+
+	// Копии успешных tasks: outputs доступны шагам нового run
+	copies := make([]domain.Task, len(reused))
+	for i, task := range reused {
+		task.ID = uuid.New()
+		task.RunID = retry.ID
+		task.CreatedAt = retry.CreatedAt
+		copies[i] = task
+	}
+
+	if err := h.runRepo.CreateRetry(r.Context(), retry, copies); err != nil {
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	h.logger.Info("run retry created",
+		"run_id", retry.ID,
+		"retry_of", run.ID,
+		"from_step", req.FromStep,
+		"reused_steps", len(copies),
+	)
+
+	if h.publisher != nil {
+		if err := h.publisher.PublishRunPending(r.Context(), retry.ID); err != nil {
+			h.logger.Warn("failed to publish run.pending", "run_id", retry.ID, "error", err)
+		}
+	}
+
+	Created(w, RunFromDomain(*retry))
+}
+
 // ListRunTasks возвращает задачи run.
 // GET /api/v1/runs/{id}/tasks
 func (h *Handler) ListRunTasks(w http.ResponseWriter, r *http.Request) {
@@ -203,6 +351,101 @@ func (h *Handler) ListRunTasks(w http.ResponseWriter, r *http.Request) {
 	List(w, result, len(result))
 }
 
+// SignalStep передаёт сигнал шагу approval / wait_for_signal.
+// Payload сигнала становится outputs шага, run продолжает выполнение.
+// POST /api/v1/runs/{id}/steps/{step}/signal
+func (h *Handler) SignalStep(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid run id")
+		return
+	}
+	stepID := r.PathValue("step")
+
+	var req SignalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	run, err := h.runRepo.GetByID(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "run not found") {
+		return
+	}
+
+	if run.Status != domain.RunStatusRunning {
+		InvalidState(w, "run is not running")
+		return
+	}
+
+	task, err := h.taskRepo.GetByRunAndStepID(r.Context(), id, stepID)
+	if HandleRepoError(w, h.logger, err, "step not found") {
+		return
+	}
+
+	if !task.IsWaiting() || !engine.IsSignalStep(task.Type) {
+		InvalidState(w, "step is not waiting for a signal")
+		return
+	}
+
+	if err := engine.ApplySignal(task, req.Decision, req.Payload); err != nil {
+		if errors.Is(err, engine.ErrInvalidDecision) {
+			BadRequest(w, err.Error())
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	// ErrInvalidState — сигнал уже получен или истёк timeout
+	if HandleRepoError(w, h.logger, h.taskRepo.CompleteWaiting(r.Context(), task), "") {
+		return
+	}
+
+	// Уведомляем Orchestrator — шаг завершён
+	if h.publisher != nil {
+		payload := mq.TaskCompletedPayload{
+			TaskID:  task.ID,
+			RunID:   task.RunID,
+			StepID:  task.StepID,
+			Status:  string(task.Status),
+			Attempt: task.Attempt,
+		}
+		if err := h.publisher.PublishTaskCompleted(r.Context(), payload); err != nil {
+			h.logger.Warn("failed to publish task.completed", "task_id", task.ID, "error", err)
+		}
+	}
+
+	Success(w, TaskFromDomain(*task))
+}
+
+// ListWaitingSteps возвращает шаги активных runs, ожидающие сигнала или callback.
+// GET /api/v1/runs/waiting?type=approval&limit=...
+func (h *Handler) ListWaitingSteps(w http.ResponseWriter, r *http.Request) {
+	stepType := r.URL.Query().Get("type")
+	if stepType != "" && !engine.IsWaitingStep(stepType) {
+		BadRequest(w, "type must be approval, wait_for_signal or wait_for_callback")
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit = int(mustParseInt(limitStr, 50))
+	}
+
+	tasks, err := h.taskRepo.ListWaiting(r.Context(), stepType, limit)
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	result := make([]WaitingStepResponse, len(tasks))
+	for i, t := range tasks {
+		result[i] = WaitingStepFromDomain(t)
+	}
+
+	List(w, result, len(result))
+}
+
 // mustParseInt парсит строку в int с дефолтным значением.
 func mustParseInt(s string, defaultVal int64) int64 {
 	var n int64
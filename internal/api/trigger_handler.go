@@ -0,0 +1,256 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/repo"
+	"github.com/shaiso/Automata/internal/trigger"
+)
+
+// ListTriggers возвращает список triggers с фильтрацией.
+// GET /api/v1/triggers?flow_id=...&type=...&enabled=...&limit=...&offset=...
+func (h *Handler) ListTriggers(w http.ResponseWriter, r *http.Request) {
+	filter := repo.TriggerFilter{
+		Type: domain.TriggerType(r.URL.Query().Get("type")),
+	}
+
+	// Парсим query параметры
+	if flowIDStr := r.URL.Query().Get("flow_id"); flowIDStr != "" {
+		flowID, err := uuid.Parse(flowIDStr)
+		if err != nil {
+			BadRequest(w, "invalid flow_id")
+			return
+		}
+		filter.FlowID = &flowID
+	}
+
+	if enabledStr := r.URL.Query().Get("enabled"); enabledStr != "" {
+		enabled := enabledStr == "true"
+		filter.Enabled = &enabled
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		filter.Limit = int(mustParseInt(limitStr, 50))
+	} else {
+		filter.Limit = 50
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		filter.Offset = int(mustParseInt(offsetStr, 0))
+	}
+
+	triggers, err := h.triggerRepo.List(r.Context(), filter)
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	result := make([]TriggerResponse, len(triggers))
+	for i := range triggers {
+		result[i] = TriggerFromDomain(&triggers[i])
+	}
+
+	List(w, result, len(result))
+}
+
+// CreateTrigger создаёт новый trigger для flow.
+// POST /api/v1/flows/{id}/triggers
+func (h *Handler) CreateTrigger(w http.ResponseWriter, r *http.Request) {
+	flowID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid flow id")
+		return
+	}
+
+	var req CreateTriggerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	// Проверяем, что flow существует
+	_, err = h.flowRepo.GetByID(r.Context(), flowID)
+	if HandleRepoError(w, h.logger, err, "flow not found") {
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	now := time.Now()
+	t := &domain.Trigger{
+		ID:           uuid.New(),
+		FlowID:       flowID,
+		Name:         req.Name,
+		Type:         domain.TriggerType(req.Type),
+		Config:       req.Config,
+		Filter:       req.Filter,
+		InputMapping: req.InputMapping,
+		Enabled:      enabled,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := trigger.Validate(t); err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+	if !h.checkUpstreamFlow(w, r, t) {
+		return
+	}
+
+	if err := h.triggerRepo.Create(r.Context(), t); err != nil {
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	Created(w, TriggerFromDomain(t))
+}
+
+// checkUpstreamFlow проверяет, что upstream flow у flow триггера существует.
+// Возвращает false, если ответ с ошибкой уже записан.
+func (h *Handler) checkUpstreamFlow(w http.ResponseWriter, r *http.Request, t *domain.Trigger) bool {
+	if t.Type != domain.TriggerTypeFlow {
+		return true
+	}
+
+	_, err := h.flowRepo.GetByID(r.Context(), *t.Config.UpstreamFlowID)
+	if errors.Is(err, repo.ErrNotFound) {
+		BadRequest(w, "upstream flow not found")
+		return false
+	}
+	if err != nil {
+		InternalError(w, h.logger, err)
+		return false
+	}
+	return true
+}
+
+// GetTrigger возвращает trigger по ID.
+// GET /api/v1/triggers/{id}
+func (h *Handler) GetTrigger(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid trigger id")
+		return
+	}
+
+	t, err := h.triggerRepo.GetByID(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "trigger not found") {
+		return
+	}
+
+	Success(w, TriggerFromDomain(t))
+}
+
+// UpdateTrigger обновляет trigger.
+// PUT /api/v1/triggers/{id}
+func (h *Handler) UpdateTrigger(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid trigger id")
+		return
+	}
+
+	var req UpdateTriggerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	t, err := h.triggerRepo.GetByID(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "trigger not found") {
+		return
+	}
+
+	if req.Name != nil {
+		t.Name = *req.Name
+	}
+	if req.Config != nil {
+		// Замаскированный секрет (из ответа API) означает «не менять»
+		if req.Config.Secret == maskedSecret {
+			req.Config.Secret = t.Config.Secret
+		}
+		t.Config = *req.Config
+	}
+	if req.Filter != nil {
+		t.Filter = *req.Filter
+	}
+	if req.InputMapping != nil {
+		t.InputMapping = *req.InputMapping
+	}
+
+	if err := trigger.Validate(t); err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+	if !h.checkUpstreamFlow(w, r, t) {
+		return
+	}
+
+	t.UpdatedAt = time.Now()
+	if err := h.triggerRepo.Update(r.Context(), t); err != nil {
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	Success(w, TriggerFromDomain(t))
+}
+
+// DeleteTrigger удаляет trigger.
+// DELETE /api/v1/triggers/{id}
+func (h *Handler) DeleteTrigger(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid trigger id")
+		return
+	}
+
+	if err := h.triggerRepo.Delete(r.Context(), id); err != nil {
+		if HandleRepoError(w, h.logger, err, "trigger not found") {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	NoContent(w)
+}
+
+// SetTriggerEnabled включает или выключает trigger.
+// PUT /api/v1/triggers/{id}/enabled
+func (h *Handler) SetTriggerEnabled(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		BadRequest(w, "invalid trigger id")
+		return
+	}
+
+	var req SetEnabledRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+
+	if err := h.triggerRepo.SetEnabled(r.Context(), id, req.Enabled); err != nil {
+		if HandleRepoError(w, h.logger, err, "trigger not found") {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	// Возвращаем обновлённый trigger
+	t, err := h.triggerRepo.GetByID(r.Context(), id)
+	if HandleRepoError(w, h.logger, err, "trigger not found") {
+		return
+	}
+
+	Success(w, TriggerFromDomain(t))
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/repo"
+	"github.com/shaiso/Automata/internal/wasmhost"
+)
+
+// maxWasmModuleSize — ограничение размера тела загрузки модуля
+// (содержимое в base64 на треть больше самого модуля).
+const maxWasmModuleSize = 48 << 20
+
+// ListWasmModules возвращает загруженные модули.
+// GET /api/v1/wasm-modules?name=score
+func (h *Handler) ListWasmModules(w http.ResponseWriter, r *http.Request) {
+	mods, err := h.wasmModuleRepo.List(r.Context(), r.URL.Query().Get("name"))
+	if HandleRepoError(w, h.logger, err, "") {
+		return
+	}
+
+	result := make([]WasmModuleResponse, len(mods))
+	for i := range mods {
+		result[i] = WasmModuleFromDomain(&mods[i])
+	}
+
+	List(w, result, len(result))
+}
+
+// UploadWasmModule загружает модуль как версию version модуля name.
+// Модуль проверяется при загрузке: это должна быть программа WASI
+// (экспорт _start, импорты только из wasi_snapshot_preview1).
+// Повторная загрузка того же содержимого возвращает существующий модуль.
+// POST /api/v1/wasm-modules
+func (h *Handler) UploadWasmModule(w http.ResponseWriter, r *http.Request) {
+	var req UploadWasmModuleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWasmModuleSize)).Decode(&req); err != nil {
+		BadRequest(w, "invalid request body")
+		return
+	}
+	if req.Name == "" {
+		BadRequest(w, "name is required")
+		return
+	}
+	if req.Version == "" {
+		BadRequest(w, "version is required")
+		return
+	}
+	if len(req.Data) == 0 {
+		BadRequest(w, "data is required")
+		return
+	}
+
+	if err := wasmhost.Validate(r.Context(), req.Data); err != nil {
+		BadRequest(w, err.Error())
+		return
+	}
+
+	mod := &domain.WasmModule{
+		Digest:    engine.WasmDigest(req.Data),
+		Name:      req.Name,
+		Version:   req.Version,
+		Size:      int64(len(req.Data)),
+		Data:      req.Data,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.wasmModuleRepo.Create(r.Context(), mod); err != nil {
+		if !errors.Is(err, repo.ErrAlreadyExists) {
+			InternalError(w, h.logger, err)
+			return
+		}
+		// То же содержимое уже загружено — загрузка идемпотентна
+		existing, getErr := h.wasmModuleRepo.GetByDigest(r.Context(), mod.Digest)
+		if errors.Is(getErr, repo.ErrNotFound) {
+			Conflict(w, fmt.Sprintf("module %s version %s already exists with different content", req.Name, req.Version))
+			return
+		}
+		if getErr != nil {
+			InternalError(w, h.logger, getErr)
+			return
+		}
+		Success(w, WasmModuleFromDomain(existing))
+		return
+	}
+
+	Created(w, WasmModuleFromDomain(mod))
+}
+
+// GetWasmModule возвращает модуль (без содержимого).
+// GET /api/v1/wasm-modules/{digest}
+func (h *Handler) GetWasmModule(w http.ResponseWriter, r *http.Request) {
+	mod, err := h.wasmModuleRepo.GetByDigest(r.Context(), r.PathValue("digest"))
+	if HandleRepoError(w, h.logger, err, "wasm module not found") {
+		return
+	}
+
+	Success(w, WasmModuleFromDomain(mod))
+}
+
+// DeleteWasmModule удаляет модуль. Шаги, ссылающиеся на него,
+// будут завершаться ошибкой.
+// DELETE /api/v1/wasm-modules/{digest}
+func (h *Handler) DeleteWasmModule(w http.ResponseWriter, r *http.Request) {
+	if err := h.wasmModuleRepo.Delete(r.Context(), r.PathValue("digest")); err != nil {
+		if HandleRepoError(w, h.logger, err, "wasm module not found") {
+			return
+		}
+		InternalError(w, h.logger, err)
+		return
+	}
+
+	NoContent(w)
+}
@@ -38,8 +38,16 @@ type RunResponse struct {
 	StartedAt      string         `json:"started_at,omitempty"`
 	FinishedAt     string         `json:"finished_at,omitempty"`
 	Error          string         `json:"error,omitempty"`
+	Outputs        map[string]any `json:"outputs,omitempty"`
+	DeadlineAt     string         `json:"deadline_at,omitempty"`
+	SLAAt          string         `json:"sla_at,omitempty"`
+	SLABreachedAt  string         `json:"sla_breached_at,omitempty"`
+	ConcurrencyKey string         `json:"concurrency_key,omitempty"`
+	WaitingReason  string         `json:"waiting_reason,omitempty"`
 	IdempotencyKey string         `json:"idempotency_key,omitempty"`
 	IsSandbox      bool           `json:"is_sandbox"`
+	RetryOf        string         `json:"retry_of,omitempty"`
+	RetryFromStep  string         `json:"retry_from_step,omitempty"`
 	CreatedAt      string         `json:"created_at"`
 }
 
@@ -60,6 +68,18 @@ type TaskResponse struct {
 	CreatedAt  string         `json:"created_at"`
 }
 
+// WaitingStepResponse — шаг, ожидающий сигнала, из API.
+type WaitingStepResponse struct {
+	TaskID       string         `json:"task_id"`
+	RunID        string         `json:"run_id"`
+	StepID       string         `json:"step_id"`
+	Name         string         `json:"name,omitempty"`
+	Type         string         `json:"type"`
+	Config       map[string]any `json:"config,omitempty"`
+	WaitingSince string         `json:"waiting_since,omitempty"`
+	DeadlineAt   string         `json:"deadline_at,omitempty"`
+}
+
 // ScheduleResponse — schedule из API.
 type ScheduleResponse struct {
 	ID          string         `json:"id"`
@@ -77,6 +97,76 @@ type ScheduleResponse struct {
 	UpdatedAt   string         `json:"updated_at"`
 }
 
+// TriggerConfig — параметры источника событий trigger.
+type TriggerConfig struct {
+	Exchange        string   `json:"exchange,omitempty"`
+	BindingKey      string   `json:"binding_key,omitempty"`
+	Secret          string   `json:"secret,omitempty"`
+	SignatureScheme string   `json:"signature_scheme,omitempty"`
+	SignatureHeader string   `json:"signature_header,omitempty"`
+	AllowedIPs      []string `json:"allowed_ips,omitempty"`
+	DedupeHeader    string   `json:"dedupe_header,omitempty"`
+	Sync            bool     `json:"sync,omitempty"`
+	SyncTimeoutSec  int      `json:"sync_timeout_sec,omitempty"`
+	UpstreamFlowID  string   `json:"upstream_flow_id,omitempty"`
+	Statuses        []string `json:"statuses,omitempty"`
+}
+
+// TriggerResponse — trigger из API.
+type TriggerResponse struct {
+	ID           string            `json:"id"`
+	FlowID       string            `json:"flow_id"`
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`
+	Config       TriggerConfig     `json:"config"`
+	Filter       string            `json:"filter,omitempty"`
+	InputMapping map[string]string `json:"input_mapping,omitempty"`
+	Enabled      bool              `json:"enabled"`
+	HookURL      string            `json:"hook_url,omitempty"`
+	LastFiredAt  string            `json:"last_fired_at,omitempty"`
+	LastRunID    string            `json:"last_run_id,omitempty"`
+	CreatedAt    string            `json:"created_at"`
+	UpdatedAt    string            `json:"updated_at"`
+}
+
+// NotificationConfig — параметры канала и шаблоны уведомления.
+type NotificationConfig struct {
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	To      []string          `json:"to,omitempty"`
+	Subject string            `json:"subject,omitempty"`
+	Message string            `json:"message,omitempty"`
+}
+
+// NotificationRuleResponse — правило уведомления из API.
+type NotificationRuleResponse struct {
+	ID        string             `json:"id"`
+	FlowID    string             `json:"flow_id"`
+	Name      string             `json:"name"`
+	On        string             `json:"on"`
+	Threshold int                `json:"threshold,omitempty"`
+	Channel   string             `json:"channel"`
+	Config    NotificationConfig `json:"config"`
+	Enabled   bool               `json:"enabled"`
+	CreatedAt string             `json:"created_at"`
+	UpdatedAt string             `json:"updated_at"`
+}
+
+// NotificationDeliveryResponse — запись истории доставки уведомления из API.
+type NotificationDeliveryResponse struct {
+	ID            string `json:"id"`
+	RuleID        string `json:"rule_id"`
+	RunID         string `json:"run_id"`
+	Status        string `json:"status"`
+	Subject       string `json:"subject,omitempty"`
+	Body          string `json:"body"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error,omitempty"`
+	NextAttemptAt string `json:"next_attempt_at,omitempty"`
+	SentAt        string `json:"sent_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
 // ProposalResponse — proposal из API.
 type ProposalResponse struct {
 	ID             string         `json:"id"`
@@ -141,6 +231,17 @@ type CreateRunRequest struct {
 	IsSandbox      bool           `json:"is_sandbox,omitempty"`
 }
 
+// RetryRunRequest — повторный запуск run.
+type RetryRunRequest struct {
+	FromStep string `json:"from_step,omitempty"`
+}
+
+// SignalRequest — сигнал для шага approval / wait_for_signal.
+type SignalRequest struct {
+	Decision string         `json:"decision"`
+	Payload  map[string]any `json:"payload,omitempty"`
+}
+
 // CreateScheduleRequest — создание schedule.
 type CreateScheduleRequest struct {
 	Name        string         `json:"name"`
@@ -159,11 +260,111 @@ type UpdateScheduleRequest struct {
 	Timezone    *string `json:"timezone,omitempty"`
 }
 
+// CreateTriggerRequest — создание trigger.
+type CreateTriggerRequest struct {
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`
+	Config       TriggerConfig     `json:"config"`
+	Filter       string            `json:"filter,omitempty"`
+	InputMapping map[string]string `json:"input_mapping,omitempty"`
+}
+
+// UpdateTriggerRequest — обновление trigger.
+type UpdateTriggerRequest struct {
+	Name         *string            `json:"name,omitempty"`
+	Config       *TriggerConfig     `json:"config,omitempty"`
+	Filter       *string            `json:"filter,omitempty"`
+	InputMapping *map[string]string `json:"input_mapping,omitempty"`
+}
+
+// CreateNotificationRuleRequest — создание правила уведомления.
+type CreateNotificationRuleRequest struct {
+	Name      string             `json:"name"`
+	On        string             `json:"on"`
+	Threshold int                `json:"threshold,omitempty"`
+	Channel   string             `json:"channel"`
+	Config    NotificationConfig `json:"config"`
+}
+
+// UpdateNotificationRuleRequest — обновление правила уведомления.
+type UpdateNotificationRuleRequest struct {
+	Name      *string             `json:"name,omitempty"`
+	On        *string             `json:"on,omitempty"`
+	Threshold *int                `json:"threshold,omitempty"`
+	Config    *NotificationConfig `json:"config,omitempty"`
+}
+
+// ResourceResponse — именованный ресурс из API.
+type ResourceResponse struct {
+	Name        string                `json:"name"`
+	Capacity    int                   `json:"capacity"`
+	Description string                `json:"description,omitempty"`
+	InUse       int                   `json:"in_use"`
+	Waiting     int                   `json:"waiting"`
+	Holders     []ResourceSlot        `json:"holders,omitempty"`
+	Waiters     []ResourceQueuedEntry `json:"waiters,omitempty"`
+	CreatedAt   string                `json:"created_at"`
+	UpdatedAt   string                `json:"updated_at"`
+}
+
+// ResourceSlot — занятый слот ресурса.
+type ResourceSlot struct {
+	RunID      string `json:"run_id"`
+	StepID     string `json:"step_id"`
+	AcquiredAt string `json:"acquired_at"`
+}
+
+// ResourceQueuedEntry — шаг в очереди ожидания ресурса.
+type ResourceQueuedEntry struct {
+	RunID        string `json:"run_id"`
+	StepID       string `json:"step_id"`
+	WaitingSince string `json:"waiting_since"`
+}
+
+// CreateResourceRequest — создание ресурса.
+type CreateResourceRequest struct {
+	Name        string `json:"name"`
+	Capacity    int    `json:"capacity"`
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateResourceRequest — обновление ресурса.
+type UpdateResourceRequest struct {
+	Capacity    *int    `json:"capacity,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// DescriptorSetResponse — descriptor set grpc шагов из API.
+type DescriptorSetResponse struct {
+	Name      string   `json:"name"`
+	SHA256    string   `json:"sha256"`
+	Services  []string `json:"services"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// WasmModuleResponse — модуль wasm шагов из API.
+type WasmModuleResponse struct {
+	Digest    string `json:"digest"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Size      int64  `json:"size"`
+	CreatedAt string `json:"created_at"`
+}
+
+// UploadWasmModuleRequest — загрузка модуля (Data в JSON — base64).
+type UploadWasmModuleRequest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Data    []byte `json:"data"`
+}
+
 // ListRunsOpts — параметры фильтрации runs.
 type ListRunsOpts struct {
-	FlowID string
-	Status string
-	Limit  int
+	FlowID  string
+	Status  string
+	RetryOf string
+	Limit   int
 }
 
 // --- API response wrappers ---
@@ -264,6 +465,9 @@ func (c *Client) ListRuns(opts ListRunsOpts) ([]RunResponse, error) {
 	if opts.Status != "" {
 		params.Set("status", opts.Status)
 	}
+	if opts.RetryOf != "" {
+		params.Set("retry_of", opts.RetryOf)
+	}
 	if opts.Limit > 0 {
 		params.Set("limit", fmt.Sprintf("%d", opts.Limit))
 	}
@@ -294,6 +498,13 @@ func (c *Client) CancelRun(id string) (*RunResponse, error) {
 	return &run, err
 }
 
+// RetryRun создаёт повторный запуск завершённого run.
+func (c *Client) RetryRun(id string, req RetryRunRequest) (*RunResponse, error) {
+	var run RunResponse
+	err := c.post("/api/v1/runs/"+id+"/retry", req, &run)
+	return &run, err
+}
+
 // ListTasks возвращает tasks для run.
 func (c *Client) ListTasks(runID string) ([]TaskResponse, error) {
 	var tasks []TaskResponse
@@ -301,6 +512,28 @@ func (c *Client) ListTasks(runID string) ([]TaskResponse, error) {
 	return tasks, err
 }
 
+// SignalStep передаёт сигнал шагу run, ожидающему решения.
+func (c *Client) SignalStep(runID, stepID string, req SignalRequest) (*TaskResponse, error) {
+	var task TaskResponse
+	err := c.post("/api/v1/runs/"+runID+"/steps/"+url.PathEscape(stepID)+"/signal", req, &task)
+	return &task, err
+}
+
+// ListWaitingSteps возвращает шаги, ожидающие сигнала. stepType — фильтр по типу шага.
+func (c *Client) ListWaitingSteps(stepType string, limit int) ([]WaitingStepResponse, error) {
+	params := url.Values{}
+	if stepType != "" {
+		params.Set("type", stepType)
+	}
+	if limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", limit))
+	}
+
+	var steps []WaitingStepResponse
+	err := c.list("/api/v1/runs/waiting", params, &steps)
+	return steps, err
+}
+
 // --- Schedules ---
 
 // ListSchedules возвращает schedules. Если flowID не пустой — фильтрует.
@@ -357,6 +590,210 @@ func (c *Client) DisableSchedule(id string) (*ScheduleResponse, error) {
 	return &schedule, err
 }
 
+// --- Triggers ---
+
+// ListTriggers возвращает triggers. Если flowID не пустой — фильтрует.
+func (c *Client) ListTriggers(flowID string) ([]TriggerResponse, error) {
+	params := url.Values{}
+	if flowID != "" {
+		params.Set("flow_id", flowID)
+	}
+
+	var triggers []TriggerResponse
+	err := c.list("/api/v1/triggers", params, &triggers)
+	return triggers, err
+}
+
+// CreateTrigger создаёт trigger для flow.
+func (c *Client) CreateTrigger(flowID string, req CreateTriggerRequest) (*TriggerResponse, error) {
+	var trigger TriggerResponse
+	err := c.post("/api/v1/flows/"+flowID+"/triggers", req, &trigger)
+	return &trigger, err
+}
+
+// GetTrigger возвращает trigger по ID.
+func (c *Client) GetTrigger(id string) (*TriggerResponse, error) {
+	var trigger TriggerResponse
+	err := c.get("/api/v1/triggers/"+id, &trigger)
+	return &trigger, err
+}
+
+// UpdateTrigger обновляет trigger.
+func (c *Client) UpdateTrigger(id string, req UpdateTriggerRequest) (*TriggerResponse, error) {
+	var trigger TriggerResponse
+	err := c.put("/api/v1/triggers/"+id, req, &trigger)
+	return &trigger, err
+}
+
+// DeleteTrigger удаляет trigger.
+func (c *Client) DeleteTrigger(id string) error {
+	return c.delete("/api/v1/triggers/" + id)
+}
+
+// SetTriggerEnabled включает или выключает trigger.
+func (c *Client) SetTriggerEnabled(id string, enabled bool) (*TriggerResponse, error) {
+	var trigger TriggerResponse
+	body := map[string]bool{"enabled": enabled}
+	err := c.put("/api/v1/triggers/"+id+"/enabled", body, &trigger)
+	return &trigger, err
+}
+
+// --- Notifications ---
+
+// ListNotificationRules возвращает правила уведомлений. Если flowID не пустой — фильтрует.
+func (c *Client) ListNotificationRules(flowID string) ([]NotificationRuleResponse, error) {
+	params := url.Values{}
+	if flowID != "" {
+		params.Set("flow_id", flowID)
+	}
+
+	var rules []NotificationRuleResponse
+	err := c.list("/api/v1/notification-rules", params, &rules)
+	return rules, err
+}
+
+// CreateNotificationRule создаёт правило уведомления для flow.
+func (c *Client) CreateNotificationRule(flowID string, req CreateNotificationRuleRequest) (*NotificationRuleResponse, error) {
+	var rule NotificationRuleResponse
+	err := c.post("/api/v1/flows/"+flowID+"/notification-rules", req, &rule)
+	return &rule, err
+}
+
+// GetNotificationRule возвращает правило уведомления по ID.
+func (c *Client) GetNotificationRule(id string) (*NotificationRuleResponse, error) {
+	var rule NotificationRuleResponse
+	err := c.get("/api/v1/notification-rules/"+id, &rule)
+	return &rule, err
+}
+
+// UpdateNotificationRule обновляет правило уведомления.
+func (c *Client) UpdateNotificationRule(id string, req UpdateNotificationRuleRequest) (*NotificationRuleResponse, error) {
+	var rule NotificationRuleResponse
+	err := c.put("/api/v1/notification-rules/"+id, req, &rule)
+	return &rule, err
+}
+
+// DeleteNotificationRule удаляет правило уведомления.
+func (c *Client) DeleteNotificationRule(id string) error {
+	return c.delete("/api/v1/notification-rules/" + id)
+}
+
+// SetNotificationRuleEnabled включает или выключает правило уведомления.
+func (c *Client) SetNotificationRuleEnabled(id string, enabled bool) (*NotificationRuleResponse, error) {
+	var rule NotificationRuleResponse
+	body := map[string]bool{"enabled": enabled}
+	err := c.put("/api/v1/notification-rules/"+id+"/enabled", body, &rule)
+	return &rule, err
+}
+
+// ListNotificationDeliveries возвращает историю доставки уведомлений правила.
+func (c *Client) ListNotificationDeliveries(ruleID string, limit int) ([]NotificationDeliveryResponse, error) {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", limit))
+	}
+
+	var deliveries []NotificationDeliveryResponse
+	err := c.list("/api/v1/notification-rules/"+ruleID+"/deliveries", params, &deliveries)
+	return deliveries, err
+}
+
+// --- Resources ---
+
+// ListResources возвращает именованные ресурсы.
+func (c *Client) ListResources() ([]ResourceResponse, error) {
+	var resources []ResourceResponse
+	err := c.list("/api/v1/resources", nil, &resources)
+	return resources, err
+}
+
+// CreateResource создаёт именованный ресурс.
+func (c *Client) CreateResource(req CreateResourceRequest) (*ResourceResponse, error) {
+	var res ResourceResponse
+	err := c.post("/api/v1/resources", req, &res)
+	return &res, err
+}
+
+// GetResource возвращает ресурс с держателями слотов и очередью.
+func (c *Client) GetResource(name string) (*ResourceResponse, error) {
+	var res ResourceResponse
+	err := c.get("/api/v1/resources/"+url.PathEscape(name), &res)
+	return &res, err
+}
+
+// UpdateResource обновляет ресурс.
+func (c *Client) UpdateResource(name string, req UpdateResourceRequest) (*ResourceResponse, error) {
+	var res ResourceResponse
+	err := c.put("/api/v1/resources/"+url.PathEscape(name), req, &res)
+	return &res, err
+}
+
+// DeleteResource удаляет ресурс.
+func (c *Client) DeleteResource(name string) error {
+	return c.delete("/api/v1/resources/" + url.PathEscape(name))
+}
+
+// --- Descriptor sets ---
+
+// ListDescriptorSets возвращает загруженные descriptor sets.
+func (c *Client) ListDescriptorSets() ([]DescriptorSetResponse, error) {
+	var sets []DescriptorSetResponse
+	err := c.list("/api/v1/descriptor-sets", nil, &sets)
+	return sets, err
+}
+
+// UploadDescriptorSet загружает или заменяет descriptor set.
+func (c *Client) UploadDescriptorSet(name string, data []byte) (*DescriptorSetResponse, error) {
+	var set DescriptorSetResponse
+	err := c.put("/api/v1/descriptor-sets/"+url.PathEscape(name), map[string][]byte{"data": data}, &set)
+	return &set, err
+}
+
+// GetDescriptorSet возвращает descriptor set.
+func (c *Client) GetDescriptorSet(name string) (*DescriptorSetResponse, error) {
+	var set DescriptorSetResponse
+	err := c.get("/api/v1/descriptor-sets/"+url.PathEscape(name), &set)
+	return &set, err
+}
+
+// DeleteDescriptorSet удаляет descriptor set.
+func (c *Client) DeleteDescriptorSet(name string) error {
+	return c.delete("/api/v1/descriptor-sets/" + url.PathEscape(name))
+}
+
+// --- Wasm modules ---
+
+// ListWasmModules возвращает загруженные модули (name — фильтр по имени).
+func (c *Client) ListWasmModules(name string) ([]WasmModuleResponse, error) {
+	params := url.Values{}
+	if name != "" {
+		params.Set("name", name)
+	}
+
+	var mods []WasmModuleResponse
+	err := c.list("/api/v1/wasm-modules", params, &mods)
+	return mods, err
+}
+
+// UploadWasmModule загружает модуль как версию version модуля name.
+func (c *Client) UploadWasmModule(req UploadWasmModuleRequest) (*WasmModuleResponse, error) {
+	var mod WasmModuleResponse
+	err := c.post("/api/v1/wasm-modules", req, &mod)
+	return &mod, err
+}
+
+// GetWasmModule возвращает модуль по дайджесту.
+func (c *Client) GetWasmModule(digest string) (*WasmModuleResponse, error) {
+	var mod WasmModuleResponse
+	err := c.get("/api/v1/wasm-modules/"+url.PathEscape(digest), &mod)
+	return &mod, err
+}
+
+// DeleteWasmModule удаляет модуль.
+func (c *Client) DeleteWasmModule(digest string) error {
+	return c.delete("/api/v1/wasm-modules/" + url.PathEscape(digest))
+}
+
 // --- Proposals ---
 
 // ListProposals возвращает список proposals.
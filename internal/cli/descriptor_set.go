@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewDescriptorSetCmd создаёт группу команд для управления descriptor sets grpc шагов.
+func NewDescriptorSetCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "descriptor-set",
+		Short: "Manage protobuf descriptor sets used by grpc steps",
+		Long: `A grpc step loads the service schema through server reflection. For
+servers without reflection upload a descriptor set built with protoc:
+
+  protoc --include_imports --descriptor_set_out=billing.pb billing/v1/*.proto
+  automata descriptor-set upload billing billing.pb
+
+and reference it from the step config:
+
+  { "id": "invoice", "type": "grpc",
+    "config": { "target": "billing:50051", "service": "billing.v1.Invoices",
+                "method": "Create", "descriptor_set": "billing" } }`,
+	}
+
+	cmd.AddCommand(
+		newDescriptorSetListCmd(clientFn, outputFn),
+		newDescriptorSetUploadCmd(clientFn, outputFn),
+		newDescriptorSetShowCmd(clientFn, outputFn),
+		newDescriptorSetDeleteCmd(clientFn, outputFn),
+	)
+
+	return cmd
+}
+
+func newDescriptorSetListCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List descriptor sets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			sets, err := client.ListDescriptorSets()
+			if err != nil {
+				return err
+			}
+
+			headers := []string{"NAME", "SERVICES", "SHA256", "UPDATED"}
+			rows := make([][]string, len(sets))
+			for i, s := range sets {
+				rows[i] = []string{s.Name, strings.Join(s.Services, ", "), shortSHA(s.SHA256), s.UpdatedAt}
+			}
+
+			out.Print(headers, rows, sets)
+			return nil
+		},
+	}
+}
+
+func newDescriptorSetUploadCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "upload NAME FILE",
+		Short: "Upload or replace a descriptor set (protoc --descriptor_set_out)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			data, err := os.ReadFile(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to read descriptor set file: %w", err)
+			}
+
+			set, err := client.UploadDescriptorSet(args[0], data)
+			if err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Descriptor set uploaded: %s", set.Name))
+			printDescriptorSet(out, set)
+			return nil
+		},
+	}
+}
+
+func newDescriptorSetShowCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show NAME",
+		Short: "Show descriptor set details",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			set, err := client.GetDescriptorSet(args[0])
+			if err != nil {
+				return err
+			}
+
+			printDescriptorSet(out, set)
+			return nil
+		},
+	}
+}
+
+func newDescriptorSetDeleteCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete a descriptor set",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if err := client.DeleteDescriptorSet(args[0]); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Descriptor set deleted: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+func printDescriptorSet(out *Output, set *DescriptorSetResponse) {
+	out.Print(
+		[]string{"NAME", "SERVICES", "SHA256", "UPDATED"},
+		[][]string{{set.Name, strings.Join(set.Services, ", "), set.SHA256, set.UpdatedAt}},
+		set,
+	)
+}
+
+// shortSHA сокращает хеш для табличного вывода.
+func shortSHA(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
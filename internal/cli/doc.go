@@ -4,7 +4,7 @@
 //
 // CLI — клиентская утилита для взаимодействия с Automata API.
 // Работает через HTTP, не импортирует внутренние пакеты системы.
-// CLI используется для управления flows, runs и schedules.
+// CLI используется для управления flows, runs, schedules и triggers.
 //
 // # Ключевые компоненты
 //
@@ -30,8 +30,13 @@
 //
 // Cobra-команды организованы по ресурсам:
 //   - flow: list, create, show, update, delete, versions, publish
-//   - run: list, start, show, cancel, tasks
+//   - run: list, start, show, cancel, retry, tasks, signal, waiting
 //   - schedule: list, create, show, update, delete, enable, disable
+//   - trigger: list, create, show, update, delete, enable, disable
+//   - notify: list, create, show, update, delete, enable, disable, deliveries
+//   - resource: list, create, show, update, delete
+//   - descriptor-set: list, upload, show, delete
+//   - wasm: list, upload, show, delete
 //
 // Каждая группа создаётся через фабричную функцию (NewFlowCmd и т.д.),
 // принимающую clientFn и outputFn — замыкания для ленивого создания
@@ -0,0 +1,387 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewNotifyCmd создаёт группу команд для управления правилами уведомлений.
+func NewNotifyCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Manage run completion notifications",
+	}
+
+	cmd.AddCommand(
+		newNotifyListCmd(clientFn, outputFn),
+		newNotifyCreateCmd(clientFn, outputFn),
+		newNotifyShowCmd(clientFn, outputFn),
+		newNotifyUpdateCmd(clientFn, outputFn),
+		newNotifyDeleteCmd(clientFn, outputFn),
+		newNotifyEnableCmd(clientFn, outputFn),
+		newNotifyDisableCmd(clientFn, outputFn),
+		newNotifyDeliveriesCmd(clientFn, outputFn),
+	)
+
+	return cmd
+}
+
+func newNotifyListCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var flowID string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List notification rules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			rules, err := client.ListNotificationRules(flowID)
+			if err != nil {
+				return err
+			}
+
+			headers := []string{"ID", "FLOW_ID", "NAME", "ON", "CHANNEL", "TARGET", "ENABLED"}
+			rows := make([][]string, len(rules))
+			for i, r := range rules {
+				rows[i] = []string{
+					r.ID, r.FlowID, r.Name, formatNotifyEvent(&r), r.Channel,
+					formatNotifyTarget(&r), strconv.FormatBool(r.Enabled),
+				}
+			}
+
+			out.Print(headers, rows, rules)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flowID, "flow-id", "", "Filter by flow ID")
+
+	return cmd
+}
+
+func newNotifyCreateCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var name string
+	var on string
+	var threshold int
+	var channel string
+	var config notifyConfigFlags
+
+	cmd := &cobra.Command{
+		Use:   "create FLOW_ID",
+		Short: "Create a notification rule for a flow",
+		Long: `Create a rule that notifies when runs of the flow finish.
+
+Events:
+  failure               a run failed (including compensated runs)
+  success               a run succeeded
+  consecutive_failures  --threshold runs failed in a row (fires once per streak)
+  sla_breach            a run is still running past the flow's sla_sec
+
+Channels:
+  webhook  POST Slack-compatible JSON {"text": ...} to --url,
+           with optional --header NAME=VALUE
+  email    send a plain-text email to --to through the orchestrator's
+           SMTP server (SMTP_ADDR); --subject sets the subject template
+
+The message is a template over the notification:
+  --message '{{ .Flow.Name }} failed: {{ .Run.Error }}'
+Available: .Flow.Name, .Flow.ID, .Run.ID, .Run.Status, .Run.Error,
+.Run.Inputs, .ConsecutiveFailures, .Rule`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			cfg, err := config.apply(cmd, NotificationConfig{})
+			if err != nil {
+				return err
+			}
+
+			req := CreateNotificationRuleRequest{
+				Name:      name,
+				On:        on,
+				Threshold: threshold,
+				Channel:   channel,
+				Config:    cfg,
+			}
+
+			rule, err := client.CreateNotificationRule(args[0], req)
+			if err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Notification rule created: %s", rule.ID))
+			printNotificationRule(out, rule)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Rule name (required)")
+	cmd.Flags().StringVar(&on, "on", "failure", "Event: failure, success, consecutive_failures or sla_breach")
+	cmd.Flags().IntVar(&threshold, "threshold", 0, "Failures in a row (consecutive_failures)")
+	cmd.Flags().StringVar(&channel, "channel", "webhook", "Channel: webhook or email")
+	config.register(cmd)
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func newNotifyShowCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show ID",
+		Short: "Show notification rule details",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			rule, err := client.GetNotificationRule(args[0])
+			if err != nil {
+				return err
+			}
+
+			out.Print(
+				[]string{"ID", "FLOW_ID", "NAME", "ON", "CHANNEL", "TARGET", "MESSAGE", "ENABLED"},
+				[][]string{{
+					rule.ID, rule.FlowID, rule.Name, formatNotifyEvent(rule), rule.Channel,
+					formatNotifyTarget(rule), rule.Config.Message, strconv.FormatBool(rule.Enabled),
+				}},
+				rule,
+			)
+			return nil
+		},
+	}
+}
+
+func newNotifyUpdateCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var name string
+	var on string
+	var threshold int
+	var config notifyConfigFlags
+
+	cmd := &cobra.Command{
+		Use:   "update ID",
+		Short: "Update a notification rule",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			req := UpdateNotificationRuleRequest{}
+			if cmd.Flags().Changed("name") {
+				req.Name = &name
+			}
+			if cmd.Flags().Changed("on") {
+				req.On = &on
+			}
+			if cmd.Flags().Changed("threshold") {
+				req.Threshold = &threshold
+			}
+			if config.changed(cmd) {
+				// Config заменяется целиком: дополняем текущий изменёнными флагами
+				current, err := client.GetNotificationRule(args[0])
+				if err != nil {
+					return err
+				}
+				updated, err := config.apply(cmd, current.Config)
+				if err != nil {
+					return err
+				}
+				req.Config = &updated
+			}
+
+			rule, err := client.UpdateNotificationRule(args[0], req)
+			if err != nil {
+				return err
+			}
+
+			out.Success("Notification rule updated")
+			printNotificationRule(out, rule)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "New rule name")
+	cmd.Flags().StringVar(&on, "on", "", "New event: failure, success, consecutive_failures or sla_breach")
+	cmd.Flags().IntVar(&threshold, "threshold", 0, "New failures-in-a-row threshold")
+	config.register(cmd)
+
+	return cmd
+}
+
+func newNotifyDeleteCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete a notification rule and its delivery history",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if err := client.DeleteNotificationRule(args[0]); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Notification rule deleted: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+func newNotifyEnableCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable ID",
+		Short: "Enable a notification rule",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if _, err := client.SetNotificationRuleEnabled(args[0], true); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Notification rule enabled: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+func newNotifyDisableCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "disable ID",
+		Short: "Disable a notification rule",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if _, err := client.SetNotificationRuleEnabled(args[0], false); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Notification rule disabled: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+func newNotifyDeliveriesCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "deliveries ID",
+		Short: "Show delivery history of a notification rule",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			deliveries, err := client.ListNotificationDeliveries(args[0], limit)
+			if err != nil {
+				return err
+			}
+
+			headers := []string{"ID", "RUN_ID", "STATUS", "ATTEMPTS", "SENT_AT", "NEXT_ATTEMPT", "ERROR"}
+			rows := make([][]string, len(deliveries))
+			for i, d := range deliveries {
+				rows[i] = []string{
+					d.ID, d.RunID, d.Status, strconv.Itoa(d.Attempts),
+					d.SentAt, d.NextAttemptAt, d.LastError,
+				}
+			}
+
+			out.Print(headers, rows, deliveries)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Max number of deliveries")
+
+	return cmd
+}
+
+// notifyConfigFlags — флаги параметров канала правила уведомления.
+type notifyConfigFlags struct {
+	url     string
+	headers []string
+	to      []string
+	subject string
+	message string
+}
+
+func (f *notifyConfigFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.url, "url", "", "Webhook URL, e.g. a Slack incoming webhook (webhook)")
+	cmd.Flags().StringArrayVar(&f.headers, "header", nil, "Extra request header NAME=VALUE (webhook, repeatable)")
+	cmd.Flags().StringSliceVar(&f.to, "to", nil, "Recipient address (email, repeatable)")
+	cmd.Flags().StringVar(&f.subject, "subject", "", "Subject template (email)")
+	cmd.Flags().StringVar(&f.message, "message", "", "Message template (default: flow, run status and error)")
+}
+
+var notifyConfigFlagNames = []string{"url", "header", "to", "subject", "message"}
+
+// changed проверяет, задан ли хотя бы один флаг config.
+func (f *notifyConfigFlags) changed(cmd *cobra.Command) bool {
+	for _, name := range notifyConfigFlagNames {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply переносит заданные флаги в config.
+func (f *notifyConfigFlags) apply(cmd *cobra.Command, cfg NotificationConfig) (NotificationConfig, error) {
+	flags := cmd.Flags()
+	if flags.Changed("url") {
+		cfg.URL = f.url
+	}
+	if flags.Changed("header") {
+		headers, err := parseMappings(f.headers)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Headers = headers
+	}
+	if flags.Changed("to") {
+		cfg.To = f.to
+	}
+	if flags.Changed("subject") {
+		cfg.Subject = f.subject
+	}
+	if flags.Changed("message") {
+		cfg.Message = f.message
+	}
+	return cfg, nil
+}
+
+func printNotificationRule(out *Output, rule *NotificationRuleResponse) {
+	out.Print(
+		[]string{"ID", "FLOW_ID", "NAME", "ON", "CHANNEL", "TARGET", "ENABLED"},
+		[][]string{{
+			rule.ID, rule.FlowID, rule.Name, formatNotifyEvent(rule), rule.Channel,
+			formatNotifyTarget(rule), strconv.FormatBool(rule.Enabled),
+		}},
+		rule,
+	)
+}
+
+// formatNotifyEvent форматирует событие правила: consecutive_failures с порогом.
+func formatNotifyEvent(r *NotificationRuleResponse) string {
+	if r.Threshold > 0 {
+		return fmt.Sprintf("%s(%d)", r.On, r.Threshold)
+	}
+	return r.On
+}
+
+// formatNotifyTarget форматирует адресата: URL для webhook, получателей для email.
+func formatNotifyTarget(r *NotificationRuleResponse) string {
+	if r.Config.URL != "" {
+		return r.Config.URL
+	}
+	return strings.Join(r.Config.To, ",")
+}
@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewResourceCmd создаёт группу команд для управления именованными ресурсами.
+func NewResourceCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resource",
+		Short: "Manage named resources (mutexes/semaphores) shared by steps",
+		Long: `Named resources limit how many steps across all runs may use an
+external system at once. A step declares them in the flow spec:
+
+  { "id": "post-invoice", "type": "http", "resources": ["erp"], ... }
+
+The orchestrator dispatches the step only after acquiring a slot in every
+declared resource and releases the slots when the task finishes. Steps
+that cannot acquire a slot wait in a FIFO queue.`,
+	}
+
+	cmd.AddCommand(
+		newResourceListCmd(clientFn, outputFn),
+		newResourceCreateCmd(clientFn, outputFn),
+		newResourceShowCmd(clientFn, outputFn),
+		newResourceUpdateCmd(clientFn, outputFn),
+		newResourceDeleteCmd(clientFn, outputFn),
+	)
+
+	return cmd
+}
+
+func newResourceListCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List resources",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			resources, err := client.ListResources()
+			if err != nil {
+				return err
+			}
+
+			headers := []string{"NAME", "CAPACITY", "IN_USE", "WAITING", "DESCRIPTION"}
+			rows := make([][]string, len(resources))
+			for i, r := range resources {
+				rows[i] = []string{
+					r.Name, strconv.Itoa(r.Capacity), strconv.Itoa(r.InUse),
+					strconv.Itoa(r.Waiting), r.Description,
+				}
+			}
+
+			out.Print(headers, rows, resources)
+			return nil
+		},
+	}
+}
+
+func newResourceCreateCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var capacity int
+	var description string
+
+	cmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create a resource",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			res, err := client.CreateResource(CreateResourceRequest{
+				Name:        args[0],
+				Capacity:    capacity,
+				Description: description,
+			})
+			if err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Resource created: %s", res.Name))
+			printResource(out, res)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&capacity, "capacity", 1, "Concurrent slots (1 = mutex)")
+	cmd.Flags().StringVar(&description, "description", "", "Resource description")
+
+	return cmd
+}
+
+func newResourceShowCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show NAME",
+		Short: "Show resource holders and waiting queue",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			res, err := client.GetResource(args[0])
+			if err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("%s: %d of %d slots in use, %d waiting",
+				res.Name, res.InUse, res.Capacity, res.Waiting))
+
+			// Держатели слотов, затем очередь в порядке выдачи слотов
+			headers := []string{"STATE", "RUN_ID", "STEP_ID", "SINCE"}
+			var rows [][]string
+			for _, h := range res.Holders {
+				rows = append(rows, []string{"holding", h.RunID, h.StepID, h.AcquiredAt})
+			}
+			for _, w := range res.Waiters {
+				rows = append(rows, []string{"waiting", w.RunID, w.StepID, w.WaitingSince})
+			}
+
+			out.Print(headers, rows, res)
+			return nil
+		},
+	}
+}
+
+func newResourceUpdateCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var capacity int
+	var description string
+
+	cmd := &cobra.Command{
+		Use:   "update NAME",
+		Short: "Update resource capacity or description",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			req := UpdateResourceRequest{}
+			if cmd.Flags().Changed("capacity") {
+				req.Capacity = &capacity
+			}
+			if cmd.Flags().Changed("description") {
+				req.Description = &description
+			}
+
+			res, err := client.UpdateResource(args[0], req)
+			if err != nil {
+				return err
+			}
+
+			out.Success("Resource updated")
+			printResource(out, res)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&capacity, "capacity", 0, "New number of concurrent slots")
+	cmd.Flags().StringVar(&description, "description", "", "New description")
+
+	return cmd
+}
+
+func newResourceDeleteCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete a resource (fails while slots are held)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if err := client.DeleteResource(args[0]); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Resource deleted: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+func printResource(out *Output, res *ResourceResponse) {
+	out.Print(
+		[]string{"NAME", "CAPACITY", "IN_USE", "WAITING", "DESCRIPTION"},
+		[][]string{{
+			res.Name, strconv.Itoa(res.Capacity), strconv.Itoa(res.InUse),
+			strconv.Itoa(res.Waiting), res.Description,
+		}},
+		res,
+	)
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -20,7 +21,10 @@ func NewRunCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command
 		newRunStartCmd(clientFn, outputFn),
 		newRunShowCmd(clientFn, outputFn),
 		newRunCancelCmd(clientFn, outputFn),
+		newRunRetryCmd(clientFn, outputFn),
 		newRunTasksCmd(clientFn, outputFn),
+		newRunSignalCmd(clientFn, outputFn),
+		newRunWaitingCmd(clientFn, outputFn),
 	)
 
 	return cmd
@@ -29,6 +33,7 @@ func NewRunCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command
 func newRunListCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
 	var flowID string
 	var status string
+	var retryOf string
 	var limit int
 
 	cmd := &cobra.Command{
@@ -39,9 +44,10 @@ func newRunListCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Comm
 			out := outputFn()
 
 			runs, err := client.ListRuns(ListRunsOpts{
-				FlowID: flowID,
-				Status: status,
-				Limit:  limit,
+				FlowID:  flowID,
+				Status:  status,
+				RetryOf: retryOf,
+				Limit:   limit,
 			})
 			if err != nil {
 				return err
@@ -59,7 +65,8 @@ func newRunListCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Comm
 	}
 
 	cmd.Flags().StringVar(&flowID, "flow-id", "", "Filter by flow ID")
-	cmd.Flags().StringVar(&status, "status", "", "Filter by status (PENDING, RUNNING, SUCCEEDED, FAILED, CANCELLED)")
+	cmd.Flags().StringVar(&status, "status", "", "Filter by status (PENDING, RUNNING, SUCCEEDED, FAILED, CANCELLED, COMPENSATING, COMPENSATED, COMPENSATION_FAILED)")
+	cmd.Flags().StringVar(&retryOf, "retry-of", "", "Filter retries of the run with this ID")
 	cmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of results")
 
 	return cmd
@@ -134,8 +141,11 @@ func newRunShowCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Comm
 			}
 
 			out.Print(
-				[]string{"ID", "FLOW_ID", "VERSION", "STATUS", "ERROR", "CREATED"},
-				[][]string{{run.ID, run.FlowID, strconv.Itoa(run.Version), run.Status, run.Error, run.CreatedAt}},
+				[]string{"ID", "FLOW_ID", "VERSION", "STATUS", "WAITING_REASON", "ERROR", "OUTPUTS", "RETRY_OF", "DEADLINE", "SLA_BREACHED", "CREATED"},
+				[][]string{{
+					run.ID, run.FlowID, strconv.Itoa(run.Version), run.Status, run.WaitingReason, run.Error,
+					formatOutputs(run.Outputs), run.RetryOf, run.DeadlineAt, run.SLABreachedAt, run.CreatedAt,
+				}},
 				run,
 			)
 			return nil
@@ -163,6 +173,42 @@ func newRunCancelCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Co
 	}
 }
 
+func newRunRetryCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var fromStep string
+
+	cmd := &cobra.Command{
+		Use:   "retry ID",
+		Short: "Retry a finished run, reusing outputs of succeeded steps",
+		Long: `Start a new run of the same flow version and inputs.
+
+Outputs of succeeded steps are reused; failed, skipped and never started
+steps run again. With --from-step the step and all steps depending on it
+run again as well. The new run references the original via RETRY_OF.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			run, err := client.RetryRun(args[0], RetryRunRequest{FromStep: fromStep})
+			if err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Run retry started: %s", run.ID))
+			out.Print(
+				[]string{"ID", "FLOW_ID", "VERSION", "STATUS", "RETRY_OF", "CREATED"},
+				[][]string{{run.ID, run.FlowID, strconv.Itoa(run.Version), run.Status, run.RetryOf, run.CreatedAt}},
+				run,
+			)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&fromStep, "from-step", "", "Rerun this step and all steps depending on it")
+
+	return cmd
+}
+
 func newRunTasksCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
 	return &cobra.Command{
 		Use:   "tasks RUN_ID",
@@ -188,3 +234,104 @@ func newRunTasksCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Com
 		},
 	}
 }
+
+func newRunSignalCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var decision string
+	var payload string
+	var fields []string
+
+	cmd := &cobra.Command{
+		Use:   "signal RUN_ID STEP_ID",
+		Short: "Send a signal to a step waiting for approval",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			req := SignalRequest{Decision: decision}
+
+			if payload != "" {
+				if err := json.Unmarshal([]byte(payload), &req.Payload); err != nil {
+					return fmt.Errorf("invalid payload JSON: %w", err)
+				}
+			}
+
+			if len(fields) > 0 {
+				if req.Payload == nil {
+					req.Payload = make(map[string]any)
+				}
+				for _, kv := range fields {
+					parts := strings.SplitN(kv, "=", 2)
+					if len(parts) != 2 {
+						return fmt.Errorf("invalid field format %q, expected KEY=VALUE", kv)
+					}
+					req.Payload[parts[0]] = parts[1]
+				}
+			}
+
+			task, err := client.SignalStep(args[0], args[1], req)
+			if err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Signal sent: %s/%s", args[0], args[1]))
+			out.Print(
+				[]string{"ID", "STEP_ID", "TYPE", "STATUS"},
+				[][]string{{task.ID, task.StepID, task.Type, task.Status}},
+				task,
+			)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&decision, "decision", "", "Decision (approve, reject or a custom value)")
+	cmd.Flags().StringVar(&payload, "payload", "", "Signal payload as JSON object")
+	cmd.Flags().StringSliceVar(&fields, "field", nil, "Payload values as KEY=VALUE (repeatable)")
+
+	return cmd
+}
+
+func newRunWaitingCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var stepType string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "waiting",
+		Short: "List steps waiting for approval or a signal",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			steps, err := client.ListWaitingSteps(stepType, limit)
+			if err != nil {
+				return err
+			}
+
+			headers := []string{"RUN_ID", "STEP_ID", "TYPE", "WAITING_SINCE", "DEADLINE"}
+			rows := make([][]string, len(steps))
+			for i, s := range steps {
+				rows[i] = []string{s.RunID, s.StepID, s.Type, s.WaitingSince, s.DeadlineAt}
+			}
+
+			out.Print(headers, rows, steps)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&stepType, "type", "", "Filter by step type (approval, wait_for_signal)")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of results")
+
+	return cmd
+}
+
+// formatOutputs форматирует outputs run компактным JSON.
+func formatOutputs(outputs map[string]any) string {
+	if len(outputs) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(outputs)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
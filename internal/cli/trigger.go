@@ -0,0 +1,408 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NewTriggerCmd создаёт группу команд для управления triggers.
+func NewTriggerCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trigger",
+		Short: "Manage event triggers",
+	}
+
+	cmd.AddCommand(
+		newTriggerListCmd(clientFn, outputFn),
+		newTriggerCreateCmd(clientFn, outputFn),
+		newTriggerShowCmd(clientFn, outputFn),
+		newTriggerUpdateCmd(clientFn, outputFn),
+		newTriggerDeleteCmd(clientFn, outputFn),
+		newTriggerEnableCmd(clientFn, outputFn),
+		newTriggerDisableCmd(clientFn, outputFn),
+	)
+
+	return cmd
+}
+
+func newTriggerListCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var flowID string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List triggers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			triggers, err := client.ListTriggers(flowID)
+			if err != nil {
+				return err
+			}
+
+			headers := []string{"ID", "FLOW_ID", "NAME", "TYPE", "SOURCE", "ENABLED", "LAST_FIRED"}
+			rows := make([][]string, len(triggers))
+			for i, t := range triggers {
+				rows[i] = []string{
+					t.ID, t.FlowID, t.Name, t.Type, formatTriggerSource(&t),
+					strconv.FormatBool(t.Enabled), t.LastFiredAt,
+				}
+			}
+
+			out.Print(headers, rows, triggers)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flowID, "flow-id", "", "Filter by flow ID")
+
+	return cmd
+}
+
+func newTriggerCreateCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var name string
+	var triggerType string
+	var config triggerConfigFlags
+	var filter string
+	var mappings []string
+
+	cmd := &cobra.Command{
+		Use:   "create FLOW_ID",
+		Short: "Create a trigger for a flow",
+		Long: `Create a trigger that starts a run of the flow for every matching event.
+
+The filter is a condition over the event without {{ }}, e.g.
+  --filter 'eq .Body.status "paid"'
+
+Input mappings are templates over the event (.Body, .Headers, .Query, .RoutingKey, .ID):
+  --map 'order_id={{ .Body.id }}' --map 'tenant={{ index .Headers "x-tenant" }}'
+
+Without mappings the event body (a JSON object) becomes the run inputs.
+
+Types:
+  amqp     consume messages from --exchange with --binding-key
+  webhook  accept POST /api/v1/hooks/<trigger_id>; optional --signature
+           (github, stripe, hmac_sha256) with --secret, --allow-ip,
+           --dedupe-header and --sync to wait for the run result
+  flow     start when a run of --upstream-flow finishes with --status
+           (default SUCCEEDED); the event body is the upstream run:
+           .Body.status, .Body.inputs, .Body.steps.<step_id>.<output>.
+           Without mappings the upstream run inputs are passed through`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			inputMapping, err := parseMappings(mappings)
+			if err != nil {
+				return err
+			}
+
+			req := CreateTriggerRequest{
+				Name:         name,
+				Type:         triggerType,
+				Config:       config.apply(cmd, TriggerConfig{}),
+				Filter:       filter,
+				InputMapping: inputMapping,
+			}
+
+			trigger, err := client.CreateTrigger(args[0], req)
+			if err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Trigger created: %s", trigger.ID))
+			printTrigger(out, trigger)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Trigger name (required)")
+	cmd.Flags().StringVar(&triggerType, "type", "amqp", "Trigger type: amqp, webhook or flow")
+	config.register(cmd)
+	cmd.Flags().StringVar(&filter, "filter", "", "Condition over the event (without {{ }})")
+	cmd.Flags().StringArrayVar(&mappings, "map", nil, "Input mapping as NAME=TEMPLATE (repeatable)")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func newTriggerShowCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show ID",
+		Short: "Show trigger details",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			trigger, err := client.GetTrigger(args[0])
+			if err != nil {
+				return err
+			}
+
+			out.Print(
+				[]string{"ID", "FLOW_ID", "NAME", "TYPE", "SOURCE", "FILTER", "ENABLED", "LAST_RUN"},
+				[][]string{{
+					trigger.ID, trigger.FlowID, trigger.Name, trigger.Type,
+					formatTriggerSource(trigger), trigger.Filter,
+					strconv.FormatBool(trigger.Enabled), trigger.LastRunID,
+				}},
+				trigger,
+			)
+			return nil
+		},
+	}
+}
+
+func newTriggerUpdateCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var name string
+	var config triggerConfigFlags
+	var filter string
+	var mappings []string
+
+	cmd := &cobra.Command{
+		Use:   "update ID",
+		Short: "Update a trigger",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			req := UpdateTriggerRequest{}
+			if cmd.Flags().Changed("name") {
+				req.Name = &name
+			}
+			if cmd.Flags().Changed("filter") {
+				req.Filter = &filter
+			}
+			if config.changed(cmd) {
+				// Config заменяется целиком: дополняем текущий изменёнными флагами
+				current, err := client.GetTrigger(args[0])
+				if err != nil {
+					return err
+				}
+				updated := config.apply(cmd, current.Config)
+				req.Config = &updated
+			}
+			if cmd.Flags().Changed("map") {
+				inputMapping, err := parseMappings(mappings)
+				if err != nil {
+					return err
+				}
+				if inputMapping == nil {
+					inputMapping = map[string]string{}
+				}
+				req.InputMapping = &inputMapping
+			}
+
+			trigger, err := client.UpdateTrigger(args[0], req)
+			if err != nil {
+				return err
+			}
+
+			out.Success("Trigger updated")
+			printTrigger(out, trigger)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "New trigger name")
+	config.register(cmd)
+	cmd.Flags().StringVar(&filter, "filter", "", "New filter (empty string removes it)")
+	cmd.Flags().StringArrayVar(&mappings, "map", nil, "Replace input mapping, NAME=TEMPLATE (repeatable)")
+
+	return cmd
+}
+
+func newTriggerDeleteCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete a trigger",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if err := client.DeleteTrigger(args[0]); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Trigger deleted: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+func newTriggerEnableCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable ID",
+		Short: "Enable a trigger",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if _, err := client.SetTriggerEnabled(args[0], true); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Trigger enabled: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+func newTriggerDisableCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "disable ID",
+		Short: "Disable a trigger",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if _, err := client.SetTriggerEnabled(args[0], false); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Trigger disabled: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+// triggerConfigFlags — флаги параметров источника событий trigger.
+type triggerConfigFlags struct {
+	exchange        string
+	bindingKey      string
+	secret          string
+	signatureScheme string
+	signatureHeader string
+	allowedIPs      []string
+	dedupeHeader    string
+	sync            bool
+	syncTimeoutSec  int
+	upstreamFlowID  string
+	statuses        []string
+}
+
+func (f *triggerConfigFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.exchange, "exchange", "", "RabbitMQ exchange to consume from (amqp)")
+	cmd.Flags().StringVar(&f.bindingKey, "binding-key", "", "Binding key, e.g. 'orders.*' (amqp)")
+	cmd.Flags().StringVar(&f.secret, "secret", "", "HMAC secret for request signatures (webhook)")
+	cmd.Flags().StringVar(&f.signatureScheme, "signature", "", "Signature scheme: github, stripe or hmac_sha256 (webhook)")
+	cmd.Flags().StringVar(&f.signatureHeader, "signature-header", "", "Header carrying the signature (webhook)")
+	cmd.Flags().StringSliceVar(&f.allowedIPs, "allow-ip", nil, "Allowed client IP or CIDR (webhook, repeatable)")
+	cmd.Flags().StringVar(&f.dedupeHeader, "dedupe-header", "", "Header with a dedupe key, e.g. X-GitHub-Delivery (webhook)")
+	cmd.Flags().BoolVar(&f.sync, "sync", false, "Wait for the run and return its result (webhook)")
+	cmd.Flags().IntVar(&f.syncTimeoutSec, "sync-timeout", 0, "Max seconds to wait in sync mode (webhook)")
+	cmd.Flags().StringVar(&f.upstreamFlowID, "upstream-flow", "", "Flow whose finished runs start this trigger (flow)")
+	cmd.Flags().StringSliceVar(&f.statuses, "status", nil, "Upstream run status, default SUCCEEDED (flow, repeatable)")
+}
+
+var triggerConfigFlagNames = []string{
+	"exchange", "binding-key", "secret", "signature", "signature-header",
+	"allow-ip", "dedupe-header", "sync", "sync-timeout", "upstream-flow", "status",
+}
+
+// changed проверяет, задан ли хотя бы один флаг config.
+func (f *triggerConfigFlags) changed(cmd *cobra.Command) bool {
+	for _, name := range triggerConfigFlagNames {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply переносит заданные флаги в config.
+func (f *triggerConfigFlags) apply(cmd *cobra.Command, cfg TriggerConfig) TriggerConfig {
+	flags := cmd.Flags()
+	if flags.Changed("exchange") {
+		cfg.Exchange = f.exchange
+	}
+	if flags.Changed("binding-key") {
+		cfg.BindingKey = f.bindingKey
+	}
+	if flags.Changed("secret") {
+		cfg.Secret = f.secret
+	}
+	if flags.Changed("signature") {
+		cfg.SignatureScheme = f.signatureScheme
+	}
+	if flags.Changed("signature-header") {
+		cfg.SignatureHeader = f.signatureHeader
+	}
+	if flags.Changed("allow-ip") {
+		cfg.AllowedIPs = f.allowedIPs
+	}
+	if flags.Changed("dedupe-header") {
+		cfg.DedupeHeader = f.dedupeHeader
+	}
+	if flags.Changed("sync") {
+		cfg.Sync = f.sync
+	}
+	if flags.Changed("sync-timeout") {
+		cfg.SyncTimeoutSec = f.syncTimeoutSec
+	}
+	if flags.Changed("upstream-flow") {
+		cfg.UpstreamFlowID = f.upstreamFlowID
+	}
+	if flags.Changed("status") {
+		cfg.Statuses = make([]string, len(f.statuses))
+		for i, status := range f.statuses {
+			cfg.Statuses[i] = strings.ToUpper(status)
+		}
+	}
+	return cfg
+}
+
+func printTrigger(out *Output, trigger *TriggerResponse) {
+	out.Print(
+		[]string{"ID", "FLOW_ID", "NAME", "TYPE", "SOURCE", "ENABLED"},
+		[][]string{{
+			trigger.ID, trigger.FlowID, trigger.Name, trigger.Type,
+			formatTriggerSource(trigger), strconv.FormatBool(trigger.Enabled),
+		}},
+		trigger,
+	)
+}
+
+// formatTriggerSource форматирует источник событий:
+// exchange/binding_key для amqp, URL для webhook, upstream flow для flow.
+func formatTriggerSource(t *TriggerResponse) string {
+	if t.HookURL != "" {
+		return t.HookURL
+	}
+	if t.Config.UpstreamFlowID != "" {
+		statuses := t.Config.Statuses
+		if len(statuses) == 0 {
+			statuses = []string{"SUCCEEDED"}
+		}
+		return "flow " + t.Config.UpstreamFlowID + " " + strings.Join(statuses, ",")
+	}
+	if t.Config.Exchange == "" {
+		return ""
+	}
+	return t.Config.Exchange + "/" + t.Config.BindingKey
+}
+
+// parseMappings разбирает NAME=TEMPLATE пары.
+func parseMappings(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(pairs))
+	for _, kv := range pairs {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid mapping format %q, expected NAME=TEMPLATE", kv)
+		}
+		result[parts[0]] = parts[1]
+	}
+	return result, nil
+}
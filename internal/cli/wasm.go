@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// NewWasmCmd создаёт группу команд для управления модулями wasm шагов.
+func NewWasmCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wasm",
+		Short: "Manage WebAssembly modules used by wasm steps",
+		Long: `A wasm step runs an uploaded WASI module. Build a command module, for
+example with Go:
+
+  GOOS=wasip1 GOARCH=wasm go build -o score.wasm ./score
+  automata wasm upload score 1.0.0 score.wasm
+
+and reference it from the step config by the digest printed on upload:
+
+  { "id": "score", "type": "wasm",
+    "config": { "module": "sha256:<digest>", "threshold": 10 } }
+
+The module reads {"config": ..., "context": ...} as JSON on stdin and
+writes its outputs as JSON to stdout.`,
+	}
+
+	cmd.AddCommand(
+		newWasmListCmd(clientFn, outputFn),
+		newWasmUploadCmd(clientFn, outputFn),
+		newWasmShowCmd(clientFn, outputFn),
+		newWasmDeleteCmd(clientFn, outputFn),
+	)
+
+	return cmd
+}
+
+func newWasmListCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List wasm modules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			mods, err := client.ListWasmModules(name)
+			if err != nil {
+				return err
+			}
+
+			headers := []string{"NAME", "VERSION", "DIGEST", "SIZE", "CREATED"}
+			rows := make([][]string, len(mods))
+			for i, m := range mods {
+				rows[i] = []string{m.Name, m.Version, shortDigest(m.Digest), strconv.FormatInt(m.Size, 10), m.CreatedAt}
+			}
+
+			out.Print(headers, rows, mods)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Show only versions of this module")
+
+	return cmd
+}
+
+func newWasmUploadCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "upload NAME VERSION FILE",
+		Short: "Upload a wasm module version",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			data, err := os.ReadFile(args[2])
+			if err != nil {
+				return fmt.Errorf("failed to read module file: %w", err)
+			}
+
+			mod, err := client.UploadWasmModule(UploadWasmModuleRequest{
+				Name:    args[0],
+				Version: args[1],
+				Data:    data,
+			})
+			if err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Wasm module uploaded: %s %s", mod.Name, mod.Version))
+			printWasmModule(out, mod)
+			return nil
+		},
+	}
+}
+
+func newWasmShowCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show DIGEST",
+		Short: "Show wasm module details",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			mod, err := client.GetWasmModule(args[0])
+			if err != nil {
+				return err
+			}
+
+			printWasmModule(out, mod)
+			return nil
+		},
+	}
+}
+
+func newWasmDeleteCmd(clientFn func() *Client, outputFn func() *Output) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete DIGEST",
+		Short: "Delete a wasm module",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := clientFn()
+			out := outputFn()
+
+			if err := client.DeleteWasmModule(args[0]); err != nil {
+				return err
+			}
+
+			out.Success(fmt.Sprintf("Wasm module deleted: %s", args[0]))
+			return nil
+		},
+	}
+}
+
+func printWasmModule(out *Output, mod *WasmModuleResponse) {
+	out.Print(
+		[]string{"NAME", "VERSION", "DIGEST", "SIZE", "CREATED"},
+		[][]string{{mod.Name, mod.Version, mod.Digest, strconv.FormatInt(mod.Size, 10), mod.CreatedAt}},
+		mod,
+	)
+}
+
+// shortDigest сокращает дайджест модуля (sha256:<hex>) для табличного вывода.
+func shortDigest(digest string) string {
+	const prefix = len("sha256:")
+	if len(digest) > prefix+12 {
+		return digest[:prefix+12]
+	}
+	return digest
+}
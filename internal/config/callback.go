@@ -0,0 +1,44 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// CallbackSecretEnv — переменная окружения с секретом подписи callback URL
+// (общим для API и Orchestrator).
+const CallbackSecretEnv = "CALLBACK_SECRET"
+
+// InsecureDevEnv — явный флаг режима разработки. Разрешает запуск без
+// CALLBACK_SECRET: секрет генерируется случайно при каждом старте.
+const InsecureDevEnv = "AUTOMATA_INSECURE_DEV"
+
+// ErrCallbackSecretRequired — CALLBACK_SECRET не задан вне режима разработки.
+var ErrCallbackSecretRequired = errors.New(CallbackSecretEnv + " is required (set " + InsecureDevEnv + "=true for local development)")
+
+// LoadCallbackSecret возвращает секрет подписи callback URL из окружения.
+//
+// Без CALLBACK_SECRET сервис не должен стартовать: иначе любой может
+// подделать callback URL. Только при AUTOMATA_INSECURE_DEV=true
+// возвращается случайный секрет и generated = true — такой секрет
+// у каждого процесса свой, поэтому callback URL, подписанные
+// Orchestrator, API не примет.
+func LoadCallbackSecret(getenv func(string) string) (secret string, generated bool, err error) {
+	if secret := getenv(CallbackSecretEnv); secret != "" {
+		return secret, false, nil
+	}
+
+	dev, _ := strconv.ParseBool(getenv(InsecureDevEnv))
+	if !dev {
+		return "", false, ErrCallbackSecretRequired
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", false, fmt.Errorf("generate callback secret: %w", err)
+	}
+	return hex.EncodeToString(b), true, nil
+}
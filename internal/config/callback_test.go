@@ -0,0 +1,31 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLoadCallbackSecret(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	secret, generated, err := LoadCallbackSecret(env(map[string]string{CallbackSecretEnv: "s3cret"}))
+	if err != nil || secret != "s3cret" || generated {
+		t.Errorf("unexpected result: %q, %t, %v", secret, generated, err)
+	}
+
+	if _, _, err := LoadCallbackSecret(env(nil)); !errors.Is(err, ErrCallbackSecretRequired) {
+		t.Errorf("expected ErrCallbackSecretRequired, got %v", err)
+	}
+
+	// В режиме разработки секрет случайный, а не общеизвестный
+	first, generated, err := LoadCallbackSecret(env(map[string]string{InsecureDevEnv: "true"}))
+	if err != nil || !generated || len(first) != 64 {
+		t.Fatalf("unexpected result: %q, %t, %v", first, generated, err)
+	}
+	second, _, _ := LoadCallbackSecret(env(map[string]string{InsecureDevEnv: "true"}))
+	if first == second {
+		t.Error("generated secrets should differ")
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConnections(t *testing.T) {
+	conns, err := LoadConnections([]string{
+		"AUTOMATA_CONN_REPORTING=postgres://reader:secret@db:5432/reports",
+		"AUTOMATA_CONN_EVENTS=amqp://guest:guest@mq:5672/",
+		"AUTOMATA_CONN_EMPTY=",
+		"DATABASE_URL=postgres://automata@localhost/automata",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := conns.Names(); !reflect.DeepEqual(got, []string{"events", "reporting"}) {
+		t.Errorf("unexpected names: %v", got)
+	}
+
+	conn, err := conns.Get("Reporting", "postgres")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conn.String() != "postgres://reader:xxxxx@db:5432/reports" {
+		t.Errorf("password should be redacted, got %s", conn)
+	}
+
+	if _, err := conns.Get("events", "postgres"); err == nil {
+		t.Error("expected error for unsupported scheme")
+	}
+	if _, err := conns.Get("missing"); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("expected ErrConnectionNotFound, got %v", err)
+	}
+}
+
+func TestNewConnections_Invalid(t *testing.T) {
+	if _, err := NewConnections(map[string]string{"db": "db.internal/reports"}); err == nil {
+		t.Error("expected error for url without scheme")
+	}
+
+	var conns *Connections
+	if _, err := conns.Get("db"); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("nil Connections: expected ErrConnectionNotFound, got %v", err)
+	}
+}
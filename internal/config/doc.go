@@ -6,4 +6,14 @@
 //
 // Каждый сервис имеет свою структуру конфигурации,
 // но все используют общие настройки (DB, RabbitMQ, etc.).
+//
+// # Именованные подключения
+//
+// Connections — подключения к внешним системам для шагов (sql, amqp_publish, email),
+// заданные переменными AUTOMATA_CONN_<NAME>=<url> в окружении воркера.
+// Шаг ссылается на подключение по имени, учётные данные остаются
+// в окружении:
+//
+//	connections, err := config.LoadConnections(os.Environ())
+//	conn, err := connections.Get("reporting", "postgres", "postgresql")
 package config
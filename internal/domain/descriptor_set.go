@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// DescriptorSet — загруженный protobuf FileDescriptorSet со схемой
+// gRPC-сервисов для шага grpc (config.descriptor_set).
+//
+// Используется для серверов без server reflection. Повторная загрузка
+// под тем же именем заменяет набор.
+type DescriptorSet struct {
+	// Name — уникальное имя набора (например, "billing").
+	Name string `json:"name"`
+
+	// Data — сериализованный FileDescriptorSet.
+	Data []byte `json:"-"`
+
+	// SHA256 — hex-дайджест Data (меняется при каждой новой загрузке).
+	SHA256 string `json:"sha256"`
+
+	// Services — полные имена сервисов набора.
+	Services []string `json:"services"`
+
+	// CreatedAt — время первой загрузки.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt — время последней загрузки.
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,7 +1,8 @@
 // Package domain содержит доменные модели системы Automata.
 //
 // Доменные модели — это чистые структуры данных, которые представляют
-// бизнес-сущности: Flow, Run, Task, Schedule, Proposal.
+// бизнес-сущности: Flow, Run, Task, Schedule, Trigger, NotificationRule, Resource,
+// DescriptorSet, WasmModule, Proposal.
 //
 // Важно: этот пакет НЕ должен зависеть от других пакетов проекта.
 // Все остальные пакеты зависят от domain, но не наоборот.
@@ -73,6 +73,23 @@ type FlowSpec struct {
 
 	// OnFailure — обработчик ошибок (выполняется при падении flow).
 	OnFailure *StepDef `json:"on_failure,omitempty"`
+
+	// TimeoutSec — дедлайн run в секундах с момента старта (0 — без ограничения).
+	// Run, не завершившийся к дедлайну, падает, его незавершённые tasks отменяются.
+	TimeoutSec int `json:"timeout_sec,omitempty"`
+
+	// SLASec — ожидаемая длительность run в секундах (0 — не задана).
+	// Превышение не останавливает run: срабатывают уведомления sla_breach.
+	SLASec int `json:"sla_sec,omitempty"`
+
+	// Concurrency — ограничение количества одновременно выполняющихся runs flow.
+	Concurrency *ConcurrencyPolicy `json:"concurrency,omitempty"`
+
+	// Outputs — результат flow: имя → Go template над контекстом run.
+	// Вычисляется при успешном завершении run и сохраняется в Run.Outputs.
+	// Шаблон из одного выражения сохраняет тип значения:
+	// "items": "{{ .Steps.fetch.Outputs.body.items }}".
+	Outputs map[string]string `json:"outputs,omitempty"`
 }
 
 // InputDef — определение входного параметра.
@@ -108,7 +125,9 @@ type StepDef struct {
 	// Name — человекочитаемое имя шага.
 	Name string `json:"name,omitempty"`
 
-	// Type — тип шага: "http", "delay", "transform", "parallel".
+	// Type — тип шага: "http", "delay", "transform", "parallel", "poll", "sql",
+	// "amqp_publish", "email", "grpc", "script", "wasm", "external",
+	// "approval", "wait_for_signal", "wait_for_callback".
 	Type string `json:"type"`
 
 	// DependsOn — список ID шагов, от которых зависит этот шаг.
@@ -136,6 +155,15 @@ type StepDef struct {
 	// Переопределяет defaults.timeout_sec.
 	TimeoutSec int `json:"timeout_sec,omitempty"`
 
+	// Resources — имена ресурсов (Resource), слот в каждом из которых нужен
+	// шагу. Шаг запускается только после получения всех слотов.
+	Resources []string `json:"resources,omitempty"`
+
+	// Compensate — шаг отмены (saga). Если run падает, компенсации всех
+	// успешно завершённых шагов выполняются в обратном топологическом порядке.
+	// Outputs исходного шага доступны в config компенсации как {{ .Outputs }}.
+	Compensate *StepDef `json:"compensate,omitempty"`
+
 	// Branches — ветки для параллельного выполнения (только для type="parallel").
 	Branches []Branch `json:"branches,omitempty"`
 }
@@ -154,15 +182,47 @@ type RetryPolicy struct {
 	// MaxDelayMs — максимальная задержка в миллисекундах.
 	MaxDelayMs int `json:"max_delay_ms,omitempty"`
 
-	// OnStatus — HTTP статусы, при которых делать retry (для http шагов).
+	// OnStatus — HTTP статусы, при которых делать retry (для http шагов;
+	// для grpc шагов — коды gRPC-статуса). Без OnStatus постоянные ошибки
+	// (SMTP 5xx у email, неповторяемые коды gRPC) не повторяются.
 	OnStatus []int `json:"on_status,omitempty"`
 }
 
+// Политики concurrency при достижении лимита.
+const (
+	// ConcurrencyQueue — новый run ждёт в PENDING, пока не освободится слот.
+	ConcurrencyQueue = "queue"
+
+	// ConcurrencySkipNew — новый run отменяется без выполнения.
+	ConcurrencySkipNew = "skip_new"
+
+	// ConcurrencyCancelRunning — самые старые выполняющиеся runs отменяются,
+	// новый run запускается.
+	ConcurrencyCancelRunning = "cancel_running"
+)
+
+// ConcurrencyPolicy — ограничение параллельных runs flow.
+type ConcurrencyPolicy struct {
+	// MaxConcurrentRuns — максимум runs в статусе RUNNING (на ключ, если задан Key).
+	MaxConcurrentRuns int `json:"max_concurrent_runs"`
+
+	// Policy — поведение при достижении лимита: "queue" (по умолчанию),
+	// "skip_new", "cancel_running".
+	Policy string `json:"policy,omitempty"`
+
+	// Key — Go template над inputs run, разделяющий лимит по значению.
+	// Например, "{{ .Inputs.customer_id }}" — лимит на каждого клиента.
+	Key string `json:"key,omitempty"`
+}
+
 // Branch — ветка параллельного выполнения.
 type Branch struct {
 	// ID — идентификатор ветки.
 	ID string `json:"id"`
 
 	// Steps — шаги внутри ветки.
+	// Без depends_on выполняются последовательно; depends_on с ID шагов
+	// этой же ветки задаёт собственный граф зависимостей ветки.
+	// Могут содержать вложенные parallel шаги.
 	Steps []StepDef `json:"steps"`
 }
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationEvent — событие run, на которое срабатывает правило уведомления.
+type NotificationEvent string
+
+const (
+	// NotifyOnFailure — run завершился ошибкой (FAILED, COMPENSATED, COMPENSATION_FAILED).
+	NotifyOnFailure NotificationEvent = "failure"
+
+	// NotifyOnSuccess — run завершился успешно.
+	NotifyOnSuccess NotificationEvent = "success"
+
+	// NotifyOnConsecutiveFailures — Threshold runs flow подряд завершились ошибкой.
+	// Срабатывает один раз на серию: при N-й ошибке подряд.
+	NotifyOnConsecutiveFailures NotificationEvent = "consecutive_failures"
+
+	// NotifyOnSLABreach — run выполняется дольше FlowSpec.SLASec.
+	// Срабатывает для ещё не завершённого run, один раз на run.
+	NotifyOnSLABreach NotificationEvent = "sla_breach"
+)
+
+// NotificationChannel — канал доставки уведомления.
+type NotificationChannel string
+
+const (
+	// NotificationChannelWebhook — POST JSON на URL (совместим со Slack incoming webhooks).
+	NotificationChannelWebhook NotificationChannel = "webhook"
+
+	// NotificationChannelEmail — письмо через SMTP сервер.
+	NotificationChannelEmail NotificationChannel = "email"
+)
+
+// NotificationRule — правило уведомления о завершении runs flow.
+//
+// Когда run flow завершается, Orchestrator проверяет включённые правила
+// flow и для каждого подходящего создаёт NotificationDelivery. Доставка
+// выполняется асинхронно, с повторами при ошибках.
+type NotificationRule struct {
+	// ID — уникальный идентификатор правила.
+	ID uuid.UUID `json:"id"`
+
+	// FlowID — flow, о runs которого уведомляет правило.
+	FlowID uuid.UUID `json:"flow_id"`
+
+	// Name — имя правила для удобства.
+	Name string `json:"name"`
+
+	// On — событие, на которое срабатывает правило.
+	On NotificationEvent `json:"on"`
+
+	// Threshold — количество ошибок подряд (для consecutive_failures).
+	Threshold int `json:"threshold,omitempty"`
+
+	// Channel — канал доставки.
+	Channel NotificationChannel `json:"channel"`
+
+	// Config — параметры канала и шаблоны сообщения.
+	Config NotificationConfig `json:"config"`
+
+	// Enabled — флаг активности правила.
+	Enabled bool `json:"enabled"`
+
+	// CreatedAt — время создания правила.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt — время последнего обновления.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotificationConfig — параметры канала и шаблоны уведомления.
+//
+// Шаблоны рендерятся над данными уведомления:
+//
+//	{{ .Flow.Name }}, {{ .Run.ID }}, {{ .Run.Status }}, {{ .Run.Error }},
+//	{{ .Run.Inputs.date }}, {{ .ConsecutiveFailures }}, {{ .Rule }}
+type NotificationConfig struct {
+	// URL — адрес webhook (webhook).
+	URL string `json:"url,omitempty"`
+
+	// Headers — дополнительные HTTP заголовки запроса (webhook).
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// To — адреса получателей (email).
+	To []string `json:"to,omitempty"`
+
+	// Subject — шаблон темы письма (email).
+	Subject string `json:"subject,omitempty"`
+
+	// Message — шаблон текста уведомления.
+	// Для webhook передаётся в поле "text" JSON тела.
+	Message string `json:"message,omitempty"`
+}
+
+// DeliveryStatus — статус доставки уведомления.
+type DeliveryStatus string
+
+const (
+	// DeliveryStatusPending — ожидает отправки (в том числе повторной).
+	DeliveryStatusPending DeliveryStatus = "PENDING"
+
+	// DeliveryStatusSent — уведомление доставлено.
+	DeliveryStatusSent DeliveryStatus = "SENT"
+
+	// DeliveryStatusFailed — все попытки доставки исчерпаны.
+	DeliveryStatusFailed DeliveryStatus = "FAILED"
+)
+
+// NotificationDelivery — попытка уведомить о run по правилу (история доставки).
+type NotificationDelivery struct {
+	// ID — уникальный идентификатор доставки.
+	ID uuid.UUID `json:"id"`
+
+	// RuleID — правило, по которому создано уведомление.
+	RuleID uuid.UUID `json:"rule_id"`
+
+	// RunID — run, о котором уведомление.
+	RunID uuid.UUID `json:"run_id"`
+
+	// Status — статус доставки.
+	Status DeliveryStatus `json:"status"`
+
+	// Subject — отрендеренная тема (email).
+	Subject string `json:"subject,omitempty"`
+
+	// Body — отрендеренное тело: JSON для webhook, текст для email.
+	Body string `json:"body"`
+
+	// Attempts — количество выполненных попыток.
+	Attempts int `json:"attempts"`
+
+	// LastError — ошибка последней попытки.
+	LastError string `json:"last_error,omitempty"`
+
+	// NextAttemptAt — время следующей попытки (для PENDING).
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+
+	// SentAt — время успешной доставки.
+	SentAt *time.Time `json:"sent_at,omitempty"`
+
+	// CreatedAt — время создания.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MarkSent помечает уведомление доставленным.
+func (d *NotificationDelivery) MarkSent() {
+	now := time.Now()
+	d.Status = DeliveryStatusSent
+	d.LastError = ""
+	d.NextAttemptAt = nil
+	d.SentAt = &now
+}
+
+// MarkAttemptFailed записывает неудачную попытку.
+// Если retryAt == nil, попытки исчерпаны и доставка переходит в FAILED.
+func (d *NotificationDelivery) MarkAttemptFailed(errMsg string, retryAt *time.Time) {
+	d.LastError = errMsg
+	d.NextAttemptAt = retryAt
+	if retryAt == nil {
+		d.Status = DeliveryStatusFailed
+	}
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Resource — именованный ресурс с ограниченной ёмкостью (мьютекс при
+// Capacity = 1, семафор при Capacity > 1), общий для всех runs.
+//
+// Шаг объявляет нужные ресурсы в StepDef.Resources. Orchestrator запускает
+// шаг, только получив слот в каждом из них, и освобождает слоты при
+// завершении task. Слоты хранятся в Postgres (ResourceLease) и переживают
+// рестарт Orchestrator.
+type Resource struct {
+	// Name — уникальное имя ресурса (например, "erp").
+	Name string `json:"name"`
+
+	// Capacity — максимальное количество одновременно занятых слотов.
+	Capacity int `json:"capacity"`
+
+	// Description — описание ресурса.
+	Description string `json:"description,omitempty"`
+
+	// InUse — количество занятых слотов (вычисляется при чтении).
+	InUse int `json:"in_use"`
+
+	// Waiting — количество шагов в очереди (вычисляется при чтении).
+	Waiting int `json:"waiting"`
+
+	// CreatedAt — время создания ресурса.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt — время последнего изменения.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ResourceLease — занятый слот ресурса: шаг run, который сейчас выполняется.
+type ResourceLease struct {
+	// Resource — имя ресурса.
+	Resource string `json:"resource"`
+
+	// RunID — run, шаг которого занимает слот.
+	RunID uuid.UUID `json:"run_id"`
+
+	// StepID — шаг, занимающий слот.
+	StepID string `json:"step_id"`
+
+	// AcquiredAt — время получения слота.
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// ResourceWaiter — шаг run, ожидающий свободного слота ресурса.
+// Слоты выдаются ожидающим в порядке очереди (WaitingSince).
+type ResourceWaiter struct {
+	// Resource — имя ресурса.
+	Resource string `json:"resource"`
+
+	// RunID — run ожидающего шага.
+	RunID uuid.UUID `json:"run_id"`
+
+	// StepID — ожидающий шаг.
+	StepID string `json:"step_id"`
+
+	// WaitingSince — время постановки в очередь.
+	WaitingSince time.Time `json:"waiting_since"`
+}
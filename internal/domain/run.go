@@ -42,6 +42,27 @@ type Run struct {
 	// Error — текст ошибки, если run завершился с FAILED.
 	Error string `json:"error,omitempty"`
 
+	// DeadlineAt — дедлайн run (StartedAt + FlowSpec.TimeoutSec).
+	// Nil, если flow не задаёт timeout_sec.
+	DeadlineAt *time.Time `json:"deadline_at,omitempty"`
+
+	// SLAAt — момент превышения ожидаемой длительности (StartedAt + FlowSpec.SLASec).
+	SLAAt *time.Time `json:"sla_at,omitempty"`
+
+	// SLABreachedAt — время, когда зафиксировано превышение SLA.
+	SLABreachedAt *time.Time `json:"sla_breached_at,omitempty"`
+
+	// ConcurrencyKey — значение FlowSpec.Concurrency.Key для inputs run.
+	ConcurrencyKey string `json:"concurrency_key,omitempty"`
+
+	// WaitingReason — почему PENDING run ещё не запущен (например, лимит concurrency).
+	// Очищается при старте run.
+	WaitingReason string `json:"waiting_reason,omitempty"`
+
+	// Outputs — результат run, вычисленный по FlowSpec.Outputs.
+	// Заполняется только для SUCCEEDED runs.
+	Outputs map[string]any `json:"outputs,omitempty"`
+
 	// IdempotencyKey — ключ идемпотентности для предотвращения дубликатов.
 	// Например, для scheduled runs: "{schedule_id}_{next_due_at}"
 	IdempotencyKey string `json:"idempotency_key,omitempty"`
@@ -53,6 +74,12 @@ type Run struct {
 	// Если задано, оркестратор использует эту спеку вместо загрузки из flow_versions.
 	SpecOverride *FlowSpec `json:"spec_override,omitempty"`
 
+	// RetryOf — исходный run, если этот run — его повторный запуск.
+	RetryOf *uuid.UUID `json:"retry_of,omitempty"`
+
+	// RetryFromStep — шаг, с которого перезапущен run (пусто — повтор упавших шагов).
+	RetryFromStep string `json:"retry_from_step,omitempty"`
+
 	// CreatedAt — время создания run.
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -76,13 +103,33 @@ func (r *Run) MarkRunning() {
 	now := time.Now()
 	r.Status = RunStatusRunning
 	r.StartedAt = &now
+	r.WaitingReason = ""
+}
+
+// SetDeadlines задаёт дедлайн и SLA run от момента старта.
+// Нулевая длительность означает отсутствие ограничения.
+func (r *Run) SetDeadlines(timeout, sla time.Duration) {
+	if r.StartedAt == nil {
+		return
+	}
+	r.DeadlineAt = nil
+	r.SLAAt = nil
+	if timeout > 0 {
+		deadline := r.StartedAt.Add(timeout)
+		r.DeadlineAt = &deadline
+	}
+	if sla > 0 {
+		slaAt := r.StartedAt.Add(sla)
+		r.SLAAt = &slaAt
+	}
 }
 
-// MarkSucceeded переводит run в статус SUCCEEDED.
-func (r *Run) MarkSucceeded() {
+// MarkSucceeded переводит run в статус SUCCEEDED с результатом outputs.
+func (r *Run) MarkSucceeded(outputs map[string]any) {
 	now := time.Now()
 	r.Status = RunStatusSucceeded
 	r.FinishedAt = &now
+	r.Outputs = outputs
 }
 
 // MarkFailed переводит run в статус FAILED с ошибкой.
@@ -98,4 +145,32 @@ func (r *Run) MarkCancelled() {
 	now := time.Now()
 	r.Status = RunStatusCancelled
 	r.FinishedAt = &now
+	r.WaitingReason = ""
+}
+
+// MarkCompensating переводит упавший run в статус COMPENSATING.
+// err — причина падения run; run ещё не завершён.
+func (r *Run) MarkCompensating(err string) {
+	r.Status = RunStatusCompensating
+	r.Error = err
+}
+
+// MarkCompensated переводит run в статус COMPENSATED (все компенсации успешны).
+func (r *Run) MarkCompensated() {
+	now := time.Now()
+	r.Status = RunStatusCompensated
+	r.FinishedAt = &now
+}
+
+// MarkCompensationFailed переводит run в статус COMPENSATION_FAILED.
+// err дополняет исходную причину падения run.
+func (r *Run) MarkCompensationFailed(err string) {
+	now := time.Now()
+	r.Status = RunStatusCompensationFailed
+	r.FinishedAt = &now
+	if r.Error != "" {
+		r.Error += "; " + err
+	} else {
+		r.Error = err
+	}
 }
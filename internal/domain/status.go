@@ -6,7 +6,11 @@ package domain
 //
 //	PENDING → RUNNING → SUCCEEDED
 //	                  ↘ FAILED
+//	                  ↘ COMPENSATING → COMPENSATED
+//	                                 ↘ COMPENSATION_FAILED
 //	          (или) → CANCELLED (из PENDING или RUNNING)
+//
+// COMPENSATING — run упал, выполняются компенсации (saga) завершённых шагов.
 type RunStatus string
 
 const (
@@ -24,12 +28,33 @@ const (
 
 	// RunStatusCancelled — run отменён пользователем.
 	RunStatusCancelled RunStatus = "CANCELLED"
+
+	// RunStatusCompensating — run упал, выполняются компенсации.
+	RunStatusCompensating RunStatus = "COMPENSATING"
+
+	// RunStatusCompensated — run упал, все компенсации выполнены успешно.
+	RunStatusCompensated RunStatus = "COMPENSATED"
+
+	// RunStatusCompensationFailed — run упал, часть компенсаций завершилась ошибкой.
+	RunStatusCompensationFailed RunStatus = "COMPENSATION_FAILED"
 )
 
 // IsTerminal возвращает true, если статус финальный (run завершён).
 func (s RunStatus) IsTerminal() bool {
 	switch s {
-	case RunStatusSucceeded, RunStatusFailed, RunStatusCancelled:
+	case RunStatusSucceeded, RunStatusFailed, RunStatusCancelled,
+		RunStatusCompensated, RunStatusCompensationFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFailure возвращает true, если run завершился ошибкой
+// (в том числе после выполнения компенсаций).
+func (s RunStatus) IsFailure() bool {
+	switch s {
+	case RunStatusFailed, RunStatusCompensated, RunStatusCompensationFailed:
 		return true
 	default:
 		return false
@@ -42,6 +67,13 @@ func (s RunStatus) IsTerminal() bool {
 //
 //	QUEUED → RUNNING → SUCCEEDED
 //	                 ↘ FAILED (может быть retry → обратно в QUEUED)
+//
+// Шаги ожидания сигнала (approval, wait_for_signal) не выполняются воркером:
+//
+//	WAITING → SUCCEEDED (сигнал или timeout с default решением)
+//	        ↘ FAILED (timeout)
+//
+// Незавершённые tasks run, превысившего дедлайн, переходят в CANCELLED.
 type TaskStatus string
 
 const (
@@ -56,12 +88,18 @@ const (
 
 	// TaskStatusFailed — task завершился с ошибкой (после всех retry).
 	TaskStatusFailed TaskStatus = "FAILED"
+
+	// TaskStatusWaiting — task ожидает внешнего сигнала, слот воркера не занят.
+	TaskStatusWaiting TaskStatus = "WAITING"
+
+	// TaskStatusCancelled — task отменён вместе с run (дедлайн run).
+	TaskStatusCancelled TaskStatus = "CANCELLED"
 )
 
 // IsTerminal возвращает true, если статус финальный.
 func (s TaskStatus) IsTerminal() bool {
 	switch s {
-	case TaskStatusSucceeded, TaskStatusFailed:
+	case TaskStatusSucceeded, TaskStatusFailed, TaskStatusCancelled:
 		return true
 	default:
 		return false
@@ -26,7 +26,7 @@ type Task struct {
 	// Name — имя шага (для удобства, копия StepDef.Name).
 	Name string `json:"name"`
 
-	// Type — тип шага: "http", "delay", "transform", "parallel".
+	// Type — тип шага: "http", "delay", "transform", "poll", "approval", "wait_for_signal".
 	Type string `json:"type"`
 
 	// Attempt — номер попытки (начиная с 1).
@@ -58,6 +58,11 @@ type Task struct {
 	// Error — текст ошибки при неудаче.
 	Error string `json:"error,omitempty"`
 
+	// NextAttemptAt — время следующего действия над task.
+	// QUEUED task (poll) с NextAttemptAt в будущем не берётся в работу.
+	// Для WAITING task — дедлайн ожидания сигнала (nil — без ограничения).
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+
 	// CreatedAt — время создания task.
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -80,9 +85,37 @@ func (t *Task) MarkRunning() {
 	now := time.Now()
 	t.Status = TaskStatusRunning
 	t.StartedAt = &now
+	t.NextAttemptAt = nil
 	t.Attempt++
 }
 
+// Reschedule возвращает task в QUEUED до времени at, сохраняя промежуточные outputs.
+// Используется шагами, которые выполняются итерациями (poll).
+func (t *Task) Reschedule(outputs map[string]any, at time.Time) {
+	t.Status = TaskStatusQueued
+	t.Outputs = outputs
+	t.NextAttemptAt = &at
+}
+
+// IsDue проверяет, наступило ли время выполнения task.
+func (t *Task) IsDue(now time.Time) bool {
+	return t.NextAttemptAt == nil || !t.NextAttemptAt.After(now)
+}
+
+// MarkWaiting переводит task в статус WAITING до получения сигнала.
+// deadline — время истечения ожидания (nil — без ограничения).
+func (t *Task) MarkWaiting(deadline *time.Time) {
+	now := time.Now()
+	t.Status = TaskStatusWaiting
+	t.StartedAt = &now
+	t.NextAttemptAt = deadline
+}
+
+// IsWaiting возвращает true, если task ожидает сигнала.
+func (t *Task) IsWaiting() bool {
+	return t.Status == TaskStatusWaiting
+}
+
 // MarkSucceeded переводит task в статус SUCCEEDED с результатами.
 func (t *Task) MarkSucceeded(outputs map[string]any) {
 	now := time.Now()
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TriggerType — тип источника событий триггера.
+type TriggerType string
+
+const (
+	// TriggerTypeAMQP — сообщения RabbitMQ из внешнего exchange.
+	TriggerTypeAMQP TriggerType = "amqp"
+
+	// TriggerTypeWebhook — входящие HTTP-запросы на /api/v1/hooks/{trigger_id}.
+	TriggerTypeWebhook TriggerType = "webhook"
+
+	// TriggerTypeFlow — завершение run другого flow (цепочки flows).
+	TriggerTypeFlow TriggerType = "flow"
+)
+
+// Trigger — запуск flow по внешнему событию.
+//
+// В отличие от Schedule, Trigger срабатывает не по времени, а по событию:
+// для каждого подходящего события создаётся run. Inputs run формируются
+// из события шаблонами InputMapping; повторная доставка того же события
+// не создаёт новый run (idempotency key строится из ID события).
+type Trigger struct {
+	// ID — уникальный идентификатор триггера.
+	ID uuid.UUID `json:"id"`
+
+	// FlowID — ссылка на flow, который нужно запускать.
+	FlowID uuid.UUID `json:"flow_id"`
+
+	// Name — имя триггера для удобства.
+	Name string `json:"name"`
+
+	// Type — тип источника событий.
+	Type TriggerType `json:"type"`
+
+	// Config — параметры источника (зависят от Type).
+	Config TriggerConfig `json:"config"`
+
+	// Filter — условие над событием в синтаксисе condition (без {{ }}).
+	// Пустой фильтр пропускает все события.
+	// Пример: eq .Body.status "paid"
+	Filter string `json:"filter,omitempty"`
+
+	// InputMapping — шаблоны inputs run (имя input → шаблон над событием).
+	// Если не задан, inputs — тело события (если это JSON объект).
+	// Пример: {"order_id": "{{ .Body.id }}"}
+	InputMapping map[string]string `json:"input_mapping,omitempty"`
+
+	// Enabled — флаг активности триггера.
+	Enabled bool `json:"enabled"`
+
+	// LastFiredAt — время последнего срабатывания.
+	LastFiredAt *time.Time `json:"last_fired_at,omitempty"`
+
+	// LastRunID — ID последнего созданного run.
+	LastRunID *uuid.UUID `json:"last_run_id,omitempty"`
+
+	// CreatedAt — время создания триггера.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt — время последнего обновления.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TriggerConfig — параметры источника событий.
+type TriggerConfig struct {
+	// Exchange — exchange RabbitMQ, из которого читаются сообщения (amqp).
+	Exchange string `json:"exchange,omitempty"`
+
+	// BindingKey — ключ привязки очереди триггера к exchange (amqp).
+	// Для topic exchange допускаются шаблоны: "orders.*", "orders.#".
+	BindingKey string `json:"binding_key,omitempty"`
+
+	// Secret — секрет HMAC подписи запроса (webhook).
+	// В ответах API не возвращается.
+	Secret string `json:"secret,omitempty"`
+
+	// SignatureScheme — формат подписи (webhook):
+	//   - "github"      — X-Hub-Signature-256: sha256=<hex(hmac(body))>
+	//   - "stripe"      — Stripe-Signature: t=<unix>,v1=<hex(hmac(t.body))>
+	//   - "hmac_sha256" — <hex(hmac(body))> в заголовке SignatureHeader
+	// Пусто — подпись не проверяется.
+	SignatureScheme string `json:"signature_scheme,omitempty"`
+
+	// SignatureHeader — заголовок с подписью (webhook).
+	// По умолчанию зависит от SignatureScheme.
+	SignatureHeader string `json:"signature_header,omitempty"`
+
+	// AllowedIPs — IP-адреса и CIDR, с которых принимаются запросы (webhook).
+	// Пусто — без ограничений.
+	AllowedIPs []string `json:"allowed_ips,omitempty"`
+
+	// DedupeHeader — заголовок с ключом дедупликации (webhook),
+	// например "X-GitHub-Delivery". Запросы с одинаковым ключом создают один run.
+	DedupeHeader string `json:"dedupe_header,omitempty"`
+
+	// Sync — ждать завершения run и вернуть результат в ответе (webhook).
+	Sync bool `json:"sync,omitempty"`
+
+	// SyncTimeoutSec — максимальное время ожидания run в синхронном режиме.
+	// По умолчанию 30 секунд; по истечении возвращается 202 с ID run.
+	SyncTimeoutSec int `json:"sync_timeout_sec,omitempty"`
+
+	// UpstreamFlowID — flow, завершение runs которого запускает триггер (flow).
+	UpstreamFlowID *uuid.UUID `json:"upstream_flow_id,omitempty"`
+
+	// Statuses — статусы run upstream flow, при которых срабатывает триггер (flow).
+	// По умолчанию только SUCCEEDED.
+	Statuses []RunStatus `json:"statuses,omitempty"`
+}
+
+// RecordRun записывает информацию о срабатывании.
+// UpdatedAt не меняется: он отражает изменение определения триггера.
+func (t *Trigger) RecordRun(runID uuid.UUID) {
+	now := time.Now()
+	t.LastFiredAt = &now
+	t.LastRunID = &runID
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// WasmModule — загруженный WebAssembly-модуль (программа WASI) для шага
+// wasm (config.module).
+//
+// Модуль адресуется по содержимому: Digest однозначно определяет сборку,
+// а Name и Version — метка для людей. Загруженный модуль не изменяется.
+type WasmModule struct {
+	// Digest — дайджест содержимого (sha256:<hex>).
+	Digest string `json:"digest"`
+
+	// Name — имя модуля (например, "score").
+	Name string `json:"name"`
+
+	// Version — версия сборки, уникальная в пределах имени.
+	Version string `json:"version"`
+
+	// Size — размер модуля в байтах.
+	Size int64 `json:"size"`
+
+	// Data — содержимое модуля.
+	Data []byte `json:"-"`
+
+	// CreatedAt — время загрузки.
+	CreatedAt time.Time `json:"created_at"`
+}
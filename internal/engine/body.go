@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Форматы тел HTTP запросов и ответов.
+const (
+	BodyFormatJSON = "json"
+	BodyFormatXML  = "xml"
+	BodyFormatCSV  = "csv"
+	BodyFormatText = "text"
+
+	// BodyFormatAuto — формат ответа по Content-Type.
+	BodyFormatAuto = "auto"
+)
+
+// Ключи config http шага, относящиеся к форматам тел.
+const (
+	// BodyFormatKey — формат тела запроса (json по умолчанию).
+	BodyFormatKey = "body_format"
+
+	// ResponseFormatKey — формат тела ответа (auto по умолчанию).
+	ResponseFormatKey = "response_format"
+
+	// BodyCSVKey — опции CSV (ParseCSVOptions).
+	BodyCSVKey = "csv"
+
+	// BodyXMLRootKey — имя корневого элемента XML тела запроса.
+	BodyXMLRootKey = "xml_root"
+)
+
+// BodyOptions — настройки форматов тел http шага.
+type BodyOptions struct {
+	// Format — формат тела запроса.
+	Format string
+
+	// ResponseFormat — формат тела ответа.
+	ResponseFormat string
+
+	// CSV — опции разбора и кодирования CSV.
+	CSV CSVOptions
+
+	// XMLRoot — корневой элемент XML тела запроса (см. EncodeXML).
+	XMLRoot string
+}
+
+// ParseBodyOptions извлекает настройки форматов тел из config http шага:
+//
+//	{
+//	  "body_format": "xml",
+//	  "xml_root": "order",
+//	  "response_format": "csv",
+//	  "csv": {"delimiter": ";", "header": true}
+//	}
+func ParseBodyOptions(config map[string]any) (BodyOptions, error) {
+	opts := BodyOptions{Format: BodyFormatJSON, ResponseFormat: BodyFormatAuto}
+
+	if v, ok := config[BodyFormatKey]; ok {
+		s, _ := v.(string)
+		if s != BodyFormatJSON && s != BodyFormatXML && s != BodyFormatCSV && s != BodyFormatText {
+			return opts, fmt.Errorf("%w: %s must be one of json, xml, csv, text", ErrInvalidBodyFormat, BodyFormatKey)
+		}
+		opts.Format = s
+	}
+	if v, ok := config[ResponseFormatKey]; ok {
+		s, _ := v.(string)
+		if s != BodyFormatAuto && s != BodyFormatJSON && s != BodyFormatXML && s != BodyFormatCSV && s != BodyFormatText {
+			return opts, fmt.Errorf("%w: %s must be one of auto, json, xml, csv, text", ErrInvalidBodyFormat, ResponseFormatKey)
+		}
+		opts.ResponseFormat = s
+	}
+
+	csvOpts, err := ParseCSVOptions(config[BodyCSVKey])
+	if err != nil {
+		return opts, fmt.Errorf("%w: %v", ErrInvalidBodyFormat, err)
+	}
+	opts.CSV = csvOpts
+
+	if v, ok := config[BodyXMLRootKey]; ok {
+		s, ok := v.(string)
+		if !ok || (s != "" && !xmlNameRe.MatchString(s)) {
+			return opts, fmt.Errorf("%w: %s must be a valid element name", ErrInvalidBodyFormat, BodyXMLRootKey)
+		}
+		opts.XMLRoot = s
+	}
+
+	return opts, nil
+}
+
+// EncodeBody кодирует тело запроса в формате opts.Format. Возвращает
+// данные и Content-Type по умолчанию.
+//
+// Строка и []byte в форматах xml, csv и text отправляются как есть:
+// тело уже сформировано шаблоном.
+func EncodeBody(v any, opts BodyOptions) ([]byte, string, error) {
+	format := opts.Format
+	if format == "" {
+		format = BodyFormatJSON
+	}
+
+	if format != BodyFormatJSON {
+		switch b := v.(type) {
+		case string:
+			return []byte(b), bodyContentType(format), nil
+		case []byte:
+			return b, bodyContentType(format), nil
+		}
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case BodyFormatJSON:
+		data, err = json.Marshal(v)
+	case BodyFormatXML:
+		data, err = EncodeXML(v, opts.XMLRoot)
+	case BodyFormatCSV:
+		data, err = EncodeCSV(v, opts.CSV)
+	case BodyFormatText:
+		// Объекты и списки в текстовом теле — JSON
+		s, scalarErr := formatScalar(v)
+		if scalarErr != nil {
+			data, err = json.Marshal(v)
+		} else {
+			data = []byte(s)
+		}
+	default:
+		err = fmt.Errorf("%w: %q", ErrInvalidBodyFormat, format)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, bodyContentType(format), nil
+}
+
+// bodyContentType — Content-Type тела запроса в формате format.
+func bodyContentType(format string) string {
+	switch format {
+	case BodyFormatXML:
+		return "application/xml; charset=utf-8"
+	case BodyFormatCSV:
+		return "text/csv; charset=utf-8"
+	case BodyFormatText:
+		return "text/plain; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// DetectBodyFormat определяет формат тела по Content-Type. Пустая
+// строка — формат не распознан.
+func DetectBodyFormat(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return BodyFormatJSON
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return BodyFormatXML
+	case mediaType == "text/csv" || mediaType == "application/csv" || mediaType == "text/tab-separated-values":
+		return BodyFormatCSV
+	case strings.HasPrefix(mediaType, "text/"):
+		return BodyFormatText
+	}
+	return ""
+}
+
+// DecodeBody разбирает тело ответа. Формат — opts.ResponseFormat либо,
+// для auto, по Content-Type (DetectBodyFormat); TSV разбирается как CSV
+// с табуляцией, если разделитель не задан. Нераспознанный формат
+// возвращается строкой.
+func DecodeBody(data []byte, contentType string, opts BodyOptions) (any, error) {
+	format := opts.ResponseFormat
+	if format == "" || format == BodyFormatAuto {
+		format = DetectBodyFormat(contentType)
+	}
+
+	switch format {
+	case BodyFormatJSON:
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case BodyFormatXML:
+		return DecodeXML(data)
+	case BodyFormatCSV:
+		csvOpts := opts.CSV
+		if csvOpts.Delimiter == 0 && strings.Contains(contentType, "tab-separated-values") {
+			csvOpts.Delimiter = '\t'
+		}
+		return DecodeCSV(data, csvOpts)
+	default:
+		return string(data), nil
+	}
+}
+
+// formatScalar форматирует скалярное значение как текст.
+func formatScalar(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(val), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case json.Number:
+		return val.String(), nil
+	default:
+		return "", fmt.Errorf("cannot format %T as text", v)
+	}
+}
@@ -0,0 +1,356 @@
+package engine
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+	}{
+		{
+			name:     "text only",
+			input:    `<?xml version="1.0"?><status>ok</status>`,
+			expected: map[string]any{"status": "ok"},
+		},
+		{
+			name:  "attributes, repeated children and mixed text",
+			input: `<order id="42"><item sku="a1">apple</item><item>pear</item><note>fragile</note>total</order>`,
+			expected: map[string]any{"order": map[string]any{
+				"@id": "42",
+				"item": []any{
+					map[string]any{"@sku": "a1", "#text": "apple"},
+					"pear",
+				},
+				"note":  "fragile",
+				"#text": "total",
+			}},
+		},
+		{
+			name: "namespaces dropped",
+			input: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+				<soap:Body><m:Price xmlns:m="urn:x">10.5</m:Price></soap:Body>
+			</soap:Envelope>`,
+			expected: map[string]any{"Envelope": map[string]any{
+				"Body": map[string]any{"Price": "10.5"},
+			}},
+		},
+		{
+			name:     "empty element",
+			input:    `<root><empty/></root>`,
+			expected: map[string]any{"root": map[string]any{"empty": ""}},
+		},
+		{
+			name:     "legacy charset",
+			input:    `<?xml version="1.0" encoding="windows-1251"?><name>Bob</name>`,
+			expected: map[string]any{"name": "Bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DecodeXML([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDecodeXML_Invalid(t *testing.T) {
+	for _, input := range []string{"", "not xml", "<a><b></a>", "<a>"} {
+		if _, err := DecodeXML([]byte(input)); !errors.Is(err, ErrInvalidXML) {
+			t.Errorf("DecodeXML(%q): expected ErrInvalidXML, got %v", input, err)
+		}
+	}
+}
+
+func TestEncodeXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		root     string
+		expected string
+	}{
+		{
+			name: "single key map",
+			value: map[string]any{"order": map[string]any{
+				"@id":  42.0,
+				"item": []any{"a", "b"},
+				"note": "<fragile> & dry",
+			}},
+			expected: `<order id="42"><item>a</item><item>b</item><note>&lt;fragile&gt; &amp; dry</note></order>`,
+		},
+		{
+			name:     "explicit root",
+			value:    map[string]any{"name": "Alice", "active": true, "#text": "x"},
+			root:     "user",
+			expected: `<user>x<active>true</active><name>Alice</name></user>`,
+		},
+		{
+			name:     "scalar",
+			value:    "ok",
+			root:     "status",
+			expected: `<status>ok</status>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EncodeXML(tt.value, tt.root)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestEncodeXML_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		root  string
+	}{
+		{"no root", map[string]any{"a": "1", "b": "2"}, ""},
+		{"invalid element name", map[string]any{"1st": "x"}, "root"},
+		{"invalid attribute name", map[string]any{"@a b": "x"}, "root"},
+		{"nested attribute value", map[string]any{"@a": map[string]any{}}, "root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := EncodeXML(tt.value, tt.root); !errors.Is(err, ErrInvalidXML) {
+				t.Errorf("expected ErrInvalidXML, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     CSVOptions
+		expected []any
+	}{
+		{
+			name:  "auto header and delimiter",
+			input: "\xef\xbb\xbfid;name\n1;Alice\n\n2;\"Bob; Jr\"\n",
+			expected: []any{
+				map[string]any{"id": "1", "name": "Alice"},
+				map[string]any{"id": "2", "name": "Bob; Jr"},
+			},
+		},
+		{
+			name:  "numeric first row is data",
+			input: "1,Alice\n2,Bob\n",
+			expected: []any{
+				map[string]any{"col1": "1", "col2": "Alice"},
+				map[string]any{"col1": "2", "col2": "Bob"},
+			},
+		},
+		{
+			name:  "ragged rows",
+			input: "id,name\n1\n2,Bob,extra\n",
+			expected: []any{
+				map[string]any{"id": "1", "name": ""},
+				map[string]any{"id": "2", "name": "Bob", "col3": "extra"},
+			},
+		},
+		{
+			name:  "header absent",
+			input: "id|name\n",
+			opts:  CSVOptions{Delimiter: '|', Header: CSVHeaderAbsent},
+			expected: []any{
+				map[string]any{"col1": "id", "col2": "name"},
+			},
+		},
+		{
+			name:  "columns replace header",
+			input: "ID\tNAME\n1\tAlice\n",
+			opts:  CSVOptions{Header: CSVHeaderPresent, Columns: []string{"id", "name"}},
+			expected: []any{
+				map[string]any{"id": "1", "name": "Alice"},
+			},
+		},
+		{
+			name:     "empty",
+			input:    "",
+			expected: []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DecodeCSV([]byte(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	rows := []any{
+		map[string]any{"id": 1.0, "name": "Alice, A.", "tags": []any{"x"}},
+		map[string]any{"id": 2.0, "extra": nil},
+	}
+
+	tests := []struct {
+		name     string
+		value    any
+		opts     CSVOptions
+		expected string
+	}{
+		{
+			name:     "sorted columns",
+			value:    rows,
+			expected: "extra,id,name,tags\n,1,\"Alice, A.\",\"[\"\"x\"\"]\"\n,2,,\n",
+		},
+		{
+			name:     "columns and delimiter",
+			value:    rows,
+			opts:     CSVOptions{Delimiter: ';', Columns: []string{"name", "id"}},
+			expected: "name;id\nAlice, A.;1\n;2\n",
+		},
+		{
+			name:     "list rows without header",
+			value:    []any{[]any{"a", 1.0, true}},
+			opts:     CSVOptions{Header: CSVHeaderAbsent},
+			expected: "a,1,true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := EncodeCSV(tt.value, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	if _, err := EncodeCSV(map[string]any{"id": 1}, CSVOptions{}); !errors.Is(err, ErrInvalidCSV) {
+		t.Errorf("expected ErrInvalidCSV for non-list value, got %v", err)
+	}
+}
+
+func TestParseBodyOptions(t *testing.T) {
+	opts, err := ParseBodyOptions(map[string]any{
+		"body_format":     "xml",
+		"xml_root":        "order",
+		"response_format": "csv",
+		"csv":             map[string]any{"delimiter": `\t`, "header": false, "columns": []any{"id"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := BodyOptions{
+		Format:         BodyFormatXML,
+		ResponseFormat: BodyFormatCSV,
+		XMLRoot:        "order",
+		CSV:            CSVOptions{Delimiter: '\t', Header: CSVHeaderAbsent, Columns: []string{"id"}},
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, opts)
+	}
+
+	invalid := []map[string]any{
+		{"body_format": "yaml"},
+		{"response_format": "{{ .Inputs.format }}"},
+		{"csv": "semicolon"},
+		{"csv": map[string]any{"delimiter": ";;"}},
+		{"csv": map[string]any{"header": "yes"}},
+		{"csv": map[string]any{"columns": []any{""}}},
+		{"xml_root": "1st"},
+	}
+	for _, config := range invalid {
+		if _, err := ParseBodyOptions(config); !errors.Is(err, ErrInvalidBodyFormat) {
+			t.Errorf("ParseBodyOptions(%v): expected ErrInvalidBodyFormat, got %v", config, err)
+		}
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+		opts        BodyOptions
+		expected    any
+	}{
+		{"json", `{"a":1}`, "application/problem+json", BodyOptions{}, map[string]any{"a": 1.0}},
+		{"xml", `<a>1</a>`, "text/xml; charset=utf-8", BodyOptions{}, map[string]any{"a": "1"}},
+		{"csv", "a,b\n1,2\n", "text/csv", BodyOptions{}, []any{map[string]any{"a": "1", "b": "2"}}},
+		{"tsv", "a b\tc\n1 2\t3\n", "text/tab-separated-values", BodyOptions{},
+			[]any{map[string]any{"a b": "1 2", "c": "3"}}},
+		{"forced format", `<a>1</a>`, "application/octet-stream",
+			BodyOptions{ResponseFormat: BodyFormatXML}, map[string]any{"a": "1"}},
+		{"forced text", `{"a":1}`, "application/json",
+			BodyOptions{ResponseFormat: BodyFormatText}, `{"a":1}`},
+		{"unknown", "raw", "application/octet-stream", BodyOptions{}, "raw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DecodeBody([]byte(tt.data), tt.contentType, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestEncodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       any
+		opts        BodyOptions
+		expected    string
+		contentType string
+	}{
+		{"json", map[string]any{"a": 1}, BodyOptions{}, `{"a":1}`, "application/json"},
+		{"xml", map[string]any{"a": "1"}, BodyOptions{Format: BodyFormatXML, XMLRoot: "req"},
+			`<req><a>1</a></req>`, "application/xml; charset=utf-8"},
+		{"csv", []any{map[string]any{"a": "1"}}, BodyOptions{Format: BodyFormatCSV},
+			"a\n1\n", "text/csv; charset=utf-8"},
+		{"prerendered xml", "<a>1</a>", BodyOptions{Format: BodyFormatXML},
+			"<a>1</a>", "application/xml; charset=utf-8"},
+		{"text object", map[string]any{"a": 1}, BodyOptions{Format: BodyFormatText},
+			`{"a":1}`, "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, contentType, err := EncodeBody(tt.value, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("expected body %q, got %q", tt.expected, data)
+			}
+			if contentType != tt.contentType {
+				t.Errorf("expected content type %q, got %q", tt.contentType, contentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// StepTypeWaitForCallback — ожидание входящего HTTP-запроса (callback) от внешней системы.
+const StepTypeWaitForCallback = "wait_for_callback"
+
+// CallbackPathPrefix — путь API, на который приходят callbacks.
+const CallbackPathPrefix = "/api/v1/callbacks/"
+
+// IsWaitingStep проверяет, ожидает ли шаг данного типа внешнего события
+// (сигнала или callback). Такие шаги не выполняются воркером: task
+// переводится в WAITING.
+func IsWaitingStep(stepType string) bool {
+	return IsSignalStep(stepType) || stepType == StepTypeWaitForCallback
+}
+
+// ParseCallbackTimeout извлекает timeout_sec шага wait_for_callback.
+// 0 — без ограничения. По истечении таймаута шаг падает.
+func ParseCallbackTimeout(config map[string]any) (time.Duration, error) {
+	v, ok := config["timeout_sec"]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := configInt(v)
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("%w: timeout_sec must be a non-negative integer", ErrInvalidCallbackConfig)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+// WaitTimeout возвращает максимальное время ожидания шага approval,
+// wait_for_signal или wait_for_callback.
+func WaitTimeout(stepType string, config map[string]any) (time.Duration, error) {
+	if stepType == StepTypeWaitForCallback {
+		return ParseCallbackTimeout(config)
+	}
+	cfg, err := ParseSignalConfig(stepType, config)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.Timeout, nil
+}
+
+// CallbackSigner формирует и проверяет подписанные callback URL.
+//
+// URL детерминирован для пары (run, step), поэтому известен ещё до запуска
+// шага и доступен предыдущим и параллельным шагам:
+//
+//	<base>/api/v1/callbacks/<run_id>/<step_id>?token=<hmac-sha256(secret, run_id:step_id)>
+type CallbackSigner struct {
+	baseURL string
+	secret  []byte
+}
+
+// NewCallbackSigner создаёт CallbackSigner.
+// baseURL — публичный адрес API, secret — общий секрет API и Orchestrator.
+func NewCallbackSigner(baseURL, secret string) *CallbackSigner {
+	return &CallbackSigner{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  []byte(secret),
+	}
+}
+
+// Token возвращает подпись callback для шага run.
+func (s *CallbackSigner) Token(runID uuid.UUID, stepID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(runID.String() + ":" + stepID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL возвращает подписанный callback URL шага run.
+func (s *CallbackSigner) URL(runID uuid.UUID, stepID string) string {
+	return s.baseURL + CallbackPathPrefix + runID.String() + "/" + url.PathEscape(stepID) +
+		"?token=" + s.Token(runID, stepID)
+}
+
+// Verify проверяет подпись callback (сравнение за постоянное время).
+func (s *CallbackSigner) Verify(runID uuid.UUID, stepID, token string) bool {
+	expected := s.Token(runID, stepID)
+	return hmac.Equal([]byte(expected), []byte(token))
+}
+
+// CallbackRequest — входящий callback.
+type CallbackRequest struct {
+	Method  string
+	Headers map[string]string
+	Body    []byte
+}
+
+// ApplyCallback завершает WAITING task шага wait_for_callback.
+//
+// Outputs шага:
+//   - callback_url — URL, на который пришёл callback
+//   - method — HTTP-метод запроса
+//   - headers — заголовки запроса
+//   - body — тело запроса (разобранный JSON или строка)
+//   - received_at — время получения (RFC3339)
+//   - timed_out — false
+func ApplyCallback(task *domain.Task, req CallbackRequest) {
+	var body any
+	if len(req.Body) > 0 {
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			body = string(req.Body)
+		}
+	}
+
+	outputs := map[string]any{
+		"method":      req.Method,
+		"headers":     req.Headers,
+		"body":        body,
+		"received_at": time.Now().UTC().Format(time.RFC3339Nano),
+		"timed_out":   false,
+	}
+	if callbackURL, ok := task.Outputs["callback_url"]; ok {
+		outputs["callback_url"] = callbackURL
+	}
+
+	task.MarkSucceeded(outputs)
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// CompensationSuffix — суффикс step_id task компенсации: <step_id>.compensate.
+const CompensationSuffix = ".compensate"
+
+// CompensationStepID возвращает step_id task компенсации шага.
+func CompensationStepID(stepID string) string {
+	return stepID + CompensationSuffix
+}
+
+// CompensatedStepID возвращает ID шага, который компенсирует task с данным step_id.
+// Второе значение false, если step_id не относится к компенсации.
+func CompensatedStepID(taskStepID string) (string, bool) {
+	return strings.CutSuffix(taskStepID, CompensationSuffix)
+}
+
+// validateCompensation валидирует compensate шага.
+//
+// Компенсация выполняется воркером как отдельный task, поэтому
+// её тип должен быть исполняемым: parallel и шаги ожидания не допускаются.
+// Зависимости, ветки и вложенные компенсации не поддерживаются —
+// порядок компенсаций определяется DAG основного flow.
+func validateCompensation(step *domain.StepDef) error {
+	comp := step.Compensate
+
+	if err := validateStepType(step.ID, comp.Type); err != nil {
+		return NewValidationError(step.ID, "compensate", err.Error(), ErrInvalidCompensation)
+	}
+	if comp.Type == "parallel" || IsWaitingStep(comp.Type) {
+		return NewValidationError(step.ID, "compensate",
+			fmt.Sprintf("compensate cannot be of type %s", comp.Type), ErrInvalidCompensation)
+	}
+	if len(comp.DependsOn) > 0 || len(comp.Branches) > 0 || comp.Compensate != nil {
+		return NewValidationError(step.ID, "compensate",
+			"compensate cannot have depends_on, branches or its own compensate", ErrInvalidCompensation)
+	}
+	if len(comp.Resources) > 0 {
+		return NewValidationError(step.ID, "compensate",
+			"compensate cannot declare resources", ErrInvalidCompensation)
+	}
+	if comp.Type == "poll" {
+		if err := validatePollStep(comp); err != nil {
+			return NewValidationError(step.ID, "compensate", err.Error(), ErrInvalidCompensation)
+		}
+	}
+
+	return nil
+}
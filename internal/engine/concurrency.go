@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// ConcurrencyPolicy возвращает политику concurrency flow с учётом значения
+// по умолчанию (queue).
+func ConcurrencyPolicy(cc *domain.ConcurrencyPolicy) string {
+	if cc == nil || cc.Policy == "" {
+		return domain.ConcurrencyQueue
+	}
+	return cc.Policy
+}
+
+// ConcurrencyKey вычисляет ключ concurrency run по шаблону Key над inputs.
+// Без Key все runs flow делят один лимит — возвращается пустая строка.
+func ConcurrencyKey(cc *domain.ConcurrencyPolicy, inputs map[string]any) (string, error) {
+	if cc == nil || cc.Key == "" {
+		return "", nil
+	}
+	key, err := Render(cc.Key, NewContext(inputs))
+	if err != nil {
+		return "", fmt.Errorf("render concurrency key: %w", err)
+	}
+	return strings.TrimSpace(key), nil
+}
+
+// validateConcurrency проверяет секцию concurrency flow.
+func validateConcurrency(cc *domain.ConcurrencyPolicy) error {
+	if cc == nil {
+		return nil
+	}
+	if cc.MaxConcurrentRuns < 1 {
+		return NewValidationError("", "concurrency.max_concurrent_runs",
+			"max_concurrent_runs must be at least 1", ErrInvalidConcurrency)
+	}
+	switch cc.Policy {
+	case "", domain.ConcurrencyQueue, domain.ConcurrencySkipNew, domain.ConcurrencyCancelRunning:
+	default:
+		return NewValidationError("", "concurrency.policy",
+			fmt.Sprintf("unknown policy %q (expected queue, skip_new or cancel_running)", cc.Policy),
+			ErrInvalidConcurrency)
+	}
+	if err := ValidateTemplate(cc.Key); err != nil {
+		return NewValidationError("", "concurrency.key", err.Error(), ErrInvalidConcurrency)
+	}
+	return nil
+}
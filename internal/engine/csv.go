@@ -0,0 +1,329 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Режимы строки заголовка CSV.
+const (
+	// CSVHeaderAuto — первая строка считается заголовком, если все её поля
+	// непустые, уникальные и не числа.
+	CSVHeaderAuto = "auto"
+
+	// CSVHeaderPresent — первая строка всегда заголовок.
+	CSVHeaderPresent = "true"
+
+	// CSVHeaderAbsent — заголовка нет, ключи — col1, col2, ...
+	CSVHeaderAbsent = "false"
+)
+
+// csvDelimiters — кандидаты при автоопределении разделителя.
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// CSVOptions — настройки разбора и кодирования CSV.
+//
+//	{"delimiter": ";", "header": "auto", "columns": ["id", "name"]}
+type CSVOptions struct {
+	// Delimiter — разделитель полей; 0 — определить по первой строке
+	// при разборе, ',' при кодировании.
+	Delimiter rune
+
+	// Header — режим строки заголовка (CSVHeaderAuto по умолчанию).
+	Header string
+
+	// Columns — имена колонок. При разборе заменяют заголовок (если он
+	// есть, строка пропускается), при кодировании задают порядок колонок.
+	Columns []string
+}
+
+// ParseCSVOptions читает опции CSV из config: значение — объект
+// с ключами delimiter ("auto" или один символ), header (true, false
+// или "auto") и columns. nil — опции по умолчанию.
+func ParseCSVOptions(raw any) (CSVOptions, error) {
+	opts := CSVOptions{Header: CSVHeaderAuto}
+	if raw == nil {
+		return opts, nil
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return opts, fmt.Errorf("%w: csv options must be an object", ErrInvalidCSV)
+	}
+
+	if v, ok := m["delimiter"]; ok {
+		s, _ := v.(string)
+		switch {
+		case s == "auto":
+		case s == `\t`:
+			opts.Delimiter = '\t'
+		case utf8.RuneCountInString(s) == 1:
+			r, _ := utf8.DecodeRuneInString(s)
+			if r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+				return opts, fmt.Errorf("%w: delimiter %q is not allowed", ErrInvalidCSV, s)
+			}
+			opts.Delimiter = r
+		default:
+			return opts, fmt.Errorf("%w: delimiter must be a single character or \"auto\"", ErrInvalidCSV)
+		}
+	}
+
+	if v, ok := m["header"]; ok {
+		switch h := v.(type) {
+		case bool:
+			opts.Header = strconv.FormatBool(h)
+		case string:
+			if h != CSVHeaderAuto && h != CSVHeaderPresent && h != CSVHeaderAbsent {
+				return opts, fmt.Errorf("%w: header must be true, false or \"auto\"", ErrInvalidCSV)
+			}
+			opts.Header = h
+		default:
+			return opts, fmt.Errorf("%w: header must be true, false or \"auto\"", ErrInvalidCSV)
+		}
+	}
+
+	if v, ok := m["columns"]; ok {
+		list, ok := v.([]any)
+		if !ok {
+			return opts, fmt.Errorf("%w: columns must be a list of strings", ErrInvalidCSV)
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok || name == "" {
+				return opts, fmt.Errorf("%w: columns must be a list of non-empty strings", ErrInvalidCSV)
+			}
+			opts.Columns = append(opts.Columns, name)
+		}
+	}
+
+	return opts, nil
+}
+
+// csvFuncOptions — опции CSV шаблонных функций fromCSV и toCSV
+// с необязательным разделителем.
+func csvFuncOptions(delimiter []string) (CSVOptions, error) {
+	if len(delimiter) == 0 {
+		return CSVOptions{Header: CSVHeaderAuto}, nil
+	}
+	return ParseCSVOptions(map[string]any{"delimiter": delimiter[0]})
+}
+
+// DecodeCSV разбирает CSV в список строк-объектов:
+//
+//	id,name
+//	1,Alice
+//
+// становится
+//
+//	[{"id": "1", "name": "Alice"}]
+//
+// Значения не приводятся к числам. Недостающие поля строки — пустые
+// строки, лишние получают ключи colN по номеру колонки. BOM в начале
+// данных отбрасывается, пустые строки пропускаются.
+func DecodeCSV(data []byte, opts CSVOptions) ([]any, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = opts.Delimiter
+	if r.Comma == 0 {
+		r.Comma = detectCSVDelimiter(data)
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+
+	rows := make([]any, 0, len(records))
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	var columns []string
+	switch opts.Header {
+	case CSVHeaderPresent:
+		columns, records = records[0], records[1:]
+	case CSVHeaderAbsent:
+	default:
+		if isCSVHeader(records[0]) {
+			columns, records = records[0], records[1:]
+		}
+	}
+	if len(opts.Columns) > 0 {
+		columns = opts.Columns
+	}
+
+	for _, record := range records {
+		row := make(map[string]any, max(len(columns), len(record)))
+		for i := range max(len(columns), len(record)) {
+			key := "col" + strconv.Itoa(i+1)
+			if i < len(columns) {
+				key = columns[i]
+			}
+			value := ""
+			if i < len(record) {
+				value = record[i]
+			}
+			row[key] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// detectCSVDelimiter выбирает разделитель, чаще всего встречающийся
+// в первой строке вне кавычек. По умолчанию — запятая.
+func detectCSVDelimiter(data []byte) rune {
+	counts := make(map[rune]int)
+	inQuotes := false
+	for _, r := range string(data) {
+		if r == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if !inQuotes && (r == '\n' || r == '\r') {
+			break
+		}
+		if !inQuotes {
+			counts[r]++
+		}
+	}
+
+	best, bestCount := ',', 0
+	for _, d := range csvDelimiters {
+		if counts[d] > bestCount {
+			best, bestCount = d, counts[d]
+		}
+	}
+	return best
+}
+
+// isCSVHeader — похожа ли строка на заголовок: все поля непустые,
+// уникальные и не числа.
+func isCSVHeader(record []string) bool {
+	seen := make(map[string]bool, len(record))
+	for _, field := range record {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			return false
+		}
+		if _, err := strconv.ParseFloat(field, 64); err == nil {
+			return false
+		}
+		seen[field] = true
+	}
+	return true
+}
+
+// EncodeCSV кодирует список строк в CSV — обратное преобразование
+// к DecodeCSV.
+//
+// Строка — объект (поля по колонкам) или список (поля по порядку).
+// Колонки объектов — opts.Columns или отсортированное объединение ключей
+// всех строк. Заголовок пишется, если есть колонки и opts.Header не
+// CSVHeaderAbsent. Вложенные объекты и списки пишутся как JSON.
+func EncodeCSV(v any, opts CSVOptions) ([]byte, error) {
+	rows, err := csvRows(v)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := opts.Columns
+	if len(columns) == 0 {
+		set := make(map[string]bool)
+		for _, row := range rows {
+			if m, ok := row.(map[string]any); ok {
+				for k := range m {
+					set[k] = true
+				}
+			}
+		}
+		for k := range set {
+			columns = append(columns, k)
+		}
+		sort.Strings(columns)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if opts.Delimiter != 0 {
+		w.Comma = opts.Delimiter
+	}
+
+	if len(columns) > 0 && opts.Header != CSVHeaderAbsent {
+		if err := w.Write(columns); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+	}
+
+	for i, row := range rows {
+		var record []string
+		switch r := row.(type) {
+		case map[string]any:
+			record = make([]string, len(columns))
+			for j, col := range columns {
+				if record[j], err = csvField(r[col]); err != nil {
+					return nil, err
+				}
+			}
+		case []any:
+			record = make([]string, len(r))
+			for j, value := range r {
+				if record[j], err = csvField(value); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%w: row %d must be an object or a list, got %T", ErrInvalidCSV, i, row)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// csvRows приводит значение к списку строк.
+func csvRows(v any) ([]any, error) {
+	switch rows := v.(type) {
+	case []any:
+		return rows, nil
+	case []map[string]any:
+		result := make([]any, len(rows))
+		for i, row := range rows {
+			result[i] = row
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("%w: value must be a list of rows, got %T", ErrInvalidCSV, v)
+	}
+}
+
+// csvField форматирует значение поля CSV.
+func csvField(v any) (string, error) {
+	switch v.(type) {
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+		return string(b), nil
+	}
+	s, err := formatScalar(v)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	return s, nil
+}
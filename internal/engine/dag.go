@@ -51,6 +51,10 @@ type DAG struct {
 // - Сам parallel шаг как "start" узел
 // - Шаги внутри веток с prefixed ID
 // - Виртуальный "join" узел, объединяющий все ветки
+//
+// Вложенные parallel разворачиваются рекурсивно: у каждого уровня
+// свой start и join, ID узлов содержат полный путь
+// (outer.branch_a.inner.branch_x.step).
 func BuildDAG(spec *domain.FlowSpec) (*DAG, error) {
 	dag := &DAG{
 		Nodes:     make(map[string]*Node),
@@ -100,7 +104,7 @@ func (d *DAG) addNode(step *domain.StepDef) error {
 
 	// Для parallel шагов добавляем вложенные узлы
 	if step.Type == "parallel" {
-		if err := d.addParallelNodes(step); err != nil {
+		if err := d.addParallelNodes(step, step.ID); err != nil {
 			return err
 		}
 	}
@@ -109,41 +113,45 @@ func (d *DAG) addNode(step *domain.StepDef) error {
 }
 
 // addParallelNodes добавляет узлы для parallel шага.
-func (d *DAG) addParallelNodes(parallelStep *domain.StepDef) error {
+//
+// fullID — полный ID parallel узла (для вложенных parallel включает
+// префиксы всех родительских веток).
+func (d *DAG) addParallelNodes(parallelStep *domain.StepDef, fullID string) error {
 	// Добавляем шаги из каждой ветки
 	for _, branch := range parallelStep.Branches {
 		for i := range branch.Steps {
 			branchStep := &branch.Steps[i]
 
 			// Полный ID: parallel_id.branch_id.step_id
-			fullID := fmt.Sprintf("%s.%s.%s", parallelStep.ID, branch.ID, branchStep.ID)
+			nodeID := branchNodeID(fullID, branch.ID, branchStep.ID)
 
 			node := &Node{
 				Step:       branchStep,
-				ID:         fullID,
-				ParallelID: parallelStep.ID,
+				ID:         nodeID,
+				ParallelID: fullID,
 				BranchID:   branch.ID,
 				DependsOn:  make([]*Node, 0),
 				Dependents: make([]*Node, 0),
 			}
-			d.Nodes[fullID] = node
+			d.Nodes[nodeID] = node
 
-			// Рекурсивно обрабатываем вложенные parallel
+			// Рекурсивно обрабатываем вложенные parallel,
+			// используя полный ID как базу для их веток
 			if branchStep.Type == "parallel" {
-				// Для вложенных parallel нужно использовать полный ID как базу
-				// Это сложный случай, который можно оставить на будущее
-				// Пока просто добавляем узел
+				if err := d.addParallelNodes(branchStep, nodeID); err != nil {
+					return err
+				}
 			}
 		}
 	}
 
 	// Создаём join-узел для parallel
-	joinID := parallelStep.ID + ".join"
+	joinID := fullID + ".join"
 	joinNode := &Node{
 		ID:         joinID,
 		Step:       nil, // виртуальный узел
 		IsJoin:     true,
-		ParallelID: parallelStep.ID,
+		ParallelID: fullID,
 		DependsOn:  make([]*Node, 0),
 		Dependents: make([]*Node, 0),
 	}
@@ -152,20 +160,53 @@ func (d *DAG) addParallelNodes(parallelStep *domain.StepDef) error {
 	return nil
 }
 
+// scope — область видимости ID шагов при разрешении depends_on.
+//
+// Для верхнего уровня prefix пустой, для ветки — "parallel_id.branch_id.".
+// Зависимость ищется сначала в текущей ветке, затем во внешних.
+type scope struct {
+	prefix string
+	parent *scope
+}
+
+// resolveDependency находит узел, от которого можно зависеть, по ID из depends_on.
+// Зависимость на parallel шаг разрешается в его join-узел:
+// зависимый шаг ждёт завершения всех веток.
+func (d *DAG) resolveDependency(sc *scope, depID string) *Node {
+	for s := sc; s != nil; s = s.parent {
+		if node := d.exitNode(s.prefix + depID); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
+// exitNode возвращает узел, завершение которого означает завершение шага.
+// Для parallel это join-узел, для остальных шагов — сам узел.
+func (d *DAG) exitNode(id string) *Node {
+	node, exists := d.Nodes[id]
+	if !exists {
+		return nil
+	}
+	if node.Step != nil && node.Step.Type == "parallel" {
+		if join, ok := d.Nodes[id+".join"]; ok {
+			return join
+		}
+	}
+	return node
+}
+
 // linkDependencies связывает узлы по зависимостям.
 func (d *DAG) linkDependencies(step *domain.StepDef) error {
 	node := d.Nodes[step.ID]
+	root := &scope{}
 
 	// Связываем depends_on на уровне flow
 	for _, depID := range step.DependsOn {
-		depNode, exists := d.Nodes[depID]
-		if !exists {
-			// Проверяем, может это join-узел
-			depNode, exists = d.Nodes[depID+".join"]
-			if !exists {
-				return NewValidationError(step.ID, "depends_on",
-					fmt.Sprintf("depends on unknown step: %s", depID), ErrMissingDependency)
-			}
+		depNode := d.resolveDependency(root, depID)
+		if depNode == nil {
+			return NewValidationError(step.ID, "depends_on",
+				fmt.Sprintf("depends on unknown step: %s", depID), ErrMissingDependency)
 		}
 
 		d.addEdge(depNode, node)
@@ -173,7 +214,7 @@ func (d *DAG) linkDependencies(step *domain.StepDef) error {
 
 	// Для parallel шагов связываем внутренние зависимости
 	if step.Type == "parallel" {
-		if err := d.linkParallelDependencies(step); err != nil {
+		if err := d.linkParallelDependencies(step, step.ID, root); err != nil {
 			return err
 		}
 	}
@@ -182,43 +223,84 @@ func (d *DAG) linkDependencies(step *domain.StepDef) error {
 }
 
 // linkParallelDependencies связывает зависимости внутри parallel шага.
-func (d *DAG) linkParallelDependencies(parallelStep *domain.StepDef) error {
-	parallelNode := d.Nodes[parallelStep.ID]
-	joinNode := d.Nodes[parallelStep.ID+".join"]
+//
+// Если ни один шаг ветки не ссылается через depends_on на соседей по ветке,
+// шаги выполняются последовательно в порядке объявления. Иначе ветка —
+// собственный мини-DAG: шаги без локальных зависимостей стартуют сразу
+// после parallel start, а шаги, от которых никто в ветке не зависит,
+// связываются с join.
+func (d *DAG) linkParallelDependencies(parallelStep *domain.StepDef, fullID string, parent *scope) error {
+	parallelNode := d.Nodes[fullID]
+	joinNode := d.Nodes[fullID+".join"]
 
 	for _, branch := range parallelStep.Branches {
-		var prevNode *Node
+		branchScope := &scope{
+			prefix: fmt.Sprintf("%s.%s.", fullID, branch.ID),
+			parent: parent,
+		}
+		local := hasLocalDependencies(branch)
+
+		// Шаги ветки, от которых зависят другие шаги этой же ветки
+		hasDependents := make(map[string]bool)
+		var prevExit *Node
 
 		for i := range branch.Steps {
 			branchStep := &branch.Steps[i]
-			fullID := fmt.Sprintf("%s.%s.%s", parallelStep.ID, branch.ID, branchStep.ID)
-			node := d.Nodes[fullID]
+			nodeID := branchScope.prefix + branchStep.ID
+			node := d.Nodes[nodeID]
 
-			if i == 0 {
+			switch {
+			case local && !dependsOnSibling(branch, branchStep):
+				// Шаг без локальных зависимостей стартует вместе с веткой
+				d.addEdge(parallelNode, node)
+			case !local && i == 0:
 				// Первый шаг ветки зависит от parallel start
 				d.addEdge(parallelNode, node)
-			} else {
+			case !local:
 				// Остальные шаги зависят от предыдущего шага в ветке
-				d.addEdge(prevNode, node)
+				d.addEdge(prevExit, node)
 			}
 
-			// Также обрабатываем явные depends_on внутри ветки
+			// Явные depends_on: сначала локальный ID, затем внешние области
 			for _, depID := range branchStep.DependsOn {
-				// Проверяем локальный ID (внутри той же ветки)
-				localDepID := fmt.Sprintf("%s.%s.%s", parallelStep.ID, branch.ID, depID)
-				if depNode, exists := d.Nodes[localDepID]; exists {
-					d.addEdge(depNode, node)
-				} else if depNode, exists := d.Nodes[depID]; exists {
-					// Зависимость на внешний шаг
-					d.addEdge(depNode, node)
+				depNode := d.resolveDependency(branchScope, depID)
+				if depNode == nil {
+					return NewValidationError(nodeID, "depends_on",
+						fmt.Sprintf("depends on unknown step: %s", depID), ErrMissingDependency)
+				}
+				if depNode.ID == nodeID {
+					return NewValidationError(nodeID, "depends_on",
+						"step cannot depend on itself", ErrSelfDependency)
+				}
+				d.addEdge(depNode, node)
+
+				if local && isSibling(branch, depID) {
+					hasDependents[depID] = true
+				}
+			}
+
+			// Вложенный parallel
+			if branchStep.Type == "parallel" {
+				if err := d.linkParallelDependencies(branchStep, nodeID, branchScope); err != nil {
+					return err
 				}
 			}
 
-			prevNode = node
+			prevExit = d.exitNode(nodeID)
+		}
 
+		if !local {
 			// Последний шаг ветки → join
-			if i == len(branch.Steps)-1 {
-				d.addEdge(node, joinNode)
+			if prevExit != nil {
+				d.addEdge(prevExit, joinNode)
+			}
+			continue
+		}
+
+		// Все "стоки" мини-DAG ветки → join
+		for i := range branch.Steps {
+			if !hasDependents[branch.Steps[i].ID] {
+				d.addEdge(d.exitNode(branchScope.prefix+branch.Steps[i].ID), joinNode)
 			}
 		}
 	}
@@ -226,6 +308,42 @@ func (d *DAG) linkParallelDependencies(parallelStep *domain.StepDef) error {
 	return nil
 }
 
+// hasLocalDependencies проверяет, ссылается ли хотя бы один шаг ветки
+// на другой шаг этой же ветки через depends_on.
+func hasLocalDependencies(branch domain.Branch) bool {
+	for i := range branch.Steps {
+		if dependsOnSibling(branch, &branch.Steps[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// dependsOnSibling проверяет, есть ли у шага зависимости внутри ветки.
+func dependsOnSibling(branch domain.Branch, step *domain.StepDef) bool {
+	for _, depID := range step.DependsOn {
+		if isSibling(branch, depID) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSibling проверяет, объявлен ли шаг с указанным ID в ветке.
+func isSibling(branch domain.Branch, stepID string) bool {
+	for i := range branch.Steps {
+		if branch.Steps[i].ID == stepID {
+			return true
+		}
+	}
+	return false
+}
+
+// branchNodeID формирует полный ID узла шага внутри ветки.
+func branchNodeID(parallelID, branchID, stepID string) string {
+	return fmt.Sprintf("%s.%s.%s", parallelID, branchID, stepID)
+}
+
 // addEdge добавляет ребро между узлами.
 // Дополнительно проверяет на дубликаты, чтобы избежать двойного учета InDegree.
 func (d *DAG) addEdge(from, to *Node) {
@@ -305,7 +423,9 @@ func (d *DAG) GetReadyNodes(completed, running map[string]bool) []*Node {
 
 	ready := make([]*Node, 0)
 
-	for _, node := range d.Nodes {
+	// Обходим узлы в топологическом порядке: join-узел помечается
+	// завершённым раньше, чем проверяются его зависимые (в т.ч. внешние join)
+	for _, node := range d.orderedNodes() {
 		// Пропускаем уже завершённые или выполняющиеся
 		if completed[node.ID] || running[node.ID] {
 			continue
@@ -345,6 +465,19 @@ func (d *DAG) GetReadyNodes(completed, running map[string]bool) []*Node {
 	return ready
 }
 
+// orderedNodes возвращает узлы в топологическом порядке.
+// Для DAG, собранного вручную без Order, возвращает узлы в произвольном порядке.
+func (d *DAG) orderedNodes() []*Node {
+	if len(d.Order) == len(d.Nodes) {
+		return d.Order
+	}
+	nodes := make([]*Node, 0, len(d.Nodes))
+	for _, node := range d.Nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
 // GetNode возвращает узел по ID.
 func (d *DAG) GetNode(id string) *Node {
 	return d.Nodes[id]
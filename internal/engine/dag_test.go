@@ -159,6 +159,240 @@ func TestBuildDAG_Parallel(t *testing.T) {
 	}
 }
 
+func TestBuildDAG_NestedParallel(t *testing.T) {
+	spec := &domain.FlowSpec{
+		Steps: []domain.StepDef{
+			{
+				ID:   "outer",
+				Type: "parallel",
+				Branches: []domain.Branch{
+					{
+						ID: "a",
+						Steps: []domain.StepDef{
+							{ID: "prepare", Type: "http"},
+							{
+								ID:   "inner",
+								Type: "parallel",
+								Branches: []domain.Branch{
+									{ID: "x", Steps: []domain.StepDef{{ID: "step", Type: "http"}}},
+									{ID: "y", Steps: []domain.StepDef{{ID: "step", Type: "delay"}}},
+								},
+							},
+							{ID: "finish", Type: "transform"},
+						},
+					},
+					{
+						ID:    "b",
+						Steps: []domain.StepDef{{ID: "step", Type: "http"}},
+					},
+				},
+			},
+			{ID: "end", Type: "http", DependsOn: []string{"outer"}},
+		},
+	}
+
+	dag, err := BuildDAG(spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedNodes := []string{
+		"outer",
+		"outer.a.prepare",
+		"outer.a.inner",
+		"outer.a.inner.x.step",
+		"outer.a.inner.y.step",
+		"outer.a.inner.join",
+		"outer.a.finish",
+		"outer.b.step",
+		"outer.join",
+		"end",
+	}
+	for _, id := range expectedNodes {
+		if dag.GetNode(id) == nil {
+			t.Errorf("expected node %s to exist", id)
+		}
+	}
+	if dag.Size() != len(expectedNodes) {
+		t.Errorf("expected %d nodes, got %d", len(expectedNodes), dag.Size())
+	}
+
+	assertDeps := func(id string, want ...string) {
+		t.Helper()
+		node := dag.GetNode(id)
+		if node == nil {
+			t.Fatalf("node %s not found", id)
+		}
+		got := make(map[string]bool)
+		for _, dep := range node.DependsOn {
+			got[dep.ID] = true
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: expected deps %v, got %v", id, want, got)
+			return
+		}
+		for _, w := range want {
+			if !got[w] {
+				t.Errorf("%s: expected dependency on %s, got %v", id, w, got)
+			}
+		}
+	}
+
+	// Шаги ветки a выполняются последовательно, вложенный parallel — через свой join
+	assertDeps("outer.a.inner", "outer.a.prepare")
+	assertDeps("outer.a.inner.x.step", "outer.a.inner")
+	assertDeps("outer.a.inner.join", "outer.a.inner.x.step", "outer.a.inner.y.step")
+	assertDeps("outer.a.finish", "outer.a.inner.join")
+	assertDeps("outer.join", "outer.a.finish", "outer.b.step")
+
+	// Зависимость на parallel — ожидание его join
+	assertDeps("end", "outer.join")
+
+	if node := dag.GetNode("outer.a.inner.x.step"); node.ParallelID != "outer.a.inner" || node.BranchID != "x" {
+		t.Errorf("unexpected parallel/branch for nested step: %s/%s", node.ParallelID, node.BranchID)
+	}
+}
+
+func TestBuildDAG_BranchLocalDependencies(t *testing.T) {
+	spec := &domain.FlowSpec{
+		Steps: []domain.StepDef{
+			{ID: "start", Type: "http"},
+			{
+				ID:        "fanout",
+				Type:      "parallel",
+				DependsOn: []string{"start"},
+				Branches: []domain.Branch{
+					{
+						ID: "a",
+						Steps: []domain.StepDef{
+							{ID: "users", Type: "http"},
+							{ID: "orders", Type: "http"},
+							{ID: "merge", Type: "transform", DependsOn: []string{"users", "orders"}},
+							{ID: "audit", Type: "http", DependsOn: []string{"start"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dag, err := BuildDAG(spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// users и orders не зависят друг от друга — стартуют вместе с веткой
+	for _, id := range []string{"fanout.a.users", "fanout.a.orders"} {
+		node := dag.GetNode(id)
+		if len(node.DependsOn) != 1 || node.DependsOn[0].ID != "fanout" {
+			t.Errorf("%s should depend only on fanout", id)
+		}
+	}
+
+	merge := dag.GetNode("fanout.a.merge")
+	if len(merge.DependsOn) != 2 {
+		t.Errorf("merge should have 2 dependencies, got %d", len(merge.DependsOn))
+	}
+
+	// audit зависит от внешнего шага и от parallel start
+	audit := dag.GetNode("fanout.a.audit")
+	if len(audit.DependsOn) != 2 {
+		t.Errorf("audit should have 2 dependencies, got %d", len(audit.DependsOn))
+	}
+
+	// join ждёт "стоки" ветки: merge и audit
+	join := dag.GetNode("fanout.join")
+	deps := make(map[string]bool)
+	for _, dep := range join.DependsOn {
+		deps[dep.ID] = true
+	}
+	if len(deps) != 2 || !deps["fanout.a.merge"] || !deps["fanout.a.audit"] {
+		t.Errorf("join should depend on merge and audit, got %v", deps)
+	}
+
+	// Первая волна: users и orders
+	completed := map[string]bool{"start": true, "fanout": true}
+	ready := dag.GetReadyNodes(completed, nil)
+	readyIDs := make(map[string]bool)
+	for _, node := range ready {
+		readyIDs[node.ID] = true
+	}
+	if !readyIDs["fanout.a.users"] || !readyIDs["fanout.a.orders"] || !readyIDs["fanout.a.audit"] {
+		t.Errorf("users, orders and audit should be ready, got %v", readyIDs)
+	}
+	if readyIDs["fanout.a.merge"] {
+		t.Error("merge should not be ready yet")
+	}
+}
+
+func TestBuildDAG_BranchUnknownDependency(t *testing.T) {
+	spec := &domain.FlowSpec{
+		Steps: []domain.StepDef{
+			{
+				ID:   "parallel",
+				Type: "parallel",
+				Branches: []domain.Branch{
+					{
+						ID:    "a",
+						Steps: []domain.StepDef{{ID: "step", Type: "http", DependsOn: []string{"missing"}}},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := BuildDAG(spec)
+	if !errors.Is(err, ErrMissingDependency) {
+		t.Errorf("expected ErrMissingDependency, got %v", err)
+	}
+}
+
+func TestGetReadyNodes_NestedJoins(t *testing.T) {
+	spec := &domain.FlowSpec{
+		Steps: []domain.StepDef{
+			{
+				ID:   "outer",
+				Type: "parallel",
+				Branches: []domain.Branch{
+					{
+						ID: "a",
+						Steps: []domain.StepDef{
+							{
+								ID:   "inner",
+								Type: "parallel",
+								Branches: []domain.Branch{
+									{ID: "x", Steps: []domain.StepDef{{ID: "step", Type: "http"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+			{ID: "end", Type: "http", DependsOn: []string{"outer"}},
+		},
+	}
+
+	dag, err := BuildDAG(spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// После последнего шага оба join завершаются за один вызов, end готов
+	completed := map[string]bool{
+		"outer":                true,
+		"outer.a.inner":        true,
+		"outer.a.inner.x.step": true,
+	}
+	ready := dag.GetReadyNodes(completed, nil)
+
+	if !completed["outer.a.inner.join"] || !completed["outer.join"] {
+		t.Error("nested and outer joins should be completed")
+	}
+	if len(ready) != 1 || ready[0].ID != "end" {
+		t.Errorf("expected only end to be ready, got %d nodes", len(ready))
+	}
+}
+
 func TestBuildDAG_CyclicDependency(t *testing.T) {
 	spec := &domain.FlowSpec{
 		Steps: []domain.StepDef{
@@ -353,3 +587,95 @@ func TestDAG_GetExecutableNodes(t *testing.T) {
 		}
 	}
 }
+
+func retrySpec() *domain.FlowSpec {
+	return &domain.FlowSpec{
+		Steps: []domain.StepDef{
+			{ID: "extract", Type: "http"},
+			{ID: "fanout", Type: "parallel", DependsOn: []string{"extract"}, Branches: []domain.Branch{
+				{ID: "a", Steps: []domain.StepDef{{ID: "load", Type: "http"}}},
+				{ID: "b", Steps: []domain.StepDef{{ID: "load", Type: "http"}}},
+			}},
+			{ID: "report", Type: "http", DependsOn: []string{"fanout"}},
+		},
+	}
+}
+
+func TestDAG_Descendants(t *testing.T) {
+	dag, err := BuildDAG(retrySpec())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := dag.Descendants("fanout.a.load")
+	for _, id := range []string{"fanout.a.load", "fanout.join", "report"} {
+		if !got[id] {
+			t.Errorf("expected %s in descendants", id)
+		}
+	}
+	if got["fanout.b.load"] || got["extract"] {
+		t.Errorf("unexpected descendants: %v", got)
+	}
+
+	if len(dag.Descendants("unknown")) != 0 {
+		t.Error("unknown node should have no descendants")
+	}
+}
+
+func TestRetryTasks(t *testing.T) {
+	dag, err := BuildDAG(retrySpec())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tasks := []domain.Task{
+		{StepID: "extract", Status: domain.TaskStatusSucceeded},
+		{StepID: "fanout.a.load", Status: domain.TaskStatusSucceeded},
+		{StepID: "fanout.b.load", Status: domain.TaskStatusFailed},
+	}
+
+	stepIDs := func(tasks []domain.Task) map[string]bool {
+		ids := make(map[string]bool)
+		for _, task := range tasks {
+			ids[task.StepID] = true
+		}
+		return ids
+	}
+
+	// Повтор упавших шагов: переиспользуются все успешные
+	reused, err := RetryTasks(dag, tasks, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := stepIDs(reused)
+	if len(ids) != 2 || !ids["extract"] || !ids["fanout.a.load"] {
+		t.Errorf("unexpected reused tasks: %v", ids)
+	}
+
+	// Перезапуск с parallel шага: все ветки выполняются заново
+	reused, err = RetryTasks(dag, tasks, "fanout")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids = stepIDs(reused)
+	if len(ids) != 1 || !ids["extract"] {
+		t.Errorf("unexpected reused tasks: %v", ids)
+	}
+
+	// Компенсированный шаг и зависимые не переиспользуются
+	compensated := append(tasks, domain.Task{
+		StepID: CompensationStepID("extract"),
+		Status: domain.TaskStatusSucceeded,
+	})
+	reused, err = RetryTasks(dag, compensated, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reused) != 0 {
+		t.Errorf("expected no reused tasks, got %v", stepIDs(reused))
+	}
+
+	if _, err := RetryTasks(dag, tasks, "missing"); !errors.Is(err, ErrRetryStepNotFound) {
+		t.Errorf("expected ErrRetryStepNotFound, got %v", err)
+	}
+}
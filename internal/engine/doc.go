@@ -23,10 +23,24 @@
 // Проверки:
 //   - Steps не пустой
 //   - Уникальные ID шагов
-//   - Известные типы шагов (http, delay, transform, parallel)
+//   - Известные типы шагов (http, delay, transform, parallel, poll, sql,
+//     amqp_publish, email, grpc, script, wasm, external, approval,
+//     wait_for_signal, wait_for_callback) и типы плагинов воркера,
+//     добавленные при старте через RegisterStepTypes
 //   - Все depends_on ссылаются на существующие шаги
 //   - Нет self-dependency
-//   - Для parallel: валидные branches
+//   - Для parallel: валидные branches и config (ParseParallelConfig)
+//   - Для sql: connection и query без шаблонов (значения — через params)
+//   - Для amqp_publish: connection и exchange или routing_key
+//   - Для email: connection, получатели, text или html, filename вложений
+//   - Для transform: jq-выражения компилируются
+//   - Для grpc: target, service и method
+//   - Для script: source компилируется и определяет main(), лимиты
+//     в допустимых пределах (ParseScriptConfig)
+//   - Для wasm: module — дайджест sha256:<hex>, лимиты (ParseWasmConfig)
+//   - Для external: topic без шаблонов (ParseExternalConfig)
+//   - Для http (и запроса poll): форматы тел и опции CSV (ParseBodyOptions)
+//   - Синтаксис шаблонов outputs flow
 //
 // ## DAG (dag.go)
 //
@@ -46,6 +60,19 @@
 // Для parallel шагов DAG автоматически:
 //   - Создаёт prefixed ID для шагов веток: parallel.branch_a.step1
 //   - Добавляет виртуальный join узел для синхронизации
+//   - Рекурсивно разворачивает вложенные parallel (outer.a.inner.x.step),
+//     у каждого уровня свой join
+//   - Зависимость на parallel шаг разрешает в его join узел
+//
+// Шаги ветки без depends_on выполняются последовательно. Если шаги ветки
+// ссылаются друг на друга через depends_on (локальные ID), ветка становится
+// мини-DAG: независимые шаги стартуют сразу, join ждёт все конечные шаги.
+//
+// ## Parallel (parallel.go)
+//
+// ParseParallelConfig читает настройки parallel шага из config:
+// failure_policy (fail_fast, wait_all, min_success), min_success
+// и max_concurrency. Применяются они Orchestrator'ом при разрешении join.
 //
 // ## Templates (template.go)
 //
@@ -67,6 +94,59 @@
 //   - {{ .Steps.stepID.Outputs.xxx }} — outputs предыдущих шагов
 //   - {{ .Steps.stepID.Status }} — статус шага (SUCCEEDED, FAILED)
 //
+// Функции fromXML/toXML и fromCSV/toCSV разбирают и формируют XML и CSV
+// так же, как http шаги (см. ниже).
+//
+// ## Форматы тел (body.go, xml.go, csv.go)
+//
+// EncodeBody и DecodeBody кодируют тело http запроса и разбирают ответ
+// в формате json, xml, csv или text; для ответа формат auto выбирается
+// по Content-Type. XML представляется объектом с ключами "@атрибут"
+// и "#text" (DecodeXML, EncodeXML), CSV — списком объектов по строке
+// заголовка (DecodeCSV, EncodeCSV):
+//
+//	opts, err := engine.ParseBodyOptions(config)
+//	body, err := engine.DecodeBody(data, resp.Header.Get("Content-Type"), opts)
+//
+// ## jq (jq.go)
+//
+// Значение config transform шага (и его объекта mappings) может быть
+// jq-выражением {"jq": "..."} над контекстом в JSON-представлении
+// (.inputs, .steps.<id>.outputs, .env).
+// Orchestrator рендерит шаблоны (RenderTransform), оставляя такие значения
+// как есть, и передаёт контекст run (JQInput); выражения вычисляет Worker
+// (EvalTransform):
+//
+//	config, err := engine.RenderTransform(step.Config, tmplCtx)
+//	input, err := engine.JQInput(tmplCtx)
+//	outputs, err := engine.EvalTransform(ctx, config, input)
+//
+// Выражения компилируются при валидации spec (CompileJQ).
+//
+// ## Outputs flow (outputs.go)
+//
+// RenderOutputs вычисляет FlowSpec.Outputs после завершения run:
+//
+//	outputs, err := engine.RenderOutputs(spec.Outputs, ctx)
+//
+// Шаблон из одного выражения ({{ .Steps.fetch.Outputs.body.items }})
+// возвращает значение исходного типа, остальные шаблоны — строку.
+//
+// ## Повторный запуск (retry.go)
+//
+// RetryTasks выбирает успешные tasks упавшего run, outputs которых
+// переиспользует повторный run; fromStep перезапускает шаг и все
+// зависящие от него (DAG.Descendants):
+//
+//	reused, err := engine.RetryTasks(dag, tasks, "load")
+//
+// ## External шаги (external.go)
+//
+// Tasks external шага выполняют внешние воркеры через API fetch-and-lock.
+// ApplyExternalFailure применяет неудачную попытку (ошибка воркера или
+// истёкшая блокировка): повтор с backoff по RetryPolicy шага
+// (StepRetryPolicy, RetryBackoff) либо завершение task с ошибкой.
+//
 // # Использование в Orchestrator
 //
 // Типичный flow работы:
@@ -103,4 +183,13 @@
 //   - parser.go   — валидация FlowSpec
 //   - dag.go      — DAG структура и алгоритмы
 //   - template.go — рендеринг Go templates
+//   - jq.go       — jq-выражения transform шагов
+//   - script.go   — настройки script шагов, контекст run для script и wasm
+//   - wasm.go     — настройки wasm шагов, дайджесты модулей
+//   - outputs.go  — вычисление outputs flow
+//   - body.go     — форматы тел http шагов
+//   - xml.go      — XML в JSON-подобные значения и обратно
+//   - csv.go      — CSV в список строк-объектов и обратно
+//   - external.go — настройки external шагов, неудачные попытки
+//   - retry.go    — выбор шагов для повторного запуска run, RetryPolicy шага
 package engine
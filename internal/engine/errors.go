@@ -10,6 +10,9 @@ var (
 	// ErrEmptyStepID — шаг не имеет ID.
 	ErrEmptyStepID = errors.New("step has empty ID")
 
+	// ErrReservedStepID — ID шага совпадает со служебным (step_id task компенсации).
+	ErrReservedStepID = errors.New("reserved step ID")
+
 	// ErrDuplicateStepID — несколько шагов с одинаковым ID.
 	ErrDuplicateStepID = errors.New("duplicate step ID")
 
@@ -24,6 +27,15 @@ var (
 
 	// ErrSelfDependency — шаг зависит от самого себя.
 	ErrSelfDependency = errors.New("step depends on itself")
+
+	// ErrInvalidOutputs — некорректные outputs flow.
+	ErrInvalidOutputs = errors.New("invalid flow outputs")
+
+	// ErrInvalidRunLimits — некорректные timeout_sec / sla_sec flow.
+	ErrInvalidRunLimits = errors.New("invalid run timeout or sla")
+
+	// ErrInvalidConcurrency — некорректная секция concurrency flow.
+	ErrInvalidConcurrency = errors.New("invalid flow concurrency")
 )
 
 // Ошибки рендеринга шаблонов.
@@ -48,6 +60,126 @@ var (
 
 	// ErrEmptyBranchSteps — ветка не содержит шагов.
 	ErrEmptyBranchSteps = errors.New("branch has no steps")
+
+	// ErrInvalidFailurePolicy — неизвестная политика обработки ошибок веток.
+	ErrInvalidFailurePolicy = errors.New("invalid parallel failure policy")
+
+	// ErrInvalidMinSuccess — некорректное значение min_success.
+	ErrInvalidMinSuccess = errors.New("invalid parallel min_success")
+
+	// ErrInvalidMaxConcurrency — некорректное значение max_concurrency.
+	ErrInvalidMaxConcurrency = errors.New("invalid parallel max_concurrency")
+)
+
+// Ошибки poll шагов.
+var (
+	// ErrInvalidPollConfig — некорректная конфигурация poll шага.
+	ErrInvalidPollConfig = errors.New("invalid poll step config")
+)
+
+// Ошибки sql шагов.
+var (
+	// ErrInvalidSQLConfig — некорректная конфигурация sql шага.
+	ErrInvalidSQLConfig = errors.New("invalid sql step config")
+)
+
+// Ошибки amqp_publish шагов.
+var (
+	// ErrInvalidAMQPConfig — некорректная конфигурация amqp_publish шага.
+	ErrInvalidAMQPConfig = errors.New("invalid amqp_publish step config")
+)
+
+// Ошибки email шагов.
+var (
+	// ErrInvalidEmailConfig — некорректная конфигурация email шага.
+	ErrInvalidEmailConfig = errors.New("invalid email step config")
+)
+
+// Ошибки grpc шагов.
+var (
+	// ErrInvalidGRPCConfig — некорректная конфигурация grpc шага.
+	ErrInvalidGRPCConfig = errors.New("invalid grpc step config")
+)
+
+// Ошибки transform шагов.
+var (
+	// ErrInvalidTransformConfig — некорректная конфигурация transform шага.
+	ErrInvalidTransformConfig = errors.New("invalid transform step config")
+
+	// ErrInvalidJQ — jq-выражение не компилируется.
+	ErrInvalidJQ = errors.New("invalid jq expression")
+
+	// ErrJQEval — ошибка вычисления jq-выражения.
+	ErrJQEval = errors.New("jq evaluation failed")
+)
+
+// Ошибки script шагов.
+var (
+	// ErrInvalidScriptConfig — некорректная конфигурация script шага.
+	ErrInvalidScriptConfig = errors.New("invalid script step config")
+)
+
+// Ошибки wasm шагов.
+var (
+	// ErrInvalidWasmConfig — некорректная конфигурация wasm шага.
+	ErrInvalidWasmConfig = errors.New("invalid wasm step config")
+)
+
+// Ошибки external шагов.
+var (
+	// ErrInvalidExternalConfig — некорректная конфигурация external шага.
+	ErrInvalidExternalConfig = errors.New("invalid external step config")
+)
+
+// Ошибки регистрации типов шагов плагинов.
+var (
+	// ErrInvalidPluginStepType — недопустимый или уже занятый тип шага плагина.
+	ErrInvalidPluginStepType = errors.New("invalid plugin step type")
+)
+
+// Ошибки кодирования тел запросов и ответов (XML, CSV).
+var (
+	// ErrInvalidXML — некорректный XML или значение, не кодируемое в XML.
+	ErrInvalidXML = errors.New("invalid xml")
+
+	// ErrInvalidCSV — некорректный CSV или значение, не кодируемое в CSV.
+	ErrInvalidCSV = errors.New("invalid csv")
+
+	// ErrInvalidBodyFormat — неизвестный формат тела или некорректные опции.
+	ErrInvalidBodyFormat = errors.New("invalid body format")
+)
+
+// Ошибки шагов ожидания сигнала (approval, wait_for_signal).
+var (
+	// ErrInvalidSignalConfig — некорректная конфигурация шага ожидания сигнала.
+	ErrInvalidSignalConfig = errors.New("invalid signal step config")
+
+	// ErrInvalidDecision — решение не входит в список допустимых.
+	ErrInvalidDecision = errors.New("invalid decision")
+)
+
+// Ошибки шагов wait_for_callback.
+var (
+	// ErrInvalidCallbackConfig — некорректная конфигурация шага wait_for_callback.
+	ErrInvalidCallbackConfig = errors.New("invalid callback step config")
+)
+
+// Ошибки компенсаций (saga).
+var (
+	// ErrInvalidCompensation — некорректное определение compensate шага.
+	ErrInvalidCompensation = errors.New("invalid compensate step")
+)
+
+// Ошибки ресурсов шагов.
+var (
+	// ErrInvalidResources — некорректный список resources шага.
+	ErrInvalidResources = errors.New("invalid step resources")
+)
+
+// Ошибки повторного запуска run.
+var (
+	// ErrRetryStepNotFound — шаг, с которого перезапускается run, не найден.
+	ErrRetryStepNotFound = errors.New("retry step not found")
 )
 
 // ValidationError — ошибка валидации с контекстом.
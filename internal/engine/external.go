@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// StepTypeExternal — шаг, выполняемый внешним воркером через API
+// external tasks (fetch-and-lock / complete / fail).
+const StepTypeExternal = "external"
+
+// externalTopicRe — формат темы: буквы, цифры, '_', '-', '.', ':'.
+var externalTopicRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]*$`)
+
+// ExternalConfig — настройки external шага.
+//
+//	{
+//	  "topic": "score-customer",
+//	  "customer_id": "{{ .Inputs.customer_id }}"
+//	}
+//
+// Ключи, кроме topic, — переменные задачи: внешний воркер получает их
+// вместе с контекстом run.
+type ExternalConfig struct {
+	// Topic — тема, по которой внешние воркеры забирают задачи.
+	Topic string
+
+	// Variables — конфигурация для внешнего воркера.
+	Variables map[string]any
+}
+
+// ParseExternalConfig извлекает настройки external шага.
+func ParseExternalConfig(config map[string]any) (*ExternalConfig, error) {
+	topic, _ := config["topic"].(string)
+	if topic == "" {
+		return nil, fmt.Errorf("%w: topic is required", ErrInvalidExternalConfig)
+	}
+	// Тема — адрес очереди, а не данные: шаблоны в ней не допускаются
+	if strings.Contains(topic, "{{") || !externalTopicRe.MatchString(topic) {
+		return nil, fmt.Errorf("%w: topic %q must contain only letters, digits, '_', '-', '.' and ':'",
+			ErrInvalidExternalConfig, topic)
+	}
+
+	cfg := &ExternalConfig{Topic: topic, Variables: make(map[string]any)}
+	for key, value := range config {
+		if key != "topic" && key != StepGlobalsKey {
+			cfg.Variables[key] = value
+		}
+	}
+	return cfg, nil
+}
+
+// ApplyExternalFailure применяет к RUNNING task external шага неудачную
+// попытку (ошибка внешнего воркера или истёкшая блокировка).
+//
+// Если RetryPolicy шага допускает ещё попытку и ошибка не постоянная,
+// task возвращается в QUEUED до времени повтора (backoff) и сохраняет
+// текст последней ошибки; иначе task завершается FAILED. Возвращает true,
+// если task поставлен на повтор.
+func ApplyExternalFailure(task *domain.Task, policy *domain.RetryPolicy, errMsg string, permanent bool, now time.Time) bool {
+	maxAttempts := 1
+	if policy != nil && policy.MaxAttempts > 0 {
+		maxAttempts = policy.MaxAttempts
+	}
+
+	if permanent || !task.CanRetry(maxAttempts) {
+		task.MarkFailed(errMsg)
+		task.NextAttemptAt = nil
+		return false
+	}
+
+	task.ResetForRetry()
+	task.Error = errMsg
+	retryAt := now.Add(RetryBackoff(task.Attempt, policy))
+	task.NextAttemptAt = &retryAt
+	return true
+}
@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// jqTimeout — ограничение времени вычисления одного jq-выражения.
+const jqTimeout = 5 * time.Second
+
+// JQExpr проверяет, задано ли значение как jq-выражение {"jq": "..."},
+// и возвращает выражение.
+//
+// Значение маппинга transform шага — либо строка (Go template), либо
+// объект с единственным ключом jq:
+//
+//	{
+//	  "total": "{{ len .Steps.fetch.Outputs.items }}",
+//	  "active": { "jq": ".steps.fetch.outputs.items | map(select(.active))" }
+//	}
+func JQExpr(value any) (string, bool) {
+	m, ok := value.(map[string]any)
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	raw, ok := m["jq"]
+	if !ok {
+		return "", false
+	}
+	expr, _ := raw.(string)
+	return expr, true
+}
+
+// CompileJQ компилирует jq-выражение. Используется при валидации spec
+// и перед вычислением.
+func CompileJQ(expr string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJQ, err)
+	}
+	// $ENV и env недоступны: переменные окружения процесса не должны
+	// попадать в outputs (переменные run доступны как .env)
+	code, err := gojq.Compile(query, gojq.WithEnvironLoader(func() []string { return nil }))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJQ, err)
+	}
+	return code, nil
+}
+
+// EvalJQ вычисляет jq-выражение над контекстом run в JSON-представлении
+// (JQInput). Выражение без результатов даёт nil, с одним результатом —
+// значение, с несколькими — массив результатов.
+func EvalJQ(ctx context.Context, expr string, tmplCtx *Context) (any, error) {
+	input, err := JQInput(tmplCtx)
+	if err != nil {
+		return nil, err
+	}
+	return evalJQ(ctx, expr, input)
+}
+
+// evalJQ вычисляет jq-выражение над input — значением JSON.
+func evalJQ(ctx context.Context, expr string, input any) (any, error) {
+	code, err := CompileJQ(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jqTimeout)
+	defer cancel()
+
+	var results []any
+	iter := code.RunWithContext(ctx, input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			if haltErr, ok := err.(*gojq.HaltError); ok && haltErr.Value() == nil {
+				break
+			}
+			return nil, fmt.Errorf("%w: %v", ErrJQEval, err)
+		}
+		results = append(results, v)
+	}
+
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	default:
+		return results, nil
+	}
+}
+
+// JQInput приводит контекст run к значениям JSON (map[string]any, []any,
+// float64), с которыми работает gojq:
+//
+//	{"inputs": {...}, "steps": {"<id>": {"outputs": {...}, "status": "..."}}, "env": {...}}
+func JQInput(tmplCtx *Context) (any, error) {
+	if tmplCtx == nil {
+		tmplCtx = NewContext(nil)
+	}
+	return jqValue(tmplCtx)
+}
+
+// jqValue приводит значение к представлению JSON через marshal/unmarshal.
+func jqValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: marshal context: %v", ErrJQEval, err)
+	}
+	var input any
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("%w: decode context: %v", ErrJQEval, err)
+	}
+	return input, nil
+}
+
+// RenderTransform рендерит шаблоны в конфигурации transform шага.
+// Значения {"jq": "..."} верхнего уровня и объекта mappings остаются
+// как есть: их вычисляет Worker (EvalTransform), чтобы тяжёлое
+// выражение не блокировало Orchestrator.
+func RenderTransform(config map[string]any, tmplCtx *Context) (map[string]any, error) {
+	return renderTransform(config, tmplCtx, "")
+}
+
+// renderTransform рендерит значения config; prefix — путь для ошибок.
+func renderTransform(config map[string]any, tmplCtx *Context, prefix string) (map[string]any, error) {
+	result := make(map[string]any, len(config))
+	for key, value := range config {
+		if _, ok := JQExpr(value); ok {
+			result[key] = value
+			continue
+		}
+
+		// mappings — формат config transform шага в steps.TransformStep
+		if mappings, ok := value.(map[string]any); ok && prefix == "" && key == "mappings" {
+			rendered, err := renderTransform(mappings, tmplCtx, "mappings.")
+			if err != nil {
+				return nil, err
+			}
+			result[key] = rendered
+			continue
+		}
+
+		rendered, err := RenderValue(value, tmplCtx)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s%s: %w", prefix, key, err)
+		}
+		result[key] = rendered
+	}
+	return result, nil
+}
+
+// EvalTransform вычисляет jq-выражения в отрендеренной конфигурации
+// transform шага (верхний уровень и объект mappings) над input —
+// контекстом run, который Orchestrator передал в JQInput-представлении.
+// Остальные значения возвращаются как есть.
+func EvalTransform(ctx context.Context, config map[string]any, input any) (map[string]any, error) {
+	input, err := jqValue(input)
+	if err != nil {
+		return nil, err
+	}
+	return evalTransform(ctx, config, input, "")
+}
+
+// evalTransform вычисляет значения config; prefix — путь для ошибок.
+func evalTransform(ctx context.Context, config map[string]any, input any, prefix string) (map[string]any, error) {
+	result := make(map[string]any, len(config))
+	for key, value := range config {
+		if expr, ok := JQExpr(value); ok {
+			v, err := evalJQ(ctx, expr, input)
+			if err != nil {
+				return nil, fmt.Errorf("transform %s%s: %w", prefix, key, err)
+			}
+			result[key] = v
+			continue
+		}
+
+		if mappings, ok := value.(map[string]any); ok && prefix == "" && key == "mappings" {
+			evaluated, err := evalTransform(ctx, mappings, input, "mappings.")
+			if err != nil {
+				return nil, err
+			}
+			result[key] = evaluated
+			continue
+		}
+
+		result[key] = value
+	}
+	return result, nil
+}
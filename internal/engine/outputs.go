@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// singleActionRe — шаблон из одного выражения {{ ... }} без окружающего текста.
+var singleActionRe = regexp.MustCompile(`^\s*\{\{-?\s*(.+?)\s*-?\}\}\s*$`)
+
+// RenderOutputs вычисляет outputs flow (FlowSpec.Outputs) над контекстом run.
+//
+// Шаблон из одного выражения сохраняет тип значения:
+//
+//	"items": "{{ .Steps.fetch.Outputs.body.items }}"  // список
+//	"count": "{{ len .Steps.fetch.Outputs.body.items }}" // число
+//
+// Шаблоны с текстом вокруг выражений рендерятся в строку:
+//
+//	"summary": "{{ len .Steps.fetch.Outputs.body.items }} items"
+func RenderOutputs(outputs map[string]string, ctx *Context) (map[string]any, error) {
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+
+	// Детерминированный порядок — первая ошибка одна и та же
+	keys := make([]string, 0, len(outputs))
+	for key := range outputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]any, len(outputs))
+	for _, key := range keys {
+		value, err := RenderOutput(outputs[key], ctx)
+		if err != nil {
+			return nil, fmt.Errorf("output %s: %w", key, err)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// RenderOutput вычисляет один output. Шаблон из одного выражения
+// возвращает значение как есть, остальные — отрендеренную строку.
+func RenderOutput(tmpl string, data any) (any, error) {
+	m := singleActionRe.FindStringSubmatch(tmpl)
+	if m == nil || strings.Contains(m[1], "}}") {
+		return RenderData(tmpl, data)
+	}
+
+	var value any
+	captured := false
+	t, err := template.New("").Funcs(templateFuncs).Funcs(template.FuncMap{
+		"captureOutput": func(v any) string {
+			value = v
+			captured = true
+			return ""
+		},
+	}).Parse("{{ captureOutput (" + m[1] + ") }}")
+	if err != nil {
+		// Не выражение (например, {{ if }}) — обычный рендеринг
+		return RenderData(tmpl, data)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTemplateRender, err)
+	}
+	if !captured {
+		return RenderData(tmpl, data)
+	}
+	return value, nil
+}
+
+// validateOutputs проверяет синтаксис шаблонов outputs flow.
+func validateOutputs(outputs map[string]string) error {
+	for key, tmpl := range outputs {
+		if key == "" {
+			return NewValidationError("", "outputs", "output has empty name", ErrInvalidOutputs)
+		}
+		if err := ValidateTemplate(tmpl); err != nil {
+			return NewValidationError("", "outputs",
+				fmt.Sprintf("output %s: %v", key, err), ErrInvalidOutputs)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// Политики обработки ошибок в ветках parallel шага.
+const (
+	// FailurePolicyFailFast — parallel падает при первой упавшей ветке (по умолчанию).
+	FailurePolicyFailFast = "fail_fast"
+
+	// FailurePolicyWaitAll — дождаться завершения всех веток,
+	// parallel падает, если упала хотя бы одна.
+	FailurePolicyWaitAll = "wait_all"
+
+	// FailurePolicyMinSuccess — parallel успешен, если успешно
+	// завершились не менее min_success веток.
+	FailurePolicyMinSuccess = "min_success"
+)
+
+// ParallelConfig — настройки выполнения parallel шага.
+//
+// Задаются в config parallel шага:
+//
+//	{
+//	    "failure_policy": "min_success",
+//	    "min_success": 2,
+//	    "max_concurrency": 3
+//	}
+type ParallelConfig struct {
+	// FailurePolicy — политика обработки упавших веток.
+	FailurePolicy string
+
+	// MinSuccess — минимальное количество успешных веток (для min_success).
+	MinSuccess int
+
+	// MaxConcurrency — максимальное количество одновременно выполняемых веток.
+	// 0 — без ограничения.
+	MaxConcurrency int
+}
+
+// ParseParallelConfig извлекает и валидирует настройки parallel шага.
+func ParseParallelConfig(step *domain.StepDef) (ParallelConfig, error) {
+	cfg := ParallelConfig{FailurePolicy: FailurePolicyFailFast}
+
+	if v, ok := step.Config["failure_policy"]; ok {
+		policy, ok := v.(string)
+		if !ok {
+			return cfg, fmt.Errorf("%w: must be a string", ErrInvalidFailurePolicy)
+		}
+		cfg.FailurePolicy = policy
+	}
+
+	switch cfg.FailurePolicy {
+	case FailurePolicyFailFast, FailurePolicyWaitAll, FailurePolicyMinSuccess:
+	default:
+		return cfg, fmt.Errorf("%w: %s", ErrInvalidFailurePolicy, cfg.FailurePolicy)
+	}
+
+	if v, ok := step.Config["min_success"]; ok {
+		n, ok := configInt(v)
+		if !ok {
+			return cfg, fmt.Errorf("%w: must be an integer", ErrInvalidMinSuccess)
+		}
+		cfg.MinSuccess = n
+	}
+
+	if cfg.FailurePolicy == FailurePolicyMinSuccess {
+		if cfg.MinSuccess < 1 || cfg.MinSuccess > len(step.Branches) {
+			return cfg, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidMinSuccess, len(step.Branches))
+		}
+	}
+
+	if v, ok := step.Config["max_concurrency"]; ok {
+		n, ok := configInt(v)
+		if !ok || n < 0 {
+			return cfg, fmt.Errorf("%w: must be a non-negative integer", ErrInvalidMaxConcurrency)
+		}
+		cfg.MaxConcurrency = n
+	}
+
+	return cfg, nil
+}
+
+// configInt приводит числовое значение из config к int.
+// Числа из JSON приходят как float64 — дробные значения не допускаются.
+func configInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		if n != float64(int(n)) {
+			return 0, false
+		}
+		return int(n), true
+	default:
+		return 0, false
+	}
+}
@@ -2,6 +2,8 @@ package engine
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/shaiso/Automata/internal/domain"
 )
@@ -12,6 +14,21 @@ var validStepTypes = map[string]bool{
 	"delay":     true,
 	"transform": true,
 	"parallel":  true,
+	"poll":      true,
+	"sql":       true,
+
+	"amqp_publish": true,
+	"email":        true,
+	"grpc":         true,
+
+	StepTypeScript: true,
+	StepTypeWasm:   true,
+
+	StepTypeExternal: true,
+
+	StepTypeApproval:        true,
+	StepTypeWaitForSignal:   true,
+	StepTypeWaitForCallback: true,
 }
 
 // Validate выполняет полную валидацию FlowSpec.
@@ -23,6 +40,9 @@ var validStepTypes = map[string]bool{
 // - Валидность зависимостей (depends_on)
 // - Отсутствие циклов (делегируется DAG)
 // - Валидность parallel веток
+// - Синтаксис шаблонов outputs flow
+// - Дедлайн и SLA run (timeout_sec, sla_sec)
+// - Ограничение concurrency runs
 func Validate(spec *domain.FlowSpec) error {
 	if spec == nil {
 		return ErrEmptySteps
@@ -49,6 +69,36 @@ func Validate(spec *domain.FlowSpec) error {
 		return err
 	}
 
+	// Валидируем outputs flow
+	if err := validateOutputs(spec.Outputs); err != nil {
+		return err
+	}
+
+	// Валидируем дедлайн и SLA run
+	if err := validateRunLimits(spec); err != nil {
+		return err
+	}
+
+	// Валидируем ограничение concurrency
+	if err := validateConcurrency(spec.Concurrency); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateRunLimits проверяет timeout_sec и sla_sec flow.
+// SLA имеет смысл только раньше дедлайна: после него run уже упал.
+func validateRunLimits(spec *domain.FlowSpec) error {
+	if spec.TimeoutSec < 0 {
+		return NewValidationError("", "timeout_sec", "timeout_sec must not be negative", ErrInvalidRunLimits)
+	}
+	if spec.SLASec < 0 {
+		return NewValidationError("", "sla_sec", "sla_sec must not be negative", ErrInvalidRunLimits)
+	}
+	if spec.TimeoutSec > 0 && spec.SLASec >= spec.TimeoutSec {
+		return NewValidationError("", "sla_sec", "sla_sec must be less than timeout_sec", ErrInvalidRunLimits)
+	}
 	return nil
 }
 
@@ -60,6 +110,13 @@ func ValidateStep(step *domain.StepDef, stepIDs map[string]bool) error {
 		return NewValidationError("", "id", "step has empty ID", ErrEmptyStepID)
 	}
 
+	// Step_id tasks компенсаций — <step_id>.compensate: шаг ветки с ID
+	// compensate (узел p.b.compensate) был бы принят за компенсацию p.b
+	if step.ID == strings.TrimPrefix(CompensationSuffix, ".") || strings.HasSuffix(step.ID, CompensationSuffix) {
+		return NewValidationError(step.ID, "id",
+			fmt.Sprintf("step ID %s is reserved for compensation tasks", step.ID), ErrReservedStepID)
+	}
+
 	// Проверка уникальности ID
 	if stepIDs[step.ID] {
 		return NewValidationError(step.ID, "id",
@@ -80,6 +137,13 @@ func ValidateStep(step *domain.StepDef, stepIDs map[string]bool) error {
 		}
 	}
 
+	// Специальная валидация для http
+	if step.Type == "http" {
+		if _, err := ParseBodyOptions(step.Config); err != nil {
+			return NewValidationError(step.ID, "config", err.Error(), err)
+		}
+	}
+
 	// Специальная валидация для parallel
 	if step.Type == "parallel" {
 		if err := validateParallelStep(step, stepIDs); err != nil {
@@ -87,6 +151,119 @@ func ValidateStep(step *domain.StepDef, stepIDs map[string]bool) error {
 		}
 	}
 
+	// Специальная валидация для transform
+	if step.Type == "transform" {
+		if err := validateTransformStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для poll
+	if step.Type == "poll" {
+		if err := validatePollStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для sql
+	if step.Type == "sql" {
+		if err := validateSQLStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для amqp_publish
+	if step.Type == "amqp_publish" {
+		if err := validateAMQPPublishStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для email
+	if step.Type == "email" {
+		if err := validateEmailStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для grpc
+	if step.Type == "grpc" {
+		if err := validateGRPCStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для script
+	if step.Type == StepTypeScript {
+		if err := validateScriptStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для wasm
+	if step.Type == StepTypeWasm {
+		if err := validateWasmStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для external
+	if step.Type == StepTypeExternal {
+		if err := validateExternalStep(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для шагов ожидания сигнала
+	if IsSignalStep(step.Type) {
+		if _, err := ParseSignalConfig(step.Type, step.Config); err != nil {
+			return NewValidationError(step.ID, "config", err.Error(), err)
+		}
+	}
+
+	// Ресурсы шага
+	if len(step.Resources) > 0 {
+		if err := validateResources(step); err != nil {
+			return err
+		}
+	}
+
+	// Компенсация (saga)
+	if step.Compensate != nil {
+		if err := validateCompensation(step); err != nil {
+			return err
+		}
+	}
+
+	// Специальная валидация для wait_for_callback
+	if step.Type == StepTypeWaitForCallback {
+		if _, err := ParseCallbackTimeout(step.Config); err != nil {
+			return NewValidationError(step.ID, "config", err.Error(), err)
+		}
+	}
+
+	return nil
+}
+
+// validateResources проверяет resources шага.
+// parallel — виртуальный узел без task, слот ему держать нечем.
+// Существование ресурсов проверяется при dispatch: они управляются через API.
+func validateResources(step *domain.StepDef) error {
+	if step.Type == "parallel" {
+		return NewValidationError(step.ID, "resources",
+			"parallel step cannot declare resources", ErrInvalidResources)
+	}
+	seen := make(map[string]bool, len(step.Resources))
+	for _, name := range step.Resources {
+		if name == "" {
+			return NewValidationError(step.ID, "resources", "resource has empty name", ErrInvalidResources)
+		}
+		if seen[name] {
+			return NewValidationError(step.ID, "resources",
+				fmt.Sprintf("duplicate resource: %s", name), ErrInvalidResources)
+		}
+		seen[name] = true
+	}
 	return nil
 }
 
@@ -106,21 +283,37 @@ func validateStepType(stepID, stepType string) error {
 }
 
 // validateDependencies проверяет, что все depends_on ссылаются на существующие шаги.
-func validateDependencies(steps []domain.StepDef, stepIDs map[string]bool) error {
+//
+// prefixes — области видимости от внутренней к внешней: для шага в ветке
+// зависимость ищется сначала среди шагов этой ветки ("parallel.branch."),
+// затем во внешних ветках и на верхнем уровне ("").
+func validateDependencies(steps []domain.StepDef, stepIDs map[string]bool, prefixes ...string) error {
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+
 	for i := range steps {
 		step := &steps[i]
+		fullID := prefixes[0] + step.ID
 
 		for _, dep := range step.DependsOn {
-			if !stepIDs[dep] {
-				return NewValidationError(step.ID, "depends_on",
+			resolved, ok := resolveStepID(dep, stepIDs, prefixes)
+			if !ok {
+				return NewValidationError(fullID, "depends_on",
 					fmt.Sprintf("depends on unknown step: %s", dep), ErrMissingDependency)
 			}
+			if resolved == fullID {
+				return NewValidationError(fullID, "depends_on",
+					"step depends on itself", ErrSelfDependency)
+			}
 		}
 
 		// Рекурсивно проверяем зависимости внутри parallel веток
 		if step.Type == "parallel" {
 			for _, branch := range step.Branches {
-				if err := validateDependencies(branch.Steps, stepIDs); err != nil {
+				branchPrefix := fmt.Sprintf("%s.%s.", fullID, branch.ID)
+				scopes := append([]string{branchPrefix}, prefixes...)
+				if err := validateDependencies(branch.Steps, stepIDs, scopes...); err != nil {
 					return err
 				}
 			}
@@ -130,6 +323,17 @@ func validateDependencies(steps []domain.StepDef, stepIDs map[string]bool) error
 	return nil
 }
 
+// resolveStepID ищет шаг по ID из depends_on в областях видимости prefixes.
+// Возвращает полный ID найденного шага.
+func resolveStepID(dep string, stepIDs map[string]bool, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if stepIDs[prefix+dep] {
+			return prefix + dep, true
+		}
+	}
+	return "", false
+}
+
 // validateParallelStep валидирует parallel шаг и его ветки.
 func validateParallelStep(step *domain.StepDef, stepIDs map[string]bool) error {
 	if len(step.Branches) == 0 {
@@ -137,6 +341,10 @@ func validateParallelStep(step *domain.StepDef, stepIDs map[string]bool) error {
 			"parallel step has no branches", ErrEmptyBranches)
 	}
 
+	if _, err := ParseParallelConfig(step); err != nil {
+		return NewValidationError(step.ID, "config", err.Error(), err)
+	}
+
 	branchIDs := make(map[string]bool)
 
 	for i := range step.Branches {
@@ -162,10 +370,17 @@ func validateParallelStep(step *domain.StepDef, stepIDs map[string]bool) error {
 		}
 
 		// Валидируем шаги внутри ветки
-		// ID шагов в ветках получают префикс: {parallel_id}.{branch_id}.{step_id}
+		// ID шагов в ветках получают префикс: {parallel_id}.{branch_id}.{step_id}.
+		// Для вложенных parallel step.ID уже содержит полный путь,
+		// поэтому префиксы накапливаются рекурсивно.
 		for j := range branch.Steps {
 			branchStep := &branch.Steps[j]
 
+			if branchStep.ID == "" {
+				return NewValidationError(step.ID, "branches",
+					fmt.Sprintf("branch %s has step with empty ID", branch.ID), ErrEmptyStepID)
+			}
+
 			// Формируем полный ID для шага внутри ветки
 			fullStepID := fmt.Sprintf("%s.%s.%s", step.ID, branch.ID, branchStep.ID)
 
@@ -185,6 +400,257 @@ func validateParallelStep(step *domain.StepDef, stepIDs map[string]bool) error {
 	return nil
 }
 
+// validatePollStep валидирует конфигурацию poll шага.
+//
+// until — условие завершения в синтаксисе condition (без {{ }}).
+// Оно вычисляется воркером над outputs каждой итерации, поэтому
+// не должно рендериться вместе с остальным config при dispatch.
+func validatePollStep(step *domain.StepDef) error {
+	until, _ := step.Config["until"].(string)
+	if strings.TrimSpace(until) == "" {
+		return NewValidationError(step.ID, "config",
+			"poll step requires until condition", ErrInvalidPollConfig)
+	}
+	if strings.Contains(until, "{{") {
+		return NewValidationError(step.ID, "config",
+			"poll until must be an expression without {{ }}", ErrInvalidPollConfig)
+	}
+
+	request, ok := step.Config["request"].(map[string]any)
+	if !ok {
+		return NewValidationError(step.ID, "config",
+			"poll step requires request config", ErrInvalidPollConfig)
+	}
+
+	action, ok := step.Config["action"].(string)
+	if ok && (action == "poll" || action == "parallel") {
+		return NewValidationError(step.ID, "config",
+			fmt.Sprintf("poll action cannot be %s", action), ErrInvalidPollConfig)
+	}
+	if !ok || action == "http" {
+		if _, err := ParseBodyOptions(request); err != nil {
+			return NewValidationError(step.ID, "config", err.Error(), err)
+		}
+	}
+
+	return nil
+}
+
+// validateSQLStep валидирует конфигурацию sql шага.
+//
+// Текст query не должен содержать шаблонов: значения из inputs и outputs
+// передаются только через params и привязываются воркером как параметры
+// $1, $2, ..., а не подставляются в SQL.
+func validateSQLStep(step *domain.StepDef) error {
+	if connection, _ := step.Config["connection"].(string); strings.TrimSpace(connection) == "" {
+		return NewValidationError(step.ID, "config",
+			"sql step requires connection", ErrInvalidSQLConfig)
+	}
+
+	query, _ := step.Config["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return NewValidationError(step.ID, "config",
+			"sql step requires query", ErrInvalidSQLConfig)
+	}
+	if strings.Contains(query, "{{") {
+		return NewValidationError(step.ID, "config",
+			"sql query must not contain templates, use params with $1, $2, ...", ErrInvalidSQLConfig)
+	}
+
+	if params, ok := step.Config["params"]; ok {
+		if _, ok := params.([]any); !ok {
+			return NewValidationError(step.ID, "config",
+				"sql params must be a list", ErrInvalidSQLConfig)
+		}
+	}
+
+	if mode, ok := step.Config["mode"].(string); ok && mode != "query" && mode != "exec" {
+		return NewValidationError(step.ID, "config",
+			fmt.Sprintf("unknown sql mode: %s", mode), ErrInvalidSQLConfig)
+	}
+
+	return nil
+}
+
+// validateAMQPPublishStep валидирует конфигурацию amqp_publish шага.
+func validateAMQPPublishStep(step *domain.StepDef) error {
+	if connection, _ := step.Config["connection"].(string); strings.TrimSpace(connection) == "" {
+		return NewValidationError(step.ID, "config",
+			"amqp_publish step requires connection", ErrInvalidAMQPConfig)
+	}
+
+	exchange, _ := step.Config["exchange"].(string)
+	routingKey, _ := step.Config["routing_key"].(string)
+	if exchange == "" && routingKey == "" {
+		return NewValidationError(step.ID, "config",
+			"amqp_publish step requires exchange or routing_key", ErrInvalidAMQPConfig)
+	}
+
+	if headers, ok := step.Config["headers"]; ok {
+		if _, ok := headers.(map[string]any); !ok {
+			return NewValidationError(step.ID, "config",
+				"amqp_publish headers must be an object", ErrInvalidAMQPConfig)
+		}
+	}
+
+	return nil
+}
+
+// validateEmailStep валидирует конфигурацию email шага.
+// Адреса не разбираются: они могут быть шаблонами и проверяются воркером.
+func validateEmailStep(step *domain.StepDef) error {
+	if connection, _ := step.Config["connection"].(string); strings.TrimSpace(connection) == "" {
+		return NewValidationError(step.ID, "config",
+			"email step requires connection", ErrInvalidEmailConfig)
+	}
+
+	if step.Config["to"] == nil && step.Config["cc"] == nil && step.Config["bcc"] == nil {
+		return NewValidationError(step.ID, "config",
+			"email step requires to, cc or bcc", ErrInvalidEmailConfig)
+	}
+
+	text, _ := step.Config["text"].(string)
+	html, _ := step.Config["html"].(string)
+	if text == "" && html == "" {
+		return NewValidationError(step.ID, "config",
+			"email step requires text or html", ErrInvalidEmailConfig)
+	}
+
+	if attachments, ok := step.Config["attachments"]; ok {
+		list, ok := attachments.([]any)
+		if !ok {
+			return NewValidationError(step.ID, "config",
+				"email attachments must be a list", ErrInvalidEmailConfig)
+		}
+		for i, item := range list {
+			att, _ := item.(map[string]any)
+			if filename, _ := att["filename"].(string); filename == "" {
+				return NewValidationError(step.ID, "config",
+					fmt.Sprintf("email attachments[%d] requires filename", i), ErrInvalidEmailConfig)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateTransformStep компилирует jq-выражения transform шага: значения
+// верхнего уровня config и маппинги в config.mappings.
+func validateTransformStep(step *domain.StepDef) error {
+	// globals заполняет Orchestrator контекстом run для jq
+	if _, ok := step.Config[StepGlobalsKey]; ok {
+		return NewValidationError(step.ID, "config",
+			fmt.Sprintf("transform config key %s is reserved", StepGlobalsKey), ErrInvalidTransformConfig)
+	}
+
+	values := make(map[string]any, len(step.Config))
+	for key, value := range step.Config {
+		values[key] = value
+	}
+	if mappings, ok := step.Config["mappings"].(map[string]any); ok {
+		for key, value := range mappings {
+			values["mappings."+key] = value
+		}
+	}
+
+	for key, value := range values {
+		expr, ok := JQExpr(value)
+		if !ok {
+			continue
+		}
+		if _, err := CompileJQ(expr); err != nil {
+			return NewValidationError(step.ID, "config",
+				fmt.Sprintf("transform %s: %v", key, err), err)
+		}
+	}
+	return nil
+}
+
+// validateGRPCStep валидирует конфигурацию grpc шага. Наличие сервиса
+// и метода в схеме проверяется воркером: схема загружается с сервера
+// (reflection) или из descriptor set во время выполнения.
+func validateGRPCStep(step *domain.StepDef) error {
+	for _, field := range []string{"target", "service", "method"} {
+		if value, _ := step.Config[field].(string); strings.TrimSpace(value) == "" {
+			return NewValidationError(step.ID, "config",
+				fmt.Sprintf("grpc step requires %s", field), ErrInvalidGRPCConfig)
+		}
+	}
+
+	for _, field := range []string{"request", "metadata"} {
+		if value, ok := step.Config[field]; ok {
+			if _, ok := value.(map[string]any); !ok {
+				return NewValidationError(step.ID, "config",
+					fmt.Sprintf("grpc %s must be an object", field), ErrInvalidGRPCConfig)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateScriptStep валидирует конфигурацию script шага: скрипт
+// компилируется заранее, ошибки синтаксиса видны при публикации версии.
+func validateScriptStep(step *domain.StepDef) error {
+	// globals заполняет Orchestrator при запуске шага
+	if _, ok := step.Config[StepGlobalsKey]; ok {
+		return NewValidationError(step.ID, "config",
+			fmt.Sprintf("script config key %s is reserved", StepGlobalsKey), ErrInvalidScriptConfig)
+	}
+	if _, err := ParseScriptConfig(step.Config); err != nil {
+		return NewValidationError(step.ID, "config", err.Error(), err)
+	}
+	return nil
+}
+
+// validateWasmStep валидирует конфигурацию wasm шага. Наличие модуля
+// с указанным дайджестом проверяется воркером при выполнении.
+func validateWasmStep(step *domain.StepDef) error {
+	if _, ok := step.Config[StepGlobalsKey]; ok {
+		return NewValidationError(step.ID, "config",
+			fmt.Sprintf("wasm config key %s is reserved", StepGlobalsKey), ErrInvalidWasmConfig)
+	}
+	if _, err := ParseWasmConfig(step.Config); err != nil {
+		return NewValidationError(step.ID, "config", err.Error(), err)
+	}
+	return nil
+}
+
+// validateExternalStep валидирует конфигурацию external шага.
+func validateExternalStep(step *domain.StepDef) error {
+	if _, ok := step.Config[StepGlobalsKey]; ok {
+		return NewValidationError(step.ID, "config",
+			fmt.Sprintf("external config key %s is reserved", StepGlobalsKey), ErrInvalidExternalConfig)
+	}
+	if _, err := ParseExternalConfig(step.Config); err != nil {
+		return NewValidationError(step.ID, "config", err.Error(), err)
+	}
+	return nil
+}
+
+// pluginStepTypeRe — формат типа шага плагина.
+var pluginStepTypeRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// RegisterStepTypes добавляет типы шагов, реализованные плагинами воркера,
+// к допустимым типам. Встроенные типы переопределить нельзя.
+//
+// Вызывается при старте сервиса до валидации spec: реестр типов
+// не защищён для конкурентного изменения.
+func RegisterStepTypes(types ...string) error {
+	for _, t := range types {
+		if !pluginStepTypeRe.MatchString(t) {
+			return fmt.Errorf("%w: %q must match %s", ErrInvalidPluginStepType, t, pluginStepTypeRe)
+		}
+		if validStepTypes[t] {
+			return fmt.Errorf("%w: %s is already registered", ErrInvalidPluginStepType, t)
+		}
+	}
+	for _, t := range types {
+		validStepTypes[t] = true
+	}
+	return nil
+}
+
 // IsValidStepType проверяет, является ли тип шага допустимым.
 func IsValidStepType(stepType string) bool {
 	return validStepTypes[stepType]
@@ -2,8 +2,11 @@ package engine
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/shaiso/Automata/internal/domain"
 )
 
@@ -34,6 +37,36 @@ func TestValidate_EmptySteps(t *testing.T) {
 	}
 }
 
+func TestValidate_ReservedStepID(t *testing.T) {
+	tests := []struct {
+		name string
+		spec *domain.FlowSpec
+	}{
+		{"top-level", &domain.FlowSpec{Steps: []domain.StepDef{{ID: "compensate", Type: "http"}}}},
+		{"suffix", &domain.FlowSpec{Steps: []domain.StepDef{{ID: "charge.compensate", Type: "http"}}}},
+		{"branch step", &domain.FlowSpec{Steps: []domain.StepDef{{
+			ID:   "p",
+			Type: "parallel",
+			Branches: []domain.Branch{
+				{ID: "b", Steps: []domain.StepDef{{ID: "compensate", Type: "http"}}},
+			},
+		}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.spec); !errors.Is(err, ErrReservedStepID) {
+				t.Errorf("expected ErrReservedStepID, got %v", err)
+			}
+		})
+	}
+
+	spec := &domain.FlowSpec{Steps: []domain.StepDef{{ID: "compensate_order", Type: "http"}}}
+	if err := Validate(spec); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
 func TestValidate_EmptyStepID(t *testing.T) {
 	spec := &domain.FlowSpec{
 		Steps: []domain.StepDef{
@@ -358,8 +391,855 @@ func TestValidate_ParallelStep(t *testing.T) {
 	})
 }
 
+func TestValidate_NestedParallel(t *testing.T) {
+	newSpec := func(innerDeps []string) *domain.FlowSpec {
+		return &domain.FlowSpec{
+			Steps: []domain.StepDef{
+				{ID: "start", Type: "http"},
+				{
+					ID:   "outer",
+					Type: "parallel",
+					Branches: []domain.Branch{
+						{
+							ID: "a",
+							Steps: []domain.StepDef{
+								{ID: "fetch", Type: "http"},
+								{
+									ID:   "inner",
+									Type: "parallel",
+									Branches: []domain.Branch{
+										{
+											ID: "x",
+											Steps: []domain.StepDef{
+												{ID: "step", Type: "http", DependsOn: innerDeps},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("valid nested with outer dependencies", func(t *testing.T) {
+		// fetch — шаг внешней ветки, start — шаг верхнего уровня
+		if err := Validate(newSpec([]string{"fetch", "start"})); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("unknown dependency", func(t *testing.T) {
+		err := Validate(newSpec([]string{"missing"}))
+		if !errors.Is(err, ErrMissingDependency) {
+			t.Errorf("expected ErrMissingDependency, got %v", err)
+		}
+	})
+
+	t.Run("self dependency by local ID", func(t *testing.T) {
+		err := Validate(newSpec([]string{"step"}))
+		if !errors.Is(err, ErrSelfDependency) {
+			t.Errorf("expected ErrSelfDependency, got %v", err)
+		}
+	})
+
+	t.Run("invalid nested step type", func(t *testing.T) {
+		spec := newSpec(nil)
+		spec.Steps[1].Branches[0].Steps[1].Branches[0].Steps[0].Type = "unknown"
+
+		var vErr *ValidationError
+		err := Validate(spec)
+		if !errors.As(err, &vErr) {
+			t.Fatalf("expected ValidationError, got %v", err)
+		}
+		if vErr.StepID != "outer.a.inner.x.step" {
+			t.Errorf("expected full step ID in error, got %s", vErr.StepID)
+		}
+	})
+}
+
+func TestValidate_BranchLocalDependencies(t *testing.T) {
+	spec := &domain.FlowSpec{
+		Steps: []domain.StepDef{
+			{
+				ID:   "parallel",
+				Type: "parallel",
+				Branches: []domain.Branch{
+					{
+						ID: "a",
+						Steps: []domain.StepDef{
+							{ID: "users", Type: "http"},
+							{ID: "orders", Type: "http"},
+							{ID: "merge", Type: "transform", DependsOn: []string{"users", "orders"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := Validate(spec); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_ParallelConfig(t *testing.T) {
+	newSpec := func(config map[string]any) *domain.FlowSpec {
+		return &domain.FlowSpec{
+			Steps: []domain.StepDef{
+				{
+					ID:     "parallel",
+					Type:   "parallel",
+					Config: config,
+					Branches: []domain.Branch{
+						{ID: "a", Steps: []domain.StepDef{{ID: "step", Type: "http"}}},
+						{ID: "b", Steps: []domain.StepDef{{ID: "step", Type: "http"}}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr error
+	}{
+		{"default", nil, nil},
+		{"wait_all", map[string]any{"failure_policy": "wait_all"}, nil},
+		{"min_success", map[string]any{"failure_policy": "min_success", "min_success": float64(1)}, nil},
+		{"max_concurrency", map[string]any{"max_concurrency": float64(1)}, nil},
+		{"unknown policy", map[string]any{"failure_policy": "best_effort"}, ErrInvalidFailurePolicy},
+		{"min_success missing", map[string]any{"failure_policy": "min_success"}, ErrInvalidMinSuccess},
+		{"min_success too large", map[string]any{"failure_policy": "min_success", "min_success": 3}, ErrInvalidMinSuccess},
+		{"fractional min_success", map[string]any{"failure_policy": "min_success", "min_success": 1.5}, ErrInvalidMinSuccess},
+		{"negative max_concurrency", map[string]any{"max_concurrency": -1}, ErrInvalidMaxConcurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(newSpec(tt.config))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_PollStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"request": map[string]any{"url": "http://x"}, "until": `eq .Outputs.status "done"`}, false},
+		{"missing until", map[string]any{"request": map[string]any{"url": "http://x"}}, true},
+		{"templated until", map[string]any{"request": map[string]any{"url": "http://x"}, "until": "{{ .Outputs.done }}"}, true},
+		{"missing request", map[string]any{"until": "true"}, true},
+		{"nested poll action", map[string]any{"action": "poll", "request": map[string]any{}, "until": "true"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "wait", Type: "poll", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidPollConfig) {
+				t.Errorf("expected ErrInvalidPollConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_HTTPStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    domain.StepDef
+		wantErr bool
+	}{
+		{"default formats", domain.StepDef{ID: "call", Type: "http", Config: map[string]any{"url": "http://x"}}, false},
+		{"xml and csv", domain.StepDef{ID: "call", Type: "http", Config: map[string]any{"url": "http://x",
+			"body_format": "xml", "xml_root": "order", "response_format": "csv", "csv": map[string]any{"delimiter": ";"}}}, false},
+		{"unknown body format", domain.StepDef{ID: "call", Type: "http", Config: map[string]any{"body_format": "yaml"}}, true},
+		{"invalid csv options", domain.StepDef{ID: "call", Type: "http", Config: map[string]any{"csv": map[string]any{"header": 1}}}, true},
+		{"poll request", domain.StepDef{ID: "wait", Type: "poll", Config: map[string]any{"until": "true",
+			"request": map[string]any{"response_format": "yaml"}}}, true},
+		{"poll non-http action", domain.StepDef{ID: "wait", Type: "poll", Config: map[string]any{"until": "true",
+			"action": "grpc", "request": map[string]any{"response_format": "yaml"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&domain.FlowSpec{Steps: []domain.StepDef{tt.step}})
+			if tt.wantErr && !errors.Is(err, ErrInvalidBodyFormat) {
+				t.Errorf("expected ErrInvalidBodyFormat, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_SQLStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"valid query", map[string]any{"connection": "reporting", "query": "SELECT * FROM orders WHERE id = $1", "params": []any{"{{ .Inputs.id }}"}}, false},
+		{"valid exec", map[string]any{"connection": "reporting", "query": "DELETE FROM tmp", "mode": "exec"}, false},
+		{"missing connection", map[string]any{"query": "SELECT 1"}, true},
+		{"missing query", map[string]any{"connection": "reporting"}, true},
+		{"templated query", map[string]any{"connection": "reporting", "query": "SELECT * FROM orders WHERE id = '{{ .Inputs.id }}'"}, true},
+		{"params not a list", map[string]any{"connection": "reporting", "query": "SELECT $1", "params": "x"}, true},
+		{"unknown mode", map[string]any{"connection": "reporting", "query": "SELECT 1", "mode": "batch"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "report", Type: "sql", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidSQLConfig) {
+				t.Errorf("expected ErrInvalidSQLConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_AMQPPublishStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"connection": "events", "exchange": "orders", "routing_key": "order.paid", "body": map[string]any{"id": "{{ .Inputs.id }}"}}, false},
+		{"default exchange", map[string]any{"connection": "events", "routing_key": "audit"}, false},
+		{"missing connection", map[string]any{"exchange": "orders"}, true},
+		{"missing destination", map[string]any{"connection": "events"}, true},
+		{"headers not an object", map[string]any{"connection": "events", "exchange": "orders", "headers": "x"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "emit", Type: "amqp_publish", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidAMQPConfig) {
+				t.Errorf("expected ErrInvalidAMQPConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_EmailStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"connection": "mail", "to": "{{ .Inputs.email }}", "subject": "Report", "html": "<p>Done</p>",
+			"attachments": []any{map[string]any{"filename": "report.csv", "content": "{{ .Steps.export.Outputs.body }}"}}}, false},
+		{"bcc only", map[string]any{"connection": "mail", "bcc": []any{"audit@example.com"}, "text": "hi"}, false},
+		{"missing connection", map[string]any{"to": "ops@example.com", "text": "hi"}, true},
+		{"missing recipients", map[string]any{"connection": "mail", "text": "hi"}, true},
+		{"missing body", map[string]any{"connection": "mail", "to": "ops@example.com"}, true},
+		{"attachment without filename", map[string]any{"connection": "mail", "to": "ops@example.com", "text": "hi",
+			"attachments": []any{map[string]any{"content": "x"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "notify", Type: "email", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidEmailConfig) {
+				t.Errorf("expected ErrInvalidEmailConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_TransformJQ(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"templates only", map[string]any{"total": "{{ .Inputs.total }}"}, false},
+		{"valid jq", map[string]any{"active": map[string]any{"jq": ".steps.fetch.outputs.items | map(select(.active))"}}, false},
+		{"valid jq in mappings", map[string]any{"mappings": map[string]any{"n": map[string]any{"jq": ".inputs | length"}}}, false},
+		{"plain object", map[string]any{"meta": map[string]any{"jq": "x", "source": "api"}}, false},
+		{"syntax error", map[string]any{"active": map[string]any{"jq": ".items | map(select(.active)"}}, true},
+		{"unknown function", map[string]any{"active": map[string]any{"jq": ".items | frobnicate"}}, true},
+		{"invalid jq in mappings", map[string]any{"mappings": map[string]any{"n": map[string]any{"jq": "[.a"}}}, true},
+		{"not a string", map[string]any{"n": map[string]any{"jq": 1}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "reshape", Type: "transform", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidJQ) {
+				t.Errorf("expected ErrInvalidJQ, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+
+	spec := &domain.FlowSpec{
+		Steps: []domain.StepDef{{ID: "reshape", Type: "transform", Config: map[string]any{"globals": "{{ .Inputs.x }}"}}},
+	}
+	if err := Validate(spec); !errors.Is(err, ErrInvalidTransformConfig) {
+		t.Errorf("expected ErrInvalidTransformConfig for reserved globals, got %v", err)
+	}
+}
+
+func TestValidate_GRPCStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"target": "billing:50051", "service": "billing.v1.Invoices", "method": "Create",
+			"request": map[string]any{"customer_id": "{{ .Inputs.customer }}"}, "metadata": map[string]any{"x-request-id": "{{ .Inputs.request_id }}"}}, false},
+		{"descriptor set", map[string]any{"target": "billing:50051", "service": "billing.v1.Invoices", "method": "Get", "descriptor_set": "billing"}, false},
+		{"missing target", map[string]any{"service": "billing.v1.Invoices", "method": "Create"}, true},
+		{"missing method", map[string]any{"target": "billing:50051", "service": "billing.v1.Invoices"}, true},
+		{"request not an object", map[string]any{"target": "billing:50051", "service": "billing.v1.Invoices", "method": "Create", "request": "{}"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "invoice", Type: "grpc", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidGRPCConfig) {
+				t.Errorf("expected ErrInvalidGRPCConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_ScriptStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"source": "def main():\n    return {\"total\": len(inputs[\"orders\"])}\n", "timeout_sec": 10}, false},
+		{"missing source", map[string]any{}, true},
+		{"syntax error", map[string]any{"source": "def main(:\n    return 1\n"}, true},
+		{"no main", map[string]any{"source": "total = 1\n"}, true},
+		{"template in source", map[string]any{"source": "def main():\n    return {\"id\": \"{{ .Inputs.id }}\"}\n"}, true},
+		{"reserved globals", map[string]any{"source": "def main():\n    return {}\n", "globals": map[string]any{}}, true},
+		{"timeout too long", map[string]any{"source": "def main():\n    return {}\n", "timeout_sec": 3600}, true},
+		{"max_memory_mb not a number", map[string]any{"source": "def main():\n    return {}\n", "max_memory_mb": "64"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "reshape", Type: "script", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidScriptConfig) {
+				t.Errorf("expected ErrInvalidScriptConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_WasmStep(t *testing.T) {
+	digest := WasmDigest([]byte("module"))
+
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"module": digest, "threshold": "{{ .Inputs.threshold }}", "max_memory_mb": 128}, false},
+		{"missing module", map[string]any{"threshold": 1}, true},
+		{"module by name", map[string]any{"module": "score@1.0.0"}, true},
+		{"uppercase digest", map[string]any{"module": strings.ToUpper(digest)}, true},
+		{"reserved globals", map[string]any{"module": digest, "globals": map[string]any{}}, true},
+		{"timeout too long", map[string]any{"module": digest, "timeout_sec": 3600}, true},
+		{"memory too large", map[string]any{"module": digest, "max_memory_mb": 4096}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "score", Type: "wasm", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidWasmConfig) {
+				t.Errorf("expected ErrInvalidWasmConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_ExternalStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"topic": "score-customer", "customer_id": "{{ .Inputs.customer_id }}"}, false},
+		{"missing topic", map[string]any{"customer_id": "c-1"}, true},
+		{"template in topic", map[string]any{"topic": "score-{{ .Inputs.region }}"}, true},
+		{"spaces in topic", map[string]any{"topic": "score customer"}, true},
+		{"reserved globals", map[string]any{"topic": "score", "globals": map[string]any{}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "score", Type: "external", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidExternalConfig) {
+				t.Errorf("expected ErrInvalidExternalConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyExternalFailure(t *testing.T) {
+	now := time.Now()
+	policy := &domain.RetryPolicy{MaxAttempts: 3, Backoff: "exponential", InitialDelayMs: 1000}
+
+	task := &domain.Task{Type: StepTypeExternal, Status: domain.TaskStatusQueued}
+	task.MarkRunning()
+	task.MarkRunning()
+
+	// Вторая попытка из трёх — повтор через backoff
+	if !ApplyExternalFailure(task, policy, "model unavailable", false, now) {
+		t.Fatal("expected task to be queued for retry")
+	}
+	if task.Status != domain.TaskStatusQueued || task.Error != "model unavailable" {
+		t.Errorf("unexpected task after retry: %+v", task)
+	}
+	if task.NextAttemptAt == nil || !task.NextAttemptAt.Equal(now.Add(2*time.Second)) {
+		t.Errorf("expected retry in 2s, got %v", task.NextAttemptAt)
+	}
+
+	// Постоянная ошибка — без повтора
+	task.MarkRunning()
+	if ApplyExternalFailure(task, policy, "bad input", true, now) {
+		t.Fatal("permanent failure should not be retried")
+	}
+	if task.Status != domain.TaskStatusFailed || task.NextAttemptAt != nil {
+		t.Errorf("unexpected task after permanent failure: %+v", task)
+	}
+
+	// Без RetryPolicy — одна попытка
+	task = &domain.Task{Type: StepTypeExternal}
+	task.MarkRunning()
+	if ApplyExternalFailure(task, nil, "lock expired", false, now) || task.Status != domain.TaskStatusFailed {
+		t.Errorf("expected failure without retry policy, got %+v", task)
+	}
+}
+
+func TestStepGlobals(t *testing.T) {
+	ctx := NewContext(map[string]any{"customer": "c-1"})
+	ctx.SetEnv("SECRET", "x")
+	ctx.AddStepResult("fetch", map[string]any{"count": 3}, "SUCCEEDED")
+
+	globals := StepGlobals(ctx)
+	if globals["inputs"].(map[string]any)["customer"] != "c-1" {
+		t.Errorf("unexpected inputs: %v", globals["inputs"])
+	}
+	fetch := globals["steps"].(map[string]any)["fetch"].(map[string]any)
+	if fetch["outputs"].(map[string]any)["count"] != 3 {
+		t.Errorf("unexpected step outputs: %v", fetch)
+	}
+	if _, ok := globals["env"]; ok {
+		t.Error("env must not be passed to step code")
+	}
+}
+
+func TestValidate_SignalStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		stepType string
+		config   map[string]any
+		wantErr  bool
+	}{
+		{"approval defaults", "approval", nil, false},
+		{"approval with timeout", "approval", map[string]any{"timeout_sec": float64(3600), "on_timeout": "default", "default_decision": "reject"}, false},
+		{"signal any decision", "wait_for_signal", map[string]any{"on_timeout": "default", "default_decision": "skip"}, false},
+		{"custom decisions", "wait_for_signal", map[string]any{"decisions": []any{"go", "stop"}}, false},
+		{"negative timeout", "approval", map[string]any{"timeout_sec": -1}, true},
+		{"unknown on_timeout", "approval", map[string]any{"on_timeout": "retry"}, true},
+		{"default without decision", "approval", map[string]any{"on_timeout": "default"}, true},
+		{"default decision not allowed", "approval", map[string]any{"on_timeout": "default", "default_decision": "maybe"}, true},
+		{"empty decisions", "wait_for_signal", map[string]any{"decisions": []any{}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "gate", Type: tt.stepType, Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignalConfig) {
+				t.Errorf("expected ErrInvalidSignalConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSignalConfig_ValidateDecision(t *testing.T) {
+	cfg, err := ParseSignalConfig(StepTypeApproval, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cfg.ValidateDecision("approve"); err != nil {
+		t.Errorf("expected approve to be valid, got %v", err)
+	}
+	if err := cfg.ValidateDecision("maybe"); !errors.Is(err, ErrInvalidDecision) {
+		t.Errorf("expected ErrInvalidDecision, got %v", err)
+	}
+
+	cfg, _ = ParseSignalConfig(StepTypeWaitForSignal, nil)
+	if err := cfg.ValidateDecision("anything"); err != nil {
+		t.Errorf("expected any decision to be valid, got %v", err)
+	}
+
+	outputs := SignalOutputs("approve", map[string]any{"comment": "ok", "decision": "spoofed"}, false)
+	if outputs["decision"] != "approve" || outputs["comment"] != "ok" || outputs["timed_out"] != false {
+		t.Errorf("unexpected outputs: %v", outputs)
+	}
+}
+
+func TestApplyWaitTimeout(t *testing.T) {
+	task := &domain.Task{
+		Type:   StepTypeApproval,
+		Status: domain.TaskStatusWaiting,
+		Payload: map[string]any{
+			"timeout_sec":      float64(60),
+			"on_timeout":       "default",
+			"default_decision": "reject",
+			"default_payload":  map[string]any{"comment": "expired"},
+		},
+	}
+	if err := ApplyWaitTimeout(task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Status != domain.TaskStatusSucceeded {
+		t.Errorf("expected SUCCEEDED, got %s", task.Status)
+	}
+	if task.Outputs["decision"] != "reject" || task.Outputs["timed_out"] != true || task.Outputs["comment"] != "expired" {
+		t.Errorf("unexpected outputs: %v", task.Outputs)
+	}
+
+	task = &domain.Task{
+		Type:    StepTypeWaitForSignal,
+		Status:  domain.TaskStatusWaiting,
+		Payload: map[string]any{"timeout_sec": float64(60)},
+	}
+	if err := ApplyWaitTimeout(task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Status != domain.TaskStatusFailed || task.Error == "" {
+		t.Errorf("expected FAILED with error, got %s %q", task.Status, task.Error)
+	}
+}
+
+func TestValidate_CallbackStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{"no timeout", nil, false},
+		{"with timeout", map[string]any{"timeout_sec": float64(600)}, false},
+		{"negative timeout", map[string]any{"timeout_sec": -5}, true},
+		{"string timeout", map[string]any{"timeout_sec": "10"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{{ID: "vendor", Type: "wait_for_callback", Config: tt.config}},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidCallbackConfig) {
+				t.Errorf("expected ErrInvalidCallbackConfig, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCallbackSigner(t *testing.T) {
+	signer := NewCallbackSigner("https://automata.example.com/", "secret")
+	runID := uuid.New()
+
+	url := signer.URL(runID, "p.a.wait")
+	prefix := "https://automata.example.com/api/v1/callbacks/" + runID.String() + "/p.a.wait?token="
+	if !strings.HasPrefix(url, prefix) {
+		t.Fatalf("unexpected callback url: %s", url)
+	}
+
+	token := strings.TrimPrefix(url, prefix)
+	if !signer.Verify(runID, "p.a.wait", token) {
+		t.Error("expected token to be valid")
+	}
+	if signer.Verify(runID, "other", token) {
+		t.Error("token must not be valid for another step")
+	}
+	if signer.Verify(uuid.New(), "p.a.wait", token) {
+		t.Error("token must not be valid for another run")
+	}
+	if NewCallbackSigner("", "other-secret").Verify(runID, "p.a.wait", token) {
+		t.Error("token must not be valid with another secret")
+	}
+}
+
+func TestApplyCallback(t *testing.T) {
+	task := &domain.Task{
+		Type:    StepTypeWaitForCallback,
+		Status:  domain.TaskStatusWaiting,
+		Outputs: map[string]any{"callback_url": "https://x/cb"},
+	}
+	ApplyCallback(task, CallbackRequest{
+		Method:  "POST",
+		Headers: map[string]string{"X-Vendor": "acme"},
+		Body:    []byte(`{"status":"done"}`),
+	})
+
+	if task.Status != domain.TaskStatusSucceeded {
+		t.Fatalf("expected SUCCEEDED, got %s", task.Status)
+	}
+	body, _ := task.Outputs["body"].(map[string]any)
+	if body["status"] != "done" {
+		t.Errorf("expected parsed JSON body, got %v", task.Outputs["body"])
+	}
+	if task.Outputs["callback_url"] != "https://x/cb" {
+		t.Errorf("callback_url should be kept, got %v", task.Outputs["callback_url"])
+	}
+
+	task = &domain.Task{Type: StepTypeWaitForCallback, Status: domain.TaskStatusWaiting}
+	ApplyCallback(task, CallbackRequest{Method: "POST", Body: []byte("plain text")})
+	if task.Outputs["body"] != "plain text" {
+		t.Errorf("expected raw body, got %v", task.Outputs["body"])
+	}
+
+	task = &domain.Task{
+		Type:    StepTypeWaitForCallback,
+		Status:  domain.TaskStatusWaiting,
+		Payload: map[string]any{"timeout_sec": float64(30)},
+	}
+	if err := ApplyWaitTimeout(task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Status != domain.TaskStatusFailed {
+		t.Errorf("expected FAILED on callback timeout, got %s", task.Status)
+	}
+}
+
+func TestValidate_Compensate(t *testing.T) {
+	tests := []struct {
+		name    string
+		comp    *domain.StepDef
+		wantErr bool
+	}{
+		{"http", &domain.StepDef{Type: "http", Config: map[string]any{"url": "{{ .Outputs.body.refund_url }}"}}, false},
+		{"unknown type", &domain.StepDef{Type: "refund"}, true},
+		{"parallel", &domain.StepDef{Type: "parallel"}, true},
+		{"approval", &domain.StepDef{Type: "approval"}, true},
+		{"with depends_on", &domain.StepDef{Type: "http", DependsOn: []string{"other"}}, true},
+		{"nested compensate", &domain.StepDef{Type: "http", Compensate: &domain.StepDef{Type: "http"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps: []domain.StepDef{
+					{ID: "other", Type: "http"},
+					{ID: "charge", Type: "http", Compensate: tt.comp},
+				},
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidCompensation) {
+				t.Errorf("expected ErrInvalidCompensation, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_RunLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout int
+		sla     int
+		wantErr bool
+	}{
+		{"none", 0, 0, false},
+		{"timeout only", 3600, 0, false},
+		{"sla only", 0, 600, false},
+		{"sla before timeout", 3600, 600, false},
+		{"negative timeout", -1, 0, true},
+		{"negative sla", 0, -1, true},
+		{"sla after timeout", 600, 3600, true},
+		{"sla equals timeout", 600, 600, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps:      []domain.StepDef{{ID: "s", Type: "delay"}},
+				TimeoutSec: tt.timeout,
+				SLASec:     tt.sla,
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidRunLimits) {
+				t.Errorf("expected ErrInvalidRunLimits, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_Concurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		cc      *domain.ConcurrencyPolicy
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"default policy", &domain.ConcurrencyPolicy{MaxConcurrentRuns: 1}, false},
+		{"skip_new", &domain.ConcurrencyPolicy{MaxConcurrentRuns: 2, Policy: "skip_new"}, false},
+		{"cancel_running with key", &domain.ConcurrencyPolicy{MaxConcurrentRuns: 1, Policy: "cancel_running", Key: "{{ .Inputs.customer }}"}, false},
+		{"zero max", &domain.ConcurrencyPolicy{MaxConcurrentRuns: 0}, true},
+		{"unknown policy", &domain.ConcurrencyPolicy{MaxConcurrentRuns: 1, Policy: "drop"}, true},
+		{"bad key template", &domain.ConcurrencyPolicy{MaxConcurrentRuns: 1, Key: "{{ .Inputs.customer "}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &domain.FlowSpec{
+				Steps:       []domain.StepDef{{ID: "s", Type: "delay"}},
+				Concurrency: tt.cc,
+			}
+			err := Validate(spec)
+			if tt.wantErr && !errors.Is(err, ErrInvalidConcurrency) {
+				t.Errorf("expected ErrInvalidConcurrency, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConcurrencyKey(t *testing.T) {
+	inputs := map[string]any{"customer": "acme"}
+
+	key, err := ConcurrencyKey(&domain.ConcurrencyPolicy{MaxConcurrentRuns: 1, Key: "customer-{{ .Inputs.customer }}"}, inputs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "customer-acme" {
+		t.Errorf("expected customer-acme, got %q", key)
+	}
+
+	key, err = ConcurrencyKey(&domain.ConcurrencyPolicy{MaxConcurrentRuns: 1}, inputs)
+	if err != nil || key != "" {
+		t.Errorf("expected empty key without template, got %q, %v", key, err)
+	}
+
+	if got := ConcurrencyPolicy(&domain.ConcurrencyPolicy{MaxConcurrentRuns: 1}); got != domain.ConcurrencyQueue {
+		t.Errorf("expected default policy queue, got %s", got)
+	}
+}
+
+func TestValidate_Resources(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    domain.StepDef
+		wantErr bool
+	}{
+		{"single", domain.StepDef{ID: "s", Type: "http", Resources: []string{"erp"}}, false},
+		{"several", domain.StepDef{ID: "s", Type: "http", Resources: []string{"erp", "crm"}}, false},
+		{"empty name", domain.StepDef{ID: "s", Type: "http", Resources: []string{""}}, true},
+		{"duplicate", domain.StepDef{ID: "s", Type: "http", Resources: []string{"erp", "erp"}}, true},
+		{"parallel", domain.StepDef{
+			ID: "p", Type: "parallel", Resources: []string{"erp"},
+			Branches: []domain.Branch{{ID: "b", Steps: []domain.StepDef{{ID: "s", Type: "delay"}}}},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&domain.FlowSpec{Steps: []domain.StepDef{tt.step}})
+			if tt.wantErr && !errors.Is(err, ErrInvalidResources) {
+				t.Errorf("expected ErrInvalidResources, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
 func TestIsValidStepType(t *testing.T) {
-	validTypes := []string{"http", "delay", "transform", "parallel"}
+	validTypes := []string{"http", "delay", "transform", "parallel", "poll", "sql", "amqp_publish", "email", "grpc", "script", "wasm", "external", "approval", "wait_for_signal", "wait_for_callback"}
 	for _, typ := range validTypes {
 		if !IsValidStepType(typ) {
 			t.Errorf("expected %s to be valid", typ)
@@ -376,15 +1256,26 @@ func TestIsValidStepType(t *testing.T) {
 
 func TestGetValidStepTypes(t *testing.T) {
 	types := GetValidStepTypes()
-	if len(types) != 4 {
-		t.Errorf("expected 4 types, got %d", len(types))
+	if len(types) != 15 {
+		t.Errorf("expected 15 types, got %d", len(types))
 	}
 
 	expected := map[string]bool{
-		"http":      true,
-		"delay":     true,
-		"transform": true,
-		"parallel":  true,
+		"http":              true,
+		"delay":             true,
+		"transform":         true,
+		"parallel":          true,
+		"poll":              true,
+		"sql":               true,
+		"amqp_publish":      true,
+		"email":             true,
+		"grpc":              true,
+		"script":            true,
+		"wasm":              true,
+		"external":          true,
+		"approval":          true,
+		"wait_for_signal":   true,
+		"wait_for_callback": true,
 	}
 
 	for _, typ := range types {
@@ -393,3 +1284,50 @@ func TestGetValidStepTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterStepTypes(t *testing.T) {
+	t.Cleanup(func() {
+		delete(validStepTypes, "slack_post")
+		delete(validStepTypes, "s3_upload")
+	})
+
+	if err := RegisterStepTypes("slack_post", "s3_upload"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !IsValidStepType("slack_post") || !IsValidStepType("s3_upload") {
+		t.Error("expected plugin types to be valid")
+	}
+
+	spec := &domain.FlowSpec{Steps: []domain.StepDef{{ID: "notify", Type: "slack_post"}}}
+	if err := Validate(spec); err != nil {
+		t.Errorf("expected spec with plugin step to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		types []string
+	}{
+		{"built-in", []string{"http"}},
+		{"already registered", []string{"slack_post"}},
+		{"uppercase", []string{"Slack"}},
+		{"empty", []string{""}},
+		{"invalid chars", []string{"slack-post"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterStepTypes(tt.types...)
+			if !errors.Is(err, ErrInvalidPluginStepType) {
+				t.Errorf("expected ErrInvalidPluginStepType, got %v", err)
+			}
+		})
+	}
+
+	// Ошибка в одном типе — не регистрируется ни один
+	if err := RegisterStepTypes("pdf_render", "HTTP"); err == nil {
+		t.Fatal("expected error")
+	}
+	if IsValidStepType("pdf_render") {
+		t.Error("expected no types registered after error")
+	}
+}
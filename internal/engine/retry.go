@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// Descendants возвращает узел id и все узлы, транзитивно зависящие от него.
+// Для parallel шага это его ветки, join и всё, что идёт после него.
+func (d *DAG) Descendants(id string) map[string]bool {
+	result := make(map[string]bool)
+
+	node, exists := d.Nodes[id]
+	if !exists {
+		return result
+	}
+
+	queue := []*Node{node}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if result[current.ID] {
+			continue
+		}
+		result[current.ID] = true
+		queue = append(queue, current.Dependents...)
+	}
+	return result
+}
+
+// RetryTasks выбирает tasks исходного run, результаты которых переиспользует
+// повторный run. Остальные шаги (упавшие, пропущенные, не запускавшиеся)
+// выполняются заново.
+//
+// Переиспользуются только SUCCEEDED tasks. Не переиспользуются:
+//   - шаги, отменённые компенсацией (есть task <step_id>.compensate),
+//     и все шаги, зависящие от них;
+//   - при fromStep — сам шаг fromStep и все шаги, зависящие от него.
+//
+// Для неизвестного fromStep возвращается ErrRetryStepNotFound.
+func RetryTasks(dag *DAG, tasks []domain.Task, fromStep string) ([]domain.Task, error) {
+	rerun := make(map[string]bool)
+
+	if fromStep != "" {
+		if dag.GetNode(fromStep) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRetryStepNotFound, fromStep)
+		}
+		for id := range dag.Descendants(fromStep) {
+			rerun[id] = true
+		}
+	}
+
+	// Компенсированный шаг отменил свой эффект — его и зависимых выполняем заново
+	for _, task := range tasks {
+		if stepID, ok := CompensatedStepID(task.StepID); ok {
+			for id := range dag.Descendants(stepID) {
+				rerun[id] = true
+			}
+		}
+	}
+
+	reused := make([]domain.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.Status != domain.TaskStatusSucceeded {
+			continue
+		}
+		if _, ok := CompensatedStepID(task.StepID); ok {
+			continue
+		}
+		// Шаг удалён из спеки (например, sandbox) — результат не нужен
+		if dag.GetNode(task.StepID) == nil || rerun[task.StepID] {
+			continue
+		}
+		reused = append(reused, task)
+	}
+	return reused, nil
+}
+
+// StepRetryPolicy возвращает RetryPolicy шага stepID (включая шаги веток
+// и компенсации) или defaults.retry spec. nil — без повторов.
+func StepRetryPolicy(spec *domain.FlowSpec, stepID string) *domain.RetryPolicy {
+	if step := FindStepDef(spec.Steps, stepID); step != nil && step.Retry != nil {
+		return step.Retry
+	}
+	if spec.Defaults != nil && spec.Defaults.Retry != nil {
+		return spec.Defaults.Retry
+	}
+	return nil
+}
+
+// RetryBackoff вычисляет задержку перед повтором попытки attempt
+// по RetryPolicy (nil — 1 секунда).
+func RetryBackoff(attempt int, policy *domain.RetryPolicy) time.Duration {
+	if policy == nil {
+		return time.Second
+	}
+
+	initialDelay := time.Duration(policy.InitialDelayMs) * time.Millisecond
+	if initialDelay <= 0 {
+		initialDelay = time.Second
+	}
+
+	maxDelay := time.Duration(policy.MaxDelayMs) * time.Millisecond
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	var delay time.Duration
+	switch policy.Backoff {
+	case "exponential":
+		// delay = initialDelay * 2^(attempt-1)
+		delay = initialDelay
+		for i := 1; i < attempt; i++ {
+			delay *= 2
+			if delay > maxDelay {
+				delay = maxDelay
+				break
+			}
+		}
+	default:
+		// "fixed" или неизвестный — используем initialDelay
+		delay = initialDelay
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+// FindStepDef ищет StepDef по ID, включая шаги внутри parallel-веток
+// любой вложенности (prefixed ID: parallel_id.branch_id.step_id).
+// Для task компенсации (<step_id>.compensate) возвращает compensate шага.
+// Если prefixed ID не найден, ищет шаг ветки по прямому ID.
+func FindStepDef(steps []domain.StepDef, stepID string) *domain.StepDef {
+	if step := findStepDefByPath(steps, "", stepID); step != nil {
+		return step
+	}
+	if compensatedID, ok := CompensatedStepID(stepID); ok {
+		if step := FindStepDef(steps, compensatedID); step != nil && step.Compensate != nil {
+			return step.Compensate
+		}
+	}
+	return findBranchStepByID(steps, stepID)
+}
+
+// findStepDefByPath рекурсивно ищет шаг по полному ID.
+func findStepDefByPath(steps []domain.StepDef, prefix, stepID string) *domain.StepDef {
+	for i := range steps {
+		step := &steps[i]
+		fullID := prefix + step.ID
+		if fullID == stepID {
+			return step
+		}
+
+		// Для parallel — спускаемся в ветки, только если ID совпадает по префиксу
+		if step.Type == "parallel" && strings.HasPrefix(stepID, fullID+".") {
+			for _, branch := range step.Branches {
+				branchPrefix := fmt.Sprintf("%s.%s.", fullID, branch.ID)
+				if found := findStepDefByPath(branch.Steps, branchPrefix, stepID); found != nil {
+					return found
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findBranchStepByID рекурсивно ищет шаг внутри веток по прямому ID.
+func findBranchStepByID(steps []domain.StepDef, stepID string) *domain.StepDef {
+	for i := range steps {
+		step := &steps[i]
+		if step.Type != "parallel" {
+			continue
+		}
+		for _, branch := range step.Branches {
+			for j := range branch.Steps {
+				if branch.Steps[j].ID == stepID {
+					return &branch.Steps[j]
+				}
+			}
+			if found := findBranchStepByID(branch.Steps, stepID); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
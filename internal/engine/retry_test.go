@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+func TestRetryBackoff_Exponential(t *testing.T) {
+	policy := &domain.RetryPolicy{
+		Backoff:        "exponential",
+		InitialDelayMs: 1000,
+		MaxDelayMs:     10000,
+	}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second}, // capped at max
+		{6, 10 * time.Second}, // stays at max
+	}
+
+	for _, tt := range tests {
+		got := RetryBackoff(tt.attempt, policy)
+		if got != tt.expected {
+			t.Errorf("attempt %d: expected %v, got %v", tt.attempt, tt.expected, got)
+		}
+	}
+}
+
+func TestRetryBackoff_Fixed(t *testing.T) {
+	policy := &domain.RetryPolicy{
+		Backoff:        "fixed",
+		InitialDelayMs: 2000,
+		MaxDelayMs:     10000,
+	}
+
+	// Все попытки — одинаковая задержка
+	for attempt := 1; attempt <= 5; attempt++ {
+		got := RetryBackoff(attempt, policy)
+		if got != 2*time.Second {
+			t.Errorf("attempt %d: expected 2s, got %v", attempt, got)
+		}
+	}
+}
+
+func TestRetryBackoff_NilPolicy(t *testing.T) {
+	got := RetryBackoff(1, nil)
+	if got != time.Second {
+		t.Errorf("expected 1s default, got %v", got)
+	}
+}
+
+func TestRetryBackoff_ZeroValues(t *testing.T) {
+	policy := &domain.RetryPolicy{
+		Backoff: "exponential",
+		// InitialDelayMs и MaxDelayMs = 0
+	}
+
+	got := RetryBackoff(1, policy)
+	if got != time.Second {
+		t.Errorf("expected 1s default for zero InitialDelayMs, got %v", got)
+	}
+}
+
+func TestFindStepDef_Nested(t *testing.T) {
+	steps := []domain.StepDef{
+		{ID: "start", Type: "http"},
+		{
+			ID:   "outer",
+			Type: "parallel",
+			Branches: []domain.Branch{
+				{
+					ID: "a",
+					Steps: []domain.StepDef{
+						{ID: "fetch", Type: "http"},
+						{
+							ID:   "inner",
+							Type: "parallel",
+							Branches: []domain.Branch{
+								{ID: "x", Steps: []domain.StepDef{{ID: "fetch", Type: "delay"}}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		stepID   string
+		wantType string
+	}{
+		{"start", "http"},
+		{"outer", "parallel"},
+		{"outer.a.fetch", "http"},
+		{"outer.a.inner", "parallel"},
+		{"outer.a.inner.x.fetch", "delay"},
+		{"fetch", "http"}, // прямой ID — первый найденный шаг ветки
+	}
+
+	for _, tt := range tests {
+		step := FindStepDef(steps, tt.stepID)
+		if step == nil {
+			t.Errorf("step %s not found", tt.stepID)
+			continue
+		}
+		if step.Type != tt.wantType {
+			t.Errorf("step %s: expected type %s, got %s", tt.stepID, tt.wantType, step.Type)
+		}
+	}
+
+	if FindStepDef(steps, "outer.a.inner.y.fetch") != nil {
+		t.Error("unknown branch should not resolve")
+	}
+}
+
+func TestFindStepDef_Compensation(t *testing.T) {
+	steps := []domain.StepDef{
+		{
+			ID:         "charge",
+			Type:       "http",
+			Compensate: &domain.StepDef{Type: "http", Retry: &domain.RetryPolicy{MaxAttempts: 5}},
+		},
+		{ID: "notify", Type: "http"},
+	}
+
+	step := FindStepDef(steps, "charge.compensate")
+	if step == nil || step.Retry == nil || step.Retry.MaxAttempts != 5 {
+		t.Fatalf("expected compensate step def, got %+v", step)
+	}
+
+	if FindStepDef(steps, "notify.compensate") != nil {
+		t.Error("step without compensate should not resolve")
+	}
+}
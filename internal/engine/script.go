@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.starlark.net/syntax"
+)
+
+// StepTypeScript — вычисления на Starlark (диалект Python) во встроенном интерпретаторе.
+const StepTypeScript = "script"
+
+// ScriptEntrypoint — функция скрипта, результат которой становится outputs шага.
+const ScriptEntrypoint = "main"
+
+// StepGlobalsKey — ключ payload task, в котором Orchestrator передаёт
+// воркеру контекст run для пользовательского кода (script, wasm; см. StepGlobals).
+const StepGlobalsKey = "globals"
+
+// Лимиты script шага: значения по умолчанию и максимальные значения,
+// которые можно задать в config.
+const (
+	DefaultScriptTimeout   = 5 * time.Second
+	MaxScriptTimeout       = 60 * time.Second
+	DefaultScriptMaxSteps  = 10_000_000
+	MaxScriptMaxSteps      = 100_000_000
+	DefaultScriptMaxMemory = 64 << 20
+	MaxScriptMaxMemory     = 512 << 20
+)
+
+// ScriptConfig — настройки script шага.
+//
+//	{
+//	  "source": "def main():\n    return {\"total\": sum([o[\"amount\"] for o in inputs[\"orders\"]])}",
+//	  "timeout_sec": 5,
+//	  "max_steps": 10000000,
+//	  "max_memory_mb": 64
+//	}
+type ScriptConfig struct {
+	// Source — текст скрипта. Должен определять функцию main().
+	Source string
+
+	// Timeout — ограничение времени выполнения.
+	Timeout time.Duration
+
+	// MaxSteps — ограничение числа шагов интерпретатора (CPU).
+	MaxSteps uint64
+
+	// MaxMemory — ограничение памяти значений скрипта (оценка по
+	// переменным и результату main()), в байтах.
+	MaxMemory uint64
+}
+
+// ScriptFileOptions возвращает диалект Starlark для script шагов:
+// разрешены while, set и переприсваивание глобальных переменных.
+// Рекурсия запрещена.
+func ScriptFileOptions() *syntax.FileOptions {
+	return &syntax.FileOptions{
+		Set:             true,
+		While:           true,
+		TopLevelControl: true,
+		GlobalReassign:  true,
+	}
+}
+
+// ParseScriptConfig извлекает настройки script шага и проверяет,
+// что скрипт компилируется и определяет main().
+func ParseScriptConfig(config map[string]any) (*ScriptConfig, error) {
+	source, _ := config["source"].(string)
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("%w: source is required", ErrInvalidScriptConfig)
+	}
+	// Данные передаются через глобальные переменные inputs и steps,
+	// а не подстановкой шаблонов в код
+	if strings.Contains(source, "{{") {
+		return nil, fmt.Errorf("%w: source must not contain templates, use inputs and steps", ErrInvalidScriptConfig)
+	}
+
+	file, err := ScriptFileOptions().Parse("script.star", source, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScriptConfig, err)
+	}
+	if !definesFunc(file, ScriptEntrypoint) {
+		return nil, fmt.Errorf("%w: script must define %s()", ErrInvalidScriptConfig, ScriptEntrypoint)
+	}
+
+	cfg := &ScriptConfig{
+		Source:    source,
+		Timeout:   DefaultScriptTimeout,
+		MaxSteps:  DefaultScriptMaxSteps,
+		MaxMemory: DefaultScriptMaxMemory,
+	}
+
+	if v, ok := config["timeout_sec"]; ok {
+		n, ok := configInt(v)
+		if !ok || n <= 0 || time.Duration(n)*time.Second > MaxScriptTimeout {
+			return nil, fmt.Errorf("%w: timeout_sec must be between 1 and %d",
+				ErrInvalidScriptConfig, int(MaxScriptTimeout/time.Second))
+		}
+		cfg.Timeout = time.Duration(n) * time.Second
+	}
+	if v, ok := config["max_steps"]; ok {
+		n, ok := configInt(v)
+		if !ok || n <= 0 || n > MaxScriptMaxSteps {
+			return nil, fmt.Errorf("%w: max_steps must be between 1 and %d", ErrInvalidScriptConfig, MaxScriptMaxSteps)
+		}
+		cfg.MaxSteps = uint64(n)
+	}
+	if v, ok := config["max_memory_mb"]; ok {
+		n, ok := configInt(v)
+		if !ok || n <= 0 || n > MaxScriptMaxMemory>>20 {
+			return nil, fmt.Errorf("%w: max_memory_mb must be between 1 and %d", ErrInvalidScriptConfig, MaxScriptMaxMemory>>20)
+		}
+		cfg.MaxMemory = uint64(n) << 20
+	}
+
+	return cfg, nil
+}
+
+// definesFunc проверяет, что файл определяет функцию верхнего уровня name.
+func definesFunc(file *syntax.File, name string) bool {
+	for _, stmt := range file.Stmts {
+		if def, ok := stmt.(*syntax.DefStmt); ok && def.Name.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// StepGlobals возвращает контекст run для script и wasm шагов: inputs и steps
+// (outputs и status выполненных шагов). Для компенсации добавляются
+// outputs компенсируемого шага. Env не передаётся.
+func StepGlobals(ctx *Context) map[string]any {
+	inputs := ctx.Inputs
+	if inputs == nil {
+		inputs = map[string]any{}
+	}
+
+	steps := make(map[string]any, len(ctx.Steps))
+	for id, step := range ctx.Steps {
+		if step == nil {
+			continue
+		}
+		steps[id] = map[string]any{
+			"outputs": step.Outputs,
+			"status":  step.Status,
+		}
+	}
+
+	globals := map[string]any{
+		"inputs": inputs,
+		"steps":  steps,
+	}
+	if ctx.Outputs != nil {
+		globals["outputs"] = ctx.Outputs
+	}
+	return globals
+}
@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// Типы шагов, ожидающих внешнего сигнала.
+const (
+	// StepTypeApproval — ожидание решения человека (approve/reject).
+	StepTypeApproval = "approval"
+
+	// StepTypeWaitForSignal — ожидание произвольного сигнала.
+	StepTypeWaitForSignal = "wait_for_signal"
+)
+
+// Решения approval шага по умолчанию.
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+// Поведение при истечении timeout_sec.
+const (
+	// OnTimeoutFail — шаг падает (по умолчанию).
+	OnTimeoutFail = "fail"
+
+	// OnTimeoutDefault — шаг завершается успешно с default_decision
+	// и default_payload.
+	OnTimeoutDefault = "default"
+)
+
+// SignalConfig — настройки шага, ожидающего сигнала.
+//
+// Задаются в config шага approval или wait_for_signal:
+//
+//	{
+//	    "decisions": ["approve", "reject"],
+//	    "timeout_sec": 86400,
+//	    "on_timeout": "default",
+//	    "default_decision": "reject",
+//	    "default_payload": {"comment": "auto-rejected"}
+//	}
+type SignalConfig struct {
+	// Decisions — допустимые решения. Пусто — любое решение
+	// (для approval по умолчанию approve и reject).
+	Decisions []string
+
+	// Timeout — максимальное время ожидания. 0 — без ограничения.
+	Timeout time.Duration
+
+	// OnTimeout — поведение при истечении Timeout.
+	OnTimeout string
+
+	// DefaultDecision — решение при OnTimeout = default.
+	DefaultDecision string
+
+	// DefaultPayload — payload при OnTimeout = default.
+	DefaultPayload map[string]any
+}
+
+// IsSignalStep проверяет, ожидает ли шаг данного типа внешнего сигнала.
+// Такие шаги не выполняются воркером: task переводится в WAITING.
+func IsSignalStep(stepType string) bool {
+	return stepType == StepTypeApproval || stepType == StepTypeWaitForSignal
+}
+
+// ParseSignalConfig извлекает и валидирует настройки шага ожидания сигнала.
+func ParseSignalConfig(stepType string, config map[string]any) (SignalConfig, error) {
+	cfg := SignalConfig{OnTimeout: OnTimeoutFail}
+
+	if stepType == StepTypeApproval {
+		cfg.Decisions = []string{DecisionApprove, DecisionReject}
+	}
+
+	if v, ok := config["decisions"]; ok {
+		list, ok := v.([]any)
+		if !ok || len(list) == 0 {
+			return cfg, fmt.Errorf("%w: decisions must be a non-empty array", ErrInvalidSignalConfig)
+		}
+		cfg.Decisions = make([]string, 0, len(list))
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok || s == "" {
+				return cfg, fmt.Errorf("%w: decisions must be non-empty strings", ErrInvalidSignalConfig)
+			}
+			cfg.Decisions = append(cfg.Decisions, s)
+		}
+	}
+
+	if v, ok := config["timeout_sec"]; ok {
+		n, ok := configInt(v)
+		if !ok || n < 0 {
+			return cfg, fmt.Errorf("%w: timeout_sec must be a non-negative integer", ErrInvalidSignalConfig)
+		}
+		cfg.Timeout = time.Duration(n) * time.Second
+	}
+
+	if v, ok := config["on_timeout"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return cfg, fmt.Errorf("%w: on_timeout must be a string", ErrInvalidSignalConfig)
+		}
+		cfg.OnTimeout = s
+	}
+
+	switch cfg.OnTimeout {
+	case OnTimeoutFail:
+	case OnTimeoutDefault:
+		cfg.DefaultDecision, _ = config["default_decision"].(string)
+		if cfg.DefaultDecision == "" {
+			return cfg, fmt.Errorf("%w: default_decision is required for on_timeout=default", ErrInvalidSignalConfig)
+		}
+		if err := cfg.ValidateDecision(cfg.DefaultDecision); err != nil {
+			return cfg, fmt.Errorf("%w: default_decision: %v", ErrInvalidSignalConfig, err)
+		}
+		if v, ok := config["default_payload"]; ok {
+			payload, ok := v.(map[string]any)
+			if !ok {
+				return cfg, fmt.Errorf("%w: default_payload must be an object", ErrInvalidSignalConfig)
+			}
+			cfg.DefaultPayload = payload
+		}
+	default:
+		return cfg, fmt.Errorf("%w: unknown on_timeout: %s", ErrInvalidSignalConfig, cfg.OnTimeout)
+	}
+
+	return cfg, nil
+}
+
+// ValidateDecision проверяет, что решение допустимо для шага.
+func (c SignalConfig) ValidateDecision(decision string) error {
+	if len(c.Decisions) == 0 {
+		return nil
+	}
+	if !slices.Contains(c.Decisions, decision) {
+		return fmt.Errorf("%w: %q, expected one of %v", ErrInvalidDecision, decision, c.Decisions)
+	}
+	return nil
+}
+
+// SignalOutputs формирует outputs шага из полученного сигнала.
+//
+// Payload становится outputs шага, дополнительно записываются:
+//   - decision — принятое решение
+//   - timed_out — true, если решение принято по истечении timeout_sec
+func SignalOutputs(decision string, payload map[string]any, timedOut bool) map[string]any {
+	outputs := make(map[string]any, len(payload)+2)
+	for k, v := range payload {
+		outputs[k] = v
+	}
+	outputs["decision"] = decision
+	outputs["timed_out"] = timedOut
+	return outputs
+}
+
+// ApplySignal завершает WAITING task полученным сигналом.
+// Конфигурация берётся из task.Payload (отрендеренный config шага).
+func ApplySignal(task *domain.Task, decision string, payload map[string]any) error {
+	cfg, err := ParseSignalConfig(task.Type, task.Payload)
+	if err != nil {
+		return err
+	}
+	if err := cfg.ValidateDecision(decision); err != nil {
+		return err
+	}
+	task.MarkSucceeded(SignalOutputs(decision, payload, false))
+	return nil
+}
+
+// ApplyWaitTimeout завершает WAITING task по истечении timeout_sec.
+//
+// Для approval / wait_for_signal — согласно on_timeout: fail — task падает,
+// default — task завершается успешно с default_decision и default_payload.
+// wait_for_callback по таймауту всегда падает.
+func ApplyWaitTimeout(task *domain.Task) error {
+	if task.Type == StepTypeWaitForCallback {
+		timeout, err := ParseCallbackTimeout(task.Payload)
+		if err != nil {
+			return err
+		}
+		outputs := map[string]any{"timed_out": true}
+		if callbackURL, ok := task.Outputs["callback_url"]; ok {
+			outputs["callback_url"] = callbackURL
+		}
+		task.Outputs = outputs
+		task.MarkFailed(fmt.Sprintf("no callback received within %s", timeout))
+		return nil
+	}
+
+	cfg, err := ParseSignalConfig(task.Type, task.Payload)
+	if err != nil {
+		return err
+	}
+	if cfg.OnTimeout == OnTimeoutDefault {
+		task.MarkSucceeded(SignalOutputs(cfg.DefaultDecision, cfg.DefaultPayload, true))
+		return nil
+	}
+	task.Outputs = map[string]any{"timed_out": true}
+	task.MarkFailed(fmt.Sprintf("no signal received within %s", cfg.Timeout))
+	return nil
+}
@@ -23,6 +23,11 @@ type Context struct {
 
 	// Env — переменные окружения.
 	Env map[string]string `json:"env"`
+
+	// Outputs — outputs текущего шага.
+	// Заполняется только при вычислении условий над результатом шага
+	// (until у poll): {{ .Outputs.body.status }}.
+	Outputs map[string]any `json:"outputs,omitempty"`
 }
 
 // StepContext — результат выполнения шага для использования в шаблонах.
@@ -115,6 +120,52 @@ var templateFuncs = template.FuncMap{
 		return result
 	},
 
+	// fromXML — парсит XML строку (см. DecodeXML)
+	"fromXML": func(s string) any {
+		result, err := DecodeXML([]byte(s))
+		if err != nil {
+			return nil
+		}
+		return result
+	},
+
+	// toXML — кодирует значение в XML; root — имя корневого элемента
+	// (без него значение — map с одним ключом), см. EncodeXML
+	"toXML": func(v any, root ...string) string {
+		b, err := EncodeXML(v, strings.Join(root, ""))
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	},
+
+	// fromCSV — парсит CSV строку в список строк-объектов с определением
+	// заголовка; delimiter по умолчанию определяется автоматически
+	"fromCSV": func(s string, delimiter ...string) any {
+		opts, err := csvFuncOptions(delimiter)
+		if err != nil {
+			return nil
+		}
+		result, err := DecodeCSV([]byte(s), opts)
+		if err != nil {
+			return nil
+		}
+		return result
+	},
+
+	// toCSV — кодирует список строк в CSV с заголовком
+	"toCSV": func(v any, delimiter ...string) string {
+		opts, err := csvFuncOptions(delimiter)
+		if err != nil {
+			return ""
+		}
+		b, err := EncodeCSV(v, opts)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	},
+
 	// join — объединяет слайс строк
 	"join": func(sep string, items []string) string {
 		return strings.Join(items, sep)
@@ -155,6 +206,13 @@ var templateFuncs = template.FuncMap{
 //	{{ .Steps.fetch.Outputs.data }}
 //	{{ if .Steps.validate.Outputs.is_valid }}...{{ end }}
 func Render(tmpl string, ctx *Context) (string, error) {
+	return RenderData(tmpl, ctx)
+}
+
+// RenderData рендерит строковый шаблон с произвольными данными.
+// Используется там, где данные — не контекст run (например, входящее
+// сообщение триггера: {{ .Body.order_id }}).
+func RenderData(tmpl string, data any) (string, error) {
 	// Проверяем, содержит ли строка шаблонные выражения
 	if !strings.Contains(tmpl, "{{") {
 		return tmpl, nil
@@ -166,7 +224,7 @@ func Render(tmpl string, ctx *Context) (string, error) {
 	}
 
 	var buf bytes.Buffer
-	if err := t.Execute(&buf, ctx); err != nil {
+	if err := t.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("%w: %v", ErrTemplateRender, err)
 	}
 
@@ -257,6 +315,12 @@ func RenderConfig(config map[string]any, ctx *Context) (map[string]any, error) {
 // RenderCondition рендерит и вычисляет условие.
 // Возвращает true, если условие выполняется.
 func RenderCondition(condition string, ctx *Context) (bool, error) {
+	return EvalCondition(condition, ctx)
+}
+
+// EvalCondition вычисляет условие над произвольными данными.
+// Пустое условие всегда выполняется.
+func EvalCondition(condition string, data any) (bool, error) {
 	if condition == "" {
 		return true, nil
 	}
@@ -264,7 +328,7 @@ func RenderCondition(condition string, ctx *Context) (bool, error) {
 	// Оборачиваем условие в if, чтобы получить bool
 	tmpl := fmt.Sprintf(`{{if %s}}true{{else}}false{{end}}`, condition)
 
-	result, err := Render(tmpl, ctx)
+	result, err := RenderData(tmpl, data)
 	if err != nil {
 		return false, err
 	}
@@ -272,6 +336,25 @@ func RenderCondition(condition string, ctx *Context) (bool, error) {
 	return result == "true", nil
 }
 
+// ValidateTemplate проверяет синтаксис шаблона без рендеринга.
+func ValidateTemplate(tmpl string) error {
+	if !strings.Contains(tmpl, "{{") {
+		return nil
+	}
+	if _, err := template.New("").Funcs(templateFuncs).Parse(tmpl); err != nil {
+		return fmt.Errorf("%w: %v", ErrTemplateParse, err)
+	}
+	return nil
+}
+
+// ValidateCondition проверяет синтаксис условия (без {{ }}).
+func ValidateCondition(condition string) error {
+	if condition == "" {
+		return nil
+	}
+	return ValidateTemplate(fmt.Sprintf(`{{if %s}}true{{else}}false{{end}}`, condition))
+}
+
 // MustRender рендерит шаблон и паникует при ошибке.
 // Используется только для тестов.
 func MustRender(tmpl string, ctx *Context) string {
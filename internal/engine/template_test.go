@@ -1,8 +1,12 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
+
+	"github.com/shaiso/Automata/internal/domain"
 )
 
 func TestNewContext(t *testing.T) {
@@ -130,6 +134,9 @@ func TestRender_TemplateFunctions(t *testing.T) {
 	ctx := NewContext(map[string]any{
 		"text": "Hello World",
 		"list": []string{"a", "b", "c"},
+		"xml":  `<order id="42"><item>a</item><item>b</item></order>`,
+		"csv":  "id;name\n1;Alice\n2;Bob\n",
+		"rows": []any{map[string]any{"id": 1.0, "name": "Alice"}},
 	})
 
 	tests := []struct {
@@ -172,6 +179,36 @@ func TestRender_TemplateFunctions(t *testing.T) {
 			template: `{{ json .Inputs.list }}`,
 			expected: `["a","b","c"]`,
 		},
+		{
+			name:     "fromXML",
+			template: `{{ $o := (fromXML .Inputs.xml).order }}{{ index $o "@id" }} {{ index $o.item 1 }}`,
+			expected: "42 b",
+		},
+		{
+			name:     "fromXML invalid",
+			template: `{{ fromXML "<order>" }}`,
+			expected: "<no value>",
+		},
+		{
+			name:     "toXML",
+			template: `{{ toXML (fromXML .Inputs.xml) }}`,
+			expected: `<order id="42"><item>a</item><item>b</item></order>`,
+		},
+		{
+			name:     "toXML with root",
+			template: `{{ toXML (index .Inputs.rows 0) "user" }}`,
+			expected: `<user><id>1</id><name>Alice</name></user>`,
+		},
+		{
+			name:     "fromCSV",
+			template: `{{ range fromCSV .Inputs.csv }}{{ .name }} {{ end }}`,
+			expected: "Alice Bob ",
+		},
+		{
+			name:     "toCSV",
+			template: `{{ toCSV .Inputs.rows ";" }}`,
+			expected: "id;name\n1;Alice\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -435,3 +472,220 @@ func TestMustRender(t *testing.T) {
 	}()
 	MustRender("{{ .Invalid", ctx)
 }
+
+func TestRenderData(t *testing.T) {
+	data := map[string]any{
+		"Body":    map[string]any{"id": 42, "status": "paid"},
+		"Headers": map[string]any{"x-tenant": "acme"},
+	}
+
+	result, err := RenderData(`{{ .Body.id }}/{{ index .Headers "x-tenant" }}`, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "42/acme" {
+		t.Errorf("expected '42/acme', got %q", result)
+	}
+
+	ok, err := EvalCondition(`eq .Body.status "paid"`, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Error("expected condition to be true")
+	}
+
+	ok, err = EvalCondition("", data)
+	if err != nil || !ok {
+		t.Errorf("empty condition: expected true, got %v (err: %v)", ok, err)
+	}
+}
+
+func TestValidateCondition(t *testing.T) {
+	if err := ValidateCondition(`eq .Body.status "paid"`); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateCondition(""); err != nil {
+		t.Errorf("unexpected error for empty condition: %v", err)
+	}
+	if err := ValidateCondition(`eq .Body.status "paid`); !errors.Is(err, ErrTemplateParse) {
+		t.Errorf("expected ErrTemplateParse, got %v", err)
+	}
+	if err := ValidateTemplate("{{ .Body.id "); !errors.Is(err, ErrTemplateParse) {
+		t.Errorf("expected ErrTemplateParse, got %v", err)
+	}
+}
+
+func TestRenderOutputs(t *testing.T) {
+	ctx := NewContext(map[string]any{"date": "2026-01-01"})
+	ctx.AddStepResult("fetch", map[string]any{
+		"body": map[string]any{
+			"items": []any{"a", "b", "c"},
+			"total": 3.0,
+		},
+	}, "SUCCEEDED")
+
+	outputs, err := RenderOutputs(map[string]string{
+		"items":   "{{ .Steps.fetch.Outputs.body.items }}",
+		"total":   "{{ .Steps.fetch.Outputs.body.total }}",
+		"count":   "{{- len .Steps.fetch.Outputs.body.items -}}",
+		"summary": "{{ len .Steps.fetch.Outputs.body.items }} items for {{ .Inputs.date }}",
+		"static":  "done",
+		"json":    "{{ .Steps.fetch.Outputs.body.items | json }}",
+	}, ctx)
+	if err != nil {
+		t.Fatalf("RenderOutputs() error = %v", err)
+	}
+
+	items, ok := outputs["items"].([]any)
+	if !ok || len(items) != 3 {
+		t.Errorf("items should keep list type, got %T %v", outputs["items"], outputs["items"])
+	}
+	if outputs["total"] != 3.0 {
+		t.Errorf("total = %v (%T), want 3.0", outputs["total"], outputs["total"])
+	}
+	if outputs["count"] != 3 {
+		t.Errorf("count = %v (%T), want int 3", outputs["count"], outputs["count"])
+	}
+	if outputs["summary"] != "3 items for 2026-01-01" {
+		t.Errorf("summary = %v", outputs["summary"])
+	}
+	if outputs["static"] != "done" {
+		t.Errorf("static = %v", outputs["static"])
+	}
+	if outputs["json"] != `["a","b","c"]` {
+		t.Errorf("json = %v", outputs["json"])
+	}
+}
+
+func TestRenderOutputs_Empty(t *testing.T) {
+	outputs, err := RenderOutputs(nil, NewContext(nil))
+	if err != nil || outputs != nil {
+		t.Errorf("RenderOutputs(nil) = %v, %v; want nil, nil", outputs, err)
+	}
+}
+
+func TestRenderOutputs_Error(t *testing.T) {
+	_, err := RenderOutputs(map[string]string{
+		"first": "{{ index .Inputs.list 5 }}",
+	}, NewContext(map[string]any{"list": []any{1}}))
+	if !errors.Is(err, ErrTemplateRender) {
+		t.Fatalf("expected ErrTemplateRender, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "output first") {
+		t.Errorf("error should name the output, got %v", err)
+	}
+}
+
+func TestValidate_Outputs(t *testing.T) {
+	spec := &domain.FlowSpec{
+		Steps:   []domain.StepDef{{ID: "s", Type: "delay"}},
+		Outputs: map[string]string{"bad": "{{ .Steps.s.Outputs "},
+	}
+	if err := Validate(spec); !errors.Is(err, ErrInvalidOutputs) {
+		t.Fatalf("expected ErrInvalidOutputs, got %v", err)
+	}
+
+	spec.Outputs = map[string]string{"ok": "{{ .Steps.s.Status }}"}
+	if err := Validate(spec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRenderTransform(t *testing.T) {
+	ctx := NewContext(map[string]any{"threshold": 10})
+	ctx.AddStepResult("fetch", map[string]any{
+		"orders": []any{
+			map[string]any{"customer": "a", "amount": 12},
+			map[string]any{"customer": "b", "amount": 4},
+			map[string]any{"customer": "a", "amount": 30},
+		},
+	}, "SUCCEEDED")
+
+	config := map[string]any{
+		"label": "orders of {{ .Inputs.threshold }}+",
+		"large": map[string]any{"jq": ".inputs.threshold as $threshold | [.steps.fetch.outputs.orders[] | select(.amount >= $threshold) | .amount]"},
+		"ids":   map[string]any{"jq": ".steps.fetch.outputs.orders[].customer"},
+		"mappings": map[string]any{
+			"count": map[string]any{"jq": ".steps.fetch.outputs.orders | length"},
+			"label": "{{ .Inputs.threshold }}",
+		},
+	}
+
+	// Orchestrator рендерит только шаблоны, jq остаётся для Worker
+	rendered, err := RenderTransform(config, ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rendered["label"] != "orders of 10+" {
+		t.Errorf("unexpected label: %v", rendered["label"])
+	}
+	if _, ok := JQExpr(rendered["large"]); !ok {
+		t.Errorf("jq expression should be kept, got %v", rendered["large"])
+	}
+	mappings, _ := rendered["mappings"].(map[string]any)
+	if _, ok := JQExpr(mappings["count"]); !ok || mappings["label"] != "10" {
+		t.Errorf("unexpected mappings: %v", rendered["mappings"])
+	}
+
+	input, err := JQInput(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rendered["missing"] = map[string]any{"jq": "empty"}
+	result, err := EvalTransform(context.Background(), rendered, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result["label"] != "orders of 10+" {
+		t.Errorf("unexpected label: %v", result["label"])
+	}
+	large, _ := result["large"].([]any)
+	if len(large) != 2 || large[0] != float64(12) || large[1] != float64(30) {
+		t.Errorf("unexpected large: %v", result["large"])
+	}
+	// Несколько результатов собираются в массив
+	if ids, _ := result["ids"].([]any); len(ids) != 3 {
+		t.Errorf("unexpected ids: %v", result["ids"])
+	}
+	if result["missing"] != nil {
+		t.Errorf("expected nil for empty result, got %v", result["missing"])
+	}
+	// jq внутри mappings вычисляется так же, как на верхнем уровне
+	mappings, _ = result["mappings"].(map[string]any)
+	if mappings["count"] != 3 || mappings["label"] != "10" {
+		t.Errorf("unexpected mappings: %v", result["mappings"])
+	}
+
+	// Неопределённая переменная — ошибка компиляции
+	_, err = EvalTransform(context.Background(), map[string]any{
+		"large": map[string]any{"jq": "[.steps.fetch.outputs.orders[] | select(.amount >= $threshold)]"},
+	}, input)
+	if !errors.Is(err, ErrInvalidJQ) {
+		t.Errorf("expected ErrInvalidJQ, got %v", err)
+	}
+
+	_, err = EvalTransform(context.Background(), map[string]any{
+		"mappings": map[string]any{"bad": map[string]any{"jq": ".x |"}},
+	}, input)
+	if !errors.Is(err, ErrInvalidJQ) || !strings.Contains(err.Error(), "mappings.bad") {
+		t.Errorf("expected ErrInvalidJQ for mappings.bad, got %v", err)
+	}
+}
+
+func TestEvalJQ_NoProcessEnv(t *testing.T) {
+	t.Setenv("AUTOMATA_TEST_SECRET", "secret")
+
+	value, err := EvalJQ(context.Background(), "$ENV.AUTOMATA_TEST_SECRET", NewContext(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != nil {
+		t.Errorf("process environment must not be visible, got %v", value)
+	}
+
+	if _, err := EvalJQ(context.Background(), "error(\"boom\")", NewContext(nil)); !errors.Is(err, ErrJQEval) {
+		t.Errorf("expected ErrJQEval, got %v", err)
+	}
+}
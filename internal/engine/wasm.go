@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// StepTypeWasm — выполнение загруженного WebAssembly-модуля (WASI).
+const StepTypeWasm = "wasm"
+
+// Лимиты wasm шага: значения по умолчанию и максимальные значения,
+// которые можно задать в config.
+const (
+	DefaultWasmTimeout   = 10 * time.Second
+	MaxWasmTimeout       = 5 * time.Minute
+	DefaultWasmMaxMemory = 64 << 20
+	MaxWasmMaxMemory     = 1 << 30
+)
+
+// wasmDigestRe — формат ссылки на модуль: sha256:<64 hex>.
+var wasmDigestRe = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// wasmConfigKeys — ключи config, которые читает сам шаг; остальные
+// передаются модулю.
+var wasmConfigKeys = map[string]bool{
+	"module":        true,
+	"timeout_sec":   true,
+	"max_memory_mb": true,
+	StepGlobalsKey:  true,
+}
+
+// WasmConfig — настройки wasm шага.
+//
+//	{
+//	  "module": "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+//	  "timeout_sec": 10,
+//	  "max_memory_mb": 64,
+//	  "threshold": "{{ .Inputs.threshold }}"
+//	}
+//
+// Ключи, кроме module и лимитов, — конфигурация модуля: она передаётся
+// ему на stdin вместе с контекстом run.
+type WasmConfig struct {
+	// Module — дайджест загруженного модуля (sha256:<hex>).
+	Module string
+
+	// Timeout — ограничение времени выполнения.
+	Timeout time.Duration
+
+	// MaxMemory — ограничение линейной памяти модуля, в байтах.
+	MaxMemory uint64
+
+	// Input — конфигурация для модуля.
+	Input map[string]any
+}
+
+// WasmDigest возвращает дайджест содержимого модуля (sha256:<hex>),
+// по которому модуль хранится и указывается в spec.
+func WasmDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// IsWasmDigest проверяет формат дайджеста модуля.
+func IsWasmDigest(s string) bool {
+	return wasmDigestRe.MatchString(s)
+}
+
+// ParseWasmConfig извлекает настройки wasm шага.
+func ParseWasmConfig(config map[string]any) (*WasmConfig, error) {
+	module, _ := config["module"].(string)
+	if module == "" {
+		return nil, fmt.Errorf("%w: module is required", ErrInvalidWasmConfig)
+	}
+	// Модуль указывается только дайджестом: версия шага не меняется
+	// при загрузке новой сборки под тем же именем
+	if !IsWasmDigest(module) {
+		return nil, fmt.Errorf("%w: module must be a digest sha256:<hex>, got %q", ErrInvalidWasmConfig, module)
+	}
+
+	cfg := &WasmConfig{
+		Module:    module,
+		Timeout:   DefaultWasmTimeout,
+		MaxMemory: DefaultWasmMaxMemory,
+		Input:     make(map[string]any),
+	}
+
+	if v, ok := config["timeout_sec"]; ok {
+		n, ok := configInt(v)
+		if !ok || n <= 0 || time.Duration(n)*time.Second > MaxWasmTimeout {
+			return nil, fmt.Errorf("%w: timeout_sec must be between 1 and %d",
+				ErrInvalidWasmConfig, int(MaxWasmTimeout/time.Second))
+		}
+		cfg.Timeout = time.Duration(n) * time.Second
+	}
+	if v, ok := config["max_memory_mb"]; ok {
+		n, ok := configInt(v)
+		if !ok || n <= 0 || n > MaxWasmMaxMemory>>20 {
+			return nil, fmt.Errorf("%w: max_memory_mb must be between 1 and %d", ErrInvalidWasmConfig, MaxWasmMaxMemory>>20)
+		}
+		cfg.MaxMemory = uint64(n) << 20
+	}
+
+	for key, value := range config {
+		if !wasmConfigKeys[key] {
+			cfg.Input[key] = value
+		}
+	}
+
+	return cfg, nil
+}
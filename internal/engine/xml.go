@@ -0,0 +1,234 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Ключи служебных полей XML в представлении map.
+const (
+	// XMLAttrPrefix — префикс ключей атрибутов: {"@id": "42"}.
+	XMLAttrPrefix = "@"
+
+	// XMLTextKey — текст элемента, у которого есть атрибуты или дочерние элементы.
+	XMLTextKey = "#text"
+)
+
+// xmlNameRe — допустимое имя элемента или атрибута при кодировании.
+var xmlNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._:-]*$`)
+
+// DecodeXML разбирает XML-документ в JSON-подобное значение:
+//
+//	<order id="42"><item>a</item><item>b</item><note>x</note></order>
+//
+// становится
+//
+//	{"order": {"@id": "42", "item": ["a", "b"], "note": "x"}}
+//
+// Правила:
+//   - элемент без атрибутов и дочерних элементов — строка с его текстом;
+//   - иначе — map: атрибуты с префиксом "@", дочерние элементы по имени
+//     (повторяющиеся — список), непустой текст — под ключом "#text";
+//   - используются локальные имена: префиксы пространств имён и
+//     объявления xmlns отбрасываются (soap:Envelope → Envelope);
+//   - значения не приводятся к числам — все листья строки.
+func DecodeXML(data []byte) (map[string]any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	// Legacy-сервисы часто объявляют windows-1251 и т.п.: читаем как есть
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: no root element", ErrInvalidXML)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidXML, err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			value, err := decodeXMLElement(dec, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// decodeXMLElement читает элемент start до закрывающего тега.
+func decodeXMLElement(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	fields := make(map[string]any)
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		fields[XMLAttrPrefix+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	hasChildren := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidXML, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			hasChildren = true
+			addXMLChild(fields, t.Name.Local, child)
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(fields) == 0 && !hasChildren {
+				return content, nil
+			}
+			if content != "" {
+				fields[XMLTextKey] = content
+			}
+			return fields, nil
+		}
+	}
+}
+
+// addXMLChild добавляет дочерний элемент; повторяющиеся собираются в список.
+func addXMLChild(fields map[string]any, name string, value any) {
+	existing, ok := fields[name]
+	if !ok {
+		fields[name] = value
+		return
+	}
+	if list, ok := existing.([]any); ok {
+		fields[name] = append(list, value)
+		return
+	}
+	fields[name] = []any{existing, value}
+}
+
+// EncodeXML кодирует значение в XML — обратное преобразование к DecodeXML.
+//
+// root — имя корневого элемента. Если root пуст, v должен быть map
+// с одним ключом — он и становится корнем. Ключи map с префиксом "@" —
+// атрибуты, "#text" — текст, остальные — дочерние элементы (в порядке
+// сортировки ключей), список — повторяющиеся элементы. Числа, bool
+// и строки становятся текстом элемента, nil — пустым элементом.
+func EncodeXML(v any, root string) ([]byte, error) {
+	if root == "" {
+		m, ok := v.(map[string]any)
+		if !ok || len(m) != 1 {
+			return nil, fmt.Errorf("%w: root element name is required unless value is a map with a single key", ErrInvalidXML)
+		}
+		for k, inner := range m {
+			root, v = k, inner
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	if err := encodeXMLElement(enc, root, v); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXML, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeXMLElement пишет элемент name со значением v.
+func encodeXMLElement(enc *xml.Encoder, name string, v any) error {
+	if !xmlNameRe.MatchString(name) {
+		return fmt.Errorf("%w: invalid element name %q", ErrInvalidXML, name)
+	}
+
+	// Список — повторяющиеся элементы с одним именем
+	if list, ok := v.([]any); ok {
+		for _, item := range list {
+			if err := encodeXMLElement(enc, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	var text string
+	var children []string
+	fields, isMap := v.(map[string]any)
+
+	if isMap {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			switch {
+			case k == XMLTextKey:
+				s, err := xmlText(fields[k])
+				if err != nil {
+					return err
+				}
+				text = s
+			case strings.HasPrefix(k, XMLAttrPrefix):
+				attr := strings.TrimPrefix(k, XMLAttrPrefix)
+				if !xmlNameRe.MatchString(attr) {
+					return fmt.Errorf("%w: invalid attribute name %q", ErrInvalidXML, attr)
+				}
+				s, err := xmlText(fields[k])
+				if err != nil {
+					return err
+				}
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attr}, Value: s})
+			default:
+				children = append(children, k)
+			}
+		}
+	} else {
+		s, err := xmlText(v)
+		if err != nil {
+			return err
+		}
+		text = s
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidXML, err)
+	}
+	if text != "" {
+		if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidXML, err)
+		}
+	}
+	for _, k := range children {
+		if err := encodeXMLElement(enc, k, fields[k]); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(start.End()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidXML, err)
+	}
+	return nil
+}
+
+// xmlText форматирует скалярное значение как текст XML.
+func xmlText(v any) (string, error) {
+	s, err := formatScalar(v)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidXML, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,23 @@
+// Package grpcschema загружает схемы gRPC-сервисов для шага grpc.
+//
+// Шаг grpc вызывает unary-метод без сгенерированного кода: сообщения
+// запроса и ответа строятся динамически (dynamicpb) по дескрипторам.
+// Дескрипторы берутся из одного из источников:
+//
+//   - Загруженный descriptor set — FileDescriptorSet, собранный
+//     protoc --descriptor_set_out=api.pb --include_imports и сохранённый
+//     через API (/api/v1/descriptor-sets). Parse разбирает его и
+//     проверяет, что все зависимости разрешаются.
+//   - Server reflection — FromReflection запрашивает у сервера файл,
+//     содержащий сервис, и его зависимости (grpc.reflection.v1, с
+//     fallback на v1alpha для старых серверов).
+//
+// Зависимости, отсутствующие в наборе, ищутся среди well-known types
+// (google/protobuf/*.proto), вкомпилированных в бинарь.
+//
+// Использование:
+//
+//	schema, err := grpcschema.Parse(data)
+//	method, err := schema.Method("billing.v1.Invoices", "Create")
+//	req := dynamicpb.NewMessage(method.Input())
+package grpcschema
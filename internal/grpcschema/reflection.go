@@ -0,0 +1,161 @@
+package grpcschema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ErrReflection — сервер не отдал схему через reflection.
+var ErrReflection = errors.New("grpc reflection failed")
+
+// fileFetcher запрашивает у сервера файлы: по символу (symbol != "")
+// или по имени файла. Возвращает сериализованные FileDescriptorProto.
+type fileFetcher func(symbol, filename string) ([][]byte, error)
+
+// FromReflection загружает схему сервиса через server reflection:
+// файл, содержащий service, и все его зависимости. Серверы без
+// grpc.reflection.v1 опрашиваются через v1alpha.
+func FromReflection(ctx context.Context, conn grpc.ClientConnInterface, service string) (*Schema, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	schema, err := fromReflectionV1(ctx, conn, service)
+	if status.Code(err) == codes.Unimplemented {
+		schema, err = fromReflectionV1Alpha(ctx, conn, service)
+	}
+	return schema, err
+}
+
+func fromReflectionV1(ctx context.Context, conn grpc.ClientConnInterface, service string) (*Schema, error) {
+	stream, err := reflectionv1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReflection, err)
+	}
+	defer stream.CloseSend() //nolint:errcheck // поток закрывается вместе с ctx
+
+	return loadSchema(service, func(symbol, filename string) ([][]byte, error) {
+		req := &reflectionv1.ServerReflectionRequest{}
+		if symbol != "" {
+			req.MessageRequest = &reflectionv1.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol}
+		} else {
+			req.MessageRequest = &reflectionv1.ServerReflectionRequest_FileByFilename{FileByFilename: filename}
+		}
+		if err := stream.Send(req); err != nil {
+			return nil, recvError(stream.RecvMsg(&reflectionv1.ServerReflectionResponse{}), err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+		}
+		return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+	})
+}
+
+func fromReflectionV1Alpha(ctx context.Context, conn grpc.ClientConnInterface, service string) (*Schema, error) {
+	stream, err := reflectionv1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReflection, err)
+	}
+	defer stream.CloseSend() //nolint:errcheck // поток закрывается вместе с ctx
+
+	return loadSchema(service, func(symbol, filename string) ([][]byte, error) {
+		req := &reflectionv1alpha.ServerReflectionRequest{}
+		if symbol != "" {
+			req.MessageRequest = &reflectionv1alpha.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol}
+		} else {
+			req.MessageRequest = &reflectionv1alpha.ServerReflectionRequest_FileByFilename{FileByFilename: filename}
+		}
+		if err := stream.Send(req); err != nil {
+			return nil, recvError(stream.RecvMsg(&reflectionv1alpha.ServerReflectionResponse{}), err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+		}
+		return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+	})
+}
+
+// recvError возвращает настоящую причину ошибки Send: при io.EOF статус
+// потока (например, Unimplemented) доступен только через Recv.
+func recvError(recvErr, sendErr error) error {
+	if errors.Is(sendErr, io.EOF) && recvErr != nil {
+		return recvErr
+	}
+	return sendErr
+}
+
+// loadSchema запрашивает файл с сервисом и недостающие зависимости.
+func loadSchema(service string, fetch fileFetcher) (*Schema, error) {
+	raw, err := fetch(service, "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrReflection, service, err)
+	}
+
+	var fdps []*descriptorpb.FileDescriptorProto
+	loaded := make(map[string]bool)
+	requested := make(map[string]bool)
+
+	// Сервер может не прислать транзитивные зависимости — догружаем по имени
+	for pending := raw; len(pending) > 0; {
+		var batch []*descriptorpb.FileDescriptorProto
+		for _, b := range pending {
+			fdp := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(b, fdp); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrReflection, err)
+			}
+			if !loaded[fdp.GetName()] {
+				loaded[fdp.GetName()] = true
+				batch = append(batch, fdp)
+			}
+		}
+		fdps = append(fdps, batch...)
+
+		pending = nil
+		for _, fdp := range batch {
+			for _, dep := range fdp.GetDependency() {
+				if loaded[dep] || requested[dep] || isWellKnown(dep) {
+					continue
+				}
+				requested[dep] = true
+				more, err := fetch("", dep)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s: %w", ErrReflection, dep, err)
+				}
+				pending = append(pending, more...)
+			}
+		}
+	}
+	if len(fdps) == 0 {
+		return nil, fmt.Errorf("%w: %s: empty response", ErrReflection, service)
+	}
+
+	files, err := buildFiles(fdps)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrReflection, err)
+	}
+	return &Schema{files: files}, nil
+}
+
+// isWellKnown проверяет, что файл — well-known type, вкомпилированный
+// в бинарь (его не нужно запрашивать у сервера).
+func isWellKnown(filename string) bool {
+	return strings.HasPrefix(filename, "google/protobuf/")
+}
@@ -0,0 +1,157 @@
+package grpcschema
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// Well-known types регистрируются в protoregistry.GlobalFiles и
+	// используются для зависимостей, отсутствующих в наборе.
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/apipb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/sourcecontextpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/typepb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var (
+	// ErrInvalidDescriptorSet — данные не являются корректным FileDescriptorSet.
+	ErrInvalidDescriptorSet = errors.New("invalid descriptor set")
+
+	// ErrServiceNotFound — сервис отсутствует в схеме.
+	ErrServiceNotFound = errors.New("grpc service not found")
+
+	// ErrMethodNotFound — метод отсутствует в сервисе.
+	ErrMethodNotFound = errors.New("grpc method not found")
+
+	// ErrStreamingMethod — метод streaming, шаг grpc поддерживает только unary.
+	ErrStreamingMethod = errors.New("grpc streaming methods are not supported")
+)
+
+// Schema — набор proto-файлов с сервисами и сообщениями.
+type Schema struct {
+	files *protoregistry.Files
+}
+
+// Parse разбирает сериализованный FileDescriptorSet.
+func Parse(data []byte) (*Schema, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDescriptorSet, err)
+	}
+	if len(set.File) == 0 {
+		return nil, fmt.Errorf("%w: no files", ErrInvalidDescriptorSet)
+	}
+
+	files, err := buildFiles(set.File)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDescriptorSet, err)
+	}
+	return &Schema{files: files}, nil
+}
+
+// Services возвращает отсортированные полные имена сервисов схемы.
+func (s *Schema) Services() []string {
+	var services []string
+	s.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			services = append(services, string(fd.Services().Get(i).FullName()))
+		}
+		return true
+	})
+	sort.Strings(services)
+	return services
+}
+
+// Method возвращает дескриптор unary-метода service/method.
+// service — полное имя с пакетом (например, "billing.v1.Invoices").
+func (s *Schema) Method(service, method string) (protoreflect.MethodDescriptor, error) {
+	desc, err := s.files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrServiceNotFound, service)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a service", ErrServiceNotFound, service)
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("%w: %s/%s", ErrMethodNotFound, service, method)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("%w: %s/%s", ErrStreamingMethod, service, method)
+	}
+	return md, nil
+}
+
+// Types возвращает resolver типов схемы (для google.protobuf.Any в JSON).
+func (s *Schema) Types() *dynamicpb.Types {
+	return dynamicpb.NewTypes(s.files)
+}
+
+// buildFiles регистрирует файлы в порядке зависимостей. Зависимости,
+// которых нет в наборе, берутся из protoregistry.GlobalFiles
+// (well-known types).
+func buildFiles(fdps []*descriptorpb.FileDescriptorProto) (*protoregistry.Files, error) {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(fdps))
+	for _, fdp := range fdps {
+		byName[fdp.GetName()] = fdp
+	}
+
+	files := new(protoregistry.Files)
+	visiting := make(map[string]bool)
+
+	var add func(name string) error
+	add = func(name string) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("import cycle at %s", name)
+		}
+
+		fdp, ok := byName[name]
+		if !ok {
+			fd, err := protoregistry.GlobalFiles.FindFileByPath(name)
+			if err != nil {
+				return fmt.Errorf("missing dependency %s", name)
+			}
+			return files.RegisterFile(fd)
+		}
+
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		for _, dep := range fdp.GetDependency() {
+			if err := add(dep); err != nil {
+				return err
+			}
+		}
+
+		fd, err := protodesc.NewFile(fdp, files)
+		if err != nil {
+			return fmt.Errorf("file %s: %v", name, err)
+		}
+		return files.RegisterFile(fd)
+	}
+
+	for _, fdp := range fdps {
+		if err := add(fdp.GetName()); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,115 @@
+package grpcschema
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// healthDescriptorSet — FileDescriptorSet с grpc/health/v1/health.proto
+// (как после protoc --descriptor_set_out).
+func healthDescriptorSet(t *testing.T) []byte {
+	t.Helper()
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto),
+		},
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal descriptor set: %v", err)
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	schema, err := Parse(healthDescriptorSet(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := schema.Services(); !reflect.DeepEqual(got, []string{"grpc.health.v1.Health"}) {
+		t.Errorf("unexpected services: %v", got)
+	}
+
+	md, err := schema.Method("grpc.health.v1.Health", "Check")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if md.Input().FullName() != "grpc.health.v1.HealthCheckRequest" {
+		t.Errorf("unexpected input type: %s", md.Input().FullName())
+	}
+
+	tests := []struct {
+		service, method string
+		want            error
+	}{
+		{"grpc.health.v1.Health", "Watch", ErrStreamingMethod},
+		{"grpc.health.v1.Health", "Missing", ErrMethodNotFound},
+		{"grpc.health.v1.Missing", "Check", ErrServiceNotFound},
+		{"grpc.health.v1.HealthCheckRequest", "Check", ErrServiceNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := schema.Method(tt.service, tt.method); !errors.Is(err, tt.want) {
+			t.Errorf("%s/%s: expected %v, got %v", tt.service, tt.method, tt.want, err)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse([]byte("not a descriptor set")); !errors.Is(err, ErrInvalidDescriptorSet) {
+		t.Errorf("expected ErrInvalidDescriptorSet, got %v", err)
+	}
+
+	// Зависимость не из well-known types и не в наборе
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("billing.proto"),
+		Package:    proto.String("billing"),
+		Dependency: []string{"common/money.proto"},
+	}
+	data, _ := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}})
+	if _, err := Parse(data); !errors.Is(err, ErrInvalidDescriptorSet) {
+		t.Errorf("expected ErrInvalidDescriptorSet for missing dependency, got %v", err)
+	}
+}
+
+func TestFromReflection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	schema, err := FromReflection(context.Background(), conn, "grpc.health.v1.Health")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := schema.Method("grpc.health.v1.Health", "Check"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := FromReflection(context.Background(), conn, "billing.v1.Missing"); !errors.Is(err, ErrReflection) {
+		t.Errorf("expected ErrReflection for unknown service, got %v", err)
+	}
+}
@@ -40,6 +40,7 @@ type Consumer struct {
 	queue    string
 	handler  Handler
 	prefetch int
+	raw      bool
 
 	cancelFunc context.CancelFunc
 }
@@ -54,6 +55,11 @@ type ConsumerConfig struct {
 
 	// Prefetch — количество сообщений для предварительной загрузки.
 	Prefetch int
+
+	// Raw — не разбирать тело как Message.
+	// Для сообщений внешних систем (триггеры): тело и заголовки
+	// доступны в Delivery.Raw, в Delivery.Message заполнены только ID и Type.
+	Raw bool
 }
 
 // NewConsumer создаёт новый Consumer.
@@ -69,6 +75,7 @@ func NewConsumer(conn *Connection, logger *slog.Logger, cfg ConsumerConfig) *Con
 		queue:    cfg.Queue,
 		handler:  cfg.Handler,
 		prefetch: prefetch,
+		raw:      cfg.Raw,
 	}
 }
 
@@ -91,7 +98,7 @@ func (c *Consumer) consume(ctx context.Context) error {
 		}
 
 		// Получаем канал доставки
-		deliveries, err := c.setupConsume()
+		deliveries, ch, err := c.setupConsume()
 		if err != nil {
 			c.logger.Error("failed to setup consume", "queue", c.queue, "error", err)
 			// Ждём переподключения
@@ -106,8 +113,11 @@ func (c *Consumer) consume(ctx context.Context) error {
 
 		c.logger.Info("consumer started", "queue", c.queue)
 
-		// Обрабатываем сообщения
-		if err := c.processDeliveries(ctx, deliveries); err != nil {
+		// Обрабатываем сообщения. Канал закрываем, чтобы неподтверждённые
+		// сообщения вернулись в очередь и consumer снялся с неё.
+		err = c.processDeliveries(ctx, deliveries)
+		ch.Close()
+		if err != nil {
 			if ctx.Err() != nil {
 				return ctx.Err()
 			}
@@ -124,16 +134,16 @@ func (c *Consumer) consume(ctx context.Context) error {
 }
 
 // setupConsume настраивает отдельный канал и начинает потребление.
-func (c *Consumer) setupConsume() (<-chan amqp.Delivery, error) {
+func (c *Consumer) setupConsume() (<-chan amqp.Delivery, *amqp.Channel, error) {
 	ch, err := c.conn.NewChannel()
 	if err != nil {
-		return nil, fmt.Errorf("new channel: %w", err)
+		return nil, nil, fmt.Errorf("new channel: %w", err)
 	}
 
 	// Устанавливаем prefetch
 	if err := ch.Qos(c.prefetch, 0, false); err != nil {
 		ch.Close()
-		return nil, fmt.Errorf("set qos: %w", err)
+		return nil, nil, fmt.Errorf("set qos: %w", err)
 	}
 
 	// Начинаем потребление
@@ -148,10 +158,10 @@ func (c *Consumer) setupConsume() (<-chan amqp.Delivery, error) {
 	)
 	if err != nil {
 		ch.Close()
-		return nil, fmt.Errorf("consume: %w", err)
+		return nil, nil, fmt.Errorf("consume: %w", err)
 	}
 
-	return deliveries, nil
+	return deliveries, ch, nil
 }
 
 // processDeliveries обрабатывает сообщения из канала.
@@ -175,7 +185,10 @@ func (c *Consumer) processDeliveries(ctx context.Context, deliveries <-chan amqp
 func (c *Consumer) handleDelivery(ctx context.Context, raw amqp.Delivery) {
 	// Парсим сообщение
 	var msg Message
-	if err := json.Unmarshal(raw.Body, &msg); err != nil {
+	if c.raw {
+		msg.ID = raw.MessageId
+		msg.Type = MessageType(raw.Type)
+	} else if err := json.Unmarshal(raw.Body, &msg); err != nil {
 		c.logger.Error("failed to unmarshal message",
 			"queue", c.queue,
 			"error", err,
@@ -10,9 +10,11 @@
 //   - run.pending      — новый run ожидает выполнения
 //   - task.ready       — задача готова к выполнению
 //   - task.completed   — задача завершена
+//   - run.completed    — run завершён (routing key run.completed.<flow_id>.<status>)
 //
 // Exchanges:
 //   - automata.runs    — события runs
 //   - automata.tasks   — события tasks
 //   - automata.dlq     — dead letter queue
+//   - automata.events  — события завершения runs для подписчиков (topic)
 package mq
@@ -19,6 +19,7 @@ const (
 	MessageTypeRunPending    MessageType = "run.pending"
 	MessageTypeTaskReady     MessageType = "task.ready"
 	MessageTypeTaskCompleted MessageType = "task.completed"
+	MessageTypeRunCompleted  MessageType = "run.completed"
 )
 
 // Publisher публикует сообщения в RabbitMQ.
@@ -71,6 +72,21 @@ type TaskCompletedPayload struct {
 	Attempt int       `json:"attempt"`
 }
 
+// RunCompletedPayload — payload события о завершённом run.
+type RunCompletedPayload struct {
+	RunID      uuid.UUID                 `json:"run_id"`
+	FlowID     uuid.UUID                 `json:"flow_id"`
+	Version    int                       `json:"version"`
+	Status     string                    `json:"status"` // терминальный статус run
+	Error      string                    `json:"error,omitempty"`
+	Inputs     map[string]any            `json:"inputs,omitempty"`
+	Outputs    map[string]any            `json:"outputs,omitempty"` // outputs flow (SUCCEEDED)
+	Steps      map[string]map[string]any `json:"steps,omitempty"`   // outputs успешных шагов
+	IsSandbox  bool                      `json:"is_sandbox,omitempty"`
+	StartedAt  *time.Time                `json:"started_at,omitempty"`
+	FinishedAt *time.Time                `json:"finished_at,omitempty"`
+}
+
 // Publish публикует сообщение в указанный exchange с routing key.
 func (p *Publisher) Publish(ctx context.Context, exchange Exchange, routingKey RoutingKey, msg *Message) error {
 	body, err := json.Marshal(msg)
@@ -147,6 +163,20 @@ func (p *Publisher) PublishTaskCompleted(ctx context.Context, payload TaskComple
 	return p.Publish(ctx, ExchangeTasks, RoutingKeyCompleted, msg)
 }
 
+// PublishRunCompleted публикует событие о завершении run в automata.events.
+// ID сообщения — ID run: подписчики могут дедуплицировать повторные публикации.
+// Потребители: flow триггеры, внешние системы.
+func (p *Publisher) PublishRunCompleted(ctx context.Context, payload RunCompletedPayload) error {
+	msg := &Message{
+		ID:        payload.RunID.String(),
+		Type:      MessageTypeRunCompleted,
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}
+
+	return p.Publish(ctx, ExchangeEvents, RunCompletedKey(payload.FlowID.String(), payload.Status), msg)
+}
+
 // PublishJSON публикует произвольный JSON payload.
 func (p *Publisher) PublishJSON(ctx context.Context, exchange Exchange, routingKey RoutingKey, msgType MessageType, payload any) error {
 	msg := &Message{
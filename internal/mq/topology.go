@@ -21,6 +21,10 @@ const (
 	ExchangeRuns  Exchange = "automata.runs"
 	ExchangeTasks Exchange = "automata.tasks"
 	ExchangeDLQ   Exchange = "automata.dlq"
+
+	// ExchangeEvents — события жизненного цикла runs для внешних подписчиков
+	// и flow триггеров (topic).
+	ExchangeEvents Exchange = "automata.events"
 )
 
 // Queues — имена очередей.
@@ -39,6 +43,15 @@ const (
 	RoutingKeyDLQTasks  RoutingKey = "tasks"
 )
 
+// RunCompletedKey возвращает routing key события завершения run:
+// run.completed.<flow_id>.<status>.
+//
+// Подписка на все завершения — "run.completed.#", на завершения flow —
+// "run.completed.<flow_id>.*", на ошибки — "run.completed.*.FAILED".
+func RunCompletedKey(flowID, status string) RoutingKey {
+	return RoutingKey("run.completed." + flowID + "." + status)
+}
+
 func SetupTopology(ctx context.Context, conn *Connection) error {
 	return conn.WithChannel(ctx, func(ch *amqp.Channel) error {
 		// 1. Создаём exchanges
@@ -69,6 +82,7 @@ func declareExchanges(ch *amqp.Channel) error {
 		{ExchangeRuns, "direct"},
 		{ExchangeTasks, "direct"},
 		{ExchangeDLQ, "direct"},
+		{ExchangeEvents, "topic"},
 	}
 
 	for _, ex := range exchanges {
@@ -160,6 +174,63 @@ func bindQueues(ch *amqp.Channel) error {
 	return nil
 }
 
+// QueueTriggerPrefix — префикс очередей AMQP триггеров (triggers.<trigger_id>).
+const QueueTriggerPrefix = "triggers."
+
+// triggerQueueExpires — очередь триггера без consumer'ов удаляется через 7 дней
+// (например, если триггер удалён, пока сервис триггеров не работал).
+const triggerQueueExpires = 7 * 24 * 60 * 60 * 1000
+
+// TriggerQueue возвращает имя очереди AMQP триггера.
+func TriggerQueue(triggerID string) Queue {
+	return Queue(QueueTriggerPrefix + triggerID)
+}
+
+// DeclareTriggerQueue создаёт очередь триггера и привязывает её к внешнему exchange.
+//
+// Exchange принадлежит внешней системе и не создаётся: если его нет,
+// возвращается ошибка. Используется отдельный канал, так как ошибка
+// привязки закрывает канал.
+func DeclareTriggerQueue(conn *Connection, queue Queue, exchange Exchange, bindingKey RoutingKey) error {
+	ch, err := conn.NewChannel()
+	if err != nil {
+		return fmt.Errorf("new channel: %w", err)
+	}
+	defer ch.Close()
+
+	_, err = ch.QueueDeclare(
+		string(queue), // name
+		true,          // durable
+		false,         // delete when unused
+		false,         // exclusive
+		false,         // no-wait
+		amqp.Table{"x-expires": int32(triggerQueueExpires)},
+	)
+	if err != nil {
+		return fmt.Errorf("declare queue %s: %w", queue, err)
+	}
+
+	if err := ch.QueueBind(string(queue), string(bindingKey), string(exchange), false, nil); err != nil {
+		return fmt.Errorf("bind queue %s to %s: %w", queue, exchange, err)
+	}
+
+	return nil
+}
+
+// DeleteQueue удаляет очередь вместе с сообщениями.
+func DeleteQueue(conn *Connection, queue Queue) error {
+	ch, err := conn.NewChannel()
+	if err != nil {
+		return fmt.Errorf("new channel: %w", err)
+	}
+	defer ch.Close()
+
+	if _, err := ch.QueueDelete(string(queue), false, false, false); err != nil {
+		return fmt.Errorf("delete queue %s: %w", queue, err)
+	}
+	return nil
+}
+
 // TopologyInfo возвращает описание топологии для логирования.
 func TopologyInfo() string {
 	return `                                                                                    
@@ -179,5 +250,13 @@ func TopologyInfo() string {
     automata.dlq (direct)                                                                           
     └── dlq.tasks [routing: tasks]                                                                  
             Manual processing                                                                       
+                                                                                                    
+    automata.events (topic)                                                                         
+    └── run.completed.<flow_id>.<status>                                                            
+            Consumers: flow triggers, external subscribers                                          
+                                                                                                    
+    <external exchange>                                                                             
+    └── triggers.<trigger_id> [routing: binding_key]                                                
+            Consumer: Trigger service                                                               
   `
 }
@@ -0,0 +1,48 @@
+// Package notify реализует уведомления о завершении runs.
+//
+// Правило уведомления (domain.NotificationRule) привязано к flow и задаёт
+// событие (failure, success, consecutive_failures, sla_breach), канал доставки
+// (webhook, email) и шаблоны сообщения.
+//
+// Поток:
+//
+//  1. Orchestrator при завершении run вызывает Notifier.Notify
+//  2. Notify выбирает включённые правила flow, для которых выполнено событие,
+//     рендерит сообщение и создаёт PENDING запись notification_deliveries
+//     (одна на run и правило — повторный вызов дублей не создаёт)
+//  3. Notifier.Run в фоне забирает записи (ClaimDue) и отправляет их через
+//     Sender канала; неудачная попытка повторяется с exponential backoff,
+//     после MaxAttempts запись переходит в FAILED
+//
+// Правила sla_breach срабатывают для ещё выполняющегося run: Orchestrator
+// вызывает Notifier.NotifySLABreach, когда run превысил FlowSpec.SLASec.
+//
+// Каналы:
+//   - webhook — POST JSON {"text": <message>, ...}, совместимый со Slack
+//     incoming webhooks; ответ не 2xx считается ошибкой
+//   - email — текстовое письмо через SMTP сервер (SMTPConfig); без
+//     SMTP_ADDR канал выключен, попытки завершаются ErrChannelNotConfigured;
+//     SMTP-сессия ограничена ctx и 30 секундами
+//
+// Данные шаблонов — Data:
+//
+//	{{ .Flow.Name }}, {{ .Run.ID }}, {{ .Run.Status }}, {{ .Run.Error }},
+//	{{ .Run.Inputs.date }}, {{ .Run.SLAAt }}, {{ .ConsecutiveFailures }}, {{ .Rule }}
+//
+// Структура:
+//   - rule.go     — валидация правил, Matches, FailureStreak, Render
+//   - notifier.go — Notifier: создание и доставка уведомлений
+//   - sender.go   — WebhookSender, EmailSender
+//
+// Использование:
+//
+//	notifier := notify.New(notify.Config{
+//	    NotificationRepo: notificationRepo,
+//	    RunRepo:          runRepo,
+//	    FlowRepo:         flowRepo,
+//	    SMTP:             notify.SMTPConfig{Addr: "localhost:1025", From: "automata@localhost"},
+//	    Logger:           logger,
+//	})
+//
+//	go notifier.Run(ctx) // блокируется до отмены ctx
+package notify
@@ -0,0 +1,14 @@
+package notify
+
+import "errors"
+
+var (
+	// ErrInvalidRule — некорректное правило уведомления.
+	ErrInvalidRule = errors.New("invalid notification rule")
+
+	// ErrDeliveryFailed — канал не принял уведомление (HTTP статус, ошибка SMTP).
+	ErrDeliveryFailed = errors.New("notification delivery failed")
+
+	// ErrChannelNotConfigured — канал не настроен в этом сервисе (нет SMTP сервера).
+	ErrChannelNotConfigured = errors.New("notification channel not configured")
+)
@@ -0,0 +1,323 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/repo"
+)
+
+// Значения по умолчанию.
+const (
+	defaultInterval    = 5 * time.Second
+	defaultBatchSize   = 50
+	defaultMaxAttempts = 5
+
+	// claimLease — на сколько откладывается забранное уведомление:
+	// если отправка прервётся, оно будет взято повторно.
+	claimLease = time.Minute
+
+	// retryBase и retryMax — границы exponential backoff между попытками.
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+)
+
+// Notifier создаёт уведомления о завершённых runs и доставляет их.
+//
+// Notify вызывается Orchestrator при завершении run: подходящие правила
+// flow превращаются в PENDING записи notification_deliveries. Run
+// отправляет их в фоне, повторяя неудачные попытки с exponential backoff,
+// пока не будет исчерпан MaxAttempts.
+type Notifier struct {
+	notificationRepo *repo.NotificationRepo
+	runRepo          *repo.RunRepo
+	flowRepo         *repo.FlowRepo
+	senders          map[domain.NotificationChannel]Sender
+	logger           *slog.Logger
+	interval         time.Duration
+	batchSize        int
+	maxAttempts      int
+}
+
+// Config — конфигурация Notifier.
+type Config struct {
+	NotificationRepo *repo.NotificationRepo
+	RunRepo          *repo.RunRepo
+	FlowRepo         *repo.FlowRepo
+	HTTPClient       *http.Client // клиент webhook (default: timeout 10s)
+	SMTP             SMTPConfig   // SMTP сервер для email (пустой Addr — email выключен)
+	Logger           *slog.Logger
+	Interval         time.Duration // интервал проверки очереди доставки (default: 5s)
+	MaxAttempts      int           // попыток доставки на уведомление (default: 5)
+}
+
+// New создаёт новый Notifier.
+func New(cfg Config) *Notifier {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Notifier{
+		notificationRepo: cfg.NotificationRepo,
+		runRepo:          cfg.RunRepo,
+		flowRepo:         cfg.FlowRepo,
+		senders: map[domain.NotificationChannel]Sender{
+			domain.NotificationChannelWebhook: NewWebhookSender(cfg.HTTPClient),
+			domain.NotificationChannelEmail:   NewEmailSender(cfg.SMTP),
+		},
+		logger:      logger,
+		interval:    interval,
+		batchSize:   defaultBatchSize,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Notify создаёт уведомления о завершённом run по правилам его flow.
+// Sandbox runs не уведомляют. Повторный вызов для того же run не создаёт дублей.
+func (n *Notifier) Notify(ctx context.Context, run *domain.Run) error {
+	if run.IsSandbox || !run.Status.IsTerminal() {
+		return nil
+	}
+
+	rules, err := n.notificationRepo.ListEnabledRules(ctx, run.FlowID)
+	if err != nil {
+		return fmt.Errorf("list notification rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	streak, err := n.failureStreak(ctx, run, rules)
+	if err != nil {
+		return err
+	}
+
+	matched := make([]domain.NotificationRule, 0, len(rules))
+	for i := range rules {
+		if Matches(&rules[i], run.Status, streak) {
+			matched = append(matched, rules[i])
+		}
+	}
+
+	return n.queue(ctx, run, matched, streak)
+}
+
+// NotifySLABreach создаёт уведомления sla_breach о run, который выполняется
+// дольше ожидаемого (FlowSpec.SLASec). Run при этом продолжает выполняться.
+func (n *Notifier) NotifySLABreach(ctx context.Context, run *domain.Run) error {
+	if run.IsSandbox {
+		return nil
+	}
+
+	rules, err := n.notificationRepo.ListEnabledRules(ctx, run.FlowID)
+	if err != nil {
+		return fmt.Errorf("list notification rules: %w", err)
+	}
+
+	matched := make([]domain.NotificationRule, 0, len(rules))
+	for i := range rules {
+		if rules[i].On == domain.NotifyOnSLABreach {
+			matched = append(matched, rules[i])
+		}
+	}
+
+	return n.queue(ctx, run, matched, 0)
+}
+
+// queue рендерит сообщения правил и создаёт PENDING записи доставки.
+func (n *Notifier) queue(ctx context.Context, run *domain.Run, rules []domain.NotificationRule, streak int) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	flow, err := n.flowRepo.GetByID(ctx, run.FlowID)
+	if err != nil {
+		return fmt.Errorf("get flow: %w", err)
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+
+		data := &Data{
+			Flow:                FlowData{ID: flow.ID, Name: flow.Name},
+			Run:                 run,
+			Rule:                rule.Name,
+			ConsecutiveFailures: streak,
+		}
+		subject, body, err := Render(rule, data)
+		if err != nil {
+			n.logger.Warn("failed to render notification",
+				"rule_id", rule.ID,
+				"run_id", run.ID,
+				"error", err,
+			)
+			continue
+		}
+
+		now := time.Now()
+		delivery := &domain.NotificationDelivery{
+			ID:            uuid.New(),
+			RuleID:        rule.ID,
+			RunID:         run.ID,
+			Status:        domain.DeliveryStatusPending,
+			Subject:       subject,
+			Body:          body,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		}
+		created, err := n.notificationRepo.CreateDelivery(ctx, delivery)
+		if err != nil {
+			return fmt.Errorf("create notification delivery: %w", err)
+		}
+		if created {
+			n.logger.Info("notification queued",
+				"rule_id", rule.ID,
+				"rule_name", rule.Name,
+				"run_id", run.ID,
+				"channel", rule.Channel,
+			)
+		}
+	}
+
+	return nil
+}
+
+// failureStreak возвращает серию ошибок подряд, если она нужна правилам.
+func (n *Notifier) failureStreak(ctx context.Context, run *domain.Run, rules []domain.NotificationRule) (int, error) {
+	if !run.Status.IsFailure() {
+		return 0, nil
+	}
+
+	// Чтобы отличить серию длины N от более длинной, нужен N+1 run
+	limit := 1
+	for i := range rules {
+		if rules[i].On == domain.NotifyOnConsecutiveFailures && rules[i].Threshold+1 > limit {
+			limit = rules[i].Threshold + 1
+		}
+	}
+	if limit == 1 {
+		return 1, nil
+	}
+
+	runs, err := n.runRepo.ListRecentFinished(ctx, run.FlowID, limit)
+	if err != nil {
+		return 0, fmt.Errorf("list recent runs: %w", err)
+	}
+	return FailureStreak(runs), nil
+}
+
+// Run доставляет уведомления до отмены контекста.
+func (n *Notifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+
+	for {
+		n.DeliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue отправляет уведомления, время попытки которых наступило.
+func (n *Notifier) DeliverDue(ctx context.Context) {
+	deliveries, err := n.notificationRepo.ClaimDue(ctx, n.batchSize, claimLease)
+	if err != nil {
+		n.logger.Error("failed to claim notifications", "error", err)
+		return
+	}
+
+	rules := make(map[uuid.UUID]*domain.NotificationRule)
+	for i := range deliveries {
+		d := &deliveries[i]
+
+		rule, ok := rules[d.RuleID]
+		if !ok {
+			rule, err = n.notificationRepo.GetRule(ctx, d.RuleID)
+			if err != nil {
+				// Правило удалено — история удалится каскадно
+				if !errors.Is(err, repo.ErrNotFound) {
+					n.logger.Error("failed to get notification rule", "rule_id", d.RuleID, "error", err)
+				}
+				continue
+			}
+			rules[d.RuleID] = rule
+		}
+
+		n.deliver(ctx, rule, d)
+	}
+}
+
+// deliver выполняет одну попытку доставки и сохраняет результат.
+func (n *Notifier) deliver(ctx context.Context, rule *domain.NotificationRule, d *domain.NotificationDelivery) {
+	d.Attempts++
+
+	err := n.send(ctx, rule, d)
+	if err == nil {
+		d.MarkSent()
+		n.logger.Info("notification sent",
+			"delivery_id", d.ID,
+			"rule_id", rule.ID,
+			"run_id", d.RunID,
+			"channel", rule.Channel,
+			"attempt", d.Attempts,
+		)
+	} else {
+		var retryAt *time.Time
+		if d.Attempts < n.maxAttempts {
+			next := time.Now().Add(Backoff(d.Attempts))
+			retryAt = &next
+		}
+		d.MarkAttemptFailed(err.Error(), retryAt)
+		n.logger.Warn("notification delivery failed",
+			"delivery_id", d.ID,
+			"rule_id", rule.ID,
+			"run_id", d.RunID,
+			"attempt", d.Attempts,
+			"will_retry", retryAt != nil,
+			"error", err,
+		)
+	}
+
+	if err := n.notificationRepo.UpdateDelivery(ctx, d); err != nil {
+		n.logger.Error("failed to update notification delivery", "delivery_id", d.ID, "error", err)
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, rule *domain.NotificationRule, d *domain.NotificationDelivery) error {
+	sender, ok := n.senders[rule.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrChannelNotConfigured, rule.Channel)
+	}
+	return sender.Send(ctx, rule, d)
+}
+
+// Backoff возвращает задержку перед следующей попыткой доставки:
+// 30s, 1m, 2m, 4m ... не больше часа.
+func Backoff(attempt int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempt && delay < retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMax)
+}
@@ -0,0 +1,475 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    domain.NotificationRule
+		wantErr bool
+	}{
+		{
+			name: "valid webhook",
+			rule: domain.NotificationRule{
+				Name: "slack", On: domain.NotifyOnFailure, Channel: domain.NotificationChannelWebhook,
+				Config: domain.NotificationConfig{URL: "https://hooks.slack.com/services/x", Message: "{{ .Run.Status }}"},
+			},
+		},
+		{
+			name: "valid email with threshold",
+			rule: domain.NotificationRule{
+				Name: "oncall", On: domain.NotifyOnConsecutiveFailures, Threshold: 3,
+				Channel: domain.NotificationChannelEmail,
+				Config:  domain.NotificationConfig{To: []string{"oncall@example.com"}},
+			},
+		},
+		{
+			name: "valid sla breach",
+			rule: domain.NotificationRule{
+				Name: "sla", On: domain.NotifyOnSLABreach, Channel: domain.NotificationChannelWebhook,
+				Config: domain.NotificationConfig{URL: "https://hooks.slack.com/services/x"},
+			},
+		},
+		{
+			name: "missing name",
+			rule: domain.NotificationRule{
+				On: domain.NotifyOnSuccess, Channel: domain.NotificationChannelWebhook,
+				Config: domain.NotificationConfig{URL: "http://localhost/hook"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown event",
+			rule: domain.NotificationRule{
+				Name: "x", On: "started", Channel: domain.NotificationChannelWebhook,
+				Config: domain.NotificationConfig{URL: "http://localhost/hook"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "consecutive failures without threshold",
+			rule: domain.NotificationRule{
+				Name: "x", On: domain.NotifyOnConsecutiveFailures, Channel: domain.NotificationChannelWebhook,
+				Config: domain.NotificationConfig{URL: "http://localhost/hook"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "webhook without url",
+			rule: domain.NotificationRule{
+				Name: "x", On: domain.NotifyOnFailure, Channel: domain.NotificationChannelWebhook,
+			},
+			wantErr: true,
+		},
+		{
+			name: "email with invalid address",
+			rule: domain.NotificationRule{
+				Name: "x", On: domain.NotifyOnFailure, Channel: domain.NotificationChannelEmail,
+				Config: domain.NotificationConfig{To: []string{"not an address"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid message template",
+			rule: domain.NotificationRule{
+				Name: "x", On: domain.NotifyOnFailure, Channel: domain.NotificationChannelWebhook,
+				Config: domain.NotificationConfig{URL: "http://localhost/hook", Message: "{{ .Run.Status "},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown channel",
+			rule: domain.NotificationRule{
+				Name: "x", On: domain.NotifyOnFailure, Channel: "sms",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRule(&tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("error should wrap ErrInvalidRule, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	onFailure := &domain.NotificationRule{On: domain.NotifyOnFailure}
+	onSuccess := &domain.NotificationRule{On: domain.NotifyOnSuccess}
+	onStreak := &domain.NotificationRule{On: domain.NotifyOnConsecutiveFailures, Threshold: 3}
+	onSLA := &domain.NotificationRule{On: domain.NotifyOnSLABreach}
+
+	tests := []struct {
+		name   string
+		rule   *domain.NotificationRule
+		status domain.RunStatus
+		streak int
+		want   bool
+	}{
+		{"failure on failed", onFailure, domain.RunStatusFailed, 1, true},
+		{"failure on compensated", onFailure, domain.RunStatusCompensated, 1, true},
+		{"failure on succeeded", onFailure, domain.RunStatusSucceeded, 0, false},
+		{"failure on cancelled", onFailure, domain.RunStatusCancelled, 0, false},
+		{"success on succeeded", onSuccess, domain.RunStatusSucceeded, 0, true},
+		{"success on failed", onSuccess, domain.RunStatusFailed, 1, false},
+		{"streak below threshold", onStreak, domain.RunStatusFailed, 2, false},
+		{"streak reaches threshold", onStreak, domain.RunStatusFailed, 3, true},
+		{"streak above threshold", onStreak, domain.RunStatusFailed, 4, false},
+		{"sla breach on failed", onSLA, domain.RunStatusFailed, 1, false},
+		{"sla breach on succeeded", onSLA, domain.RunStatusSucceeded, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Matches(tt.rule, tt.status, tt.streak); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailureStreak(t *testing.T) {
+	runs := func(statuses ...domain.RunStatus) []domain.Run {
+		result := make([]domain.Run, len(statuses))
+		for i, s := range statuses {
+			result[i] = domain.Run{Status: s}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name string
+		runs []domain.Run
+		want int
+	}{
+		{"empty", nil, 0},
+		{"latest succeeded", runs(domain.RunStatusSucceeded, domain.RunStatusFailed), 0},
+		{"two failures then success", runs(domain.RunStatusFailed, domain.RunStatusCompensated, domain.RunStatusSucceeded), 2},
+		{"all failed", runs(domain.RunStatusFailed, domain.RunStatusFailed, domain.RunStatusFailed), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FailureStreak(tt.runs); got != tt.want {
+				t.Errorf("FailureStreak() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func testData() *Data {
+	return &Data{
+		Flow: FlowData{ID: uuid.New(), Name: "nightly-export"},
+		Run: &domain.Run{
+			ID:     uuid.New(),
+			Status: domain.RunStatusFailed,
+			Error:  "step export failed",
+			Inputs: map[string]any{"date": "2026-01-01"},
+		},
+		Rule:                "oncall",
+		ConsecutiveFailures: 3,
+	}
+}
+
+func TestRender_Webhook(t *testing.T) {
+	data := testData()
+	rule := &domain.NotificationRule{Channel: domain.NotificationChannelWebhook}
+
+	subject, body, err := Render(rule, data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if subject != "" {
+		t.Errorf("webhook subject = %q, want empty", subject)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+
+	want := `Flow "nightly-export": run ` + data.Run.ID.String() + ` FAILED (3 failures in a row): step export failed`
+	if payload["text"] != want {
+		t.Errorf("text = %q, want %q", payload["text"], want)
+	}
+	if payload["run_id"] != data.Run.ID.String() || payload["status"] != "FAILED" || payload["rule"] != "oncall" {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+}
+
+func TestRender_EmailTemplates(t *testing.T) {
+	data := testData()
+	rule := &domain.NotificationRule{
+		Channel: domain.NotificationChannelEmail,
+		Config: domain.NotificationConfig{
+			Subject: "{{ .Flow.Name }} {{ .Run.Inputs.date }}",
+			Message: "{{ .Run.Status }} after {{ .ConsecutiveFailures }} tries",
+		},
+	}
+
+	subject, body, err := Render(rule, data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if subject != "nightly-export 2026-01-01" {
+		t.Errorf("subject = %q", subject)
+	}
+	if body != "FAILED after 3 tries" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestRender_SLABreachDefaults(t *testing.T) {
+	data := testData()
+	slaAt := time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC)
+	data.Run.Status = domain.RunStatusRunning
+	data.Run.SLAAt = &slaAt
+
+	rule := &domain.NotificationRule{
+		On:      domain.NotifyOnSLABreach,
+		Channel: domain.NotificationChannelEmail,
+		Config:  domain.NotificationConfig{To: []string{"oncall@example.com"}},
+	}
+
+	subject, body, err := Render(rule, data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if subject != "[Automata] nightly-export: run exceeded SLA" {
+		t.Errorf("subject = %q", subject)
+	}
+	want := `Flow "nightly-export": run ` + data.Run.ID.String() +
+		` exceeded its SLA (expected to finish by 2026-01-01 06:00:00 UTC)`
+	if body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookSender(t *testing.T) {
+	var gotBody, gotAuth, gotType string
+	status := http.StatusOK
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		gotAuth = r.Header.Get("Authorization")
+		gotType = r.Header.Get("Content-Type")
+		w.WriteHeader(status)
+		w.Write([]byte("no_service"))
+	}))
+	defer srv.Close()
+
+	rule := &domain.NotificationRule{
+		Channel: domain.NotificationChannelWebhook,
+		Config: domain.NotificationConfig{
+			URL:     srv.URL,
+			Headers: map[string]string{"Authorization": "Bearer t"},
+		},
+	}
+	d := &domain.NotificationDelivery{Body: `{"text":"hi"}`}
+
+	sender := NewWebhookSender(nil)
+	if err := sender.Send(context.Background(), rule, d); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if gotBody != d.Body || gotAuth != "Bearer t" || gotType != "application/json" {
+		t.Errorf("unexpected request: body=%q auth=%q type=%q", gotBody, gotAuth, gotType)
+	}
+
+	status = http.StatusNotFound
+	err := sender.Send(context.Background(), rule, d)
+	if !errors.Is(err, ErrDeliveryFailed) {
+		t.Fatalf("expected ErrDeliveryFailed, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "no_service") {
+		t.Errorf("error should include status and response, got %v", err)
+	}
+}
+
+// fakeSMTP — минимальный SMTP сервер: принимает одно письмо и возвращает его в канал.
+func fakeSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				var msg strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					msg.WriteString(l)
+				}
+				messages <- msg.String()
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), messages
+}
+
+func TestEmailSender(t *testing.T) {
+	addr, messages := fakeSMTP(t)
+
+	sender := NewEmailSender(SMTPConfig{Addr: addr, From: "automata@localhost"})
+	rule := &domain.NotificationRule{
+		Channel: domain.NotificationChannelEmail,
+		Config:  domain.NotificationConfig{To: []string{"oncall@example.com"}},
+	}
+	d := &domain.NotificationDelivery{Subject: "nightly failed", Body: "line 1\nline 2"}
+
+	if err := sender.Send(context.Background(), rule, d); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case msg := <-messages:
+		for _, want := range []string{
+			"From: automata@localhost\r\n",
+			"To: oncall@example.com\r\n",
+			"Subject: nightly failed\r\n",
+			"\r\n\r\nline 1\r\nline 2\r\n",
+		} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("message missing %q:\n%s", want, msg)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestEmailSender_Timeout(t *testing.T) {
+	// Сервер принимает соединение, но не отвечает приветствием
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { conn.Close() })
+	}()
+
+	sender := NewEmailSender(SMTPConfig{Addr: ln.Addr().String(), From: "automata@localhost"})
+	rule := &domain.NotificationRule{Config: domain.NotificationConfig{To: []string{"a@example.com"}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = sender.Send(ctx, rule, &domain.NotificationDelivery{Subject: "s", Body: "b"})
+	if !errors.Is(err, ErrDeliveryFailed) {
+		t.Fatalf("expected ErrDeliveryFailed, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send should stop at ctx deadline, took %v", elapsed)
+	}
+}
+
+func TestEmailSender_NotConfigured(t *testing.T) {
+	sender := NewEmailSender(SMTPConfig{})
+	rule := &domain.NotificationRule{Config: domain.NotificationConfig{To: []string{"a@example.com"}}}
+
+	err := sender.Send(context.Background(), rule, &domain.NotificationDelivery{})
+	if !errors.Is(err, ErrChannelNotConfigured) {
+		t.Fatalf("expected ErrChannelNotConfigured, got %v", err)
+	}
+}
+
+func TestDeliveryStatusTransitions(t *testing.T) {
+	d := &domain.NotificationDelivery{Status: domain.DeliveryStatusPending}
+
+	next := time.Now().Add(time.Minute)
+	d.MarkAttemptFailed("timeout", &next)
+	if d.Status != domain.DeliveryStatusPending || d.NextAttemptAt == nil {
+		t.Errorf("retryable failure should stay PENDING, got %s", d.Status)
+	}
+
+	d.MarkAttemptFailed("timeout", nil)
+	if d.Status != domain.DeliveryStatusFailed || d.NextAttemptAt != nil {
+		t.Errorf("exhausted attempts should be FAILED, got %s", d.Status)
+	}
+
+	d.MarkSent()
+	if d.Status != domain.DeliveryStatusSent || d.SentAt == nil || d.LastError != "" {
+		t.Errorf("unexpected state after MarkSent: %+v", d)
+	}
+}
@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+)
+
+// Шаблоны по умолчанию.
+const (
+	defaultMessage = `Flow "{{ .Flow.Name }}": run {{ .Run.ID }} {{ .Run.Status }}` +
+		`{{ if gt .ConsecutiveFailures 1 }} ({{ .ConsecutiveFailures }} failures in a row){{ end }}` +
+		`{{ if .Run.Error }}: {{ .Run.Error }}{{ end }}`
+
+	defaultSubject = `[Automata] {{ .Flow.Name }}: run {{ .Run.Status }}`
+
+	defaultSLAMessage = `Flow "{{ .Flow.Name }}": run {{ .Run.ID }} exceeded its SLA` +
+		`{{ if .Run.SLAAt }} (expected to finish by {{ .Run.SLAAt.Format "2006-01-02 15:04:05 MST" }}){{ end }}`
+
+	defaultSLASubject = `[Automata] {{ .Flow.Name }}: run exceeded SLA`
+)
+
+// Data — данные шаблонов уведомления.
+type Data struct {
+	// Flow — flow завершённого run.
+	Flow FlowData
+
+	// Run — завершённый run.
+	Run *domain.Run
+
+	// Rule — имя правила.
+	Rule string
+
+	// ConsecutiveFailures — текущая серия ошибок подряд (0 для успешного run).
+	ConsecutiveFailures int
+}
+
+// FlowData — данные flow в шаблонах.
+type FlowData struct {
+	ID   uuid.UUID
+	Name string
+}
+
+// ValidateRule проверяет правило уведомления.
+func ValidateRule(rule *domain.NotificationRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+
+	switch rule.On {
+	case domain.NotifyOnFailure, domain.NotifyOnSuccess, domain.NotifyOnSLABreach:
+	case domain.NotifyOnConsecutiveFailures:
+		if rule.Threshold < 1 {
+			return fmt.Errorf("%w: consecutive_failures requires threshold >= 1", ErrInvalidRule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown event %q (expected failure, success, consecutive_failures or sla_breach)",
+			ErrInvalidRule, rule.On)
+	}
+
+	cfg := &rule.Config
+	switch rule.Channel {
+	case domain.NotificationChannelWebhook:
+		u, err := url.Parse(cfg.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: webhook requires an http(s) config.url", ErrInvalidRule)
+		}
+	case domain.NotificationChannelEmail:
+		if len(cfg.To) == 0 {
+			return fmt.Errorf("%w: email requires config.to", ErrInvalidRule)
+		}
+		for _, addr := range cfg.To {
+			if _, err := mail.ParseAddress(addr); err != nil {
+				return fmt.Errorf("%w: config.to: invalid address %q", ErrInvalidRule, addr)
+			}
+		}
+		if err := engine.ValidateTemplate(cfg.Subject); err != nil {
+			return fmt.Errorf("%w: config.subject: %v", ErrInvalidRule, err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown channel %q (expected webhook or email)", ErrInvalidRule, rule.Channel)
+	}
+
+	if err := engine.ValidateTemplate(cfg.Message); err != nil {
+		return fmt.Errorf("%w: config.message: %v", ErrInvalidRule, err)
+	}
+
+	return nil
+}
+
+// Matches проверяет, срабатывает ли правило на завершённый run с данным статусом.
+// streak — серия ошибок подряд, включая этот run.
+// Правила sla_breach срабатывают не на завершение, а через Notifier.NotifySLABreach.
+func Matches(rule *domain.NotificationRule, status domain.RunStatus, streak int) bool {
+	switch rule.On {
+	case domain.NotifyOnFailure:
+		return status.IsFailure()
+	case domain.NotifyOnSuccess:
+		return status == domain.RunStatusSucceeded
+	case domain.NotifyOnConsecutiveFailures:
+		// Один раз на серию — когда она достигает порога
+		return status.IsFailure() && streak == rule.Threshold
+	default:
+		return false
+	}
+}
+
+// FailureStreak считает ошибки подряд с начала списка runs (новые первыми).
+func FailureStreak(runs []domain.Run) int {
+	streak := 0
+	for i := range runs {
+		if !runs[i].Status.IsFailure() {
+			break
+		}
+		streak++
+	}
+	return streak
+}
+
+// Render рендерит тему и тело уведомления.
+//
+// Тело webhook — JSON, совместимый со Slack incoming webhooks:
+// {"text": <message>, ...} с дополнительными полями о run.
+// Тело email — текст сообщения.
+func Render(rule *domain.NotificationRule, data *Data) (subject, body string, err error) {
+	tmpl := rule.Config.Message
+	if tmpl == "" {
+		tmpl = defaultMessage
+		if rule.On == domain.NotifyOnSLABreach {
+			tmpl = defaultSLAMessage
+		}
+	}
+	message, err := engine.RenderData(tmpl, data)
+	if err != nil {
+		return "", "", fmt.Errorf("render message: %w", err)
+	}
+
+	switch rule.Channel {
+	case domain.NotificationChannelEmail:
+		tmpl := rule.Config.Subject
+		if tmpl == "" {
+			tmpl = defaultSubject
+			if rule.On == domain.NotifyOnSLABreach {
+				tmpl = defaultSLASubject
+			}
+		}
+		subject, err = engine.RenderData(tmpl, data)
+		if err != nil {
+			return "", "", fmt.Errorf("render subject: %w", err)
+		}
+		return subject, message, nil
+
+	default:
+		payload, err := json.Marshal(webhookPayload{
+			Text:     message,
+			FlowID:   data.Flow.ID,
+			FlowName: data.Flow.Name,
+			RunID:    data.Run.ID,
+			Status:   string(data.Run.Status),
+			Error:    data.Run.Error,
+			Rule:     data.Rule,
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("marshal webhook payload: %w", err)
+		}
+		return "", string(payload), nil
+	}
+}
+
+// webhookPayload — тело webhook уведомления.
+type webhookPayload struct {
+	Text     string    `json:"text"`
+	FlowID   uuid.UUID `json:"flow_id"`
+	FlowName string    `json:"flow_name"`
+	RunID    uuid.UUID `json:"run_id"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Rule     string    `json:"rule"`
+}
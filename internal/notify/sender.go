@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// Sender доставляет уведомление по своему каналу.
+type Sender interface {
+	Send(ctx context.Context, rule *domain.NotificationRule, d *domain.NotificationDelivery) error
+}
+
+// WebhookSender отправляет уведомления POST запросом с JSON телом.
+type WebhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender создаёт WebhookSender. nil client — клиент с timeout 10s.
+func NewWebhookSender(client *http.Client) *WebhookSender {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookSender{client: client}
+}
+
+// Send отправляет тело уведомления на config.url. Ответ не 2xx — ошибка.
+func (s *WebhookSender) Send(ctx context.Context, rule *domain.NotificationRule, d *domain.NotificationDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.Config.URL, strings.NewReader(d.Body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range rule.Config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDeliveryFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%w: webhook returned %d: %s", ErrDeliveryFailed, resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
+
+// SMTPConfig — параметры SMTP сервера.
+type SMTPConfig struct {
+	Addr     string // host:port; пусто — email канал выключен
+	From     string // адрес отправителя
+	Username string // опционально, PLAIN auth
+	Password string
+}
+
+// emailTimeout — ограничение SMTP-сессии, если ctx не задаёт более
+// ранний deadline.
+const emailTimeout = 30 * time.Second
+
+// EmailSender отправляет уведомления письмом через SMTP.
+type EmailSender struct {
+	cfg SMTPConfig
+}
+
+// NewEmailSender создаёт EmailSender.
+func NewEmailSender(cfg SMTPConfig) *EmailSender {
+	return &EmailSender{cfg: cfg}
+}
+
+// Send отправляет письмо получателям config.to. SMTP-сессия ограничена
+// ctx и emailTimeout: зависший сервер не блокирует доставку уведомлений.
+func (s *EmailSender) Send(ctx context.Context, rule *domain.NotificationRule, d *domain.NotificationDelivery) error {
+	if s.cfg.Addr == "" {
+		return fmt.Errorf("%w: SMTP_ADDR is not set", ErrChannelNotConfigured)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+
+	msg := buildEmail(s.cfg.From, rule.Config.To, d.Subject, d.Body, time.Now())
+	if err := s.send(ctx, rule.Config.To, msg); err != nil {
+		return fmt.Errorf("%w: %v", ErrDeliveryFailed, err)
+	}
+	return nil
+}
+
+// send проводит SMTP-сессию: STARTTLS (если сервер поддерживает), AUTH,
+// MAIL, RCPT, DATA. Deadline ctx действует на соединение целиком.
+func (s *EmailSender) send(ctx context.Context, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline) //nolint:errcheck // ошибка проявится при чтении
+	}
+	// Отмена ctx прерывает ожидание ответа сервера
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now()) //nolint:errcheck // соединение закрывается
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail формирует текстовое письмо (RFC 5322) в UTF-8.
+func buildEmail(from string, to []string, subject, body string, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
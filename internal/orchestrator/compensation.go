@@ -0,0 +1,206 @@
+package orchestrator
+
+import (
+	"slices"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+)
+
+// compensationState — состояние компенсаций упавшего run.
+//
+// Компенсации выполняются последовательно: следующая запускается
+// после завершения предыдущей. Ошибка компенсации не останавливает
+// остальные — run завершается в COMPENSATION_FAILED.
+type compensationState struct {
+	// queue — шаги, ожидающие компенсации, в порядке выполнения.
+	queue []string
+
+	// running — шаг, компенсация которого выполняется ("" — нет).
+	running string
+
+	// failed — шаги, компенсация которых завершилась ошибкой.
+	failed []string
+}
+
+// StartCompensation переводит RunState в режим компенсации.
+//
+// В очередь попадают успешно завершённые шаги с compensate
+// (кроме пропущенных по condition) в обратном топологическом порядке.
+// Компенсации, уже выполненные до рестарта (tasks <step_id>.compensate),
+// повторно не запускаются.
+//
+// Шаги с compensate, tasks которых ещё выполняются (соседние шаги,
+// ветки parallel), могут завершиться успешно уже после падения run —
+// пока они активны, компенсация считается начатой.
+//
+// Возвращает false, если компенсировать нечего.
+func (s *RunState) StartCompensation() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.compensation != nil {
+		return true
+	}
+
+	comp := &compensationState{}
+	for i := len(s.DAG.Order) - 1; i >= 0; i-- {
+		node := s.DAG.Order[i]
+		if !s.needsCompensation(node) {
+			continue
+		}
+
+		task := s.tasks[engine.CompensationStepID(node.ID)]
+		switch {
+		case task == nil:
+			comp.queue = append(comp.queue, node.ID)
+		case task.Status == domain.TaskStatusFailed:
+			comp.failed = append(comp.failed, node.ID)
+		case !task.IsFinished():
+			comp.running = node.ID
+		}
+	}
+
+	if len(comp.queue) == 0 && comp.running == "" && len(comp.failed) == 0 {
+		// Нет ни одной компенсации (включая уже выполненные до рестарта)
+		// и не появится после завершения активных шагов
+		if !s.hasCompensationTasks() && !s.hasActiveCompensableSteps() {
+			return false
+		}
+	}
+
+	s.compensation = comp
+	return true
+}
+
+// needsCompensation проверяет, нужно ли компенсировать шаг.
+func (s *RunState) needsCompensation(node *engine.Node) bool {
+	if node.Step == nil || node.Step.Compensate == nil || node.IsJoin {
+		return false
+	}
+	if !s.completed[node.ID] {
+		return false
+	}
+	if stepCtx := s.Context.Steps[node.ID]; stepCtx != nil {
+		if skipped, _ := stepCtx.Outputs["skipped"].(bool); skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// hasCompensationTasks проверяет, создавались ли tasks компенсаций.
+func (s *RunState) hasCompensationTasks() bool {
+	for stepID := range s.tasks {
+		if _, ok := engine.CompensatedStepID(stepID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasActiveCompensableSteps проверяет, есть ли шаги с compensate,
+// tasks которых ещё не завершены (в т.ч. QUEUED после рестарта).
+func (s *RunState) hasActiveCompensableSteps() bool {
+	for stepID, task := range s.tasks {
+		if _, ok := engine.CompensatedStepID(stepID); ok {
+			continue
+		}
+		if !s.running[stepID] && (s.completed[stepID] || s.failed[stepID] || task.IsFinished()) {
+			continue
+		}
+		if node := s.DAG.Nodes[stepID]; node != nil && node.Step != nil && node.Step.Compensate != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCompensations возвращает true, если запускалась хотя бы одна
+// компенсация. Run, в котором компенсировать оказалось нечего,
+// завершается как FAILED.
+func (s *RunState) HasCompensations() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.hasCompensationTasks() || (s.compensation != nil && len(s.compensation.failed) > 0)
+}
+
+// IsCompensating возвращает true, если run в режиме компенсации.
+func (s *RunState) IsCompensating() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.compensation != nil
+}
+
+// NextCompensation извлекает из очереди следующий шаг для компенсации.
+// Возвращает false, если очередь пуста или компенсация уже выполняется.
+func (s *RunState) NextCompensation() (string, *domain.StepDef, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comp := s.compensation
+	if comp == nil || comp.running != "" || len(comp.queue) == 0 {
+		return "", nil, false
+	}
+
+	stepID := comp.queue[0]
+	comp.queue = comp.queue[1:]
+	comp.running = stepID
+
+	return stepID, s.DAG.Nodes[stepID].Step.Compensate, true
+}
+
+// EnqueueCompensation добавляет шаг, успешно завершившийся уже после
+// начала компенсации, в начало очереди (он позже остальных в порядке выполнения).
+func (s *RunState) EnqueueCompensation(stepID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node := s.DAG.Nodes[stepID]
+	if s.compensation == nil || node == nil || !s.needsCompensation(node) {
+		return
+	}
+	s.compensation.queue = append([]string{stepID}, s.compensation.queue...)
+}
+
+// MarkCompensationDone фиксирует завершение компенсации шага.
+func (s *RunState) MarkCompensationDone(stepID string, succeeded bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comp := s.compensation
+	if comp == nil {
+		return
+	}
+	if comp.running == stepID {
+		comp.running = ""
+	}
+	// После рестарта упавшая компенсация уже учтена в StartCompensation
+	if !succeeded && !slices.Contains(comp.failed, stepID) {
+		comp.failed = append(comp.failed, stepID)
+	}
+}
+
+// IsCompensationDone возвращает true, если все компенсации завершены
+// и не осталось активных шагов с compensate: успешное завершение такого
+// шага добавит его в очередь (EnqueueCompensation).
+func (s *RunState) IsCompensationDone() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comp := s.compensation
+	return comp != nil && comp.running == "" && len(comp.queue) == 0 && !s.hasActiveCompensableSteps()
+}
+
+// GetFailedCompensations возвращает шаги, компенсация которых завершилась ошибкой.
+func (s *RunState) GetFailedCompensations() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.compensation == nil {
+		return nil
+	}
+	return append([]string(nil), s.compensation.failed...)
+}
@@ -0,0 +1,116 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/repo"
+)
+
+// admitRun проверяет лимит concurrency flow перед стартом run.
+//
+// Возвращает true, если run можно запускать. Иначе run остаётся в PENDING
+// с причиной ожидания (queue) или отменяется (skip_new). Для cancel_running
+// самые старые выполняющиеся runs отменяются, и новый run допускается.
+//
+// Вызывается внутри admission: подсчёт выполняющихся runs и перевод run
+// в RUNNING (admission.Start) не должны перемежаться с другими runs flow.
+func (o *Orchestrator) admitRun(ctx context.Context, admission *repo.RunAdmission, run *domain.Run, cc *domain.ConcurrencyPolicy) (bool, error) {
+	key, err := engine.ConcurrencyKey(cc, run.Inputs)
+	if err != nil {
+		return false, o.failRun(ctx, run, err.Error())
+	}
+	run.ConcurrencyKey = key
+
+	running, err := admission.ListRunning(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("list running runs: %w", err)
+	}
+	if len(running) < cc.MaxConcurrentRuns {
+		return true, nil
+	}
+
+	reason := fmt.Sprintf("concurrency limit reached: %d of %d runs running", len(running), cc.MaxConcurrentRuns)
+	if key != "" {
+		reason += fmt.Sprintf(" (key %q)", key)
+	}
+
+	switch engine.ConcurrencyPolicy(cc) {
+	case domain.ConcurrencySkipNew:
+		run.MarkCancelled()
+		run.Error = "skipped: " + reason
+		if err := o.runRepo.Update(ctx, run); err != nil {
+			return false, fmt.Errorf("update skipped run: %w", err)
+		}
+		o.logger.Info("run skipped by concurrency policy",
+			"run_id", run.ID,
+			"flow_id", run.FlowID,
+			"concurrency_key", key,
+		)
+		o.publishRunCompleted(ctx, run, nil)
+		o.notifyRun(ctx, run)
+		return false, nil
+
+	case domain.ConcurrencyCancelRunning:
+		// Освобождаем ровно столько слотов, сколько нужно новому run
+		excess := len(running) - cc.MaxConcurrentRuns + 1
+		for i := 0; i < excess; i++ {
+			old := &running[i]
+			if err := o.abortRun(ctx, old, domain.RunStatusCancelled,
+				fmt.Sprintf("cancelled by newer run %s (concurrency policy cancel_running)", run.ID)); err != nil {
+				return false, fmt.Errorf("cancel running run %s: %w", old.ID, err)
+			}
+		}
+		return true, nil
+
+	default:
+		// queue: run ждёт в PENDING; пишем в БД только изменившуюся причину
+		if run.WaitingReason != reason {
+			run.WaitingReason = reason
+			if err := o.runRepo.Update(ctx, run); err != nil {
+				return false, fmt.Errorf("update waiting run: %w", err)
+			}
+		}
+		o.logger.Debug("run queued by concurrency policy",
+			"run_id", run.ID,
+			"flow_id", run.FlowID,
+			"concurrency_key", key,
+		)
+		return false, nil
+	}
+}
+
+// startQueuedRuns пытается запустить runs flow, ожидающие слота concurrency.
+// Вызывается после завершения run, чтобы очередь не ждала следующего poll.
+func (o *Orchestrator) startQueuedRuns(ctx context.Context, flowID uuid.UUID) {
+	runs, err := o.runRepo.ListQueued(ctx, flowID, o.batchSize)
+	if err != nil {
+		o.logger.Error("failed to list queued runs", "flow_id", flowID, "error", err)
+		return
+	}
+
+	for i := range runs {
+		if o.isRunActive(runs[i].ID) {
+			continue
+		}
+		if err := o.processRun(ctx, runs[i].ID); err != nil {
+			o.logger.Error("failed to process queued run",
+				"run_id", runs[i].ID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// releaseConcurrencySlot запускает очередь flow, если у него задан лимит
+// concurrency. state может быть nil (run не в памяти) — тогда очередь
+// разберёт следующий poll.
+func (o *Orchestrator) releaseConcurrencySlot(ctx context.Context, state *RunState) {
+	if state == nil || state.Run.IsSandbox || state.FlowVersion.Spec.Concurrency == nil {
+		return
+	}
+	o.startQueuedRuns(ctx, state.Run.FlowID)
+}
@@ -95,6 +95,88 @@
 //  4. Проверяет завершение run (все шаги выполнены?)
 //  5. Если run завершён — финализирует, иначе — запускает следующие готовые шаги
 //
+// ## Parallel шаги
+//
+// Start-узел parallel и join-узлы виртуальные: task для них не создаётся.
+// Join разрешается RunState (parallel.go) согласно failure_policy из config:
+//   - fail_fast (по умолчанию) — parallel падает при первой упавшей ветке,
+//     оставшиеся шаги его веток не запускаются
+//   - wait_all — ждём все ветки, parallel падает, если упала хотя бы одна
+//   - min_success — parallel успешен, если успешны не менее min_success веток
+//
+// При разрешении join outputs шагов веток собираются в контекст parallel шага
+// (steps.AggregateParallelOutputs): .steps.<parallel>.outputs.<branch>.<step>.
+// Ошибка внутри ветки приводит к падению run, только если падает сам parallel.
+//
+// max_concurrency ограничивает число одновременно выполняемых веток:
+// GetReadySteps не возвращает шаги новых веток сверх лимита.
+//
+// ## Шаги ожидания сигнала и callback
+//
+// Шаги approval, wait_for_signal и wait_for_callback не отправляются воркеру:
+// parkStep создаёт task в статусе WAITING с дедлайном timeout_sec в next_attempt_at.
+// Шаг остаётся running, пока API не завершит task и не опубликует task.completed:
+//   - сигнал (POST /api/v1/runs/{id}/steps/{step}/signal) — payload
+//     становится outputs шага
+//   - callback (POST /api/v1/callbacks/{run_id}/{step}?token=...) — тело
+//     и заголовки запроса становятся outputs шага
+//
+// Callback URL подписан HMAC (engine.CallbackSigner) и детерминирован для
+// пары (run, step): ExposeCallbacks кладёт его в контекст как
+// .steps.<id>.outputs.callback_url при старте и восстановлении run,
+// поэтому предыдущие шаги могут передать URL внешней системе.
+//
+// Истёкшие ожидания завершаются при polling (engine.ApplyWaitTimeout).
+//
+// ## External шаги
+//
+// Task external шага создаётся в статусе QUEUED, но task.ready не
+// публикуется: его забирает внешний воркер через
+// POST /api/v1/external-tasks/fetch-and-lock. Блокировка хранится в
+// worker_id и next_attempt_at task. Если воркер не ответил до её
+// истечения, polling считает попытку неудачной (engine.ApplyExternalFailure):
+// task возвращается в очередь с backoff из RetryPolicy шага либо шаг падает.
+//
+// ## Компенсации (saga)
+//
+// Если run падает, а среди успешно завершённых шагов есть шаги с compensate,
+// run переводится в COMPENSATING и компенсации выполняются по одной
+// в обратном топологическом порядке (compensation.go). Task компенсации
+// имеет step_id <step_id>.compensate и виден в списке tasks run; config
+// компенсации рендерится с outputs исходного шага в .Outputs.
+// Ошибка компенсации не останавливает остальные. Итог:
+//   - COMPENSATED — все компенсации успешны
+//   - COMPENSATION_FAILED — часть компенсаций упала
+//
+// Шаги с compensate, которые ещё выполнялись в момент падения (соседние
+// шаги, ветки parallel), run дожидается в COMPENSATING: успешно
+// завершившийся такой шаг тоже компенсируется.
+//
+// Шаги без compensate не компенсируются; если компенсировать нечего,
+// run завершается в FAILED.
+//
+// ## Именованные ресурсы
+//
+// Шаг с resources запускается, только получив слот в каждом ресурсе
+// (resources.go, repo.ResourceRepo.Acquire — все слоты сразу или ни одного).
+// Без слота шаг остаётся готовым и встаёт в очередь resource_waiters (FIFO).
+// Слоты освобождаются при task.completed шага и при завершении run;
+// после освобождения wakeResourceWaiters повторяет dispatch для ожидающих runs.
+// Слоты и очередь хранятся в Postgres, поэтому переживают рестарт.
+//
+// ## Ограничение concurrency
+//
+// Если в FlowSpec задан concurrency, processRun перед стартом run считает
+// RUNNING и COMPENSATING runs flow с тем же ключом (concurrency.go). Подсчёт и перевод
+// в RUNNING выполняются в одной транзакции под advisory lock flow
+// (repo.RunAdmission), поэтому лимит соблюдается и при нескольких
+// экземплярах Orchestrator. При достижении лимита:
+//   - queue — run остаётся PENDING с waiting_reason; после завершения
+//     любого run flow очередь разбирается в порядке создания
+//     (startQueuedRuns), иначе — при следующем poll
+//   - skip_new — run переводится в CANCELLED без выполнения
+//   - cancel_running — самые старые выполняющиеся runs отменяются (abortRun)
+//
 // # Polling Fallback
 //
 // Polling нужен для надёжности:
@@ -102,8 +184,16 @@
 //   - RabbitMQ может потерять сообщения при сбое
 //
 // Каждые N секунд (по умолчанию 10) Orchestrator:
-//  1. Запрашивает pending runs из БД
-//  2. Для каждого run, который не в activeRuns — запускает обработку
+//  1. Завершает WAITING tasks с истёкшим дедлайном
+//  2. Обрабатывает external tasks с истёкшей блокировкой: повтор
+//     по RetryPolicy шага или завершение шага с ошибкой
+//  3. Переводит в FAILED runs с истёкшим дедлайном (FlowSpec.TimeoutSec)
+//     и отменяет их незавершённые tasks
+//  4. Создаёт уведомления sla_breach для runs, превысивших FlowSpec.SLASec
+//  5. Повторяет dispatch шагов, ожидающих слотов ресурсов
+//  6. Запрашивает pending runs из БД
+//  7. Для каждого run, который не в activeRuns — запускает обработку
+//     (runs в очереди concurrency проверяются повторно)
 //
 // # Восстановление после рестарта
 //
@@ -116,6 +206,10 @@
 //  6. Добавляем в activeRuns
 //  7. Продолжаем обработку
 //
+// Тот же механизм используется для повторных runs (Run.RetryOf): API копирует
+// в новый run успешные tasks исходного, processRun восстанавливает их через
+// RestoreFromTasks и запускает только оставшиеся шаги.
+//
 // # Потокобезопасность
 //
 // Orchestrator использует sync.RWMutex для защиты activeRuns.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -116,14 +117,51 @@ func (o *Orchestrator) processRun(ctx context.Context, runID uuid.UUID) error {
 		return o.failRun(ctx, run, fmt.Sprintf("initialization failed: %v", err))
 	}
 
+	// Повторный run: outputs успешных шагов исходного run скопированы в его tasks
+	if run.RetryOf != nil {
+		tasks, err := o.taskRepo.ListByRunID(ctx, runID)
+		if err != nil {
+			return fmt.Errorf("list tasks: %w", err)
+		}
+		state.RestoreFromTasks(tasks)
+	}
+	state.ExposeCallbacks(o.callbacks)
+
+	// Лимит concurrency flow: run ждёт в PENDING, пропускается или вытесняет
+	// старые runs. Допуск сериализуется по flow в Postgres (RunAdmission):
+	// блокировка держится до записи RUNNING в БД, в том числе между
+	// экземплярами Orchestrator.
+	var admission *repo.RunAdmission
+	if cc := version.Spec.Concurrency; cc != nil && !run.IsSandbox {
+		admission, err = o.runRepo.BeginAdmission(ctx, run.FlowID)
+		if err != nil {
+			return fmt.Errorf("begin admission: %w", err)
+		}
+		defer admission.Release(ctx)
+
+		admitted, err := o.admitRun(ctx, admission, run, cc)
+		if err != nil || !admitted {
+			return err
+		}
+	}
+
 	// 6. Добавляем в активные runs
 	if err := o.addActiveRun(state); err != nil {
 		return err
 	}
 
-	// 7. Переводим run в RUNNING
+	// 7. Переводим run в RUNNING, дедлайн и SLA отсчитываются от старта
 	run.MarkRunning()
-	if err := o.runRepo.Update(ctx, run); err != nil {
+	run.SetDeadlines(
+		time.Duration(version.Spec.TimeoutSec)*time.Second,
+		time.Duration(version.Spec.SLASec)*time.Second,
+	)
+	if admission != nil {
+		err = admission.Start(ctx, run)
+	} else {
+		err = o.runRepo.Update(ctx, run)
+	}
+	if err != nil {
 		o.removeActiveRun(runID)
 		return fmt.Errorf("update run to running: %w", err)
 	}
@@ -139,6 +177,13 @@ func (o *Orchestrator) processRun(ctx context.Context, runID uuid.UUID) error {
 	if err := o.dispatchReadySteps(ctx, state); err != nil {
 		o.logger.Error("failed to dispatch initial steps", "run_id", runID, "error", err)
 		// Не удаляем из активных — попробуем при следующем событии
+		return nil
+	}
+
+	// Task не создан ни для одного шага (все пропущены по condition
+	// или переиспользованы повторным run) — событий task.completed не будет
+	if state.IsComplete() {
+		return o.completeRun(ctx, state, !state.HasFailed())
 	}
 
 	return nil
@@ -172,6 +217,26 @@ func (o *Orchestrator) processTaskCompleted(ctx context.Context, payload mq.Task
 		return fmt.Errorf("get task: %w", err)
 	}
 
+	// Шаг больше не держит слоты ресурсов — после обработки запускаем ожидающих
+	if o.releaseStepResources(ctx, payload.RunID, payload.StepID) {
+		defer o.wakeResourceWaiters(ctx)
+	}
+
+	// Завершение компенсации (saga)
+	if compensatedID, ok := engine.CompensatedStepID(payload.StepID); ok {
+		state.SetTask(payload.StepID, task)
+		succeeded := payload.Status == string(domain.TaskStatusSucceeded)
+		state.MarkCompensationDone(compensatedID, succeeded)
+		if !succeeded {
+			o.logger.Warn("compensation failed",
+				"run_id", payload.RunID,
+				"step_id", compensatedID,
+				"error", payload.Error,
+			)
+		}
+		return o.dispatchCompensation(ctx, state)
+	}
+
 	// 3. Обновляем состояние шага
 	stepID := payload.StepID
 
@@ -192,6 +257,15 @@ func (o *Orchestrator) processTaskCompleted(ctx context.Context, payload mq.Task
 		)
 	}
 
+	// Шаг завершился уже после начала компенсации — новые шаги не запускаем,
+	// успешный шаг тоже компенсируется
+	if state.IsCompensating() {
+		if payload.Status == string(domain.TaskStatusSucceeded) {
+			state.EnqueueCompensation(stepID)
+		}
+		return o.dispatchCompensation(ctx, state)
+	}
+
 	// 4. Проверяем завершение run
 	if state.HasFailed() {
 		// Если есть упавший шаг — завершаем run с ошибкой
@@ -209,29 +283,61 @@ func (o *Orchestrator) processTaskCompleted(ctx context.Context, payload mq.Task
 
 // dispatchReadySteps создаёт tasks для готовых шагов и публикует их.
 func (o *Orchestrator) dispatchReadySteps(ctx context.Context, state *RunState) error {
-	readySteps := state.GetReadySteps()
+	// Виртуальные узлы (parallel start, join) и пропущенные по condition шаги
+	// завершаются сразу, без task. После них могут стать готовыми новые шаги,
+	// поэтому повторяем, пока появляются такие узлы.
+	for {
+		readySteps := state.GetReadySteps()
 
-	if len(readySteps) == 0 {
-		return nil
-	}
+		if len(readySteps) == 0 {
+			return nil
+		}
 
-	o.logger.Debug("dispatching ready steps",
-		"run_id", state.RunID(),
-		"count", len(readySteps),
-	)
+		o.logger.Debug("dispatching ready steps",
+			"run_id", state.RunID(),
+			"count", len(readySteps),
+		)
 
-	for _, node := range readySteps {
-		if err := o.dispatchStep(ctx, state, node); err != nil {
-			o.logger.Error("failed to dispatch step",
-				"run_id", state.RunID(),
-				"step_id", node.ID,
-				"error", err,
-			)
-			// Продолжаем с другими шагами
+		resolved := false
+		for _, node := range readySteps {
+			if err := o.dispatchStep(ctx, state, node); err != nil {
+				o.logger.Error("failed to dispatch step",
+					"run_id", state.RunID(),
+					"step_id", node.ID,
+					"error", err,
+				)
+				// Продолжаем с другими шагами
+				continue
+			}
+
+			if state.IsStepCompleted(node.ID) {
+				resolved = true
+			}
 		}
+
+		if !resolved {
+			return nil
+		}
+	}
+}
+
+// checkCondition вычисляет condition шага. Шаг без condition выполняется.
+func (o *Orchestrator) checkCondition(state *RunState, node *engine.Node) (bool, error) {
+	if node.Step.Condition == "" {
+		return true, nil
 	}
 
-	return nil
+	shouldRun, err := engine.RenderCondition(node.Step.Condition, state.Context)
+	if err != nil {
+		return false, fmt.Errorf("render condition for %s: %w", node.ID, err)
+	}
+	if !shouldRun {
+		o.logger.Debug("step skipped due to condition",
+			"run_id", state.RunID(),
+			"step_id", node.ID,
+		)
+	}
+	return shouldRun, nil
 }
 
 // dispatchStep создаёт task для шага и публикует его.
@@ -247,27 +353,52 @@ func (o *Orchestrator) dispatchStep(ctx context.Context, state *RunState, node *
 		return fmt.Errorf("%w: node has no step definition", ErrStepNotFound)
 	}
 
+	// parallel start — виртуальный узел: ветки запускаются оркестратором,
+	// воркеру task не отправляется
+	if step.Type == "parallel" {
+		shouldRun, err := o.checkCondition(state, node)
+		if err != nil {
+			return err
+		}
+		if !shouldRun {
+			// Условие не выполнено — пропускаем parallel вместе с ветками
+			state.SkipParallel(node.ID)
+			return nil
+		}
+		state.MarkStepCompleted(node.ID, nil)
+		return nil
+	}
+
 	// Рендерим конфигурацию шага
-	config, err := engine.RenderConfig(step.Config, state.Context)
+	config, err := renderStepConfig(step.Type, step.Config, state.Context)
 	if err != nil {
 		return fmt.Errorf("render config for %s: %w", node.ID, err)
 	}
 
 	// Проверяем condition (если есть)
-	if step.Condition != "" {
-		shouldRun, err := engine.RenderCondition(step.Condition, state.Context)
-		if err != nil {
-			return fmt.Errorf("render condition for %s: %w", node.ID, err)
-		}
-		if !shouldRun {
-			// Условие не выполнено — пропускаем шаг
-			o.logger.Debug("step skipped due to condition",
-				"run_id", state.RunID(),
-				"step_id", node.ID,
-			)
-			state.MarkStepCompleted(node.ID, map[string]any{"skipped": true})
-			return nil
+	shouldRun, err := o.checkCondition(state, node)
+	if err != nil {
+		return err
+	}
+	if !shouldRun {
+		// Условие не выполнено — пропускаем шаг
+		state.MarkStepCompleted(node.ID, map[string]any{"skipped": true})
+		return nil
+	}
+
+	// Слоты именованных ресурсов: без них шаг ждёт в очереди
+	acquired, err := o.acquireResources(ctx, state, node)
+	if err != nil || !acquired {
+		return err
+	}
+
+	// Шаги ожидания сигнала и callback не отправляются воркеру
+	if engine.IsWaitingStep(step.Type) {
+		if err := o.parkStep(ctx, state, node, config); err != nil {
+			o.releaseUndispatchedResources(ctx, state, node)
+			return err
 		}
+		return nil
 	}
 
 	// Создаём task
@@ -285,12 +416,23 @@ func (o *Orchestrator) dispatchStep(ctx context.Context, state *RunState, node *
 
 	// Сохраняем в БД
 	if err := o.taskRepo.Create(ctx, task); err != nil {
+		o.releaseUndispatchedResources(ctx, state, node)
 		return fmt.Errorf("create task: %w", err)
 	}
 
 	// Помечаем шаг как running
 	state.MarkStepRunning(node.ID, task)
 
+	// Task external шага забирает внешний воркер (fetch-and-lock)
+	if step.Type == engine.StepTypeExternal {
+		o.logger.Debug("external task created",
+			"task_id", task.ID,
+			"run_id", state.RunID(),
+			"step_id", node.ID,
+		)
+		return nil
+	}
+
 	// Публикуем событие для Worker
 	if err := o.publisher.PublishTaskReady(ctx, task.ID, task.RunID); err != nil {
 		o.logger.Warn("failed to publish task.ready",
@@ -311,19 +453,381 @@ func (o *Orchestrator) dispatchStep(ctx context.Context, state *RunState, node *
 	return nil
 }
 
+// renderStepConfig рендерит config шага перед отправкой воркеру.
+// Transform получает контекст run для jq (JQInput): jq-выражения вычисляет
+// Worker, а не Orchestrator. Script, wasm и external получают контекст run
+// (StepGlobals): script — как глобальные переменные, wasm — на stdin,
+// external — в ответе fetch-and-lock.
+func renderStepConfig(stepType string, config map[string]any, tmplCtx *engine.Context) (map[string]any, error) {
+	switch stepType {
+	case "transform":
+		rendered, err := engine.RenderTransform(config, tmplCtx)
+		if err != nil {
+			return nil, err
+		}
+		input, err := engine.JQInput(tmplCtx)
+		if err != nil {
+			return nil, err
+		}
+		rendered[engine.StepGlobalsKey] = input
+		return rendered, nil
+	case engine.StepTypeScript, engine.StepTypeWasm, engine.StepTypeExternal:
+		rendered, err := engine.RenderConfig(config, tmplCtx)
+		if err != nil {
+			return nil, err
+		}
+		rendered[engine.StepGlobalsKey] = engine.StepGlobals(tmplCtx)
+		return rendered, nil
+	default:
+		return engine.RenderConfig(config, tmplCtx)
+	}
+}
+
+// parkStep создаёт WAITING task для шага approval / wait_for_signal / wait_for_callback.
+// Шаг остаётся running до сигнала или callback через API либо истечения timeout_sec.
+func (o *Orchestrator) parkStep(ctx context.Context, state *RunState, node *engine.Node, config map[string]any) error {
+	timeout, err := engine.WaitTimeout(node.Step.Type, config)
+	if err != nil {
+		return fmt.Errorf("parse wait config for %s: %w", node.ID, err)
+	}
+
+	task := &domain.Task{
+		ID:        uuid.New(),
+		RunID:     state.RunID(),
+		StepID:    node.ID,
+		Name:      node.Step.Name,
+		Type:      node.Step.Type,
+		Payload:   config,
+		CreatedAt: time.Now(),
+	}
+
+	// callback_url сохраняется в outputs — он виден в API, пока шаг ждёт
+	if node.Step.Type == engine.StepTypeWaitForCallback {
+		task.Outputs = map[string]any{
+			"callback_url": o.callbacks.URL(state.RunID(), node.ID),
+		}
+	}
+
+	var deadline *time.Time
+	if timeout > 0 {
+		at := task.CreatedAt.Add(timeout)
+		deadline = &at
+	}
+	task.MarkWaiting(deadline)
+
+	if err := o.taskRepo.Create(ctx, task); err != nil {
+		return fmt.Errorf("create task: %w", err)
+	}
+
+	state.MarkStepRunning(node.ID, task)
+
+	o.logger.Info("step waiting",
+		"task_id", task.ID,
+		"run_id", state.RunID(),
+		"step_id", node.ID,
+		"type", node.Step.Type,
+		"deadline", deadline,
+	)
+
+	return nil
+}
+
+// expireWaitingTasks завершает WAITING tasks с истёкшим timeout_sec
+// и продолжает выполнение их runs.
+func (o *Orchestrator) expireWaitingTasks(ctx context.Context) {
+	tasks, err := o.taskRepo.ListExpiredWaiting(ctx, o.batchSize)
+	if err != nil {
+		o.logger.Error("failed to list expired waiting tasks", "error", err)
+		return
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+
+		if err := engine.ApplyWaitTimeout(task); err != nil {
+			o.logger.Error("failed to apply wait timeout",
+				"task_id", task.ID,
+				"run_id", task.RunID,
+				"error", err,
+			)
+			continue
+		}
+
+		if err := o.taskRepo.CompleteWaiting(ctx, task); err != nil {
+			// ErrInvalidState — сигнал пришёл раньше, task уже завершён
+			if !errors.Is(err, repo.ErrInvalidState) {
+				o.logger.Error("failed to complete waiting task",
+					"task_id", task.ID,
+					"run_id", task.RunID,
+					"error", err,
+				)
+			}
+			continue
+		}
+
+		o.logger.Info("wait timed out",
+			"task_id", task.ID,
+			"run_id", task.RunID,
+			"step_id", task.StepID,
+			"status", task.Status,
+		)
+
+		payload := mq.TaskCompletedPayload{
+			TaskID:  task.ID,
+			RunID:   task.RunID,
+			StepID:  task.StepID,
+			Status:  string(task.Status),
+			Error:   task.Error,
+			Attempt: task.Attempt,
+		}
+		if err := o.processTaskCompleted(ctx, payload); err != nil {
+			o.logger.Error("failed to process timed out task",
+				"task_id", task.ID,
+				"run_id", task.RunID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// expireExternalLocks обрабатывает tasks external шагов, блокировка
+// которых истекла без ответа внешнего воркера: попытка считается
+// неудачной и повторяется по RetryPolicy шага либо шаг падает.
+func (o *Orchestrator) expireExternalLocks(ctx context.Context) {
+	tasks, err := o.taskRepo.ListExpiredExternal(ctx, o.batchSize)
+	if err != nil {
+		o.logger.Error("failed to list expired external tasks", "error", err)
+		return
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+
+		policy, err := o.stepRetryPolicy(ctx, task)
+		if err != nil {
+			o.logger.Error("failed to load retry policy",
+				"task_id", task.ID,
+				"run_id", task.RunID,
+				"error", err,
+			)
+			continue
+		}
+
+		retried := engine.ApplyExternalFailure(task, policy, "external task lock expired", false, time.Now())
+		if err := o.taskRepo.ExpireExternalLock(ctx, task); err != nil {
+			// ErrInvalidState — воркер успел завершить task или продлить блокировку
+			if !errors.Is(err, repo.ErrInvalidState) {
+				o.logger.Error("failed to expire external lock",
+					"task_id", task.ID,
+					"run_id", task.RunID,
+					"error", err,
+				)
+			}
+			continue
+		}
+
+		o.logger.Warn("external task lock expired",
+			"task_id", task.ID,
+			"run_id", task.RunID,
+			"step_id", task.StepID,
+			"attempt", task.Attempt,
+			"retry", retried,
+		)
+		if retried {
+			continue
+		}
+
+		payload := mq.TaskCompletedPayload{
+			TaskID:  task.ID,
+			RunID:   task.RunID,
+			StepID:  task.StepID,
+			Status:  string(task.Status),
+			Error:   task.Error,
+			Attempt: task.Attempt,
+		}
+		if err := o.processTaskCompleted(ctx, payload); err != nil {
+			o.logger.Error("failed to process expired external task",
+				"task_id", task.ID,
+				"run_id", task.RunID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// stepRetryPolicy возвращает RetryPolicy шага task по spec его run.
+func (o *Orchestrator) stepRetryPolicy(ctx context.Context, task *domain.Task) (*domain.RetryPolicy, error) {
+	if state := o.getActiveRun(task.RunID); state != nil {
+		return engine.StepRetryPolicy(&state.FlowVersion.Spec, task.StepID), nil
+	}
+
+	run, err := o.runRepo.GetByID(ctx, task.RunID)
+	if err != nil {
+		return nil, fmt.Errorf("get run: %w", err)
+	}
+	if run.SpecOverride != nil {
+		return engine.StepRetryPolicy(run.SpecOverride, task.StepID), nil
+	}
+	version, err := o.flowRepo.GetVersion(ctx, run.FlowID, run.Version)
+	if err != nil {
+		return nil, fmt.Errorf("get flow version: %w", err)
+	}
+	return engine.StepRetryPolicy(&version.Spec, task.StepID), nil
+}
+
+// expireOverdueRuns переводит в FAILED runs, не завершившиеся к дедлайну
+// (FlowSpec.TimeoutSec), и отменяет их незавершённые tasks.
+//
+// Компенсации не запускаются, а у COMPENSATING run прерываются:
+// дедлайн — жёсткая граница run.
+func (o *Orchestrator) expireOverdueRuns(ctx context.Context) {
+	runs, err := o.runRepo.ListOverdue(ctx, o.batchSize)
+	if err != nil {
+		o.logger.Error("failed to list overdue runs", "error", err)
+		return
+	}
+
+	for i := range runs {
+		if err := o.timeoutRun(ctx, &runs[i]); err != nil {
+			o.logger.Error("failed to time out run",
+				"run_id", runs[i].ID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// timeoutRun завершает просроченный run с ошибкой.
+func (o *Orchestrator) timeoutRun(ctx context.Context, run *domain.Run) error {
+	errMsg := "run deadline exceeded"
+	if run.StartedAt != nil && run.DeadlineAt != nil {
+		errMsg = fmt.Sprintf("run deadline exceeded: not finished within %s", run.DeadlineAt.Sub(*run.StartedAt))
+	}
+	state := o.getActiveRun(run.ID)
+	if err := o.abortRun(ctx, run, domain.RunStatusFailed, errMsg); err != nil {
+		return err
+	}
+	o.releaseConcurrencySlot(ctx, state)
+	return nil
+}
+
+// abortRun принудительно завершает выполняющийся run (дедлайн, политика
+// concurrency cancel_running): отменяет незавершённые tasks и переводит
+// run в status (FAILED или CANCELLED) с причиной errMsg.
+// Компенсации не запускаются. Очередь concurrency не запускается —
+// это делает вызывающий код (abortRun вызывается и при допуске run).
+func (o *Orchestrator) abortRun(ctx context.Context, run *domain.Run, status domain.RunStatus, errMsg string) error {
+	cancelled, err := o.taskRepo.CancelActive(ctx, run.ID, errMsg)
+	if err != nil {
+		return err
+	}
+
+	// Outputs шагов берём из активного состояния, если run в памяти
+	var steps map[string]map[string]any
+	if state := o.getActiveRun(run.ID); state != nil {
+		steps = state.StepOutputs()
+		run = state.Run
+	}
+
+	if status == domain.RunStatusCancelled {
+		run.MarkCancelled()
+		run.Error = errMsg
+	} else {
+		run.MarkFailed(errMsg)
+	}
+	if err := o.runRepo.Update(ctx, run); err != nil {
+		return fmt.Errorf("update run to %s: %w", strings.ToLower(string(status)), err)
+	}
+	o.removeActiveRun(run.ID)
+
+	o.logger.Warn("run aborted",
+		"run_id", run.ID,
+		"flow_id", run.FlowID,
+		"status", status,
+		"reason", errMsg,
+		"cancelled_tasks", cancelled,
+	)
+
+	o.publishRunCompleted(ctx, run, steps)
+	o.notifyRun(ctx, run)
+	o.releaseRunResources(ctx, run.ID)
+
+	return nil
+}
+
+// checkRunSLA создаёт уведомления sla_breach для runs, выполняющихся
+// дольше FlowSpec.SLASec. Run продолжает выполнение.
+func (o *Orchestrator) checkRunSLA(ctx context.Context) {
+	runs, err := o.runRepo.ClaimSLABreached(ctx, o.batchSize)
+	if err != nil {
+		o.logger.Error("failed to claim sla breached runs", "error", err)
+		return
+	}
+
+	for i := range runs {
+		run := &runs[i]
+
+		o.logger.Warn("run SLA breached",
+			"run_id", run.ID,
+			"flow_id", run.FlowID,
+			"started_at", run.StartedAt,
+			"sla_at", run.SLAAt,
+		)
+
+		if o.notifier == nil {
+			continue
+		}
+		if err := o.notifier.NotifySLABreach(ctx, run); err != nil {
+			o.logger.Warn("failed to create sla notifications",
+				"run_id", run.ID,
+				"error", err,
+			)
+		}
+	}
+}
+
 // completeRun завершает run (успешно или с ошибкой).
 func (o *Orchestrator) completeRun(ctx context.Context, state *RunState, success bool) error {
 	run := state.Run
 
+	// Outputs flow вычисляются над финальным контекстом;
+	// ошибка шаблона — ошибка run
+	var outputs map[string]any
+	var errMsg string
+	if success {
+		var err error
+		outputs, err = state.RenderOutputs()
+		if err != nil {
+			success = false
+			errMsg = fmt.Sprintf("render outputs: %v", err)
+		}
+	}
+
 	if success {
-		run.MarkSucceeded()
+		run.MarkSucceeded(outputs)
 		o.logger.Info("run succeeded",
 			"run_id", run.ID,
 			"duration", run.Duration(),
 		)
 	} else {
 		failedSteps := state.GetFailedSteps()
-		errMsg := fmt.Sprintf("steps failed: %v", failedSteps)
+		if errMsg == "" {
+			errMsg = fmt.Sprintf("steps failed: %v", failedSteps)
+		}
+
+		// Есть завершённые шаги с compensate — сначала выполняем компенсации
+		if state.StartCompensation() {
+			run.MarkCompensating(errMsg)
+			if err := o.runRepo.Update(ctx, run); err != nil {
+				return fmt.Errorf("update run to compensating: %w", err)
+			}
+			o.logger.Warn("run failed, compensating",
+				"run_id", run.ID,
+				"failed_steps", failedSteps,
+			)
+			return o.dispatchCompensation(ctx, state)
+		}
+
 		run.MarkFailed(errMsg)
 		o.logger.Warn("run failed",
 			"run_id", run.ID,
@@ -340,6 +844,129 @@ func (o *Orchestrator) completeRun(ctx context.Context, state *RunState, success
 	// Удаляем из активных
 	o.removeActiveRun(run.ID)
 
+	o.publishRunCompleted(ctx, run, state.StepOutputs())
+	o.notifyRun(ctx, run)
+	o.releaseRunResources(ctx, run.ID)
+	o.releaseConcurrencySlot(ctx, state)
+
+	return nil
+}
+
+// dispatchCompensation запускает следующую компенсацию упавшего run
+// или завершает run, если компенсаций больше нет.
+//
+// Компенсации выполняются по одной, в обратном топологическом порядке.
+// Config компенсации рендерится с outputs исходного шага в .Outputs.
+func (o *Orchestrator) dispatchCompensation(ctx context.Context, state *RunState) error {
+	for {
+		if state.IsCompensationDone() {
+			return o.finishCompensation(ctx, state)
+		}
+
+		stepID, comp, ok := state.NextCompensation()
+		if !ok {
+			// Компенсация уже выполняется — ждём её task.completed
+			return nil
+		}
+
+		if err := o.dispatchCompensationStep(ctx, state, stepID, comp); err != nil {
+			o.logger.Error("failed to dispatch compensation",
+				"run_id", state.RunID(),
+				"step_id", stepID,
+				"error", err,
+			)
+			state.MarkCompensationDone(stepID, false)
+			continue
+		}
+
+		return nil
+	}
+}
+
+// dispatchCompensationStep создаёт task компенсации шага и публикует его.
+func (o *Orchestrator) dispatchCompensationStep(ctx context.Context, state *RunState, stepID string, comp *domain.StepDef) error {
+	// Outputs исходного шага доступны как .Outputs
+	renderCtx := *state.Context
+	if stepCtx := state.Context.Steps[stepID]; stepCtx != nil {
+		renderCtx.Outputs = stepCtx.Outputs
+	}
+
+	config, err := renderStepConfig(comp.Type, comp.Config, &renderCtx)
+	if err != nil {
+		return fmt.Errorf("render compensate config for %s: %w", stepID, err)
+	}
+
+	name := comp.Name
+	if name == "" {
+		name = "compensate " + stepID
+	}
+
+	task := &domain.Task{
+		ID:        uuid.New(),
+		RunID:     state.RunID(),
+		StepID:    engine.CompensationStepID(stepID),
+		Name:      name,
+		Type:      comp.Type,
+		Status:    domain.TaskStatusQueued,
+		Payload:   config,
+		CreatedAt: time.Now(),
+	}
+
+	if err := o.taskRepo.Create(ctx, task); err != nil {
+		return fmt.Errorf("create compensation task: %w", err)
+	}
+	state.SetTask(task.StepID, task)
+
+	// Компенсацию external шага забирает внешний воркер
+	if comp.Type != engine.StepTypeExternal {
+		if err := o.publisher.PublishTaskReady(ctx, task.ID, task.RunID); err != nil {
+			o.logger.Warn("failed to publish task.ready",
+				"task_id", task.ID,
+				"run_id", state.RunID(),
+				"error", err,
+			)
+		}
+	}
+
+	o.logger.Info("compensation dispatched",
+		"task_id", task.ID,
+		"run_id", state.RunID(),
+		"step_id", stepID,
+		"type", comp.Type,
+	)
+
+	return nil
+}
+
+// finishCompensation завершает run после выполнения всех компенсаций.
+func (o *Orchestrator) finishCompensation(ctx context.Context, state *RunState) error {
+	run := state.Run
+
+	if failed := state.GetFailedCompensations(); len(failed) > 0 {
+		run.MarkCompensationFailed(fmt.Sprintf("compensation failed: %v", failed))
+		o.logger.Warn("run compensation failed",
+			"run_id", run.ID,
+			"failed_compensations", failed,
+		)
+	} else if !state.HasCompensations() {
+		// Активные шаги с compensate завершились ошибкой — отменять нечего
+		run.MarkFailed(run.Error)
+		o.logger.Warn("run failed", "run_id", run.ID, "duration", run.Duration())
+	} else {
+		run.MarkCompensated()
+		o.logger.Info("run compensated", "run_id", run.ID)
+	}
+
+	if err := o.runRepo.Update(ctx, run); err != nil {
+		return fmt.Errorf("update run status: %w", err)
+	}
+
+	o.removeActiveRun(run.ID)
+	o.publishRunCompleted(ctx, run, state.StepOutputs())
+	o.notifyRun(ctx, run)
+	o.releaseRunResources(ctx, run.ID)
+	o.releaseConcurrencySlot(ctx, state)
+
 	return nil
 }
 
@@ -356,9 +983,56 @@ func (o *Orchestrator) failRun(ctx context.Context, run *domain.Run, errMsg stri
 		"error", errMsg,
 	)
 
+	o.publishRunCompleted(ctx, run, nil)
+	o.notifyRun(ctx, run)
+
 	return fmt.Errorf("run failed: %s", errMsg)
 }
 
+// publishRunCompleted публикует событие run.completed в automata.events.
+// Ошибка публикации не влияет на run: статус уже сохранён в БД.
+func (o *Orchestrator) publishRunCompleted(ctx context.Context, run *domain.Run, steps map[string]map[string]any) {
+	if o.publisher == nil {
+		return
+	}
+
+	payload := mq.RunCompletedPayload{
+		RunID:      run.ID,
+		FlowID:     run.FlowID,
+		Version:    run.Version,
+		Status:     string(run.Status),
+		Error:      run.Error,
+		Inputs:     run.Inputs,
+		Outputs:    run.Outputs,
+		Steps:      steps,
+		IsSandbox:  run.IsSandbox,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+	}
+
+	if err := o.publisher.PublishRunCompleted(ctx, payload); err != nil {
+		o.logger.Warn("failed to publish run.completed",
+			"run_id", run.ID,
+			"error", err,
+		)
+	}
+}
+
+// notifyRun создаёт уведомления о завершённом run по правилам flow.
+// Ошибка не влияет на run: статус уже сохранён в БД.
+func (o *Orchestrator) notifyRun(ctx context.Context, run *domain.Run) {
+	if o.notifier == nil {
+		return
+	}
+
+	if err := o.notifier.Notify(ctx, run); err != nil {
+		o.logger.Warn("failed to create run notifications",
+			"run_id", run.ID,
+			"error", err,
+		)
+	}
+}
+
 // restoreRunState восстанавливает RunState из БД.
 // Используется когда task.completed приходит для run, которого нет в памяти
 // (после рестарта Orchestrator).
@@ -404,6 +1078,12 @@ func (o *Orchestrator) restoreRunState(ctx context.Context, runID uuid.UUID) (*R
 		return nil, fmt.Errorf("list tasks: %w", err)
 	}
 	state.RestoreFromTasks(tasks)
+	state.ExposeCallbacks(o.callbacks)
+
+	// Run упал до рестарта — продолжаем компенсации
+	if run.Status == domain.RunStatusCompensating {
+		state.StartCompensation()
+	}
 
 	// Добавляем в активные
 	if err := o.addActiveRun(state); err != nil {
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/engine"
 	"github.com/shaiso/Automata/internal/mq"
+	"github.com/shaiso/Automata/internal/notify"
 	"github.com/shaiso/Automata/internal/repo"
 )
 
@@ -29,9 +31,10 @@ const (
 //   - Финализирует runs (SUCCEEDED/FAILED)
 type Orchestrator struct {
 	// Repositories
-	runRepo  *repo.RunRepo
-	taskRepo *repo.TaskRepo
-	flowRepo *repo.FlowRepo
+	runRepo      *repo.RunRepo
+	taskRepo     *repo.TaskRepo
+	flowRepo     *repo.FlowRepo
+	resourceRepo *repo.ResourceRepo
 
 	// MQ
 	publisher *mq.Publisher
@@ -49,6 +52,12 @@ type Orchestrator struct {
 	pollInterval time.Duration
 	batchSize    int
 
+	// Подпись callback URL для шагов wait_for_callback
+	callbacks *engine.CallbackSigner
+
+	// Уведомления о завершении runs (опционально)
+	notifier *notify.Notifier
+
 	// Lifecycle
 	logger     *slog.Logger
 	cancelFunc context.CancelFunc
@@ -60,9 +69,10 @@ type Orchestrator struct {
 // Config — конфигурация Orchestrator.
 type Config struct {
 	// Repositories
-	RunRepo  *repo.RunRepo
-	TaskRepo *repo.TaskRepo
-	FlowRepo *repo.FlowRepo
+	RunRepo      *repo.RunRepo
+	TaskRepo     *repo.TaskRepo
+	FlowRepo     *repo.FlowRepo
+	ResourceRepo *repo.ResourceRepo // именованные ресурсы шагов (опционально)
 
 	// MQ
 	Publisher *mq.Publisher
@@ -72,6 +82,13 @@ type Config struct {
 	PollInterval time.Duration // интервал polling (default: 10s)
 	BatchSize    int           // количество runs за один poll (default: 100)
 
+	// Callbacks (wait_for_callback)
+	CallbackBaseURL string // публичный адрес API для callback URL
+	CallbackSecret  string // секрет подписи callback URL (общий с API)
+
+	// Notifications (опционально)
+	Notifier *notify.Notifier
+
 	// Logger
 	Logger *slog.Logger
 }
@@ -97,11 +114,14 @@ func New(cfg Config) *Orchestrator {
 		runRepo:      cfg.RunRepo,
 		taskRepo:     cfg.TaskRepo,
 		flowRepo:     cfg.FlowRepo,
+		resourceRepo: cfg.ResourceRepo,
 		publisher:    cfg.Publisher,
 		conn:         cfg.Conn,
 		activeRuns:   make(map[uuid.UUID]*RunState),
 		pollInterval: pollInterval,
 		batchSize:    batchSize,
+		callbacks:    engine.NewCallbackSigner(cfg.CallbackBaseURL, cfg.CallbackSecret),
+		notifier:     cfg.Notifier,
 		logger:       logger,
 	}
 }
@@ -159,6 +179,15 @@ func (o *Orchestrator) Start(ctx context.Context) error {
 		o.pollLoop(ctx)
 	}()
 
+	// Запускаем доставку уведомлений
+	if o.notifier != nil {
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+			o.notifier.Run(ctx)
+		}()
+	}
+
 	o.logger.Info("orchestrator started")
 	return nil
 }
@@ -218,6 +247,19 @@ func (o *Orchestrator) pollLoop(ctx context.Context) {
 
 // poll выполняет один цикл polling.
 func (o *Orchestrator) poll(ctx context.Context) {
+	// Истёкшие ожидания сигналов
+	o.expireWaitingTasks(ctx)
+
+	// Истёкшие блокировки external tasks
+	o.expireExternalLocks(ctx)
+
+	// Дедлайны и SLA runs
+	o.expireOverdueRuns(ctx)
+	o.checkRunSLA(ctx)
+
+	// Шаги, ожидающие слотов ресурсов
+	o.wakeResourceWaiters(ctx)
+
 	runs, err := o.runRepo.ListPending(ctx, o.batchSize)
 	if err != nil {
 		o.logger.Error("failed to list pending runs", "error", err)
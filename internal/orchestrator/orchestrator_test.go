@@ -1,11 +1,13 @@
 package orchestrator
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
 )
 
 // --- RunState Tests ---
@@ -420,6 +422,549 @@ func TestRunState_RestoreFromTasks(t *testing.T) {
 	}
 }
 
+func TestRunState_RestoreFromTasks_Waiting(t *testing.T) {
+	run := &domain.Run{ID: uuid.New()}
+	version := &domain.FlowVersion{
+		Spec: domain.FlowSpec{
+			Steps: []domain.StepDef{
+				{ID: "approve", Type: "approval", Config: map[string]any{"timeout_sec": float64(60)}},
+				{ID: "pay", Type: "http", DependsOn: []string{"approve"}, Condition: `eq .Steps.approve.Outputs.decision "approve"`},
+			},
+		},
+	}
+	state := NewRunState(run, version)
+	if err := state.Initialize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state.RestoreFromTasks([]domain.Task{
+		{ID: uuid.New(), StepID: "approve", Type: "approval", Status: domain.TaskStatusWaiting},
+	})
+
+	if !state.IsStepRunning("approve") {
+		t.Error("waiting step should be restored as running")
+	}
+	if ready := state.GetReadySteps(); len(ready) != 0 {
+		t.Errorf("expected no ready steps while waiting, got %d", len(ready))
+	}
+	if state.IsComplete() {
+		t.Error("run should not be complete while waiting for a signal")
+	}
+
+	// Сигнал получен — зависимый шаг готов и видит решение
+	task := state.GetTask("approve")
+	if err := engine.ApplySignal(task, "approve", map[string]any{"approver": "alice"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state.MarkStepCompleted("approve", task.Outputs)
+
+	ready := state.GetReadySteps()
+	if len(ready) != 1 || ready[0].ID != "pay" {
+		t.Fatalf("expected pay to be ready, got %v", ready)
+	}
+	if state.Context.Steps["approve"].Outputs["approver"] != "alice" {
+		t.Error("signal payload should become step outputs")
+	}
+}
+
+func TestRunState_ExposeCallbacks(t *testing.T) {
+	run := &domain.Run{ID: uuid.New()}
+	version := &domain.FlowVersion{
+		Spec: domain.FlowSpec{
+			Steps: []domain.StepDef{
+				{ID: "register", Type: "http", Config: map[string]any{"url": "http://vendor", "body": "{{ .Steps.vendor.Outputs.callback_url }}"}},
+				{ID: "vendor", Type: "wait_for_callback", DependsOn: []string{"register"}},
+			},
+		},
+	}
+	signer := engine.NewCallbackSigner("http://api", "secret")
+
+	state := NewRunState(run, version)
+	if err := state.Initialize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state.ExposeCallbacks(signer)
+
+	// URL доступен предыдущему шагу до запуска wait_for_callback
+	config, err := engine.RenderConfig(version.Spec.Steps[0].Config, state.Context)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config["body"] != signer.URL(run.ID, "vendor") {
+		t.Errorf("expected callback url in rendered config, got %v", config["body"])
+	}
+
+	// После рестарта результат завершённого шага не перезаписывается
+	restored := NewRunState(run, version)
+	_ = restored.Initialize()
+	restored.RestoreFromTasks([]domain.Task{
+		{ID: uuid.New(), StepID: "register", Status: domain.TaskStatusSucceeded},
+		{ID: uuid.New(), StepID: "vendor", Status: domain.TaskStatusSucceeded, Outputs: map[string]any{"body": "done"}},
+	})
+	restored.ExposeCallbacks(signer)
+
+	if restored.Context.Steps["vendor"].Outputs["body"] != "done" {
+		t.Error("restored outputs should not be overwritten")
+	}
+	if !restored.IsComplete() {
+		t.Error("restored run should be complete")
+	}
+}
+
+// newSagaState создаёт RunState заказа: reserve_stock → charge_card → create_shipment.
+func newSagaState(t *testing.T) *RunState {
+	t.Helper()
+
+	version := &domain.FlowVersion{
+		Spec: domain.FlowSpec{
+			Steps: []domain.StepDef{
+				{ID: "reserve_stock", Type: "http", Compensate: &domain.StepDef{Type: "http"}},
+				{ID: "notify", Type: "transform", DependsOn: []string{"reserve_stock"}},
+				{ID: "charge_card", Type: "http", DependsOn: []string{"reserve_stock"}, Compensate: &domain.StepDef{Type: "http"}},
+				{ID: "create_shipment", Type: "http", DependsOn: []string{"charge_card"}},
+			},
+		},
+	}
+	state := NewRunState(&domain.Run{ID: uuid.New()}, version)
+	if err := state.Initialize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return state
+}
+
+func TestRunState_CompensationOrder(t *testing.T) {
+	state := newSagaState(t)
+
+	state.MarkStepCompleted("reserve_stock", map[string]any{"reservation": "r1"})
+	state.MarkStepCompleted("notify", nil)
+	state.MarkStepCompleted("charge_card", map[string]any{"charge": "c1"})
+	state.MarkStepFailed("create_shipment", "boom")
+
+	if !state.StartCompensation() {
+		t.Fatal("expected compensation to start")
+	}
+
+	// Сначала refund (charge_card), затем release (reserve_stock)
+	stepID, comp, ok := state.NextCompensation()
+	if !ok || stepID != "charge_card" || comp == nil {
+		t.Fatalf("expected charge_card first, got %q", stepID)
+	}
+
+	// Следующая компенсация не запускается, пока выполняется текущая
+	if _, _, ok := state.NextCompensation(); ok {
+		t.Error("compensations should run one at a time")
+	}
+
+	state.MarkCompensationDone("charge_card", true)
+
+	stepID, _, ok = state.NextCompensation()
+	if !ok || stepID != "reserve_stock" {
+		t.Fatalf("expected reserve_stock second, got %q", stepID)
+	}
+	state.MarkCompensationDone("reserve_stock", false)
+
+	if !state.IsCompensationDone() {
+		t.Error("compensation should be done")
+	}
+	if failed := state.GetFailedCompensations(); len(failed) != 1 || failed[0] != "reserve_stock" {
+		t.Errorf("expected reserve_stock compensation failure, got %v", failed)
+	}
+}
+
+func TestRunState_CompensationNothingToUndo(t *testing.T) {
+	state := newSagaState(t)
+	state.MarkStepFailed("reserve_stock", "boom")
+
+	if state.StartCompensation() {
+		t.Error("no completed steps with compensate — compensation should not start")
+	}
+}
+
+func TestRunState_CompensationWaitsForActiveSteps(t *testing.T) {
+	for _, lateSuccess := range []bool{true, false} {
+		state := newSagaState(t)
+
+		// notify упал, пока соседний charge_card ещё выполняется
+		state.MarkStepCompleted("reserve_stock", map[string]any{"reservation": "r1"})
+		state.MarkStepRunning("charge_card", &domain.Task{StepID: "charge_card", Status: domain.TaskStatusRunning})
+		state.MarkStepFailed("notify", "boom")
+
+		if !state.StartCompensation() {
+			t.Fatal("expected compensation to start")
+		}
+		stepID, _, ok := state.NextCompensation()
+		if !ok || stepID != "reserve_stock" {
+			t.Fatalf("expected reserve_stock, got %q", stepID)
+		}
+		state.MarkCompensationDone("reserve_stock", true)
+
+		if state.IsCompensationDone() {
+			t.Fatal("compensation must wait for running charge_card")
+		}
+
+		if !lateSuccess {
+			state.MarkStepFailed("charge_card", "declined")
+			if !state.IsCompensationDone() {
+				t.Error("compensation should be done after charge_card failed")
+			}
+			continue
+		}
+
+		// charge_card успешно завершился после падения run — его тоже компенсируем
+		state.MarkStepCompleted("charge_card", map[string]any{"charge": "c1"})
+		state.EnqueueCompensation("charge_card")
+		if state.IsCompensationDone() {
+			t.Fatal("late charge_card must be compensated")
+		}
+
+		stepID, _, ok = state.NextCompensation()
+		if !ok || stepID != "charge_card" {
+			t.Fatalf("expected charge_card compensation, got %q", stepID)
+		}
+		state.MarkCompensationDone("charge_card", true)
+
+		if !state.IsCompensationDone() {
+			t.Error("compensation should be done")
+		}
+	}
+}
+
+func TestRunState_CompensationOnlyActiveSteps(t *testing.T) {
+	state := newSagaState(t)
+
+	// Ничего ещё не завершено, но reserve_stock с compensate выполняется
+	state.MarkStepRunning("reserve_stock", &domain.Task{StepID: "reserve_stock", Status: domain.TaskStatusQueued})
+	state.MarkStepFailed("create_shipment", "boom")
+
+	if !state.StartCompensation() {
+		t.Fatal("compensation should wait for running reserve_stock")
+	}
+	if state.IsCompensationDone() {
+		t.Fatal("compensation must not finish while reserve_stock is running")
+	}
+
+	state.MarkStepFailed("reserve_stock", "boom")
+	if !state.IsCompensationDone() {
+		t.Error("compensation should be done")
+	}
+	if state.HasCompensations() {
+		t.Error("nothing was compensated")
+	}
+}
+
+func TestRunState_CompensationRestore(t *testing.T) {
+	state := newSagaState(t)
+
+	// Рестарт во время компенсации: refund выполнен, release ещё нет
+	state.RestoreFromTasks([]domain.Task{
+		{ID: uuid.New(), StepID: "reserve_stock", Status: domain.TaskStatusSucceeded},
+		{ID: uuid.New(), StepID: "charge_card", Status: domain.TaskStatusSucceeded},
+		{ID: uuid.New(), StepID: "create_shipment", Status: domain.TaskStatusFailed},
+		{ID: uuid.New(), StepID: "charge_card.compensate", Status: domain.TaskStatusSucceeded},
+	})
+
+	if state.IsStepCompleted("charge_card.compensate") {
+		t.Error("compensation task should not be tracked as a DAG step")
+	}
+
+	if !state.StartCompensation() {
+		t.Fatal("expected compensation to resume")
+	}
+
+	stepID, _, ok := state.NextCompensation()
+	if !ok || stepID != "reserve_stock" {
+		t.Fatalf("expected only reserve_stock to remain, got %q", stepID)
+	}
+	state.MarkCompensationDone("reserve_stock", true)
+
+	if !state.IsCompensationDone() || len(state.GetFailedCompensations()) != 0 {
+		t.Error("compensation should be done without failures")
+	}
+}
+
+// newParallelState создаёт RunState с parallel шагом из трёх веток.
+func newParallelState(t *testing.T, config map[string]any) *RunState {
+	t.Helper()
+
+	run := &domain.Run{ID: uuid.New()}
+	version := &domain.FlowVersion{
+		Spec: domain.FlowSpec{
+			Steps: []domain.StepDef{
+				{
+					ID:     "fanout",
+					Type:   "parallel",
+					Config: config,
+					Branches: []domain.Branch{
+						{ID: "a", Steps: []domain.StepDef{{ID: "fetch", Type: "http"}}},
+						{ID: "b", Steps: []domain.StepDef{{ID: "fetch", Type: "http"}}},
+						{ID: "c", Steps: []domain.StepDef{{ID: "fetch", Type: "http"}}},
+					},
+				},
+				{ID: "report", Type: "transform", DependsOn: []string{"fanout"}},
+			},
+		},
+	}
+	state := NewRunState(run, version)
+	if err := state.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	state.MarkStepCompleted("fanout", nil)
+	return state
+}
+
+func TestRunState_ParallelAggregatesOutputs(t *testing.T) {
+	state := newParallelState(t, nil)
+
+	state.MarkStepCompleted("fanout.a.fetch", map[string]any{"n": 1})
+	state.MarkStepCompleted("fanout.b.fetch", map[string]any{"n": 2})
+	state.MarkStepCompleted("fanout.c.fetch", map[string]any{"n": 3})
+
+	ready := state.GetReadySteps()
+	if len(ready) != 1 || ready[0].ID != "report" {
+		t.Fatalf("expected report to be ready, got %d nodes", len(ready))
+	}
+
+	stepCtx := state.Context.Steps["fanout"]
+	if stepCtx == nil || stepCtx.Status != "SUCCEEDED" {
+		t.Fatal("fanout should be succeeded in context")
+	}
+
+	branchB, ok := stepCtx.Outputs["b"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected branch b outputs, got %T", stepCtx.Outputs["b"])
+	}
+	fetch, ok := branchB["fetch"].(map[string]any)
+	if !ok || fetch["n"] != 2 {
+		t.Errorf("unexpected outputs for fanout.b.fetch: %v", branchB["fetch"])
+	}
+
+	// Outputs доступны в шаблонах следующих шагов
+	got, err := engine.Render("{{ .Steps.fanout.Outputs.c.fetch.n }}", state.Context)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got != "3" {
+		t.Errorf("expected 3, got %s", got)
+	}
+}
+
+func TestRunState_ParallelFailFast(t *testing.T) {
+	state := newParallelState(t, nil)
+
+	state.MarkStepRunning("fanout.b.fetch", &domain.Task{})
+	state.MarkStepFailed("fanout.a.fetch", "boom")
+
+	if !state.HasFailed() {
+		t.Error("fail_fast parallel should fail the run on first branch failure")
+	}
+
+	// Оставшиеся ветки упавшего parallel не запускаются
+	for _, node := range state.GetReadySteps() {
+		t.Errorf("unexpected ready step %s", node.ID)
+	}
+}
+
+func TestOrchestrator_DispatchParallelCondition(t *testing.T) {
+	newState := func(t *testing.T, enabled bool) *RunState {
+		t.Helper()
+		run := &domain.Run{ID: uuid.New(), Inputs: map[string]any{"enabled": enabled}}
+		version := &domain.FlowVersion{
+			Spec: domain.FlowSpec{
+				Steps: []domain.StepDef{
+					{
+						ID:        "fanout",
+						Type:      "parallel",
+						Condition: ".Inputs.enabled",
+						Branches: []domain.Branch{
+							{ID: "a", Steps: []domain.StepDef{{ID: "fetch", Type: "http"}}},
+							{ID: "b", Steps: []domain.StepDef{{
+								ID:   "inner",
+								Type: "parallel",
+								Branches: []domain.Branch{
+									{ID: "x", Steps: []domain.StepDef{{ID: "fetch", Type: "http"}}},
+								},
+							}}},
+						},
+					},
+					{ID: "report", Type: "transform", DependsOn: []string{"fanout"}},
+				},
+			},
+		}
+		state := NewRunState(run, version)
+		if err := state.Initialize(); err != nil {
+			t.Fatalf("initialize: %v", err)
+		}
+		return state
+	}
+
+	orch := New(Config{})
+
+	t.Run("condition false skips branches", func(t *testing.T) {
+		state := newState(t, false)
+		if err := orch.dispatchStep(t.Context(), state, state.DAG.Nodes["fanout"]); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+
+		ready := state.GetReadySteps()
+		if len(ready) != 1 || ready[0].ID != "report" {
+			t.Fatalf("expected only report to be ready, got %v", nodeIDs(ready))
+		}
+		for _, id := range []string{"fanout.a.fetch", "fanout.b.inner", "fanout.b.inner.x.fetch", "fanout.join"} {
+			if !state.IsStepCompleted(id) {
+				t.Errorf("%s should be skipped", id)
+			}
+		}
+		if out := state.Context.Steps["fanout"].Outputs; out["skipped"] != true {
+			t.Errorf("expected fanout skipped outputs, got %v", out)
+		}
+	})
+
+	t.Run("condition true runs branches", func(t *testing.T) {
+		state := newState(t, true)
+		if err := orch.dispatchStep(t.Context(), state, state.DAG.Nodes["fanout"]); err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+
+		ready := nodeIDs(state.GetReadySteps())
+		if len(ready) != 2 || ready["fanout.a.fetch"] != true || ready["fanout.b.inner"] != true {
+			t.Errorf("expected branch steps to be ready, got %v", ready)
+		}
+	})
+}
+
+func TestRunState_ParallelWaitAll(t *testing.T) {
+	state := newParallelState(t, map[string]any{"failure_policy": "wait_all"})
+
+	state.MarkStepFailed("fanout.a.fetch", "boom")
+	if state.HasFailed() {
+		t.Fatal("wait_all should wait for remaining branches")
+	}
+
+	state.MarkStepCompleted("fanout.b.fetch", nil)
+	state.MarkStepCompleted("fanout.c.fetch", nil)
+
+	if !state.HasFailed() {
+		t.Error("wait_all should fail when any branch failed")
+	}
+	if state.Context.Steps["fanout"].Status != "FAILED" {
+		t.Errorf("expected fanout FAILED, got %s", state.Context.Steps["fanout"].Status)
+	}
+}
+
+func TestRunState_ParallelMinSuccess(t *testing.T) {
+	t.Run("enough branches succeeded", func(t *testing.T) {
+		state := newParallelState(t, map[string]any{"failure_policy": "min_success", "min_success": float64(2)})
+
+		state.MarkStepFailed("fanout.a.fetch", "boom")
+		state.MarkStepCompleted("fanout.b.fetch", map[string]any{"ok": true})
+		state.MarkStepCompleted("fanout.c.fetch", map[string]any{"ok": true})
+
+		if state.HasFailed() {
+			t.Fatal("min_success=2 should tolerate one failed branch")
+		}
+
+		outputs := state.Context.Steps["fanout"].Outputs
+		if branchA := outputs["a"].(map[string]any); len(branchA) != 0 {
+			t.Errorf("failed branch should have no outputs, got %v", branchA)
+		}
+
+		state.MarkStepCompleted("report", nil)
+		if !state.IsComplete() {
+			t.Error("run should be complete")
+		}
+	})
+
+	t.Run("success unreachable", func(t *testing.T) {
+		state := newParallelState(t, map[string]any{"failure_policy": "min_success", "min_success": 2})
+
+		state.MarkStepRunning("fanout.c.fetch", &domain.Task{})
+		state.MarkStepFailed("fanout.a.fetch", "boom")
+		state.MarkStepFailed("fanout.b.fetch", "boom")
+
+		if !state.HasFailed() {
+			t.Error("parallel should fail as soon as min_success is unreachable")
+		}
+	})
+}
+
+func TestRunState_ParallelMaxConcurrency(t *testing.T) {
+	state := newParallelState(t, map[string]any{"max_concurrency": 2})
+
+	ready := state.GetReadySteps()
+	if len(ready) != 2 {
+		t.Fatalf("expected 2 ready steps, got %d", len(ready))
+	}
+	for _, node := range ready {
+		state.MarkStepRunning(node.ID, &domain.Task{})
+	}
+
+	if ready := state.GetReadySteps(); len(ready) != 0 {
+		t.Errorf("expected no ready steps while 2 branches run, got %d", len(ready))
+	}
+
+	state.MarkStepCompleted(ready[0].ID, nil)
+
+	ready = state.GetReadySteps()
+	if len(ready) != 1 {
+		t.Fatalf("expected third branch to start, got %d ready steps", len(ready))
+	}
+}
+
+func TestRunState_Initialize_InvalidParallelConfig(t *testing.T) {
+	run := &domain.Run{ID: uuid.New()}
+	version := &domain.FlowVersion{
+		Spec: domain.FlowSpec{
+			Steps: []domain.StepDef{
+				{
+					ID:     "fanout",
+					Type:   "parallel",
+					Config: map[string]any{"failure_policy": "best_effort"},
+					Branches: []domain.Branch{
+						{ID: "a", Steps: []domain.StepDef{{ID: "fetch", Type: "http"}}},
+					},
+				},
+			},
+		},
+	}
+
+	err := NewRunState(run, version).Initialize()
+	if !errors.Is(err, ErrInvalidFlowSpec) {
+		t.Errorf("expected ErrInvalidFlowSpec, got %v", err)
+	}
+}
+
+func TestRunState_RenderOutputs(t *testing.T) {
+	run := &domain.Run{ID: uuid.New(), Inputs: map[string]any{"date": "2026-01-01"}}
+	version := &domain.FlowVersion{
+		Spec: domain.FlowSpec{
+			Steps: []domain.StepDef{
+				{ID: "fetch", Type: "http", Config: map[string]any{"url": "http://example.com"}},
+			},
+			Outputs: map[string]string{
+				"status": "{{ .Steps.fetch.Outputs.status }}",
+				"report": "report for {{ .Inputs.date }}",
+			},
+		},
+	}
+
+	state := NewRunState(run, version)
+	if err := state.Initialize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state.MarkStepCompleted("fetch", map[string]any{"status": 200})
+
+	outputs, err := state.RenderOutputs()
+	if err != nil {
+		t.Fatalf("RenderOutputs() error = %v", err)
+	}
+	if outputs["status"] != 200 {
+		t.Errorf("status = %v (%T), want 200", outputs["status"], outputs["status"])
+	}
+	if outputs["report"] != "report for 2026-01-01" {
+		t.Errorf("report = %v", outputs["report"])
+	}
+}
+
 func TestRunState_RunID(t *testing.T) {
 	runID := uuid.New()
 	run := &domain.Run{ID: runID}
@@ -567,3 +1112,12 @@ func TestOrchestrator_IsStopped(t *testing.T) {
 		t.Error("should be stopped")
 	}
 }
+
+// nodeIDs возвращает множество ID узлов.
+func nodeIDs(nodes []*engine.Node) map[string]bool {
+	ids := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		ids[node.ID] = true
+	}
+	return ids
+}
@@ -0,0 +1,342 @@
+package orchestrator
+
+import (
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/steps"
+)
+
+// branchStatus — состояние ветки parallel шага.
+type branchStatus int
+
+const (
+	// branchPending — ни один шаг ветки ещё не запускался.
+	branchPending branchStatus = iota
+
+	// branchRunning — ветка начала выполнение, но ещё не завершена.
+	branchRunning
+
+	// branchSucceeded — все шаги ветки завершены успешно.
+	branchSucceeded
+
+	// branchFailed — ветка завершена, часть шагов упала или не будет выполнена.
+	branchFailed
+)
+
+// Все методы ниже вызываются под s.mu.
+
+// resolveJoins разрешает join-узлы parallel шагов согласно failure_policy.
+//
+// Join завершается успешно или с ошибкой, когда этого требует политика.
+// При завершении outputs веток собираются в контекст parallel шага:
+// .steps.<parallel>.outputs.<branch>.<step>.
+//
+// Join-узлы обходятся в топологическом порядке, поэтому вложенные parallel
+// разрешаются раньше внешних. Цикл повторяется, пока разрешаются новые join.
+func (s *RunState) resolveJoins() {
+	for {
+		blocked := s.blockedNodes()
+		changed := false
+
+		for _, node := range s.DAG.Order {
+			if !node.IsJoin || s.completed[node.ID] || s.failed[node.ID] {
+				continue
+			}
+			if s.resolveJoin(node, blocked) {
+				changed = true
+			}
+		}
+
+		if !changed {
+			return
+		}
+	}
+}
+
+// resolveJoin пытается разрешить один join-узел.
+// Возвращает true, если join завершён (успешно или с ошибкой).
+func (s *RunState) resolveJoin(join *engine.Node, blocked map[string]bool) bool {
+	parallelID := join.ParallelID
+	parallel := s.DAG.Nodes[parallelID]
+	if parallel == nil || parallel.Step == nil {
+		return false
+	}
+	cfg := s.parallels[parallelID]
+
+	var succeeded, failed int
+	total := len(parallel.Step.Branches)
+	for i := range parallel.Step.Branches {
+		switch s.branchStatus(parallelID, &parallel.Step.Branches[i], blocked) {
+		case branchSucceeded:
+			succeeded++
+		case branchFailed:
+			failed++
+		}
+	}
+	finished := succeeded+failed == total
+
+	var success bool
+	switch cfg.FailurePolicy {
+	case engine.FailurePolicyWaitAll:
+		if !finished {
+			return false
+		}
+		success = failed == 0
+
+	case engine.FailurePolicyMinSuccess:
+		// Падаем сразу, если нужное количество успешных веток уже недостижимо
+		if total-failed < cfg.MinSuccess {
+			success = false
+			break
+		}
+		if !finished {
+			return false
+		}
+		success = succeeded >= cfg.MinSuccess
+
+	default: // fail_fast
+		if failed > 0 {
+			success = false
+			break
+		}
+		if !finished {
+			return false
+		}
+		success = true
+	}
+
+	outputs := s.collectParallelOutputs(parallelID, parallel.Step)
+	if success {
+		s.completed[join.ID] = true
+		s.Context.AddStepResult(parallelID, outputs, string(domain.TaskStatusSucceeded))
+	} else {
+		s.failed[join.ID] = true
+		s.Context.AddStepResult(parallelID, outputs, string(domain.TaskStatusFailed))
+	}
+
+	return true
+}
+
+// branchStatus вычисляет состояние ветки по exit-узлам её шагов
+// (для вложенного parallel — по его join).
+func (s *RunState) branchStatus(parallelID string, branch *domain.Branch, blocked map[string]bool) branchStatus {
+	started := false
+	done := true
+	hasFailed := false
+
+	for i := range branch.Steps {
+		step := &branch.Steps[i]
+		nodeID := parallelID + "." + branch.ID + "." + step.ID
+		exitID := nodeID
+		if step.Type == "parallel" {
+			exitID = nodeID + ".join"
+		}
+
+		switch {
+		case s.completed[exitID]:
+			started = true
+		case s.failed[exitID]:
+			started = true
+			hasFailed = true
+		case blocked[nodeID]:
+			// Шаг никогда не будет выполнен из-за упавшей зависимости
+			hasFailed = true
+		default:
+			done = false
+			if s.running[nodeID] || s.completed[nodeID] {
+				started = true
+			}
+		}
+	}
+
+	switch {
+	case done && hasFailed:
+		return branchFailed
+	case done:
+		return branchSucceeded
+	case started:
+		return branchRunning
+	default:
+		return branchPending
+	}
+}
+
+// blockedNodes возвращает узлы, которые никогда не будут выполнены:
+// зависящие от упавших (или таких же заблокированных) узлов,
+// а также находящиеся внутри упавшего parallel.
+func (s *RunState) blockedNodes() map[string]bool {
+	blocked := make(map[string]bool)
+
+	for _, node := range s.DAG.Order {
+		if node.IsJoin || s.completed[node.ID] || s.failed[node.ID] || s.running[node.ID] {
+			continue
+		}
+
+		if s.insideFailedParallel(node) {
+			blocked[node.ID] = true
+			continue
+		}
+
+		for _, dep := range node.DependsOn {
+			if s.failed[dep.ID] || blocked[dep.ID] {
+				blocked[node.ID] = true
+				break
+			}
+		}
+	}
+
+	return blocked
+}
+
+// insideFailedParallel проверяет, упал ли один из parallel, содержащих узел.
+func (s *RunState) insideFailedParallel(node *engine.Node) bool {
+	for parallelID := node.ParallelID; parallelID != ""; {
+		if s.failed[parallelID+".join"] {
+			return true
+		}
+		parent := s.DAG.Nodes[parallelID]
+		if parent == nil {
+			return false
+		}
+		parallelID = parent.ParallelID
+	}
+	return false
+}
+
+// SkipParallel пропускает parallel шаг с невыполненным condition:
+// сам шаг, все шаги его веток (включая вложенные parallel) и join
+// завершаются без запуска с outputs {"skipped": true}.
+func (s *RunState) SkipParallel(parallelID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	skipped := map[string]any{"skipped": true}
+	for _, node := range s.DAG.Order {
+		if node.ID != parallelID && !s.insideParallel(node, parallelID) {
+			continue
+		}
+		s.completed[node.ID] = true
+		if !node.IsJoin {
+			s.Context.AddStepResult(node.ID, skipped, string(domain.TaskStatusSucceeded))
+		}
+	}
+
+	s.resolveJoins()
+}
+
+// insideParallel проверяет, находится ли узел (в т.ч. join вложенного
+// parallel или join самого parallelID) внутри parallel шага parallelID.
+func (s *RunState) insideParallel(node *engine.Node, parallelID string) bool {
+	for id := node.ParallelID; id != ""; {
+		if id == parallelID {
+			return true
+		}
+		parent := s.DAG.Nodes[id]
+		if parent == nil {
+			return false
+		}
+		id = parent.ParallelID
+	}
+	return false
+}
+
+// enclosingParallel возвращает ID parallel шага, внутри ветки которого
+// находится узел. Для join — parallel, содержащий сам parallel шаг.
+func (s *RunState) enclosingParallel(node *engine.Node) string {
+	if !node.IsJoin {
+		return node.ParallelID
+	}
+	if parallel := s.DAG.Nodes[node.ParallelID]; parallel != nil {
+		return parallel.ParallelID
+	}
+	return ""
+}
+
+// collectParallelOutputs собирает outputs успешно завершённых шагов веток.
+func (s *RunState) collectParallelOutputs(parallelID string, step *domain.StepDef) map[string]any {
+	branchOutputs := make(map[string]map[string]map[string]any, len(step.Branches))
+
+	for _, branch := range step.Branches {
+		stepOutputs := make(map[string]map[string]any, len(branch.Steps))
+		for i := range branch.Steps {
+			nodeID := parallelID + "." + branch.ID + "." + branch.Steps[i].ID
+			exitID := nodeID
+			if branch.Steps[i].Type == "parallel" {
+				exitID = nodeID + ".join"
+			}
+			if !s.completed[exitID] {
+				continue
+			}
+			if stepCtx := s.Context.Steps[nodeID]; stepCtx != nil {
+				stepOutputs[branch.Steps[i].ID] = stepCtx.Outputs
+			}
+		}
+		branchOutputs[branch.ID] = stepOutputs
+	}
+
+	return steps.AggregateParallelOutputs(branchOutputs)
+}
+
+// filterByConcurrency отбрасывает готовые узлы, запуск которых нарушит
+// max_concurrency родительского parallel, а также узлы упавших parallel.
+//
+// Ограничение действует на ветки: шаги уже начатой ветки запускаются
+// без ограничений, новая ветка стартует, только если число выполняющихся
+// веток меньше max_concurrency.
+func (s *RunState) filterByConcurrency(ready []*engine.Node) []*engine.Node {
+	blocked := s.blockedNodes()
+	admitted := make(map[string]map[string]bool)
+	result := make([]*engine.Node, 0, len(ready))
+
+	for _, node := range ready {
+		if s.insideFailedParallel(node) {
+			continue
+		}
+		if node.ParallelID == "" || s.admitBranch(node, admitted, blocked) {
+			result = append(result, node)
+		}
+	}
+
+	return result
+}
+
+// admitBranch проверяет, можно ли запустить шаг ветки с учётом max_concurrency.
+// admitted — ветки, допущенные к старту в текущем вызове.
+func (s *RunState) admitBranch(node *engine.Node, admitted map[string]map[string]bool, blocked map[string]bool) bool {
+	cfg := s.parallels[node.ParallelID]
+	if cfg.MaxConcurrency <= 0 {
+		return true
+	}
+
+	parallel := s.DAG.Nodes[node.ParallelID]
+	if parallel == nil || parallel.Step == nil {
+		return true
+	}
+
+	if admitted[node.ParallelID] == nil {
+		admitted[node.ParallelID] = make(map[string]bool)
+	}
+	if admitted[node.ParallelID][node.BranchID] {
+		return true
+	}
+
+	active := len(admitted[node.ParallelID])
+	for i := range parallel.Step.Branches {
+		branch := &parallel.Step.Branches[i]
+		status := s.branchStatus(node.ParallelID, branch, blocked)
+		if branch.ID == node.BranchID && status != branchPending {
+			// Ветка уже выполняется
+			return true
+		}
+		if status == branchRunning {
+			active++
+		}
+	}
+
+	if active >= cfg.MaxConcurrency {
+		return false
+	}
+
+	admitted[node.ParallelID][node.BranchID] = true
+	return true
+}
@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/engine"
+)
+
+// acquireResources занимает слоты ресурсов шага перед dispatch.
+// Возвращает false, если шаг поставлен в очередь ожидания: он останется
+// готовым и будет запущен wakeResourceWaiters после освобождения слота.
+func (o *Orchestrator) acquireResources(ctx context.Context, state *RunState, node *engine.Node) (bool, error) {
+	resources := node.Step.Resources
+	if len(resources) == 0 {
+		return true, nil
+	}
+	if o.resourceRepo == nil {
+		return false, fmt.Errorf("step %s declares resources, but resource repo is not configured", node.ID)
+	}
+
+	acquired, err := o.resourceRepo.Acquire(ctx, state.RunID(), node.ID, resources)
+	if err != nil {
+		return false, fmt.Errorf("acquire resources %v: %w", resources, err)
+	}
+	if !acquired {
+		o.logger.Debug("step waiting for resources",
+			"run_id", state.RunID(),
+			"step_id", node.ID,
+			"resources", resources,
+		)
+	}
+	return acquired, nil
+}
+
+// releaseStepResources освобождает слоты завершившегося шага.
+// Возвращает true, если слоты были заняты (стоит разбудить очередь).
+func (o *Orchestrator) releaseStepResources(ctx context.Context, runID uuid.UUID, stepID string) bool {
+	if o.resourceRepo == nil {
+		return false
+	}
+
+	released, err := o.resourceRepo.Release(ctx, runID, stepID)
+	if err != nil {
+		// Слоты останутся занятыми до завершения run (releaseRunResources)
+		o.logger.Error("failed to release step resources",
+			"run_id", runID,
+			"step_id", stepID,
+			"error", err,
+		)
+		return false
+	}
+	return released > 0
+}
+
+// releaseUndispatchedResources освобождает слоты шага, task которого не
+// удалось создать: иначе слоты держались бы до завершения run и блокировали
+// другие runs. Ожидающие шаги не будятся сразу — их запустит следующий poll
+// (при недоступной БД немедленный повтор снова занял бы и освободил слоты).
+func (o *Orchestrator) releaseUndispatchedResources(ctx context.Context, state *RunState, node *engine.Node) {
+	if len(node.Step.Resources) == 0 {
+		return
+	}
+	o.releaseStepResources(ctx, state.RunID(), node.ID)
+}
+
+// releaseRunResources освобождает все слоты завершённого run и убирает
+// его шаги из очереди, затем запускает ожидающие шаги других runs.
+func (o *Orchestrator) releaseRunResources(ctx context.Context, runID uuid.UUID) {
+	if o.resourceRepo == nil {
+		return
+	}
+
+	released, err := o.resourceRepo.ReleaseRun(ctx, runID)
+	if err != nil {
+		o.logger.Error("failed to release run resources", "run_id", runID, "error", err)
+		return
+	}
+	if released > 0 {
+		o.wakeResourceWaiters(ctx)
+	}
+}
+
+// wakeResourceWaiters повторяет dispatch для runs, шаги которых ждут слотов.
+// Вызывается после освобождения слотов и при каждом poll (изменение
+// capacity через API, рестарт Orchestrator). Порядок выдачи слотов
+// определяет очередь в БД, а не порядок обхода runs.
+func (o *Orchestrator) wakeResourceWaiters(ctx context.Context) {
+	if o.resourceRepo == nil {
+		return
+	}
+
+	runIDs, err := o.resourceRepo.ListWaitingRuns(ctx, o.batchSize)
+	if err != nil {
+		o.logger.Error("failed to list runs waiting for resources", "error", err)
+		return
+	}
+
+	for _, runID := range runIDs {
+		state := o.getActiveRun(runID)
+		if state == nil {
+			state, err = o.restoreRunState(ctx, runID)
+			if err != nil {
+				o.logger.Error("failed to restore run waiting for resources",
+					"run_id", runID,
+					"error", err,
+				)
+				continue
+			}
+		}
+
+		// Run завершён или компенсируется — его шаги больше не запустятся
+		if state == nil || state.IsCompensating() {
+			if _, err := o.resourceRepo.CancelWaits(ctx, runID); err != nil {
+				o.logger.Warn("failed to cancel resource waits", "run_id", runID, "error", err)
+			}
+			continue
+		}
+
+		if err := o.dispatchReadySteps(ctx, state); err != nil {
+			o.logger.Error("failed to dispatch steps waiting for resources",
+				"run_id", runID,
+				"error", err,
+			)
+		}
+	}
+}
@@ -12,7 +12,8 @@ import (
 // RunState — состояние выполнения одного run в памяти.
 //
 // RunState создаётся когда Orchestrator начинает обработку run
-// и удаляется когда run завершается (SUCCEEDED/FAILED/CANCELLED).
+// и удаляется когда run завершается (SUCCEEDED/FAILED/CANCELLED,
+// а при компенсации — COMPENSATED/COMPENSATION_FAILED).
 //
 // Содержит:
 //   - Кэш данных из БД (Run, FlowVersion)
@@ -45,6 +46,12 @@ type RunState struct {
 	// tasks — созданные tasks (stepID → Task).
 	tasks map[string]*domain.Task
 
+	// parallels — настройки parallel шагов (полный ID узла → config).
+	parallels map[string]engine.ParallelConfig
+
+	// compensation — состояние компенсаций (saga), nil пока run не упал.
+	compensation *compensationState
+
 	// mu — мьютекс для потокобезопасного доступа.
 	mu sync.RWMutex
 }
@@ -58,6 +65,7 @@ func NewRunState(run *domain.Run, version *domain.FlowVersion) *RunState {
 		running:     make(map[string]bool),
 		failed:      make(map[string]bool),
 		tasks:       make(map[string]*domain.Task),
+		parallels:   make(map[string]engine.ParallelConfig),
 	}
 }
 
@@ -80,19 +88,55 @@ func (s *RunState) Initialize() error {
 	}
 	s.DAG = dag
 
+	// Настройки parallel шагов (уже провалидированы)
+	for id, node := range dag.Nodes {
+		if node.Step != nil && node.Step.Type == "parallel" {
+			cfg, err := engine.ParseParallelConfig(node.Step)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidFlowSpec, err)
+			}
+			s.parallels[id] = cfg
+		}
+	}
+
 	// 3. Создание контекста с inputs
 	s.Context = engine.NewContext(s.Run.Inputs)
 
 	return nil
 }
 
+// ExposeCallbacks добавляет в контекст callback URL шагов wait_for_callback.
+//
+// URL доступен как .Steps.<id>.Outputs.callback_url ещё до запуска шага,
+// чтобы предыдущий или параллельный шаг мог передать его внешней системе.
+// Результаты уже завершённых шагов (после RestoreFromTasks) не перезаписываются.
+func (s *RunState) ExposeCallbacks(signer *engine.CallbackSigner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, node := range s.DAG.Nodes {
+		if node.Step == nil || node.Step.Type != engine.StepTypeWaitForCallback {
+			continue
+		}
+		if _, exists := s.Context.Steps[id]; exists {
+			continue
+		}
+		s.Context.AddStepResult(id, map[string]any{
+			"callback_url": signer.URL(s.Run.ID, id),
+		}, string(domain.TaskStatusWaiting))
+	}
+}
+
 // GetReadySteps возвращает шаги, готовые к выполнению.
 // Шаг готов, если все его зависимости завершены и он ещё не запущен.
+// Шаги веток сверх max_concurrency и шаги упавших parallel не возвращаются.
 func (s *RunState) GetReadySteps() []*engine.Node {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return s.DAG.GetReadyNodes(s.completed, s.running)
+	s.resolveJoins()
+
+	return s.filterByConcurrency(s.DAG.GetReadyNodes(s.completed, s.running))
 }
 
 // MarkStepRunning помечает шаг как выполняющийся.
@@ -115,6 +159,8 @@ func (s *RunState) MarkStepCompleted(stepID string, outputs map[string]any) {
 
 	// Добавляем результат в контекст
 	s.Context.AddStepResult(stepID, outputs, string(domain.TaskStatusSucceeded))
+
+	s.resolveJoins()
 }
 
 // MarkStepFailed помечает шаг как упавший.
@@ -127,6 +173,8 @@ func (s *RunState) MarkStepFailed(stepID string, errMsg string) {
 
 	// Добавляем результат в контекст (со статусом FAILED)
 	s.Context.AddStepResult(stepID, nil, string(domain.TaskStatusFailed))
+
+	s.resolveJoins()
 }
 
 // IsStepRunning проверяет, выполняется ли шаг.
@@ -162,25 +210,47 @@ func (s *RunState) SetTask(stepID string, task *domain.Task) {
 }
 
 // IsComplete проверяет, все ли шаги завершены (успешно или с ошибкой).
+// Шаги, которые не будут выполнены из-за упавших зависимостей
+// (например, в упавшей ветке min_success), считаются завершёнными.
 func (s *RunState) IsComplete() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	blocked := s.blockedNodes()
+
 	// Проверяем, что все исполняемые узлы завершены
 	for _, node := range s.DAG.GetExecutableNodes() {
-		if !s.completed[node.ID] && !s.failed[node.ID] {
+		if !s.completed[node.ID] && !s.failed[node.ID] && !blocked[node.ID] {
 			return false
 		}
 	}
 	return true
 }
 
-// HasFailed проверяет, есть ли упавшие шаги.
+// HasFailed проверяет, есть ли упавшие шаги, приводящие к падению run.
+//
+// Ошибки внутри веток parallel не приводят к падению run напрямую:
+// решение принимает failure_policy, и при падении parallel
+// упавшим считается его join-узел.
 func (s *RunState) HasFailed() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return len(s.failed) > 0
+	for stepID := range s.failed {
+		node := s.DAG.Nodes[stepID]
+		if node == nil || s.enclosingParallel(node) == "" {
+			return true
+		}
+	}
+
+	// Шаг верхнего уровня, который не будет выполнен из-за упавшей зависимости
+	for stepID := range s.blockedNodes() {
+		if node := s.DAG.Nodes[stepID]; node != nil && node.ParallelID == "" {
+			return true
+		}
+	}
+
+	return false
 }
 
 // GetFailedSteps возвращает список упавших шагов.
@@ -195,6 +265,28 @@ func (s *RunState) GetFailedSteps() []string {
 	return steps
 }
 
+// StepOutputs возвращает outputs успешно завершённых шагов (stepID → outputs).
+func (s *RunState) StepOutputs() map[string]map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	outputs := make(map[string]map[string]any, len(s.completed))
+	for stepID, stepCtx := range s.Context.Steps {
+		if stepCtx.Status == string(domain.TaskStatusSucceeded) {
+			outputs[stepID] = stepCtx.Outputs
+		}
+	}
+	return outputs
+}
+
+// RenderOutputs вычисляет outputs flow (FlowSpec.Outputs) над контекстом run.
+func (s *RunState) RenderOutputs() (map[string]any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return engine.RenderOutputs(s.FlowVersion.Spec.Outputs, s.Context)
+}
+
 // RunID возвращает ID run.
 func (s *RunState) RunID() uuid.UUID {
 	return s.Run.ID
@@ -238,6 +330,11 @@ func (s *RunState) RestoreFromTasks(tasks []domain.Task) {
 		task := &tasks[i]
 		s.tasks[task.StepID] = task
 
+		// Tasks компенсаций учитываются в StartCompensation
+		if _, ok := engine.CompensatedStepID(task.StepID); ok {
+			continue
+		}
+
 		switch task.Status {
 		case domain.TaskStatusSucceeded:
 			s.completed[task.StepID] = true
@@ -247,11 +344,14 @@ func (s *RunState) RestoreFromTasks(tasks []domain.Task) {
 			s.failed[task.StepID] = true
 			s.Context.AddStepResult(task.StepID, nil, string(domain.TaskStatusFailed))
 
-		case domain.TaskStatusRunning:
+		case domain.TaskStatusRunning, domain.TaskStatusWaiting:
+			// WAITING task завершится сигналом через API или по timeout
 			s.running[task.StepID] = true
 
 		case domain.TaskStatusQueued:
 			// Task в очереди — ничего не делаем, будет обработан worker'ом
 		}
 	}
+
+	s.resolveJoins()
 }
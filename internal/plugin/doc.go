@@ -0,0 +1,44 @@
+// Package plugin запускает плагины воркера — исполняемые файлы,
+// реализующие дополнительные типы шагов.
+//
+// # Обнаружение
+//
+// Плагины лежат в каталоге AUTOMATA_PLUGIN_DIR, каждый — в своём
+// подкаталоге с манифестом plugin.json (см. Manifest): имя, версия
+// протокола, команда запуска и типы шагов. Discover читает манифесты
+// без запуска плагинов, поэтому Orchestrator регистрирует типы шагов
+// (engine.RegisterStepTypes) по тому же каталогу.
+//
+// # Протокол (версия 1)
+//
+// JSON поверх stdio, одно сообщение — одна строка:
+//
+//  1. Плагин пишет в stdout приветствие:
+//     {"protocol": 1, "step_types": ["slack_post"]}
+//     Воркер проверяет версию и что все типы из манифеста реализованы.
+//  2. Воркер пишет запросы в stdin:
+//     {"id": 1, "method": "execute", "params": {"task_id": "...", "run_id": "...",
+//     "step_id": "notify", "type": "slack_post", "attempt": 1, "config": {...}}}
+//     {"id": 2, "method": "health"}
+//  3. Плагин отвечает в stdout в любом порядке, по id:
+//     {"id": 1, "result": {"outputs": {...}, "error": "", "permanent": false}}
+//     {"id": 2, "result": {}}
+//     {"id": 1, "error": {"message": "connection refused"}}
+//
+// result.error — логическая ошибка шага, error ответа — инфраструктурная
+// (шаг повторяется по RetryPolicy). stderr плагина пишется в журнал
+// воркера. Закрытие stdin — сигнал завершиться.
+//
+// # Жизненный цикл
+//
+// Plugin запускает процесс при первом запросе и обслуживает через него
+// запросы конкурентно. Упавший процесс перезапускается с задержкой,
+// удваивающейся при повторных падениях; процесс, не ответивший на
+// проверку живости, завершается и перезапускается.
+//
+// Плагины на Go реализуют протокол через Serve:
+//
+//	plugin.Serve([]string{"slack_post"}, func(ctx context.Context, req *plugin.ExecuteRequest) (*plugin.ExecuteResult, error) {
+//	    return &plugin.ExecuteResult{Outputs: map[string]any{"ok": true}}, nil
+//	})
+package plugin
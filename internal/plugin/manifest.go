@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ProtocolVersion — версия протокола, которую поддерживает воркер.
+	ProtocolVersion = 1
+
+	// DirEnv — переменная окружения с каталогом плагинов.
+	DirEnv = "AUTOMATA_PLUGIN_DIR"
+
+	// ManifestFile — имя манифеста в каталоге плагина.
+	ManifestFile = "plugin.json"
+)
+
+// stepTypeRe — формат типа шага плагина (совпадает с engine.RegisterStepTypes).
+var stepTypeRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Manifest — описание плагина (plugin.json).
+//
+//	{
+//	  "name": "slack",
+//	  "version": "1.2.0",
+//	  "protocol": 1,
+//	  "command": "./slack-plugin",
+//	  "args": ["--verbose"],
+//	  "step_types": ["slack_post", "slack_upload"]
+//	}
+//
+// Манифест читается без запуска плагина: по нему Orchestrator узнаёт
+// типы шагов, а воркер — как запустить исполняемый файл.
+type Manifest struct {
+	// Name — имя плагина (по умолчанию — имя каталога).
+	Name string `json:"name"`
+
+	// Version — версия плагина (для логов).
+	Version string `json:"version,omitempty"`
+
+	// Protocol — версия протокола, на котором говорит плагин.
+	Protocol int `json:"protocol"`
+
+	// Command — исполняемый файл; относительный путь — от каталога плагина.
+	Command string `json:"command"`
+
+	// Args — аргументы командной строки.
+	Args []string `json:"args,omitempty"`
+
+	// StepTypes — типы шагов, которые реализует плагин.
+	StepTypes []string `json:"step_types"`
+
+	// Dir — каталог плагина (заполняется при загрузке).
+	Dir string `json:"-"`
+}
+
+// Path возвращает путь к исполняемому файлу плагина.
+func (m *Manifest) Path() string {
+	if filepath.IsAbs(m.Command) {
+		return m.Command
+	}
+	return filepath.Join(m.Dir, m.Command)
+}
+
+// Validate проверяет манифест и исполняемый файл плагина.
+func (m *Manifest) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidManifest)
+	}
+	if m.Protocol != ProtocolVersion {
+		return fmt.Errorf("%w: plugin %s: protocol %d is not supported (want %d)",
+			ErrInvalidManifest, m.Name, m.Protocol, ProtocolVersion)
+	}
+	if m.Command == "" {
+		return fmt.Errorf("%w: plugin %s: command is required", ErrInvalidManifest, m.Name)
+	}
+	if len(m.StepTypes) == 0 {
+		return fmt.Errorf("%w: plugin %s: step_types is required", ErrInvalidManifest, m.Name)
+	}
+	seen := make(map[string]bool, len(m.StepTypes))
+	for _, t := range m.StepTypes {
+		if !stepTypeRe.MatchString(t) {
+			return fmt.Errorf("%w: plugin %s: step type %q must match %s", ErrInvalidManifest, m.Name, t, stepTypeRe)
+		}
+		if seen[t] {
+			return fmt.Errorf("%w: plugin %s: duplicate step type %s", ErrInvalidManifest, m.Name, t)
+		}
+		seen[t] = true
+	}
+
+	info, err := os.Stat(m.Path())
+	if err != nil {
+		return fmt.Errorf("%w: plugin %s: %v", ErrInvalidManifest, m.Name, err)
+	}
+	if info.IsDir() || info.Mode()&0o111 == 0 {
+		return fmt.Errorf("%w: plugin %s: %s is not executable", ErrInvalidManifest, m.Name, m.Path())
+	}
+	return nil
+}
+
+// LoadManifest читает и проверяет манифест плагина из каталога dir.
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidManifest, filepath.Join(dir, ManifestFile), err)
+	}
+	m.Dir = dir
+	if m.Name == "" {
+		m.Name = filepath.Base(dir)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Discover загружает плагины из каталога dir: каждый подкаталог
+// с plugin.json — отдельный плагин. Пустой dir — плагинов нет.
+// Тип шага может реализовывать только один плагин.
+func Discover(dir string) ([]*Manifest, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read plugin dir: %w", err)
+	}
+
+	var manifests []*Manifest
+	owners := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		pluginDir := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(pluginDir, ManifestFile)); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		m, err := LoadManifest(pluginDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range m.StepTypes {
+			if owner, ok := owners[t]; ok {
+				return nil, fmt.Errorf("%w: step type %s is implemented by plugins %s and %s",
+					ErrInvalidManifest, t, owner, m.Name)
+			}
+			owners[t] = m.Name
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// StepTypes возвращает отсортированный список типов шагов всех плагинов.
+func StepTypes(manifests []*Manifest) []string {
+	var types []string
+	for _, m := range manifests {
+		types = append(types, m.StepTypes...)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,238 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStartTimeout    = 10 * time.Second
+	defaultHealthInterval  = 15 * time.Second
+	defaultHealthTimeout   = 5 * time.Second
+	defaultRestartDelay    = time.Second
+	defaultMaxRestartDelay = time.Minute
+
+	// stableUptime — после такой работы падение процесса не увеличивает
+	// задержку перезапуска.
+	stableUptime = time.Minute
+)
+
+// Options — настройки запуска и контроля процесса плагина.
+// Нулевые значения заменяются значениями по умолчанию.
+type Options struct {
+	// StartTimeout — ожидание приветствия после запуска. Default: 10s.
+	StartTimeout time.Duration
+
+	// HealthInterval — период проверок живости. Default: 15s.
+	HealthInterval time.Duration
+
+	// HealthTimeout — ожидание ответа на проверку живости. Default: 5s.
+	HealthTimeout time.Duration
+
+	// RestartDelay — задержка перезапуска после первого падения;
+	// при повторных падениях удваивается до MaxRestartDelay. Default: 1s.
+	RestartDelay time.Duration
+
+	// MaxRestartDelay — максимальная задержка перезапуска. Default: 1m.
+	MaxRestartDelay time.Duration
+
+	// Logger — логгер (stderr плагина пишется в него). Default: slog.Default().
+	Logger *slog.Logger
+}
+
+// Plugin — плагин воркера: процесс, запускаемый по манифесту.
+//
+// Процесс запускается при первом запросе и обслуживает запросы
+// конкурентно. Упавший процесс перезапускается с нарастающей задержкой,
+// зависший (не ответил на проверку живости) — завершается
+// и перезапускается. Безопасен для конкурентного использования.
+type Plugin struct {
+	manifest *Manifest
+	opts     Options
+	logger   *slog.Logger
+
+	mu        sync.Mutex
+	proc      *process
+	closed    bool
+	failures  int       // падения подряд без стабильной работы
+	nextStart time.Time // не перезапускать раньше
+
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// New создаёт плагин по манифесту и запускает проверки живости.
+// Процесс стартует при первом запросе.
+func New(m *Manifest, opts Options) *Plugin {
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = defaultStartTimeout
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = defaultHealthInterval
+	}
+	if opts.HealthTimeout <= 0 {
+		opts.HealthTimeout = defaultHealthTimeout
+	}
+	if opts.RestartDelay <= 0 {
+		opts.RestartDelay = defaultRestartDelay
+	}
+	if opts.MaxRestartDelay < opts.RestartDelay {
+		opts.MaxRestartDelay = max(defaultMaxRestartDelay, opts.RestartDelay)
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	p := &Plugin{
+		manifest: m,
+		opts:     opts,
+		logger:   logger.With("plugin", m.Name),
+		stop:     make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.healthLoop()
+	return p
+}
+
+// Manifest возвращает манифест плагина.
+func (p *Plugin) Manifest() *Manifest {
+	return p.manifest
+}
+
+// Execute выполняет task шага в процессе плагина.
+//
+// Ошибка — инфраструктурная (процесс упал, перезапускается, нарушил
+// протокол или вернул error); логическая ошибка шага — в ExecuteResult.
+func (p *Plugin) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResult, error) {
+	proc, err := p.process()
+	if err != nil {
+		return nil, err
+	}
+
+	var result ExecuteResult
+	if err := proc.call(ctx, MethodExecute, req, &result); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.manifest.Name, err)
+	}
+	return &result, nil
+}
+
+// process возвращает работающий процесс, при необходимости запуская его.
+func (p *Plugin) process() (*process, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, fmt.Errorf("plugin %s: %w", p.manifest.Name, ErrClosed)
+	}
+	if p.proc != nil {
+		if !p.proc.exited() {
+			return p.proc, nil
+		}
+		// Процесс упал — учитываем падение один раз
+		p.onExit(p.proc)
+		p.proc = nil
+	}
+
+	if wait := time.Until(p.nextStart); wait > 0 {
+		return nil, fmt.Errorf("plugin %s: %w: restarting in %s", p.manifest.Name, ErrUnavailable, wait.Round(time.Millisecond))
+	}
+
+	proc, err := startProcess(p.manifest, p.opts.StartTimeout, p.logger)
+	if err != nil {
+		p.failures++
+		p.nextStart = time.Now().Add(p.restartDelay())
+		p.logger.Error("plugin failed to start", "error", err, "failures", p.failures)
+		return nil, fmt.Errorf("plugin %s: %w", p.manifest.Name, err)
+	}
+
+	p.logger.Info("plugin started", "pid", proc.pid(), "version", p.manifest.Version)
+	p.proc = proc
+	return proc, nil
+}
+
+// onExit учитывает падение процесса и назначает время перезапуска.
+func (p *Plugin) onExit(proc *process) {
+	if proc.exitedAt.Sub(proc.startedAt) >= stableUptime {
+		p.failures = 0
+	}
+	p.failures++
+	p.nextStart = proc.exitedAt.Add(p.restartDelay())
+	p.logger.Warn("plugin exited", "pid", proc.pid(), "error", proc.err, "failures", p.failures)
+}
+
+// restartDelay — задержка перезапуска после failures падений подряд.
+func (p *Plugin) restartDelay() time.Duration {
+	delay := p.opts.RestartDelay
+	for i := 1; i < p.failures && delay < p.opts.MaxRestartDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.opts.MaxRestartDelay)
+}
+
+// healthLoop периодически проверяет процесс: зависший завершается,
+// упавший перезапускается (если плагин уже запускался).
+func (p *Plugin) healthLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.opts.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.checkHealth()
+		}
+	}
+}
+
+// checkHealth выполняет одну проверку живости.
+func (p *Plugin) checkHealth() {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+
+	// Ещё не запускался — запустится при первом запросе
+	if proc == nil {
+		return
+	}
+	if proc.exited() {
+		if _, err := p.process(); err != nil && !errors.Is(err, ErrUnavailable) && !errors.Is(err, ErrClosed) {
+			p.logger.Warn("plugin restart failed", "error", err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.HealthTimeout)
+	defer cancel()
+	if err := proc.call(ctx, MethodHealth, nil, nil); err != nil && !proc.exited() {
+		p.logger.Warn("plugin health check failed, killing", "pid", proc.pid(), "error", err)
+		proc.kill()
+	}
+}
+
+// Close останавливает проверки и процесс плагина (закрывает stdin,
+// по истечении таймаута завершает процесс). Повторный вызов безопасен.
+func (p *Plugin) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		p.wg.Wait()
+
+		p.mu.Lock()
+		p.closed = true
+		proc := p.proc
+		p.proc = nil
+		p.mu.Unlock()
+
+		if proc != nil {
+			proc.shutdown()
+		}
+	})
+}
@@ -0,0 +1,331 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testPluginOnce sync.Once
+	testPluginDir  string
+	testPluginPath string
+	testPluginErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if testPluginDir != "" {
+		os.RemoveAll(testPluginDir)
+	}
+	os.Exit(code)
+}
+
+// buildTestPlugin собирает testdata/testplugin (один раз на пакет).
+func buildTestPlugin(t *testing.T) string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	testPluginOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "testplugin")
+		if err != nil {
+			testPluginErr = err
+			return
+		}
+		// Бинарник нужен всем тестам — каталог удаляет TestMain
+		testPluginDir = dir
+
+		out := filepath.Join(dir, "testplugin")
+		cmd := exec.Command(goBin, "build", "-o", out, "./testdata/testplugin")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			testPluginErr = errors.New(string(output))
+			return
+		}
+		testPluginPath = out
+	})
+	if testPluginErr != nil {
+		t.Fatalf("build testplugin: %v", testPluginErr)
+	}
+	return testPluginPath
+}
+
+// writePlugin создаёт каталог плагина с манифестом в root.
+func writePlugin(t *testing.T, root string, m Manifest) string {
+	t.Helper()
+	dir := filepath.Join(root, m.Name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(m)
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// newTestPlugin запускает testplugin в режиме mode с типами types.
+func newTestPlugin(t *testing.T, mode string, types []string, opts Options) *Plugin {
+	t.Helper()
+	m := &Manifest{
+		Name:      "test",
+		Protocol:  ProtocolVersion,
+		Command:   buildTestPlugin(t),
+		Args:      append([]string{mode}, types...),
+		StepTypes: types,
+		Dir:       t.TempDir(),
+	}
+	p := New(m, opts)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func execute(t *testing.T, p *Plugin, config map[string]any) (*ExecuteResult, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return p.Execute(ctx, &ExecuteRequest{TaskID: "t1", StepID: "notify", Type: "echo", Attempt: 1, Config: config})
+}
+
+func TestDiscover(t *testing.T) {
+	bin := buildTestPlugin(t)
+	root := t.TempDir()
+
+	writePlugin(t, root, Manifest{Name: "slack", Protocol: 1, Command: bin, StepTypes: []string{"slack_post", "slack_upload"}})
+	// Имя по умолчанию — каталог
+	dir := writePlugin(t, root, Manifest{Name: "pdf", Protocol: 1, Command: bin, StepTypes: []string{"pdf_render"}})
+	data, _ := json.Marshal(map[string]any{"protocol": 1, "command": bin, "step_types": []string{"pdf_render"}})
+	os.WriteFile(filepath.Join(dir, ManifestFile), data, 0o644)
+	// Каталог без манифеста и файлы пропускаются
+	os.MkdirAll(filepath.Join(root, "shared"), 0o755)
+	os.WriteFile(filepath.Join(root, "README"), []byte("plugins"), 0o644)
+
+	manifests, err := Discover(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(manifests) != 2 || manifests[0].Name != "pdf" || manifests[1].Name != "slack" {
+		t.Fatalf("unexpected manifests: %+v", manifests)
+	}
+	got := strings.Join(StepTypes(manifests), ",")
+	if got != "pdf_render,slack_post,slack_upload" {
+		t.Errorf("unexpected step types: %s", got)
+	}
+
+	manifests, err = Discover("")
+	if err != nil || manifests != nil {
+		t.Errorf("expected no plugins for empty dir, got %v, %v", manifests, err)
+	}
+}
+
+func TestDiscover_Invalid(t *testing.T) {
+	bin := buildTestPlugin(t)
+	notExec := filepath.Join(t.TempDir(), "script")
+	os.WriteFile(notExec, []byte("#!/bin/sh"), 0o644)
+
+	tests := []struct {
+		name      string
+		manifests []Manifest
+		wantError string
+	}{
+		{"unsupported protocol", []Manifest{{Name: "a", Protocol: 2, Command: bin, StepTypes: []string{"a"}}}, "protocol 2"},
+		{"no command", []Manifest{{Name: "a", Protocol: 1, StepTypes: []string{"a"}}}, "command is required"},
+		{"no step types", []Manifest{{Name: "a", Protocol: 1, Command: bin}}, "step_types is required"},
+		{"invalid step type", []Manifest{{Name: "a", Protocol: 1, Command: bin, StepTypes: []string{"Slack-Post"}}}, "must match"},
+		{"missing command", []Manifest{{Name: "a", Protocol: 1, Command: "./missing", StepTypes: []string{"a"}}}, "no such file"},
+		{"not executable", []Manifest{{Name: "a", Protocol: 1, Command: notExec, StepTypes: []string{"a"}}}, "not executable"},
+		{"duplicate type", []Manifest{
+			{Name: "a", Protocol: 1, Command: bin, StepTypes: []string{"notify"}},
+			{Name: "b", Protocol: 1, Command: bin, StepTypes: []string{"notify"}},
+		}, "implemented by plugins a and b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, m := range tt.manifests {
+				writePlugin(t, root, m)
+			}
+			_, err := Discover(root)
+			if !errors.Is(err, ErrInvalidManifest) || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("expected ErrInvalidManifest containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestPlugin_Execute(t *testing.T) {
+	p := newTestPlugin(t, "serve", []string{"echo", "other"}, Options{})
+
+	result, err := execute(t, p, map[string]any{"channel": "#ops"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config, _ := result.Outputs["config"].(map[string]any)
+	if result.Outputs["type"] != "echo" || result.Outputs["attempt"] != float64(1) || config["channel"] != "#ops" {
+		t.Errorf("unexpected outputs: %v", result.Outputs)
+	}
+
+	// Логическая ошибка шага
+	result, err = execute(t, p, map[string]any{"error": "channel not found", "permanent": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Error != "channel not found" || !result.Permanent || result.Outputs["code"] != float64(42) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// Ошибка ответа — инфраструктурная
+	_, err = execute(t, p, map[string]any{"fail": "rate limited"})
+	if !errors.Is(err, ErrFailed) || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("expected ErrFailed, got %v", err)
+	}
+
+	// Конкурентные запросы обслуживает один процесс
+	var wg sync.WaitGroup
+	pids := make(chan any, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := execute(t, p, nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			pids <- result.Outputs["pid"]
+		}()
+	}
+	wg.Wait()
+	close(pids)
+	first := <-pids
+	for pid := range pids {
+		if pid != first {
+			t.Errorf("expected one process, got pids %v and %v", first, pid)
+		}
+	}
+}
+
+func TestPlugin_Cancel(t *testing.T) {
+	p := newTestPlugin(t, "serve", []string{"echo"}, Options{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := p.Execute(ctx, &ExecuteRequest{Type: "echo", Config: map[string]any{"hang": true}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	// Процесс продолжает обслуживать запросы
+	if _, err := execute(t, p, nil); err != nil {
+		t.Errorf("unexpected error after cancel: %v", err)
+	}
+}
+
+func TestPlugin_Restart(t *testing.T) {
+	p := newTestPlugin(t, "serve", []string{"echo"}, Options{RestartDelay: 200 * time.Millisecond})
+
+	result, err := execute(t, p, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pid := result.Outputs["pid"]
+
+	_, err = execute(t, p, map[string]any{"crash": true})
+	if !errors.Is(err, ErrExited) || !strings.Contains(err.Error(), "exit status 2") {
+		t.Fatalf("expected ErrExited, got %v", err)
+	}
+
+	// До истечения задержки — плагин недоступен
+	if _, err := execute(t, p, nil); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	result, err = execute(t, p, nil)
+	if err != nil {
+		t.Fatalf("unexpected error after restart: %v", err)
+	}
+	if result.Outputs["pid"] == pid {
+		t.Error("expected new process after crash")
+	}
+}
+
+func TestPlugin_HealthCheck(t *testing.T) {
+	p := newTestPlugin(t, "unhealthy", []string{"echo"}, Options{
+		HealthInterval: 50 * time.Millisecond,
+		HealthTimeout:  50 * time.Millisecond,
+		RestartDelay:   10 * time.Millisecond,
+	})
+
+	result, err := execute(t, p, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pid := result.Outputs["pid"]
+
+	// Процесс не отвечает на health — завершается и перезапускается
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		result, err := execute(t, p, nil)
+		if err == nil && result.Outputs["pid"] != pid {
+			return
+		}
+	}
+	t.Fatal("expected unhealthy plugin to be restarted")
+}
+
+func TestPlugin_ProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		manifest  []string
+		wantErr   error
+		wantError string
+	}{
+		{"no handshake", "silent", []string{"echo"}, ErrHandshake, "no handshake"},
+		{"old protocol", "oldproto", []string{"echo"}, ErrHandshake, "protocol 0"},
+		{"invalid response", "garbage", []string{"echo"}, ErrProtocol, "not json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlugin(t, tt.mode, tt.manifest, Options{StartTimeout: 500 * time.Millisecond})
+			_, err := execute(t, p, nil)
+			if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("expected %v containing %q, got %v", tt.wantErr, tt.wantError, err)
+			}
+		})
+	}
+
+	// Тип из манифеста, который плагин не реализует
+	bin := buildTestPlugin(t)
+	p := New(&Manifest{Name: "test", Protocol: 1, Command: bin, Args: []string{"serve", "echo"}, StepTypes: []string{"echo", "other"}}, Options{})
+	defer p.Close()
+	if _, err := execute(t, p, nil); !errors.Is(err, ErrHandshake) || !strings.Contains(err.Error(), "other") {
+		t.Errorf("expected handshake error for missing step type, got %v", err)
+	}
+}
+
+func TestPlugin_Close(t *testing.T) {
+	p := newTestPlugin(t, "serve", []string{"echo"}, Options{})
+	if _, err := execute(t, p, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p.Close()
+	p.Close()
+	if _, err := execute(t, p, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shutdownTimeout — сколько ждать завершения плагина после закрытия stdin.
+const shutdownTimeout = 5 * time.Second
+
+// process — запущенный процесс плагина.
+type process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	logger *slog.Logger
+
+	writeMu sync.Mutex
+	nextID  atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[uint64]chan *Response
+
+	startedAt time.Time
+	done      chan struct{}
+
+	// Заполняются до закрытия done
+	exitedAt time.Time
+	err      error
+}
+
+// startProcess запускает плагин и дожидается приветствия.
+func startProcess(m *Manifest, startTimeout time.Duration, logger *slog.Logger) (*process, error) {
+	cmd := exec.Command(m.Path(), m.Args...)
+	cmd.Dir = m.Dir
+	cmd.Env = append(os.Environ(), fmt.Sprintf("AUTOMATA_PLUGIN_PROTOCOL=%d", ProtocolVersion))
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", m.Path(), err)
+	}
+
+	p := &process{
+		cmd:       cmd,
+		stdin:     stdin,
+		logger:    logger,
+		pending:   make(map[uint64]chan *Response),
+		startedAt: time.Now(),
+		done:      make(chan struct{}),
+	}
+
+	// stderr плагина — его журнал
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		scanner := bufio.NewScanner(stderr)
+		scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+		for scanner.Scan() {
+			logger.Info("plugin log", "pid", cmd.Process.Pid, "line", scanner.Text())
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxMessageSize)
+
+	handshake := make(chan error, 1)
+	go func() {
+		handshake <- readHandshake(scanner, m)
+	}()
+
+	var hsErr error
+	select {
+	case hsErr = <-handshake:
+	case <-time.After(startTimeout):
+		hsErr = fmt.Errorf("%w: no handshake within %s", ErrHandshake, startTimeout)
+	}
+
+	go p.readLoop(stdout, scanner, stderrDone, hsErr != nil)
+
+	if hsErr != nil {
+		p.kill()
+		<-p.done
+		return nil, hsErr
+	}
+	return p, nil
+}
+
+// readHandshake читает и проверяет приветствие плагина.
+func readHandshake(scanner *bufio.Scanner, m *Manifest) error {
+	if !scanner.Scan() {
+		err := scanner.Err()
+		if err == nil {
+			err = io.EOF
+		}
+		return fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+
+	var hs Handshake
+	if err := json.Unmarshal(scanner.Bytes(), &hs); err != nil {
+		return fmt.Errorf("%w: invalid handshake %q: %v", ErrHandshake, truncate(scanner.Text()), err)
+	}
+	if hs.Protocol != ProtocolVersion {
+		return fmt.Errorf("%w: plugin speaks protocol %d, want %d", ErrHandshake, hs.Protocol, ProtocolVersion)
+	}
+	for _, t := range m.StepTypes {
+		if !slices.Contains(hs.StepTypes, t) {
+			return fmt.Errorf("%w: plugin does not implement step type %s declared in manifest", ErrHandshake, t)
+		}
+	}
+	return nil
+}
+
+// readLoop читает ответы плагина и доставляет их ожидающим запросам.
+// Когда stdout закрыт, процесс больше не может отвечать: он завершается
+// и readLoop дожидается его выхода. Если handshake не удался, ответы
+// не читаются.
+func (p *process) readLoop(stdout io.Reader, scanner *bufio.Scanner, stderrDone <-chan struct{}, skip bool) {
+	var protoErr error
+	for !skip && scanner.Scan() {
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			protoErr = fmt.Errorf("%w: invalid message %q: %v", ErrProtocol, truncate(scanner.Text()), err)
+			p.kill()
+			break
+		}
+
+		p.mu.Lock()
+		ch := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.mu.Unlock()
+
+		// Ответ на отменённый запрос — отбрасываем
+		if ch != nil {
+			ch <- &resp
+		}
+	}
+	if err := scanner.Err(); err != nil && !skip && protoErr == nil {
+		protoErr = fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	p.kill()
+	io.Copy(io.Discard, stdout)
+	<-stderrDone
+	waitErr := p.cmd.Wait()
+
+	p.exitedAt = time.Now()
+	switch {
+	case protoErr != nil:
+		p.err = protoErr
+	case waitErr != nil:
+		p.err = fmt.Errorf("%w: %v", ErrExited, waitErr)
+	default:
+		p.err = fmt.Errorf("%w: exit status 0", ErrExited)
+	}
+	close(p.done)
+}
+
+// call отправляет запрос и ждёт ответ. out — куда разобрать result (может быть nil).
+func (p *process) call(ctx context.Context, method string, params, out any) error {
+	req := Request{ID: p.nextID.Add(1), Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("encode %s params: %w", method, err)
+		}
+		req.Params = raw
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encode %s request: %w", method, err)
+	}
+
+	ch := make(chan *Response, 1)
+	p.mu.Lock()
+	p.pending[req.ID] = ch
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, req.ID)
+		p.mu.Unlock()
+	}()
+
+	p.writeMu.Lock()
+	_, err = p.stdin.Write(append(line, '\n'))
+	p.writeMu.Unlock()
+	if err != nil {
+		select {
+		case <-p.done:
+			return p.err
+		default:
+			return fmt.Errorf("%w: write request: %v", ErrExited, err)
+		}
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return fmt.Errorf("%w: %s", ErrFailed, resp.Error.Message)
+		}
+		if out != nil {
+			if err := json.Unmarshal(resp.Result, out); err != nil {
+				return fmt.Errorf("%w: invalid %s result: %v", ErrProtocol, method, err)
+			}
+		}
+		return nil
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pid возвращает PID процесса.
+func (p *process) pid() int {
+	return p.cmd.Process.Pid
+}
+
+// exited возвращает true, если процесс завершился.
+func (p *process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// kill завершает процесс немедленно.
+func (p *process) kill() {
+	p.cmd.Process.Kill()
+}
+
+// shutdown закрывает stdin (сигнал плагину завершиться) и ждёт выхода,
+// по истечении shutdownTimeout завершает процесс.
+func (p *process) shutdown() {
+	p.writeMu.Lock()
+	p.stdin.Close()
+	p.writeMu.Unlock()
+
+	select {
+	case <-p.done:
+	case <-time.After(shutdownTimeout):
+		p.logger.Warn("plugin did not exit after stdin closed, killing", "pid", p.pid())
+		p.kill()
+		<-p.done
+	}
+}
+
+// truncate обрезает сообщение для текста ошибки.
+func truncate(s string) string {
+	const limit = 200
+	if len(s) > limit {
+		return s[:limit] + "..."
+	}
+	return s
+}
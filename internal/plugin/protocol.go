@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Методы протокола.
+const (
+	// MethodExecute — выполнить task шага.
+	MethodExecute = "execute"
+
+	// MethodHealth — проверка живости; плагин отвечает пустым result.
+	MethodHealth = "health"
+)
+
+// maxMessageSize — максимальный размер одного сообщения (строки JSON).
+const maxMessageSize = 16 << 20
+
+// Ошибки плагинов.
+var (
+	// ErrInvalidManifest — некорректный plugin.json или исполняемый файл.
+	ErrInvalidManifest = errors.New("invalid plugin manifest")
+
+	// ErrHandshake — плагин не прислал корректное приветствие при запуске.
+	ErrHandshake = errors.New("plugin handshake failed")
+
+	// ErrProtocol — плагин нарушил протокол (некорректное сообщение).
+	ErrProtocol = errors.New("plugin protocol error")
+
+	// ErrExited — процесс плагина завершился.
+	ErrExited = errors.New("plugin exited")
+
+	// ErrUnavailable — плагин перезапускается после падения.
+	ErrUnavailable = errors.New("plugin unavailable")
+
+	// ErrFailed — плагин вернул ошибку выполнения запроса.
+	ErrFailed = errors.New("plugin error")
+
+	// ErrClosed — плагин остановлен.
+	ErrClosed = errors.New("plugin closed")
+)
+
+// Handshake — первая строка stdout плагина после запуска.
+//
+//	{"protocol": 1, "step_types": ["slack_post"]}
+type Handshake struct {
+	Protocol  int      `json:"protocol"`
+	StepTypes []string `json:"step_types"`
+}
+
+// Request — запрос воркера (строка JSON в stdin плагина).
+//
+//	{"id": 1, "method": "execute", "params": {...}}
+type Request struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response — ответ плагина (строка JSON в stdout). Ответы могут
+// приходить в любом порядке и сопоставляются с запросами по id.
+//
+//	{"id": 1, "result": {...}}
+//	{"id": 1, "error": {"message": "..."}}
+type Response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError  `json:"error,omitempty"`
+}
+
+// ResponseError — ошибка выполнения запроса (инфраструктурная: воркер
+// повторяет шаг по RetryPolicy как при сетевой ошибке).
+type ResponseError struct {
+	Message string `json:"message"`
+}
+
+// ExecuteRequest — параметры метода execute.
+type ExecuteRequest struct {
+	TaskID  string         `json:"task_id"`
+	RunID   string         `json:"run_id"`
+	StepID  string         `json:"step_id"`
+	Type    string         `json:"type"`
+	Attempt int            `json:"attempt"`
+	Config  map[string]any `json:"config"`
+}
+
+// ExecuteResult — результат метода execute.
+//
+// Error — логическая ошибка шага (как ExecutionResult.Error воркера),
+// Permanent отключает её повторы.
+type ExecuteResult struct {
+	Outputs   map[string]any `json:"outputs,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Permanent bool           `json:"permanent,omitempty"`
+}
@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Handler выполняет task шага плагина. Ошибка — инфраструктурная
+// (воркер повторит шаг), логическая ошибка шага — ExecuteResult.Error.
+type Handler func(ctx context.Context, req *ExecuteRequest) (*ExecuteResult, error)
+
+// Serve реализует сторону плагина для плагинов на Go: отправляет
+// приветствие с типами шагов stepTypes и обрабатывает запросы воркера
+// из stdin, пока он не будет закрыт. Запросы выполняются конкурентно;
+// при закрытии stdin контекст незавершённых запросов отменяется.
+//
+//	func main() {
+//	    if err := plugin.Serve([]string{"slack_post"}, post); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func Serve(stepTypes []string, handler Handler) error {
+	return serve(os.Stdin, os.Stdout, stepTypes, handler)
+}
+
+func serve(r io.Reader, w io.Writer, stepTypes []string, handler Handler) error {
+	var writeMu sync.Mutex
+	enc := json.NewEncoder(w)
+	write := func(v any) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return enc.Encode(v)
+	}
+
+	if err := write(Handshake{Protocol: ProtocolVersion, StepTypes: stepTypes}); err != nil {
+		return fmt.Errorf("write handshake: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxMessageSize)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return fmt.Errorf("%w: invalid request: %v", ErrProtocol, err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			write(handle(ctx, &req, handler))
+		}()
+	}
+	return scanner.Err()
+}
+
+// handle выполняет один запрос воркера.
+func handle(ctx context.Context, req *Request, handler Handler) *Response {
+	resp := &Response{ID: req.ID}
+
+	switch req.Method {
+	case MethodHealth:
+		resp.Result = json.RawMessage("{}")
+
+	case MethodExecute:
+		var params ExecuteRequest
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &ResponseError{Message: fmt.Sprintf("invalid execute params: %v", err)}
+			return resp
+		}
+		result, err := handler(ctx, &params)
+		if err != nil {
+			resp.Error = &ResponseError{Message: err.Error()}
+			return resp
+		}
+		if result == nil {
+			result = &ExecuteResult{}
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &ResponseError{Message: fmt.Sprintf("encode result: %v", err)}
+			return resp
+		}
+		resp.Result = data
+
+	default:
+		resp.Error = &ResponseError{Message: fmt.Sprintf("unknown method %q", req.Method)}
+	}
+	return resp
+}
@@ -0,0 +1,91 @@
+// Тестовый плагин воркера: go build.
+//
+// Режим — первый аргумент, типы шагов — остальные:
+//   - serve: plugin.Serve; поведение execute задаётся config:
+//     crash — процесс падает, hang — ждёт отмены, error — логическая
+//     ошибка (permanent), fail — ошибка ответа; иначе outputs — pid,
+//     type, attempt и config
+//   - unhealthy: отвечает на execute, но не на health
+//   - garbage: на execute пишет в stdout не JSON
+//   - silent: не отправляет приветствие
+//   - oldproto: приветствие с неподдерживаемой версией протокола
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shaiso/Automata/internal/plugin"
+)
+
+func main() {
+	mode, types := os.Args[1], os.Args[2:]
+
+	switch mode {
+	case "serve":
+		if err := plugin.Serve(types, execute); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "unhealthy", "garbage":
+		raw(mode, types)
+	case "silent":
+		io.Copy(io.Discard, os.Stdin)
+	case "oldproto":
+		json.NewEncoder(os.Stdout).Encode(plugin.Handshake{Protocol: 0, StepTypes: types})
+		io.Copy(io.Discard, os.Stdin)
+	}
+}
+
+func execute(ctx context.Context, req *plugin.ExecuteRequest) (*plugin.ExecuteResult, error) {
+	fmt.Fprintln(os.Stderr, "executing", req.StepID)
+
+	switch {
+	case req.Config["crash"] == true:
+		os.Exit(2)
+	case req.Config["hang"] == true:
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case req.Config["error"] != nil:
+		return &plugin.ExecuteResult{
+			Outputs:   map[string]any{"code": 42},
+			Error:     fmt.Sprint(req.Config["error"]),
+			Permanent: req.Config["permanent"] == true,
+		}, nil
+	case req.Config["fail"] != nil:
+		return nil, errors.New(fmt.Sprint(req.Config["fail"]))
+	}
+
+	return &plugin.ExecuteResult{Outputs: map[string]any{
+		"pid":     os.Getpid(),
+		"type":    req.Type,
+		"step_id": req.StepID,
+		"attempt": req.Attempt,
+		"config":  req.Config,
+	}}, nil
+}
+
+// raw реализует протокол вручную с нарушениями.
+func raw(mode string, types []string) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.Encode(plugin.Handshake{Protocol: plugin.ProtocolVersion, StepTypes: types})
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req plugin.Request
+		json.Unmarshal(scanner.Bytes(), &req)
+		if req.Method != plugin.MethodExecute {
+			continue
+		}
+		if mode == "garbage" {
+			fmt.Println("not json")
+			continue
+		}
+		enc.Encode(plugin.Response{ID: req.ID, Result: json.RawMessage(fmt.Sprintf(`{"outputs": {"pid": %d}}`, os.Getpid()))})
+	}
+}
@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// DescriptorSetRepo — репозиторий загруженных protobuf descriptor sets.
+type DescriptorSetRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewDescriptorSetRepo создаёт новый DescriptorSetRepo.
+func NewDescriptorSetRepo(pool *pgxpool.Pool) *DescriptorSetRepo {
+	return &DescriptorSetRepo{pool: pool}
+}
+
+// Put сохраняет набор: создаёт новый или заменяет существующий с тем же
+// именем (CreatedAt сохраняется от первой загрузки).
+func (r *DescriptorSetRepo) Put(ctx context.Context, set *domain.DescriptorSet) error {
+	query := `
+		INSERT INTO descriptor_sets (name, data, sha256, services, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) DO UPDATE
+		SET data = EXCLUDED.data,
+		    sha256 = EXCLUDED.sha256,
+		    services = EXCLUDED.services,
+		    updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+	err := r.pool.QueryRow(ctx, query,
+		set.Name,
+		set.Data,
+		set.SHA256,
+		set.Services,
+		set.CreatedAt,
+		set.UpdatedAt,
+	).Scan(&set.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("put descriptor set: %w", err)
+	}
+	return nil
+}
+
+// GetByName возвращает набор вместе с данными.
+func (r *DescriptorSetRepo) GetByName(ctx context.Context, name string) (*domain.DescriptorSet, error) {
+	query := `
+		SELECT name, data, sha256, services, created_at, updated_at
+		FROM descriptor_sets
+		WHERE name = $1
+	`
+	var set domain.DescriptorSet
+	err := r.pool.QueryRow(ctx, query, name).Scan(
+		&set.Name,
+		&set.Data,
+		&set.SHA256,
+		&set.Services,
+		&set.CreatedAt,
+		&set.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get descriptor set: %w", err)
+	}
+	return &set, nil
+}
+
+// List возвращает наборы без данных, отсортированные по имени.
+func (r *DescriptorSetRepo) List(ctx context.Context) ([]domain.DescriptorSet, error) {
+	query := `
+		SELECT name, sha256, services, created_at, updated_at
+		FROM descriptor_sets
+		ORDER BY name
+	`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list descriptor sets: %w", err)
+	}
+	defer rows.Close()
+
+	var sets []domain.DescriptorSet
+	for rows.Next() {
+		var set domain.DescriptorSet
+		if err := rows.Scan(&set.Name, &set.SHA256, &set.Services, &set.CreatedAt, &set.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan descriptor set: %w", err)
+		}
+		sets = append(sets, set)
+	}
+	return sets, rows.Err()
+}
+
+// Delete удаляет набор.
+func (r *DescriptorSetRepo) Delete(ctx context.Context, name string) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM descriptor_sets WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("delete descriptor set: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
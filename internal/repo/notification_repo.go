@@ -0,0 +1,365 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// NotificationRepo — репозиторий правил уведомлений и истории их доставки.
+type NotificationRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewNotificationRepo создаёт новый NotificationRepo.
+func NewNotificationRepo(pool *pgxpool.Pool) *NotificationRepo {
+	return &NotificationRepo{pool: pool}
+}
+
+// ruleColumns — колонки notification_rules в порядке scanRule.
+const ruleColumns = `id, flow_id, name, event, threshold, channel, config, enabled,
+		       created_at, updated_at`
+
+// deliveryColumns — колонки notification_deliveries в порядке scanDelivery.
+const deliveryColumns = `id, rule_id, run_id, status, subject, body, attempts, last_error,
+		       next_attempt_at, sent_at, created_at`
+
+// --- Rules ---
+
+// CreateRule создаёт новое правило.
+func (r *NotificationRepo) CreateRule(ctx context.Context, rule *domain.NotificationRule) error {
+	configJSON, err := json.Marshal(rule.Config)
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+
+	query := `
+		INSERT INTO notification_rules (id, flow_id, name, event, threshold, channel, config,
+		                                enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err = r.pool.Exec(ctx, query,
+		rule.ID,
+		rule.FlowID,
+		rule.Name,
+		string(rule.On),
+		nullInt(rule.Threshold),
+		string(rule.Channel),
+		configJSON,
+		rule.Enabled,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert notification rule: %w", err)
+	}
+	return nil
+}
+
+// GetRule возвращает правило по ID.
+func (r *NotificationRepo) GetRule(ctx context.Context, id uuid.UUID) (*domain.NotificationRule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM notification_rules WHERE id = $1`
+	return r.scanRule(r.pool.QueryRow(ctx, query, id))
+}
+
+// ListRules возвращает правила с фильтрацией.
+func (r *NotificationRepo) ListRules(ctx context.Context, filter NotificationRuleFilter) ([]domain.NotificationRule, error) {
+	query := `
+		SELECT ` + ruleColumns + `
+		FROM notification_rules
+		WHERE ($1::uuid IS NULL OR flow_id = $1)
+		  AND ($2::boolean IS NULL OR enabled = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	return r.queryRules(ctx, query,
+		nullUUID(filter.FlowID),
+		filter.Enabled,
+		filter.Limit,
+		filter.Offset,
+	)
+}
+
+// ListEnabledRules возвращает включённые правила flow.
+func (r *NotificationRepo) ListEnabledRules(ctx context.Context, flowID uuid.UUID) ([]domain.NotificationRule, error) {
+	query := `
+		SELECT ` + ruleColumns + `
+		FROM notification_rules
+		WHERE flow_id = $1 AND enabled = true
+		ORDER BY created_at ASC
+	`
+	return r.queryRules(ctx, query, flowID)
+}
+
+// UpdateRule обновляет правило.
+func (r *NotificationRepo) UpdateRule(ctx context.Context, rule *domain.NotificationRule) error {
+	configJSON, err := json.Marshal(rule.Config)
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+
+	query := `
+		UPDATE notification_rules
+		SET name = $2, event = $3, threshold = $4, channel = $5, config = $6,
+		    enabled = $7, updated_at = $8
+		WHERE id = $1
+	`
+	result, err := r.pool.Exec(ctx, query,
+		rule.ID,
+		rule.Name,
+		string(rule.On),
+		nullInt(rule.Threshold),
+		string(rule.Channel),
+		configJSON,
+		rule.Enabled,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update notification rule: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteRule удаляет правило вместе с историей доставки.
+func (r *NotificationRepo) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM notification_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete notification rule: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetRuleEnabled включает/выключает правило.
+func (r *NotificationRepo) SetRuleEnabled(ctx context.Context, id uuid.UUID, enabled bool) error {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE notification_rules SET enabled = $2, updated_at = NOW() WHERE id = $1
+	`, id, enabled)
+	if err != nil {
+		return fmt.Errorf("set enabled: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// --- Deliveries ---
+
+// CreateDelivery создаёт уведомление для отправки.
+// Возвращает false, если уведомление о run по правилу уже существует.
+func (r *NotificationRepo) CreateDelivery(ctx context.Context, d *domain.NotificationDelivery) (bool, error) {
+	query := `
+		INSERT INTO notification_deliveries (id, rule_id, run_id, status, subject, body,
+		                                     attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (rule_id, run_id) DO NOTHING
+	`
+	result, err := r.pool.Exec(ctx, query,
+		d.ID,
+		d.RuleID,
+		d.RunID,
+		string(d.Status),
+		nullString(d.Subject),
+		d.Body,
+		d.Attempts,
+		d.NextAttemptAt,
+		d.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert notification delivery: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// ClaimDue забирает PENDING уведомления, время попытки которых наступило.
+//
+// next_attempt_at забранных записей сдвигается на lease: другие экземпляры
+// Orchestrator их не возьмут, а если отправка прервётся (рестарт),
+// уведомление будет отправлено повторно после lease.
+func (r *NotificationRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.NotificationDelivery, error) {
+	query := `
+		UPDATE notification_deliveries
+		SET next_attempt_at = now() + $2 * interval '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+	return r.queryDeliveries(ctx, query, limit, lease.Milliseconds())
+}
+
+// UpdateDelivery сохраняет результат попытки доставки.
+func (r *NotificationRepo) UpdateDelivery(ctx context.Context, d *domain.NotificationDelivery) error {
+	query := `
+		UPDATE notification_deliveries
+		SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, sent_at = $6
+		WHERE id = $1
+	`
+	result, err := r.pool.Exec(ctx, query,
+		d.ID,
+		string(d.Status),
+		d.Attempts,
+		nullString(d.LastError),
+		d.NextAttemptAt,
+		d.SentAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update notification delivery: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDeliveries возвращает историю доставки (новые первыми).
+func (r *NotificationRepo) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]domain.NotificationDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM notification_deliveries
+		WHERE ($1::uuid IS NULL OR rule_id = $1)
+		  AND ($2::uuid IS NULL OR run_id = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	return r.queryDeliveries(ctx, query,
+		nullUUID(filter.RuleID),
+		nullUUID(filter.RunID),
+		filter.Limit,
+		filter.Offset,
+	)
+}
+
+// --- Helpers ---
+
+// NotificationRuleFilter — параметры фильтрации правил.
+type NotificationRuleFilter struct {
+	FlowID  *uuid.UUID
+	Enabled *bool
+	Limit   int
+	Offset  int
+}
+
+// DeliveryFilter — параметры фильтрации истории доставки.
+type DeliveryFilter struct {
+	RuleID *uuid.UUID
+	RunID  *uuid.UUID
+	Limit  int
+	Offset int
+}
+
+func (r *NotificationRepo) queryRules(ctx context.Context, query string, args ...any) ([]domain.NotificationRule, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list notification rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []domain.NotificationRule
+	for rows.Next() {
+		rule, err := r.scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// scanRule сканирует правило из pgx.Row (QueryRow или Rows).
+func (r *NotificationRepo) scanRule(row pgx.Row) (*domain.NotificationRule, error) {
+	var rule domain.NotificationRule
+	var event, channel string
+	var threshold *int
+	var configJSON []byte
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.FlowID,
+		&rule.Name,
+		&event,
+		&threshold,
+		&channel,
+		&configJSON,
+		&rule.Enabled,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan notification rule: %w", err)
+	}
+
+	rule.On = domain.NotificationEvent(event)
+	rule.Channel = domain.NotificationChannel(channel)
+	if threshold != nil {
+		rule.Threshold = *threshold
+	}
+	if configJSON != nil {
+		if err := json.Unmarshal(configJSON, &rule.Config); err != nil {
+			return nil, fmt.Errorf("unmarshal config: %w", err)
+		}
+	}
+
+	return &rule, nil
+}
+
+func (r *NotificationRepo) queryDeliveries(ctx context.Context, query string, args ...any) ([]domain.NotificationDelivery, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.NotificationDelivery
+	for rows.Next() {
+		var d domain.NotificationDelivery
+		var status string
+		var subject, lastError *string
+
+		err := rows.Scan(
+			&d.ID,
+			&d.RuleID,
+			&d.RunID,
+			&status,
+			&subject,
+			&d.Body,
+			&d.Attempts,
+			&lastError,
+			&d.NextAttemptAt,
+			&d.SentAt,
+			&d.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan notification delivery: %w", err)
+		}
+
+		d.Status = domain.DeliveryStatus(status)
+		if subject != nil {
+			d.Subject = *subject
+		}
+		if lastError != nil {
+			d.LastError = *lastError
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
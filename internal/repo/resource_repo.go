@@ -0,0 +1,385 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// ResourceRepo — репозиторий именованных ресурсов, их занятых слотов
+// и очереди ожидающих шагов.
+type ResourceRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewResourceRepo создаёт новый ResourceRepo.
+func NewResourceRepo(pool *pgxpool.Pool) *ResourceRepo {
+	return &ResourceRepo{pool: pool}
+}
+
+// resourceColumns — колонки resources в порядке scanResource
+// (in_use и waiting вычисляются подзапросами).
+const resourceColumns = `name, capacity, description, created_at, updated_at,
+		       (SELECT count(*) FROM resource_leases l WHERE l.resource = resources.name),
+		       (SELECT count(*) FROM resource_waiters w WHERE w.resource = resources.name)`
+
+// pgUniqueViolation — код ошибки Postgres при нарушении уникальности.
+const pgUniqueViolation = "23505"
+
+// --- Resources ---
+
+// Create создаёт новый ресурс.
+// Для уже существующего имени возвращает ErrAlreadyExists.
+func (r *ResourceRepo) Create(ctx context.Context, res *domain.Resource) error {
+	query := `
+		INSERT INTO resources (name, capacity, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		res.Name,
+		res.Capacity,
+		nullString(res.Description),
+		res.CreatedAt,
+		res.UpdatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("insert resource: %w", err)
+	}
+	return nil
+}
+
+// GetByName возвращает ресурс по имени.
+func (r *ResourceRepo) GetByName(ctx context.Context, name string) (*domain.Resource, error) {
+	query := `SELECT ` + resourceColumns + ` FROM resources WHERE name = $1`
+	return r.scanResource(r.pool.QueryRow(ctx, query, name))
+}
+
+// List возвращает все ресурсы, отсортированные по имени.
+func (r *ResourceRepo) List(ctx context.Context) ([]domain.Resource, error) {
+	query := `SELECT ` + resourceColumns + ` FROM resources ORDER BY name`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list resources: %w", err)
+	}
+	defer rows.Close()
+
+	var resources []domain.Resource
+	for rows.Next() {
+		res, err := r.scanResource(rows)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *res)
+	}
+	return resources, rows.Err()
+}
+
+// Update обновляет ёмкость и описание ресурса.
+// Уменьшение ёмкости не отзывает занятые слоты: новые выдаются,
+// когда занятых станет меньше Capacity.
+func (r *ResourceRepo) Update(ctx context.Context, res *domain.Resource) error {
+	query := `
+		UPDATE resources
+		SET capacity = $2, description = $3, updated_at = $4
+		WHERE name = $1
+	`
+	result, err := r.pool.Exec(ctx, query,
+		res.Name,
+		res.Capacity,
+		nullString(res.Description),
+		res.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update resource: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete удаляет ресурс вместе с очередью ожидающих.
+// Ресурс с занятыми слотами удалить нельзя — возвращается ErrInvalidState.
+func (r *ResourceRepo) Delete(ctx context.Context, name string) error {
+	query := `
+		DELETE FROM resources
+		WHERE name = $1
+		  AND NOT EXISTS (SELECT 1 FROM resource_leases WHERE resource = $1)
+	`
+	result, err := r.pool.Exec(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("delete resource: %w", err)
+	}
+	if result.RowsAffected() > 0 {
+		return nil
+	}
+
+	if _, err := r.GetByName(ctx, name); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: resource %s has acquired slots", ErrInvalidState, name)
+}
+
+// --- Leases ---
+
+// Acquire пытается занять слот в каждом из ресурсов names для шага run.
+//
+// Слоты выдаются атомарно: либо все, либо ни одного (шаг не держит часть
+// ресурсов, ожидая остальные, — взаимных блокировок нет). Если хотя бы
+// в одном ресурсе нет свободного слота, шаг ставится в очередь ожидающих
+// всех ресурсов и возвращается false. Очередь FIFO: слот получает шаг,
+// только если занятых слотов и ожидающих перед ним меньше Capacity.
+//
+// Повторный Acquire для шага, уже занявшего слоты, возвращает true.
+// Для неизвестного ресурса возвращается ErrNotFound.
+func (r *ResourceRepo) Acquire(ctx context.Context, runID uuid.UUID, stepID string, names []string) (bool, error) {
+	// Единый порядок блокировок строк resources между транзакциями
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit — no-op
+
+	rows, err := tx.Query(ctx, `
+		SELECT name, capacity FROM resources
+		WHERE name = ANY($1)
+		ORDER BY name
+		FOR UPDATE
+	`, names)
+	if err != nil {
+		return false, fmt.Errorf("lock resources: %w", err)
+	}
+	capacity := make(map[string]int, len(names))
+	for rows.Next() {
+		var name string
+		var c int
+		if err := rows.Scan(&name, &c); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("scan resource: %w", err)
+		}
+		capacity[name] = c
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("lock resources: %w", err)
+	}
+
+	available := true
+	for _, name := range names {
+		c, ok := capacity[name]
+		if !ok {
+			return false, fmt.Errorf("%w: resource %s", ErrNotFound, name)
+		}
+
+		// Занятые слоты (кроме своего) + ожидающие, вставшие в очередь раньше
+		var used, ahead int
+		var held bool
+		err := tx.QueryRow(ctx, `
+			SELECT
+				(SELECT count(*) FROM resource_leases
+				 WHERE resource = $1 AND NOT (run_id = $2 AND step_id = $3)),
+				(SELECT count(*) FROM resource_waiters
+				 WHERE resource = $1 AND NOT (run_id = $2 AND step_id = $3)
+				   AND created_at < COALESCE(
+				       (SELECT created_at FROM resource_waiters
+				        WHERE resource = $1 AND run_id = $2 AND step_id = $3),
+				       'infinity')),
+				EXISTS (SELECT 1 FROM resource_leases
+				        WHERE resource = $1 AND run_id = $2 AND step_id = $3)
+		`, name, runID, stepID).Scan(&used, &ahead, &held)
+		if err != nil {
+			return false, fmt.Errorf("count resource usage: %w", err)
+		}
+		if !held && used+ahead >= c {
+			available = false
+		}
+	}
+
+	if !available {
+		for _, name := range names {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO resource_waiters (resource, run_id, step_id, created_at)
+				VALUES ($1, $2, $3, now())
+				ON CONFLICT DO NOTHING
+			`, name, runID, stepID)
+			if err != nil {
+				return false, fmt.Errorf("insert resource waiter: %w", err)
+			}
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return false, fmt.Errorf("commit tx: %w", err)
+		}
+		return false, nil
+	}
+
+	for _, name := range names {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO resource_leases (resource, run_id, step_id, acquired_at)
+			VALUES ($1, $2, $3, now())
+			ON CONFLICT DO NOTHING
+		`, name, runID, stepID)
+		if err != nil {
+			return false, fmt.Errorf("insert resource lease: %w", err)
+		}
+	}
+	_, err = tx.Exec(ctx, `DELETE FROM resource_waiters WHERE run_id = $1 AND step_id = $2`, runID, stepID)
+	if err != nil {
+		return false, fmt.Errorf("delete resource waiter: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+	return true, nil
+}
+
+// Release освобождает слоты, занятые шагом run.
+// Возвращает количество освобождённых слотов.
+func (r *ResourceRepo) Release(ctx context.Context, runID uuid.UUID, stepID string) (int, error) {
+	result, err := r.pool.Exec(ctx,
+		`DELETE FROM resource_leases WHERE run_id = $1 AND step_id = $2`, runID, stepID)
+	if err != nil {
+		return 0, fmt.Errorf("release resources: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// ReleaseRun освобождает все слоты run и убирает его шаги из очереди.
+// Вызывается при завершении run. Возвращает количество освобождённых слотов.
+func (r *ResourceRepo) ReleaseRun(ctx context.Context, runID uuid.UUID) (int, error) {
+	if _, err := r.CancelWaits(ctx, runID); err != nil {
+		return 0, err
+	}
+	result, err := r.pool.Exec(ctx, `DELETE FROM resource_leases WHERE run_id = $1`, runID)
+	if err != nil {
+		return 0, fmt.Errorf("release run resources: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// CancelWaits убирает шаги run из очереди ожидающих (занятые слоты остаются).
+func (r *ResourceRepo) CancelWaits(ctx context.Context, runID uuid.UUID) (int, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM resource_waiters WHERE run_id = $1`, runID)
+	if err != nil {
+		return 0, fmt.Errorf("cancel resource waits: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// ListWaitingRuns возвращает runs, шаги которых ждут слотов,
+// в порядке очереди (по самому раннему ожиданию run).
+func (r *ResourceRepo) ListWaitingRuns(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT run_id
+		FROM resource_waiters
+		GROUP BY run_id
+		ORDER BY min(created_at)
+		LIMIT $1
+	`
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list waiting runs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan run id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListLeases возвращает занятые слоты ресурса (старые первыми).
+func (r *ResourceRepo) ListLeases(ctx context.Context, name string) ([]domain.ResourceLease, error) {
+	query := `
+		SELECT resource, run_id, step_id, acquired_at
+		FROM resource_leases
+		WHERE resource = $1
+		ORDER BY acquired_at ASC
+	`
+	rows, err := r.pool.Query(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("list resource leases: %w", err)
+	}
+	defer rows.Close()
+
+	var leases []domain.ResourceLease
+	for rows.Next() {
+		var l domain.ResourceLease
+		if err := rows.Scan(&l.Resource, &l.RunID, &l.StepID, &l.AcquiredAt); err != nil {
+			return nil, fmt.Errorf("scan resource lease: %w", err)
+		}
+		leases = append(leases, l)
+	}
+	return leases, rows.Err()
+}
+
+// ListWaiters возвращает очередь ожидающих слота ресурса (первый — следующий).
+func (r *ResourceRepo) ListWaiters(ctx context.Context, name string) ([]domain.ResourceWaiter, error) {
+	query := `
+		SELECT resource, run_id, step_id, created_at
+		FROM resource_waiters
+		WHERE resource = $1
+		ORDER BY created_at ASC
+	`
+	rows, err := r.pool.Query(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("list resource waiters: %w", err)
+	}
+	defer rows.Close()
+
+	var waiters []domain.ResourceWaiter
+	for rows.Next() {
+		var w domain.ResourceWaiter
+		if err := rows.Scan(&w.Resource, &w.RunID, &w.StepID, &w.WaitingSince); err != nil {
+			return nil, fmt.Errorf("scan resource waiter: %w", err)
+		}
+		waiters = append(waiters, w)
+	}
+	return waiters, rows.Err()
+}
+
+// scanResource сканирует ресурс из pgx.Row (QueryRow или Rows).
+func (r *ResourceRepo) scanResource(row pgx.Row) (*domain.Resource, error) {
+	var res domain.Resource
+	var description *string
+
+	err := row.Scan(
+		&res.Name,
+		&res.Capacity,
+		&description,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+		&res.InUse,
+		&res.Waiting,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan resource: %w", err)
+	}
+
+	if description != nil {
+		res.Description = *description
+	}
+	return &res, nil
+}
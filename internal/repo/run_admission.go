@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// runAdmissionLockNamespace — первый ключ advisory lock допуска runs,
+// отделяет его от других advisory locks в той же базе.
+const runAdmissionLockNamespace = "automata.run_admission"
+
+// RunAdmission — транзакция допуска run по лимиту concurrency flow.
+//
+// Держит advisory lock flow (pg_advisory_xact_lock) до Start или Release:
+// подсчёт выполняющихся runs и перевод нового run в RUNNING не
+// перемежаются с допуском других runs того же flow — в том числе
+// на других экземплярах Orchestrator.
+type RunAdmission struct {
+	repo   *RunRepo
+	tx     pgx.Tx
+	flowID uuid.UUID
+}
+
+// BeginAdmission начинает допуск run flow: открывает транзакцию и ждёт
+// advisory lock flow. Вызывающий код обязан завершить допуск через
+// Start или Release.
+func (r *RunRepo) BeginAdmission(ctx context.Context, flowID uuid.UUID) (*RunAdmission, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`,
+		runAdmissionLockNamespace, flowID.String())
+	if err != nil {
+		tx.Rollback(ctx) //nolint:errcheck // ошибка блокировки важнее
+		return nil, fmt.Errorf("lock flow admission: %w", err)
+	}
+
+	return &RunAdmission{repo: r, tx: tx, flowID: flowID}, nil
+}
+
+// ListRunning возвращает выполняющиеся (RUNNING и COMPENSATING) runs
+// flow с тем же ключом concurrency (старые первыми). Пустой key — runs
+// без ключа.
+func (a *RunAdmission) ListRunning(ctx context.Context, key string) ([]domain.Run, error) {
+	query := `
+		SELECT id, flow_id, version, status, inputs, started_at, finished_at,
+		       error, idempotency_key, is_sandbox, spec_override, outputs,
+		       retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		       concurrency_key, waiting_reason, created_at
+		FROM runs
+		WHERE flow_id = $1 AND status IN ('RUNNING', 'COMPENSATING') AND is_sandbox = false
+		  AND concurrency_key IS NOT DISTINCT FROM $2
+		ORDER BY started_at ASC
+	`
+	rows, err := a.tx.Query(ctx, query, a.flowID, nullString(key))
+	if err != nil {
+		return nil, fmt.Errorf("list running runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.Run
+	for rows.Next() {
+		run, err := a.repo.scanRunFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// Start сохраняет допущенный run (обычно уже в статусе RUNNING)
+// и фиксирует транзакцию, освобождая блокировку flow.
+func (a *RunAdmission) Start(ctx context.Context, run *domain.Run) error {
+	if err := updateRun(ctx, a.tx, run); err != nil {
+		return err
+	}
+	if err := a.tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// Release откатывает транзакцию и освобождает блокировку flow.
+// После Start — no-op.
+func (a *RunAdmission) Release(ctx context.Context) {
+	a.tx.Rollback(ctx) //nolint:errcheck // после Commit — no-op
+}
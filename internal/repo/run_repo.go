@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shaiso/Automata/internal/domain"
 )
@@ -24,6 +25,36 @@ func NewRunRepo(pool *pgxpool.Pool) *RunRepo {
 
 // Create создаёт новый run.
 func (r *RunRepo) Create(ctx context.Context, run *domain.Run) error {
+	return insertRun(ctx, r.pool, run)
+}
+
+// CreateRetry атомарно создаёт повторный run вместе с tasks,
+// результаты которых он переиспользует из исходного run.
+func (r *RunRepo) CreateRetry(ctx context.Context, run *domain.Run, tasks []domain.Task) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit — no-op
+
+	if err := insertRun(ctx, tx, run); err != nil {
+		return err
+	}
+	for i := range tasks {
+		if err := insertTask(ctx, tx, &tasks[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// insertRun вставляет run через pool или транзакцию.
+// Run с уже занятым idempotency key flow — ErrAlreadyExists.
+func insertRun(ctx context.Context, db execer, run *domain.Run) error {
 	inputsJSON, err := json.Marshal(run.Inputs)
 	if err != nil {
 		return fmt.Errorf("marshal inputs: %w", err)
@@ -38,10 +69,11 @@ func (r *RunRepo) Create(ctx context.Context, run *domain.Run) error {
 	}
 
 	query := `
-		INSERT INTO runs (id, flow_id, version, status, inputs, idempotency_key, is_sandbox, spec_override, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO runs (id, flow_id, version, status, inputs, idempotency_key, is_sandbox, spec_override,
+		                  retry_of, retry_from_step, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
-	_, err = r.pool.Exec(ctx, query,
+	_, err = db.Exec(ctx, query,
 		run.ID,
 		run.FlowID,
 		run.Version,
@@ -50,9 +82,15 @@ func (r *RunRepo) Create(ctx context.Context, run *domain.Run) error {
 		nullString(run.IdempotencyKey),
 		run.IsSandbox,
 		specOverrideJSON,
+		run.RetryOf,
+		nullString(run.RetryFromStep),
 		run.CreatedAt,
 	)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return fmt.Errorf("insert run: %w", ErrAlreadyExists)
+		}
 		return fmt.Errorf("insert run: %w", err)
 	}
 	return nil
@@ -62,7 +100,9 @@ func (r *RunRepo) Create(ctx context.Context, run *domain.Run) error {
 func (r *RunRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Run, error) {
 	query := `
 		SELECT id, flow_id, version, status, inputs, started_at, finished_at,
-		       error, idempotency_key, is_sandbox, spec_override, created_at
+		       error, idempotency_key, is_sandbox, spec_override, outputs,
+		       retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		       concurrency_key, waiting_reason, created_at
 		FROM runs
 		WHERE id = $1
 	`
@@ -73,7 +113,9 @@ func (r *RunRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Run, error
 func (r *RunRepo) GetByIdempotencyKey(ctx context.Context, flowID uuid.UUID, key string) (*domain.Run, error) {
 	query := `
 		SELECT id, flow_id, version, status, inputs, started_at, finished_at,
-		       error, idempotency_key, is_sandbox, spec_override, created_at
+		       error, idempotency_key, is_sandbox, spec_override, outputs,
+		       retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		       concurrency_key, waiting_reason, created_at
 		FROM runs
 		WHERE flow_id = $1 AND idempotency_key = $2
 	`
@@ -84,10 +126,13 @@ func (r *RunRepo) GetByIdempotencyKey(ctx context.Context, flowID uuid.UUID, key
 func (r *RunRepo) List(ctx context.Context, filter RunFilter) ([]domain.Run, error) {
 	query := `
 		SELECT id, flow_id, version, status, inputs, started_at, finished_at,
-		       error, idempotency_key, is_sandbox, spec_override, created_at
+		       error, idempotency_key, is_sandbox, spec_override, outputs,
+		       retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		       concurrency_key, waiting_reason, created_at
 		FROM runs
 		WHERE ($1::uuid IS NULL OR flow_id = $1)
 		  AND ($2::text IS NULL OR status = $2::run_status)
+		  AND ($5::uuid IS NULL OR retry_of = $5)
 		ORDER BY created_at DESC
 		LIMIT $3 OFFSET $4
 	`
@@ -96,6 +141,7 @@ func (r *RunRepo) List(ctx context.Context, filter RunFilter) ([]domain.Run, err
 		nullString(string(filter.Status)),
 		filter.Limit,
 		filter.Offset,
+		nullUUID(filter.RetryOf),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list runs: %w", err)
@@ -115,17 +161,37 @@ func (r *RunRepo) List(ctx context.Context, filter RunFilter) ([]domain.Run, err
 
 // Update обновляет run.
 func (r *RunRepo) Update(ctx context.Context, run *domain.Run) error {
+	return updateRun(ctx, r.pool, run)
+}
+
+// updateRun обновляет run через pool или транзакцию.
+func updateRun(ctx context.Context, db execer, run *domain.Run) error {
+	var outputsJSON []byte
+	if run.Outputs != nil {
+		var err error
+		outputsJSON, err = json.Marshal(run.Outputs)
+		if err != nil {
+			return fmt.Errorf("marshal outputs: %w", err)
+		}
+	}
+
 	query := `
 		UPDATE runs
-		SET status = $2, started_at = $3, finished_at = $4, error = $5
+		SET status = $2, started_at = $3, finished_at = $4, error = $5, outputs = $6,
+		    deadline_at = $7, sla_at = $8, concurrency_key = $9, waiting_reason = $10
 		WHERE id = $1
 	`
-	result, err := r.pool.Exec(ctx, query,
+	result, err := db.Exec(ctx, query,
 		run.ID,
 		run.Status,
 		run.StartedAt,
 		run.FinishedAt,
 		nullString(run.Error),
+		outputsJSON,
+		run.DeadlineAt,
+		run.SLAAt,
+		nullString(run.ConcurrencyKey),
+		nullString(run.WaitingReason),
 	)
 	if err != nil {
 		return fmt.Errorf("update run: %w", err)
@@ -137,13 +203,16 @@ func (r *RunRepo) Update(ctx context.Context, run *domain.Run) error {
 }
 
 // ListPending возвращает runs в статусе PENDING.
+// Runs, ожидающие слота concurrency, идут после новых.
 func (r *RunRepo) ListPending(ctx context.Context, limit int) ([]domain.Run, error) {
 	query := `
 		SELECT id, flow_id, version, status, inputs, started_at, finished_at,
-		       error, idempotency_key, is_sandbox, spec_override, created_at
+		       error, idempotency_key, is_sandbox, spec_override, outputs,
+		       retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		       concurrency_key, waiting_reason, created_at
 		FROM runs
 		WHERE status = 'PENDING'
-		ORDER BY created_at ASC
+		ORDER BY waiting_reason IS NOT NULL, created_at ASC
 		LIMIT $1
 	`
 	rows, err := r.pool.Query(ctx, query, limit)
@@ -163,14 +232,143 @@ func (r *RunRepo) ListPending(ctx context.Context, limit int) ([]domain.Run, err
 	return runs, rows.Err()
 }
 
+// ListRecentFinished возвращает последние завершённые runs flow (новые первыми).
+// Отменённые и sandbox runs не учитываются: по ним считаются серии ошибок.
+func (r *RunRepo) ListRecentFinished(ctx context.Context, flowID uuid.UUID, limit int) ([]domain.Run, error) {
+	query := `
+		SELECT id, flow_id, version, status, inputs, started_at, finished_at,
+		       error, idempotency_key, is_sandbox, spec_override, outputs,
+		       retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		       concurrency_key, waiting_reason, created_at
+		FROM runs
+		WHERE flow_id = $1 AND is_sandbox = false
+		  AND status IN ('SUCCEEDED', 'FAILED', 'COMPENSATED', 'COMPENSATION_FAILED')
+		ORDER BY finished_at DESC
+		LIMIT $2
+	`
+	rows, err := r.pool.Query(ctx, query, flowID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent finished runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.Run
+	for rows.Next() {
+		run, err := r.scanRunFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// ListQueued возвращает PENDING runs flow, ожидающие слота concurrency
+// (с waiting_reason), в порядке создания.
+func (r *RunRepo) ListQueued(ctx context.Context, flowID uuid.UUID, limit int) ([]domain.Run, error) {
+	query := `
+		SELECT id, flow_id, version, status, inputs, started_at, finished_at,
+		       error, idempotency_key, is_sandbox, spec_override, outputs,
+		       retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		       concurrency_key, waiting_reason, created_at
+		FROM runs
+		WHERE flow_id = $1 AND status = 'PENDING' AND waiting_reason IS NOT NULL
+		ORDER BY created_at ASC
+		LIMIT $2
+	`
+	rows, err := r.pool.Query(ctx, query, flowID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list queued runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.Run
+	for rows.Next() {
+		run, err := r.scanRunFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// ListOverdue возвращает выполняющиеся (RUNNING и COMPENSATING) runs
+// с истёкшим дедлайном (deadline_at).
+func (r *RunRepo) ListOverdue(ctx context.Context, limit int) ([]domain.Run, error) {
+	query := `
+		SELECT id, flow_id, version, status, inputs, started_at, finished_at,
+		       error, idempotency_key, is_sandbox, spec_override, outputs,
+		       retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		       concurrency_key, waiting_reason, created_at
+		FROM runs
+		WHERE status IN ('RUNNING', 'COMPENSATING') AND deadline_at IS NOT NULL AND deadline_at <= now()
+		ORDER BY deadline_at ASC
+		LIMIT $1
+	`
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list overdue runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.Run
+	for rows.Next() {
+		run, err := r.scanRunFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// ClaimSLABreached отмечает превышение SLA у выполняющихся (RUNNING
+// и COMPENSATING) runs, для которых наступил sla_at, и возвращает их. Каждый run возвращается один раз —
+// даже при нескольких экземплярах Orchestrator.
+func (r *RunRepo) ClaimSLABreached(ctx context.Context, limit int) ([]domain.Run, error) {
+	query := `
+		UPDATE runs
+		SET sla_breached_at = now()
+		WHERE id IN (
+			SELECT id FROM runs
+			WHERE status IN ('RUNNING', 'COMPENSATING') AND sla_at IS NOT NULL AND sla_at <= now()
+			  AND sla_breached_at IS NULL
+			ORDER BY sla_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, flow_id, version, status, inputs, started_at, finished_at,
+		          error, idempotency_key, is_sandbox, spec_override, outputs,
+		          retry_of, retry_from_step, deadline_at, sla_at, sla_breached_at,
+		          concurrency_key, waiting_reason, created_at
+	`
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim sla breached runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.Run
+	for rows.Next() {
+		run, err := r.scanRunFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
 // --- Helpers ---
 
 // RunFilter — параметры фильтрации runs.
 type RunFilter struct {
-	FlowID *uuid.UUID
-	Status domain.RunStatus
-	Limit  int
-	Offset int
+	FlowID  *uuid.UUID
+	Status  domain.RunStatus
+	RetryOf *uuid.UUID
+	Limit   int
+	Offset  int
 }
 
 // scanRun сканирует одну строку в Run.
@@ -180,6 +378,10 @@ func (r *RunRepo) scanRun(row pgx.Row) (*domain.Run, error) {
 	var idempotencyKey *string
 	var runError *string
 	var specOverrideJSON []byte
+	var outputsJSON []byte
+	var retryFromStep *string
+	var concurrencyKey *string
+	var waitingReason *string
 
 	err := row.Scan(
 		&run.ID,
@@ -193,6 +395,14 @@ func (r *RunRepo) scanRun(row pgx.Row) (*domain.Run, error) {
 		&idempotencyKey,
 		&run.IsSandbox,
 		&specOverrideJSON,
+		&outputsJSON,
+		&run.RetryOf,
+		&retryFromStep,
+		&run.DeadlineAt,
+		&run.SLAAt,
+		&run.SLABreachedAt,
+		&concurrencyKey,
+		&waitingReason,
 		&run.CreatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -215,12 +425,27 @@ func (r *RunRepo) scanRun(row pgx.Row) (*domain.Run, error) {
 		}
 	}
 
+	if outputsJSON != nil {
+		if err := json.Unmarshal(outputsJSON, &run.Outputs); err != nil {
+			return nil, fmt.Errorf("unmarshal outputs: %w", err)
+		}
+	}
+
 	if idempotencyKey != nil {
 		run.IdempotencyKey = *idempotencyKey
 	}
 	if runError != nil {
 		run.Error = *runError
 	}
+	if retryFromStep != nil {
+		run.RetryFromStep = *retryFromStep
+	}
+	if concurrencyKey != nil {
+		run.ConcurrencyKey = *concurrencyKey
+	}
+	if waitingReason != nil {
+		run.WaitingReason = *waitingReason
+	}
 
 	return &run, nil
 }
@@ -232,6 +457,10 @@ func (r *RunRepo) scanRunFromRows(rows pgx.Rows) (*domain.Run, error) {
 	var idempotencyKey *string
 	var runError *string
 	var specOverrideJSON []byte
+	var outputsJSON []byte
+	var retryFromStep *string
+	var concurrencyKey *string
+	var waitingReason *string
 
 	err := rows.Scan(
 		&run.ID,
@@ -245,6 +474,14 @@ func (r *RunRepo) scanRunFromRows(rows pgx.Rows) (*domain.Run, error) {
 		&idempotencyKey,
 		&run.IsSandbox,
 		&specOverrideJSON,
+		&outputsJSON,
+		&run.RetryOf,
+		&retryFromStep,
+		&run.DeadlineAt,
+		&run.SLAAt,
+		&run.SLABreachedAt,
+		&concurrencyKey,
+		&waitingReason,
 		&run.CreatedAt,
 	)
 	if err != nil {
@@ -264,12 +501,27 @@ func (r *RunRepo) scanRunFromRows(rows pgx.Rows) (*domain.Run, error) {
 		}
 	}
 
+	if outputsJSON != nil {
+		if err := json.Unmarshal(outputsJSON, &run.Outputs); err != nil {
+			return nil, fmt.Errorf("unmarshal outputs: %w", err)
+		}
+	}
+
 	if idempotencyKey != nil {
 		run.IdempotencyKey = *idempotencyKey
 	}
 	if runError != nil {
 		run.Error = *runError
 	}
+	if retryFromStep != nil {
+		run.RetryFromStep = *retryFromStep
+	}
+	if concurrencyKey != nil {
+		run.ConcurrencyKey = *concurrencyKey
+	}
+	if waitingReason != nil {
+		run.WaitingReason = *waitingReason
+	}
 
 	return &run, nil
 }
@@ -282,6 +534,11 @@ func nullString(s string) *string {
 	return &s
 }
 
+// execer — общий интерфейс pgxpool.Pool и pgx.Tx для INSERT/UPDATE.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
 // nullUUID возвращает nil для пустого UUID.
 func nullUUID(id *uuid.UUID) *uuid.UUID {
 	if id == nil || *id == uuid.Nil {
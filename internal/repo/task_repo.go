@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -24,16 +25,30 @@ func NewTaskRepo(pool *pgxpool.Pool) *TaskRepo {
 
 // Create создаёт новый task.
 func (r *TaskRepo) Create(ctx context.Context, task *domain.Task) error {
+	return insertTask(ctx, r.pool, task)
+}
+
+// insertTask вставляет task через pool или транзакцию.
+func insertTask(ctx context.Context, db execer, task *domain.Task) error {
 	payloadJSON, err := json.Marshal(task.Payload)
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
 
+	var outputsJSON []byte
+	if task.Outputs != nil {
+		outputsJSON, err = json.Marshal(task.Outputs)
+		if err != nil {
+			return fmt.Errorf("marshal outputs: %w", err)
+		}
+	}
+
 	query := `
-		INSERT INTO tasks (id, run_id, step_id, name, type, attempt, status, payload, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO tasks (id, run_id, step_id, name, type, attempt, status, payload, created_at,
+		                   started_at, next_attempt_at, outputs, finished_at, result_ref)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
-	_, err = r.pool.Exec(ctx, query,
+	_, err = db.Exec(ctx, query,
 		task.ID,
 		task.RunID,
 		task.StepID,
@@ -43,6 +58,11 @@ func (r *TaskRepo) Create(ctx context.Context, task *domain.Task) error {
 		task.Status,
 		payloadJSON,
 		task.CreatedAt,
+		task.StartedAt,
+		task.NextAttemptAt,
+		outputsJSON,
+		task.FinishedAt,
+		nullString(task.ResultRef),
 	)
 	if err != nil {
 		return fmt.Errorf("insert task: %w", err)
@@ -54,7 +74,7 @@ func (r *TaskRepo) Create(ctx context.Context, task *domain.Task) error {
 func (r *TaskRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
 	query := `
 		SELECT id, run_id, step_id, name, type, attempt, status, payload, outputs,
-		       result_ref, started_at, finished_at, error, created_at
+		       result_ref, started_at, finished_at, error, created_at, next_attempt_at
 		FROM tasks
 		WHERE id = $1
 	`
@@ -65,7 +85,7 @@ func (r *TaskRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Task, err
 func (r *TaskRepo) ListByRunID(ctx context.Context, runID uuid.UUID) ([]domain.Task, error) {
 	query := `
 		SELECT id, run_id, step_id, name, type, attempt, status, payload, outputs,
-		       result_ref, started_at, finished_at, error, created_at
+		       result_ref, started_at, finished_at, error, created_at, next_attempt_at
 		FROM tasks
 		WHERE run_id = $1
 		ORDER BY created_at ASC
@@ -91,7 +111,7 @@ func (r *TaskRepo) ListByRunID(ctx context.Context, runID uuid.UUID) ([]domain.T
 func (r *TaskRepo) GetByRunAndStepID(ctx context.Context, runID uuid.UUID, stepID string) (*domain.Task, error) {
 	query := `
 		SELECT id, run_id, step_id, name, type, attempt, status, payload, outputs,
-		       result_ref, started_at, finished_at, error, created_at
+		       result_ref, started_at, finished_at, error, created_at, next_attempt_at
 		FROM tasks
 		WHERE run_id = $1 AND step_id = $2
 	`
@@ -108,7 +128,7 @@ func (r *TaskRepo) Update(ctx context.Context, task *domain.Task) error {
 	query := `
 		UPDATE tasks
 		SET attempt = $2, status = $3, outputs = $4, result_ref = $5,
-		    started_at = $6, finished_at = $7, error = $8
+		    started_at = $6, finished_at = $7, error = $8, next_attempt_at = $9
 		WHERE id = $1
 	`
 	result, err := r.pool.Exec(ctx, query,
@@ -120,6 +140,7 @@ func (r *TaskRepo) Update(ctx context.Context, task *domain.Task) error {
 		task.StartedAt,
 		task.FinishedAt,
 		nullString(task.Error),
+		task.NextAttemptAt,
 	)
 	if err != nil {
 		return fmt.Errorf("update task: %w", err)
@@ -130,13 +151,37 @@ func (r *TaskRepo) Update(ctx context.Context, task *domain.Task) error {
 	return nil
 }
 
-// ListQueued возвращает tasks в статусе QUEUED.
+// Claim переводит QUEUED task в RUNNING (task.MarkRunning), только если
+// она всё ещё в QUEUED и время попытки наступило. Защищает от повторного
+// выполнения task, которую одновременно подхватили событие, таймер
+// и polling. Возвращает ErrInvalidState, если task уже забрана.
+func (r *TaskRepo) Claim(ctx context.Context, task *domain.Task) error {
+	query := `
+		UPDATE tasks
+		SET attempt = $2, status = $3, started_at = $4, next_attempt_at = NULL
+		WHERE id = $1 AND status = 'QUEUED'
+		  AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+	`
+	result, err := r.pool.Exec(ctx, query, task.ID, task.Attempt, task.Status, task.StartedAt)
+	if err != nil {
+		return fmt.Errorf("claim task: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+// ListQueued возвращает tasks в статусе QUEUED, время выполнения которых наступило.
+// Tasks external шагов не возвращаются — их забирают внешние воркеры
+// (FetchAndLockExternal).
 func (r *TaskRepo) ListQueued(ctx context.Context, limit int) ([]domain.Task, error) {
 	query := `
 		SELECT id, run_id, step_id, name, type, attempt, status, payload, outputs,
-		       result_ref, started_at, finished_at, error, created_at
+		       result_ref, started_at, finished_at, error, created_at, next_attempt_at
 		FROM tasks
-		WHERE status = 'QUEUED'
+		WHERE status = 'QUEUED' AND type <> 'external'
+		  AND (next_attempt_at IS NULL OR next_attempt_at <= now())
 		ORDER BY created_at ASC
 		LIMIT $1
 	`
@@ -157,6 +202,82 @@ func (r *TaskRepo) ListQueued(ctx context.Context, limit int) ([]domain.Task, er
 	return tasks, rows.Err()
 }
 
+// CompleteWaiting сохраняет результат WAITING task (сигнал или timeout).
+// Обновление выполняется только если task всё ещё в статусе WAITING —
+// это защищает от гонки между двумя сигналами или сигналом и timeout.
+// Возвращает ErrInvalidState, если task уже завершён.
+func (r *TaskRepo) CompleteWaiting(ctx context.Context, task *domain.Task) error {
+	outputsJSON, err := json.Marshal(task.Outputs)
+	if err != nil {
+		return fmt.Errorf("marshal outputs: %w", err)
+	}
+
+	query := `
+		UPDATE tasks
+		SET status = $2, outputs = $3, finished_at = $4, error = $5, next_attempt_at = NULL
+		WHERE id = $1 AND status = 'WAITING'
+	`
+	result, err := r.pool.Exec(ctx, query,
+		task.ID,
+		task.Status,
+		outputsJSON,
+		task.FinishedAt,
+		nullString(task.Error),
+	)
+	if err != nil {
+		return fmt.Errorf("complete waiting task: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+// ListWaiting возвращает WAITING tasks активных runs.
+// stepType фильтрует по типу шага (пусто — все типы).
+func (r *TaskRepo) ListWaiting(ctx context.Context, stepType string, limit int) ([]domain.Task, error) {
+	query := `
+		SELECT t.id, t.run_id, t.step_id, t.name, t.type, t.attempt, t.status, t.payload, t.outputs,
+		       t.result_ref, t.started_at, t.finished_at, t.error, t.created_at, t.next_attempt_at
+		FROM tasks t
+		JOIN runs r ON r.id = t.run_id
+		WHERE t.status = 'WAITING' AND r.status = 'RUNNING' AND ($1 = '' OR t.type = $1)
+		ORDER BY t.created_at ASC
+		LIMIT $2
+	`
+	return r.queryTasks(ctx, query, stepType, limit)
+}
+
+// ListExpiredWaiting возвращает WAITING tasks активных runs с истёкшим дедлайном.
+func (r *TaskRepo) ListExpiredWaiting(ctx context.Context, limit int) ([]domain.Task, error) {
+	query := `
+		SELECT t.id, t.run_id, t.step_id, t.name, t.type, t.attempt, t.status, t.payload, t.outputs,
+		       t.result_ref, t.started_at, t.finished_at, t.error, t.created_at, t.next_attempt_at
+		FROM tasks t
+		JOIN runs r ON r.id = t.run_id
+		WHERE t.status = 'WAITING' AND r.status = 'RUNNING'
+		  AND t.next_attempt_at IS NOT NULL AND t.next_attempt_at <= now()
+		ORDER BY t.next_attempt_at ASC
+		LIMIT $1
+	`
+	return r.queryTasks(ctx, query, limit)
+}
+
+// CancelActive отменяет незавершённые tasks run (QUEUED, RUNNING, WAITING).
+// Возвращает количество отменённых tasks.
+func (r *TaskRepo) CancelActive(ctx context.Context, runID uuid.UUID, reason string) (int, error) {
+	query := `
+		UPDATE tasks
+		SET status = 'CANCELLED', finished_at = now(), error = $2, next_attempt_at = NULL
+		WHERE run_id = $1 AND status IN ('QUEUED', 'RUNNING', 'WAITING')
+	`
+	result, err := r.pool.Exec(ctx, query, runID, reason)
+	if err != nil {
+		return 0, fmt.Errorf("cancel active tasks: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
 // CountByRunAndStatus возвращает количество tasks по статусу для run.
 func (r *TaskRepo) CountByRunAndStatus(ctx context.Context, runID uuid.UUID, status domain.TaskStatus) (int, error) {
 	var count int
@@ -169,8 +290,130 @@ func (r *TaskRepo) CountByRunAndStatus(ctx context.Context, runID uuid.UUID, sta
 	return count, nil
 }
 
+// --- External tasks ---
+
+// FetchAndLockExternal блокирует за воркером workerID до limit QUEUED tasks
+// external шагов темы topic, время выполнения которых наступило: tasks
+// переводятся в RUNNING (новая попытка), дедлайн блокировки — через
+// lockDuration. Tasks, заблокированные параллельным запросом, пропускаются.
+func (r *TaskRepo) FetchAndLockExternal(ctx context.Context, topic, workerID string, lockDuration time.Duration, limit int) ([]domain.Task, error) {
+	query := `
+		UPDATE tasks t
+		SET status = 'RUNNING', attempt = t.attempt + 1, started_at = now(), finished_at = NULL,
+		    worker_id = $2, next_attempt_at = now() + $3 * interval '1 millisecond'
+		FROM (
+			SELECT id FROM tasks
+			WHERE type = 'external' AND status = 'QUEUED' AND payload->>'topic' = $1
+			  AND (next_attempt_at IS NULL OR next_attempt_at <= now())
+			ORDER BY created_at ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		) locked
+		WHERE t.id = locked.id
+		RETURNING t.id, t.run_id, t.step_id, t.name, t.type, t.attempt, t.status, t.payload, t.outputs,
+		          t.result_ref, t.started_at, t.finished_at, t.error, t.created_at, t.next_attempt_at
+	`
+	return r.queryTasks(ctx, query, topic, workerID, lockDuration.Milliseconds(), limit)
+}
+
+// ExtendExternalLock переносит дедлайн блокировки task на until.
+// Возвращает ErrInvalidState, если task не заблокирован воркером workerID.
+func (r *TaskRepo) ExtendExternalLock(ctx context.Context, id uuid.UUID, workerID string, until time.Time) error {
+	query := `
+		UPDATE tasks SET next_attempt_at = $3
+		WHERE id = $1 AND type = 'external' AND status = 'RUNNING' AND worker_id = $2
+	`
+	result, err := r.pool.Exec(ctx, query, id, workerID, until)
+	if err != nil {
+		return fmt.Errorf("extend external lock: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+// ReleaseExternal сохраняет результат попытки task, заблокированного
+// воркером workerID (SUCCEEDED, FAILED или QUEUED для повтора), и снимает
+// блокировку. Возвращает ErrInvalidState, если блокировка уже потеряна.
+func (r *TaskRepo) ReleaseExternal(ctx context.Context, task *domain.Task, workerID string) error {
+	return r.releaseExternal(ctx, task, "worker_id = $9", workerID)
+}
+
+// ListExpiredExternal возвращает RUNNING tasks external шагов с истёкшей блокировкой.
+func (r *TaskRepo) ListExpiredExternal(ctx context.Context, limit int) ([]domain.Task, error) {
+	query := `
+		SELECT id, run_id, step_id, name, type, attempt, status, payload, outputs,
+		       result_ref, started_at, finished_at, error, created_at, next_attempt_at
+		FROM tasks
+		WHERE type = 'external' AND status = 'RUNNING' AND next_attempt_at <= now()
+		ORDER BY next_attempt_at ASC
+		LIMIT $1
+	`
+	return r.queryTasks(ctx, query, limit)
+}
+
+// ExpireExternalLock сохраняет результат попытки с истёкшей блокировкой.
+// Обновление выполняется, только если блокировка всё ещё истекла: это
+// защищает от гонки с complete и extend-lock внешнего воркера.
+// Возвращает ErrInvalidState, если воркер успел ответить.
+func (r *TaskRepo) ExpireExternalLock(ctx context.Context, task *domain.Task) error {
+	return r.releaseExternal(ctx, task, "next_attempt_at <= now()")
+}
+
+// releaseExternal обновляет RUNNING task external шага при дополнительном
+// условии cond (параметры cond — начиная с $9).
+func (r *TaskRepo) releaseExternal(ctx context.Context, task *domain.Task, cond string, condArgs ...any) error {
+	outputsJSON, err := json.Marshal(task.Outputs)
+	if err != nil {
+		return fmt.Errorf("marshal outputs: %w", err)
+	}
+
+	query := `
+		UPDATE tasks
+		SET attempt = $2, status = $3, outputs = $4, started_at = $5, finished_at = $6,
+		    error = $7, next_attempt_at = $8, worker_id = NULL
+		WHERE id = $1 AND type = 'external' AND status = 'RUNNING' AND ` + cond
+	args := append([]any{
+		task.ID,
+		task.Attempt,
+		task.Status,
+		outputsJSON,
+		task.StartedAt,
+		task.FinishedAt,
+		nullString(task.Error),
+		task.NextAttemptAt,
+	}, condArgs...)
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("release external task: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrInvalidState
+	}
+	return nil
+}
+
 // --- Helpers ---
 
+func (r *TaskRepo) queryTasks(ctx context.Context, query string, args ...any) ([]domain.Task, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []domain.Task
+	for rows.Next() {
+		task, err := r.scanTaskFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	return tasks, rows.Err()
+}
+
 func (r *TaskRepo) scanTask(row pgx.Row) (*domain.Task, error) {
 	var task domain.Task
 	var payloadJSON, outputsJSON []byte
@@ -191,6 +434,7 @@ func (r *TaskRepo) scanTask(row pgx.Row) (*domain.Task, error) {
 		&task.FinishedAt,
 		&taskError,
 		&task.CreatedAt,
+		&task.NextAttemptAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -239,6 +483,7 @@ func (r *TaskRepo) scanTaskFromRows(rows pgx.Rows) (*domain.Task, error) {
 		&task.FinishedAt,
 		&taskError,
 		&task.CreatedAt,
+		&task.NextAttemptAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scan task: %w", err)
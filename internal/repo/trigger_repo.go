@@ -0,0 +1,275 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// TriggerRepo — репозиторий для работы с triggers.
+type TriggerRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewTriggerRepo создаёт новый TriggerRepo.
+func NewTriggerRepo(pool *pgxpool.Pool) *TriggerRepo {
+	return &TriggerRepo{pool: pool}
+}
+
+// triggerColumns — колонки triggers в порядке scanTrigger.
+const triggerColumns = `id, flow_id, name, type, config, filter, input_mapping, enabled,
+		       last_fired_at, last_run_id, created_at, updated_at`
+
+// Create создаёт новый trigger.
+func (r *TriggerRepo) Create(ctx context.Context, trigger *domain.Trigger) error {
+	configJSON, mappingJSON, err := marshalTrigger(trigger)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO triggers (id, flow_id, name, type, config, filter, input_mapping,
+		                      enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err = r.pool.Exec(ctx, query,
+		trigger.ID,
+		trigger.FlowID,
+		trigger.Name,
+		string(trigger.Type),
+		configJSON,
+		nullString(trigger.Filter),
+		mappingJSON,
+		trigger.Enabled,
+		trigger.CreatedAt,
+		trigger.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert trigger: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает trigger по ID.
+func (r *TriggerRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Trigger, error) {
+	query := `SELECT ` + triggerColumns + ` FROM triggers WHERE id = $1`
+	return r.scanTrigger(r.pool.QueryRow(ctx, query, id))
+}
+
+// List возвращает список triggers с фильтрацией.
+func (r *TriggerRepo) List(ctx context.Context, filter TriggerFilter) ([]domain.Trigger, error) {
+	query := `
+		SELECT ` + triggerColumns + `
+		FROM triggers
+		WHERE ($1::uuid IS NULL OR flow_id = $1)
+		  AND ($2::text IS NULL OR type = $2)
+		  AND ($3::boolean IS NULL OR enabled = $3)
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5
+	`
+	var triggerType *string
+	if filter.Type != "" {
+		t := string(filter.Type)
+		triggerType = &t
+	}
+
+	rows, err := r.pool.Query(ctx, query,
+		nullUUID(filter.FlowID),
+		triggerType,
+		filter.Enabled,
+		filter.Limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list triggers: %w", err)
+	}
+	defer rows.Close()
+
+	var triggers []domain.Trigger
+	for rows.Next() {
+		trigger, err := r.scanTrigger(rows)
+		if err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, *trigger)
+	}
+	return triggers, rows.Err()
+}
+
+// ListEnabled возвращает все включённые triggers указанных типов.
+func (r *TriggerRepo) ListEnabled(ctx context.Context, types ...domain.TriggerType) ([]domain.Trigger, error) {
+	typeNames := make([]string, len(types))
+	for i, t := range types {
+		typeNames[i] = string(t)
+	}
+
+	query := `
+		SELECT ` + triggerColumns + `
+		FROM triggers
+		WHERE type = ANY($1) AND enabled = true
+		ORDER BY created_at ASC
+	`
+	rows, err := r.pool.Query(ctx, query, typeNames)
+	if err != nil {
+		return nil, fmt.Errorf("list enabled triggers: %w", err)
+	}
+	defer rows.Close()
+
+	var triggers []domain.Trigger
+	for rows.Next() {
+		trigger, err := r.scanTrigger(rows)
+		if err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, *trigger)
+	}
+	return triggers, rows.Err()
+}
+
+// Update обновляет trigger.
+func (r *TriggerRepo) Update(ctx context.Context, trigger *domain.Trigger) error {
+	configJSON, mappingJSON, err := marshalTrigger(trigger)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE triggers
+		SET name = $2, config = $3, filter = $4, input_mapping = $5,
+		    enabled = $6, updated_at = $7
+		WHERE id = $1
+	`
+	result, err := r.pool.Exec(ctx, query,
+		trigger.ID,
+		trigger.Name,
+		configJSON,
+		nullString(trigger.Filter),
+		mappingJSON,
+		trigger.Enabled,
+		trigger.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update trigger: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordRun сохраняет время срабатывания и ID созданного run.
+func (r *TriggerRepo) RecordRun(ctx context.Context, trigger *domain.Trigger) error {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE triggers SET last_fired_at = $2, last_run_id = $3 WHERE id = $1
+	`, trigger.ID, trigger.LastFiredAt, trigger.LastRunID)
+	if err != nil {
+		return fmt.Errorf("record trigger run: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete удаляет trigger.
+func (r *TriggerRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM triggers WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete trigger: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetEnabled включает/выключает trigger.
+func (r *TriggerRepo) SetEnabled(ctx context.Context, id uuid.UUID, enabled bool) error {
+	result, err := r.pool.Exec(ctx, `
+		UPDATE triggers SET enabled = $2, updated_at = NOW() WHERE id = $1
+	`, id, enabled)
+	if err != nil {
+		return fmt.Errorf("set enabled: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// --- Helpers ---
+
+// TriggerFilter — параметры фильтрации triggers.
+type TriggerFilter struct {
+	FlowID  *uuid.UUID
+	Type    domain.TriggerType
+	Enabled *bool
+	Limit   int
+	Offset  int
+}
+
+func marshalTrigger(trigger *domain.Trigger) (configJSON, mappingJSON []byte, err error) {
+	configJSON, err = json.Marshal(trigger.Config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal config: %w", err)
+	}
+	if trigger.InputMapping != nil {
+		mappingJSON, err = json.Marshal(trigger.InputMapping)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshal input_mapping: %w", err)
+		}
+	}
+	return configJSON, mappingJSON, nil
+}
+
+// scanTrigger сканирует trigger из pgx.Row (QueryRow или Rows).
+func (r *TriggerRepo) scanTrigger(row pgx.Row) (*domain.Trigger, error) {
+	var t domain.Trigger
+	var triggerType string
+	var filter *string
+	var configJSON, mappingJSON []byte
+
+	err := row.Scan(
+		&t.ID,
+		&t.FlowID,
+		&t.Name,
+		&triggerType,
+		&configJSON,
+		&filter,
+		&mappingJSON,
+		&t.Enabled,
+		&t.LastFiredAt,
+		&t.LastRunID,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan trigger: %w", err)
+	}
+
+	t.Type = domain.TriggerType(triggerType)
+	if filter != nil {
+		t.Filter = *filter
+	}
+	if configJSON != nil {
+		if err := json.Unmarshal(configJSON, &t.Config); err != nil {
+			return nil, fmt.Errorf("unmarshal config: %w", err)
+		}
+	}
+	if mappingJSON != nil {
+		if err := json.Unmarshal(mappingJSON, &t.InputMapping); err != nil {
+			return nil, fmt.Errorf("unmarshal input_mapping: %w", err)
+		}
+	}
+
+	return &t, nil
+}
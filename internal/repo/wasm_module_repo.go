@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// WasmModuleRepo — репозиторий загруженных WebAssembly-модулей.
+type WasmModuleRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewWasmModuleRepo создаёт новый WasmModuleRepo.
+func NewWasmModuleRepo(pool *pgxpool.Pool) *WasmModuleRepo {
+	return &WasmModuleRepo{pool: pool}
+}
+
+// Create сохраняет модуль. Если модуль с тем же дайджестом или та же
+// пара name/version уже существует, возвращает ErrAlreadyExists.
+func (r *WasmModuleRepo) Create(ctx context.Context, mod *domain.WasmModule) error {
+	query := `
+		INSERT INTO wasm_modules (digest, name, version, size, data, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		mod.Digest,
+		mod.Name,
+		mod.Version,
+		mod.Size,
+		mod.Data,
+		mod.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("insert wasm module: %w", err)
+	}
+	return nil
+}
+
+// GetByDigest возвращает модуль вместе с содержимым.
+func (r *WasmModuleRepo) GetByDigest(ctx context.Context, digest string) (*domain.WasmModule, error) {
+	query := `
+		SELECT digest, name, version, size, data, created_at
+		FROM wasm_modules
+		WHERE digest = $1
+	`
+	var mod domain.WasmModule
+	err := r.pool.QueryRow(ctx, query, digest).Scan(
+		&mod.Digest,
+		&mod.Name,
+		&mod.Version,
+		&mod.Size,
+		&mod.Data,
+		&mod.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get wasm module: %w", err)
+	}
+	return &mod, nil
+}
+
+// List возвращает модули без содержимого: по имени, новые версии первыми.
+// Непустой name оставляет только версии этого модуля.
+func (r *WasmModuleRepo) List(ctx context.Context, name string) ([]domain.WasmModule, error) {
+	query := `
+		SELECT digest, name, version, size, created_at
+		FROM wasm_modules
+		WHERE $1 = '' OR name = $1
+		ORDER BY name, created_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("list wasm modules: %w", err)
+	}
+	defer rows.Close()
+
+	var mods []domain.WasmModule
+	for rows.Next() {
+		var mod domain.WasmModule
+		if err := rows.Scan(&mod.Digest, &mod.Name, &mod.Version, &mod.Size, &mod.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan wasm module: %w", err)
+		}
+		mods = append(mods, mod)
+	}
+	return mods, rows.Err()
+}
+
+// Delete удаляет модуль. Шаги, ссылающиеся на него, завершатся ошибкой.
+func (r *WasmModuleRepo) Delete(ctx context.Context, digest string) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM wasm_modules WHERE digest = $1`, digest)
+	if err != nil {
+		return fmt.Errorf("delete wasm module: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -50,7 +50,9 @@
 //	    "body": {"key": "value"},
 //	    "follow_redirects": true,
 //	    "validate_ssl": true,
-//	    "timeout_sec": 30
+//	    "timeout_sec": 30,
+//	    "body_format": "json",      // json, xml, csv, text
+//	    "response_format": "auto"   // auto (по Content-Type), json, xml, csv, text
 //	}
 //
 // Outputs:
@@ -58,7 +60,7 @@
 //	{
 //	    "status_code": 200,
 //	    "headers": {"Content-Type": "application/json"},
-//	    "body": {...}  // parsed JSON или string
+//	    "body": {...}  // parsed JSON, XML, CSV или string
 //	}
 //
 // ## Delay (delay.go)
@@ -4,12 +4,13 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/shaiso/Automata/internal/engine"
 )
 
 const (
@@ -50,15 +51,22 @@ const (
 //	    },
 //	    "follow_redirects": true,
 //	    "validate_ssl": true,
-//	    "timeout_sec": 30
+//	    "timeout_sec": 30,
+//	    "body_format": "json",
+//	    "response_format": "auto"
 //	}
 //
+// body_format — кодирование body: json (по умолчанию), xml (корень —
+// xml_root), csv (опции — csv) или text; строка отправляется как есть.
+// response_format — разбор ответа: auto (по Content-Type), json, xml,
+// csv или text (см. engine.ParseBodyOptions).
+//
 // Outputs:
 //
 //	{
 //	    "status_code": 200,
 //	    "headers": {"Content-Type": "application/json", ...},
-//	    "body": {...}  // parsed JSON or string
+//	    "body": {...}  // разобранный JSON, XML, CSV или строка
 //	}
 type HTTPStep struct {
 	client *http.Client
@@ -106,7 +114,7 @@ func (s *HTTPStep) Execute(ctx context.Context, req *Request) (*Response, error)
 	defer resp.Body.Close()
 
 	// Читаем и парсим ответ
-	return s.parseResponse(resp)
+	return s.parseResponse(resp, cfg)
 }
 
 // httpConfig — распарсенная конфигурация HTTP шага.
@@ -118,6 +126,7 @@ type httpConfig struct {
 	FollowRedirects bool
 	ValidateSSL     bool
 	TimeoutSec      int
+	Formats         engine.BodyOptions
 }
 
 // parseConfig парсит конфигурацию HTTP шага.
@@ -137,6 +146,12 @@ func (s *HTTPStep) parseConfig(config map[string]any) (*httpConfig, error) {
 		return nil, fmt.Errorf("%w: %s: url is required", ErrInvalidConfig, StepTypeHTTP)
 	}
 
+	formats, err := engine.ParseBodyOptions(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, StepTypeHTTP, err)
+	}
+	cfg.Formats = formats
+
 	// Метод по умолчанию — GET
 	if cfg.Method == "" {
 		cfg.Method = http.MethodGet
@@ -190,7 +205,7 @@ func (s *HTTPStep) buildRequest(ctx context.Context, cfg *httpConfig) (*http.Req
 
 	// Подготавливаем body
 	if cfg.Body != nil {
-		bodyBytes, err := s.serializeBody(cfg.Body)
+		bodyBytes, contentType, err := s.serializeBody(cfg.Body, cfg.Formats)
 		if err != nil {
 			return nil, fmt.Errorf("serialize body: %w", err)
 		}
@@ -198,7 +213,7 @@ func (s *HTTPStep) buildRequest(ctx context.Context, cfg *httpConfig) (*http.Req
 
 		// Устанавливаем Content-Type, если не задан
 		if _, hasContentType := cfg.Headers["Content-Type"]; !hasContentType {
-			cfg.Headers["Content-Type"] = "application/json"
+			cfg.Headers["Content-Type"] = contentType
 		}
 	}
 
@@ -216,35 +231,30 @@ func (s *HTTPStep) buildRequest(ctx context.Context, cfg *httpConfig) (*http.Req
 	return req, nil
 }
 
-// serializeBody сериализует body в bytes.
-func (s *HTTPStep) serializeBody(body any) ([]byte, error) {
-	switch v := body.(type) {
-	case string:
-		return []byte(v), nil
-	case []byte:
-		return v, nil
-	default:
-		return json.Marshal(v)
+// serializeBody сериализует body в bytes в формате body_format
+// и возвращает Content-Type по умолчанию.
+func (s *HTTPStep) serializeBody(body any, formats engine.BodyOptions) ([]byte, string, error) {
+	if v, ok := body.(string); ok {
+		body = []byte(v)
+	}
+	if v, ok := body.([]byte); ok && formats.Format == engine.BodyFormatJSON {
+		return v, "application/json", nil
 	}
+	return engine.EncodeBody(body, formats)
 }
 
 // parseResponse парсит HTTP ответ в Response.
-func (s *HTTPStep) parseResponse(resp *http.Response) (*Response, error) {
+func (s *HTTPStep) parseResponse(resp *http.Response, cfg *httpConfig) (*Response, error) {
 	// Читаем body с ограничением размера
 	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
 	if err != nil {
 		return nil, fmt.Errorf("read response body: %w", err)
 	}
 
-	// Парсим body
-	var body any
-	contentType := resp.Header.Get("Content-Type")
-	if strings.Contains(contentType, "application/json") {
-		if err := json.Unmarshal(bodyBytes, &body); err != nil {
-			// Если не удалось распарсить JSON, возвращаем как строку
-			body = string(bodyBytes)
-		}
-	} else {
+	// Парсим body по response_format или Content-Type
+	body, err := engine.DecodeBody(bodyBytes, resp.Header.Get("Content-Type"), cfg.Formats)
+	if err != nil {
+		// Если не удалось распарсить, возвращаем как строку
 		body = string(bodyBytes)
 	}
 
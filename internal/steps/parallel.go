@@ -20,11 +20,17 @@ const (
 // 1. Валидации конфигурации (через Registry)
 // 2. Сбора outputs всех веток в единый результат
 //
-// Конфигурация задаётся через domain.StepDef.Branches:
+// Ветки задаются через domain.StepDef.Branches, настройки выполнения — через config
+// (см. engine.ParseParallelConfig):
 //
 //	{
 //	    "id": "parallel_step",
 //	    "type": "parallel",
+//	    "config": {
+//	        "failure_policy": "wait_all",  // fail_fast | wait_all | min_success
+//	        "min_success": 1,              // для min_success
+//	        "max_concurrency": 2           // 0 — без ограничения
+//	    },
 //	    "branches": [
 //	        {
 //	            "id": "branch_a",
@@ -41,7 +47,8 @@ const (
 //	    ]
 //	}
 //
-// Outputs (собираются Orchestrator'ом):
+// Outputs (собираются Orchestrator'ом при разрешении join,
+// в шаблонах доступны как .steps.parallel_step.outputs.branch_a.step1):
 //
 //	{
 //	    "branch_a": {
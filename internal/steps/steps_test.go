@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -323,6 +324,109 @@ func TestHTTPStep_InvalidConfig(t *testing.T) {
 	}
 }
 
+func TestHTTPStep_XML(t *testing.T) {
+	var receivedBody, receivedContentType string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		receivedBody, receivedContentType = string(b), r.Header.Get("Content-Type")
+
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.Write([]byte(`<result status="ok"><id>1</id><id>2</id></result>`))
+	}))
+	defer server.Close()
+
+	req := &Request{
+		StepID: "test",
+		Config: map[string]any{
+			"method":      "POST",
+			"url":         server.URL,
+			"body":        map[string]any{"customer": "42", "@version": "2"},
+			"body_format": "xml",
+			"xml_root":    "order",
+		},
+	}
+
+	resp, err := NewHTTPStep().Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedBody != `<order version="2"><customer>42</customer></order>` {
+		t.Errorf("unexpected request body %s", receivedBody)
+	}
+	if receivedContentType != "application/xml; charset=utf-8" {
+		t.Errorf("unexpected Content-Type %s", receivedContentType)
+	}
+
+	body, ok := resp.Outputs["body"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected body to be map, got %T", resp.Outputs["body"])
+	}
+	result, _ := body["result"].(map[string]any)
+	if result["@status"] != "ok" {
+		t.Errorf("expected @status 'ok', got %v", result["@status"])
+	}
+	if ids, _ := result["id"].([]any); len(ids) != 2 {
+		t.Errorf("expected 2 ids, got %v", result["id"])
+	}
+}
+
+func TestHTTPStep_CSV(t *testing.T) {
+	var receivedBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		receivedBody = string(b)
+
+		// Content-Type не указывает на CSV — формат задан response_format
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("1;Alice\n2;Bob\n"))
+	}))
+	defer server.Close()
+
+	req := &Request{
+		StepID: "test",
+		Config: map[string]any{
+			"method":          "POST",
+			"url":             server.URL,
+			"body":            []any{map[string]any{"id": 1.0, "name": "Alice"}},
+			"body_format":     "csv",
+			"response_format": "csv",
+			"csv":             map[string]any{"delimiter": ";", "columns": []any{"id", "name"}},
+		},
+	}
+
+	resp, err := NewHTTPStep().Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedBody != "id;name\n1;Alice\n" {
+		t.Errorf("unexpected request body %q", receivedBody)
+	}
+
+	rows, ok := resp.Outputs["body"].([]any)
+	if !ok || len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", resp.Outputs["body"])
+	}
+	if row, _ := rows[1].(map[string]any); row["name"] != "Bob" {
+		t.Errorf("expected name 'Bob', got %v", rows[1])
+	}
+}
+
+func TestHTTPStep_InvalidFormat(t *testing.T) {
+	req := &Request{
+		StepID: "test",
+		Config: map[string]any{"url": "http://localhost", "body_format": "yaml"},
+	}
+
+	_, err := NewHTTPStep().Execute(context.Background(), req)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
 func TestHTTPStep_Cancellation(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(500 * time.Millisecond)
@@ -402,6 +506,52 @@ func TestTransformStep_Execute(t *testing.T) {
 	}
 }
 
+func TestTransformStep_JQ(t *testing.T) {
+	step := NewTransformStep()
+
+	tmplCtx := engine.NewContext(nil)
+	tmplCtx.AddStepResult("fetch", map[string]any{
+		"items": []any{
+			map[string]any{"id": 1, "status": "active", "amount": 10},
+			map[string]any{"id": 2, "status": "closed", "amount": 5},
+			map[string]any{"id": 3, "status": "active", "amount": 7},
+		},
+	}, "SUCCEEDED")
+
+	req := &Request{
+		StepID:          "transform_test",
+		TemplateContext: tmplCtx,
+		Config: map[string]any{
+			"mappings": map[string]any{
+				"count":     "{{ len .Steps.fetch.Outputs.items }}",
+				"active":    map[string]any{"jq": "[.steps.fetch.outputs.items[] | select(.status == \"active\") | .id]"},
+				"by_status": map[string]any{"jq": ".steps.fetch.outputs.items | group_by(.status) | map({(.[0].status): length}) | add"},
+				"total":     map[string]any{"jq": "reduce .steps.fetch.outputs.items[] as $i (0; . + $i.amount)"},
+			},
+		},
+	}
+
+	resp, err := step.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Outputs["count"] != int64(3) {
+		t.Errorf("expected count 3, got %v", resp.Outputs["count"])
+	}
+	active, _ := resp.Outputs["active"].([]any)
+	if len(active) != 2 || active[0] != float64(1) || active[1] != float64(3) {
+		t.Errorf("unexpected active: %v", resp.Outputs["active"])
+	}
+	byStatus, _ := resp.Outputs["by_status"].(map[string]any)
+	if byStatus["active"] != 2 || byStatus["closed"] != 1 {
+		t.Errorf("unexpected by_status: %v", resp.Outputs["by_status"])
+	}
+	if resp.Outputs["total"] != float64(22) {
+		t.Errorf("expected total 22, got %v (type %T)", resp.Outputs["total"], resp.Outputs["total"])
+	}
+}
+
 func TestTransformStep_EmptyMappings(t *testing.T) {
 	step := NewTransformStep()
 	ctx := context.Background()
@@ -18,7 +18,9 @@ const (
 
 // TransformStep — шаг трансформации данных.
 //
-// Применяет Go templates для преобразования данных из предыдущих шагов.
+// Применяет Go templates или jq-выражения для преобразования данных
+// из предыдущих шагов. jq работает с контекстом в JSON-представлении
+// (.inputs, .steps.<id>.outputs) — см. engine.EvalJQ.
 //
 // Конфигурация:
 //
@@ -26,16 +28,18 @@ const (
 //	    "mappings": {
 //	        "total": "{{ len .Steps.fetch.Outputs.items }}",
 //	        "first_item": "{{ index .Steps.fetch.Outputs.items 0 }}",
-//	        "ids": "{{ range .Steps.fetch.Outputs.items }}{{ .id }},{{ end }}"
+//	        "ids": "{{ range .Steps.fetch.Outputs.items }}{{ .id }},{{ end }}",
+//	        "by_status": {"jq": ".steps.fetch.outputs.items | group_by(.status) | map({(.[0].status): length}) | add"}
 //	    }
 //	}
 //
-// Outputs: результаты рендеринга mappings
+// Outputs: результаты рендеринга mappings (jq — значения JSON как есть)
 //
 //	{
 //	    "total": "10",
 //	    "first_item": "{...}",
-//	    "ids": "1,2,3,4,5,"
+//	    "ids": "1,2,3,4,5,",
+//	    "by_status": {"active": 3, "closed": 2}
 //	}
 type TransformStep struct{}
 
@@ -73,7 +77,20 @@ func (s *TransformStep) Execute(ctx context.Context, req *Request) (*Response, e
 
 	// Рендерим каждый mapping
 	outputs := make(map[string]any, len(mappings))
-	for key, tmpl := range mappings {
+	for key, mapping := range mappings {
+		if expr, ok := engine.JQExpr(mapping); ok {
+			value, err := engine.EvalJQ(ctx, expr, tmplCtx)
+			if err != nil {
+				return nil, fmt.Errorf("transform %s: %w", key, err)
+			}
+			outputs[key] = value
+			continue
+		}
+
+		tmpl, ok := mapping.(string)
+		if !ok {
+			continue
+		}
 		rendered, err := engine.Render(tmpl, tmplCtx)
 		if err != nil {
 			return nil, fmt.Errorf("transform %s: %w", key, err)
@@ -86,8 +103,9 @@ func (s *TransformStep) Execute(ctx context.Context, req *Request) (*Response, e
 	return &Response{Outputs: outputs}, nil
 }
 
-// parseMappings извлекает mappings из конфигурации.
-func (s *TransformStep) parseMappings(config map[string]any) map[string]string {
+// parseMappings извлекает mappings из конфигурации: строки (шаблоны)
+// и объекты {"jq": "..."}.
+func (s *TransformStep) parseMappings(config map[string]any) map[string]any {
 	raw := config[configMappings]
 	if raw == nil {
 		return nil
@@ -95,17 +113,15 @@ func (s *TransformStep) parseMappings(config map[string]any) map[string]string {
 
 	switch m := raw.(type) {
 	case map[string]string:
-		return m
-
-	case map[string]any:
-		result := make(map[string]string, len(m))
+		result := make(map[string]any, len(m))
 		for key, val := range m {
-			if str, ok := val.(string); ok {
-				result[key] = str
-			}
+			result[key] = val
 		}
 		return result
 
+	case map[string]any:
+		return m
+
 	default:
 		return nil
 	}
@@ -0,0 +1,81 @@
+// Package trigger реализует запуск flow по внешним событиям.
+//
+// Trigger (domain.Trigger) связывает flow с источником событий. Для каждого
+// подходящего события создаётся run последней версии flow:
+//
+//   - filter — условие над событием (без {{ }}), пустое пропускает всё
+//   - input_mapping — шаблоны inputs run над событием
+//   - idempotency key run — trigger:<trigger_id>:<event_id>, поэтому
+//     повторная доставка события не создаёт второй run
+//
+// Данные шаблонов — Event:
+//
+//	.ID          — ID события (AMQP message_id / sha256 тела, webhook dedupe ключ,
+//	               ID upstream run для flow триггера)
+//	.Body        — тело (разобранный JSON или строка; для flow — payload run.completed)
+//	.Headers     — заголовки (для webhook — имена в нижнем регистре)
+//	.Query       — параметры query string (webhook)
+//	.Exchange    — exchange сообщения
+//	.RoutingKey  — routing key сообщения
+//	.Timestamp   — время события
+//
+// Структура:
+//   - event.go    — Event, валидация триггера, фильтр и маппинг inputs
+//   - launcher.go — Launcher: создание run по событию (Fire)
+//   - service.go  — Service: consumers очередей AMQP триггеров
+//   - webhook.go  — webhook триггеры: подпись, allow-list, Event из запроса
+//   - flow.go     — flow триггеры: цепочки flows по событиям run.completed
+//
+// AMQP триггеры:
+//
+// Service создаёт для каждого включённого amqp триггера очередь
+// triggers.<trigger_id>, привязанную к внешнему exchange (config.exchange)
+// с ключом config.binding_key, и читает её через mq.Consumer в raw режиме
+// (тело не обязано быть mq.Message). Определения перечитываются из БД
+// каждые ReloadInterval.
+//
+// Webhook триггеры:
+//
+// Запросы POST /api/v1/hooks/{trigger_id} принимает API: проверяет
+// allow-list (AllowedIPs), HMAC подпись (github, stripe, hmac_sha256)
+// и вызывает Launcher.Fire. Ключ дедупликации берётся из заголовка
+// dedupe_header. В синхронном режиме (sync) API ждёт завершения run
+// и возвращает результат в ответе.
+//
+// Flow триггеры:
+//
+// Orchestrator публикует событие run.completed в exchange automata.events
+// с routing key run.completed.<flow_id>.<status> при любом завершении run.
+// Очередь flow триггера привязана к run.completed.<upstream_flow_id>.*;
+// run downstream flow создаётся, если статус upstream run входит в
+// config.statuses (по умолчанию SUCCEEDED). Sandbox runs цепочки не запускают.
+// Без input_mapping downstream run получает inputs upstream run:
+//
+//	{{ .Body.inputs.date }}            — inputs upstream run
+//	{{ .Body.steps.extract.rows }}     — outputs шага upstream run
+//	{{ .Body.outputs.rows }}           — outputs flow upstream run
+//	eq .Body.status "SUCCEEDED"        — статус (также error, run_id, version)
+//
+// Использование:
+//
+//	launcher := trigger.NewLauncher(trigger.LauncherConfig{
+//	    TriggerRepo: triggerRepo,
+//	    RunRepo:     runRepo,
+//	    FlowRepo:    flowRepo,
+//	    Publisher:   publisher,
+//	    Logger:      logger,
+//	})
+//
+//	svc := trigger.New(trigger.Config{
+//	    TriggerRepo: triggerRepo,
+//	    Launcher:    launcher,
+//	    Conn:        mqConn,
+//	    Logger:      logger,
+//	})
+//
+//	svc.Run(ctx) // блокируется до отмены ctx
+//
+// Несколько экземпляров сервиса могут работать одновременно: они читают
+// одни и те же очереди как конкурирующие consumers, а idempotency key
+// защищает от дублей.
+package trigger
@@ -0,0 +1,15 @@
+package trigger
+
+import "errors"
+
+var (
+	// ErrInvalidTrigger — некорректное определение триггера.
+	ErrInvalidTrigger = errors.New("invalid trigger")
+
+	// ErrEventRejected — событие не может запустить run (ошибка шаблона,
+	// у flow нет версий). Повторная доставка не поможет.
+	ErrEventRejected = errors.New("event rejected")
+
+	// ErrInvalidSignature — подпись webhook запроса отсутствует или неверна.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
@@ -0,0 +1,188 @@
+package trigger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+)
+
+// Event — событие, которое может запустить flow.
+//
+// Event — данные шаблонов filter и input_mapping триггера:
+//
+//	{{ .Body.order_id }}
+//	{{ index .Headers "x-tenant" }}
+//	eq .RoutingKey "orders.paid"
+type Event struct {
+	// ID — идентификатор события, из него строится idempotency key run.
+	ID string
+
+	// Body — тело события (разобранный JSON или строка).
+	Body any
+
+	// Headers — заголовки события.
+	Headers map[string]any
+
+	// Query — параметры query string (webhook).
+	Query map[string]any
+
+	// Exchange — exchange, в который опубликовано сообщение (amqp).
+	Exchange string
+
+	// RoutingKey — ключ маршрутизации сообщения (amqp).
+	RoutingKey string
+
+	// Timestamp — время события (если известно).
+	Timestamp time.Time
+}
+
+// EventFromDelivery создаёт Event из сообщения RabbitMQ.
+//
+// ID — свойство message_id. Если издатель его не задал, используется
+// SHA-256 тела: повторная доставка того же сообщения не создаст второй run.
+func EventFromDelivery(d amqp.Delivery) Event {
+	id := d.MessageId
+	if id == "" {
+		sum := sha256.Sum256(d.Body)
+		id = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return Event{
+		ID:         id,
+		Body:       parseBody(d.Body),
+		Headers:    convertTable(d.Headers),
+		Exchange:   d.Exchange,
+		RoutingKey: d.RoutingKey,
+		Timestamp:  d.Timestamp,
+	}
+}
+
+// parseBody разбирает тело как JSON, иначе возвращает строку.
+func parseBody(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	return v
+}
+
+// convertTable приводит заголовки AMQP к обычным map/slice.
+func convertTable(table amqp.Table) map[string]any {
+	result := make(map[string]any, len(table))
+	for k, v := range table {
+		result[k] = convertValue(v)
+	}
+	return result
+}
+
+func convertValue(v any) any {
+	switch val := v.(type) {
+	case amqp.Table:
+		return convertTable(val)
+	case []any:
+		items := make([]any, len(val))
+		for i, item := range val {
+			items[i] = convertValue(item)
+		}
+		return items
+	case []byte:
+		return string(val)
+	case amqp.Decimal:
+		return fmt.Sprintf("%de-%d", val.Value, val.Scale)
+	default:
+		return val
+	}
+}
+
+// Validate проверяет определение триггера.
+func Validate(t *domain.Trigger) error {
+	if t.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTrigger)
+	}
+
+	switch t.Type {
+	case domain.TriggerTypeAMQP:
+		if t.Config.Exchange == "" {
+			return fmt.Errorf("%w: amqp trigger requires config.exchange", ErrInvalidTrigger)
+		}
+		if t.Config.BindingKey == "" {
+			return fmt.Errorf("%w: amqp trigger requires config.binding_key", ErrInvalidTrigger)
+		}
+	case domain.TriggerTypeWebhook:
+		if err := validateWebhook(&t.Config); err != nil {
+			return err
+		}
+	case domain.TriggerTypeFlow:
+		if err := validateFlow(t); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidTrigger, t.Type)
+	}
+
+	if err := engine.ValidateCondition(t.Filter); err != nil {
+		return fmt.Errorf("%w: filter: %v", ErrInvalidTrigger, err)
+	}
+	for name, tmpl := range t.InputMapping {
+		if err := engine.ValidateTemplate(tmpl); err != nil {
+			return fmt.Errorf("%w: input_mapping.%s: %v", ErrInvalidTrigger, name, err)
+		}
+	}
+
+	return nil
+}
+
+// Match проверяет, проходит ли событие фильтр триггера.
+// Для flow триггера сначала проверяется статус upstream run.
+func Match(t *domain.Trigger, ev *Event) (bool, error) {
+	if t.Type == domain.TriggerTypeFlow && !matchRun(t, ev) {
+		return false, nil
+	}
+
+	ok, err := engine.EvalCondition(t.Filter, ev)
+	if err != nil {
+		return false, fmt.Errorf("%w: filter: %v", ErrEventRejected, err)
+	}
+	return ok, nil
+}
+
+// MapInputs формирует inputs run из события.
+//
+// Без input_mapping inputs — тело события, если это JSON объект,
+// иначе {"body": <тело>}. Flow триггер без input_mapping передаёт
+// inputs upstream run.
+func MapInputs(t *domain.Trigger, ev *Event) (map[string]any, error) {
+	if len(t.InputMapping) == 0 {
+		if t.Type == domain.TriggerTypeFlow {
+			return upstreamInputs(ev), nil
+		}
+		if body, ok := ev.Body.(map[string]any); ok {
+			return body, nil
+		}
+		return map[string]any{"body": ev.Body}, nil
+	}
+
+	inputs := make(map[string]any, len(t.InputMapping))
+	for name, tmpl := range t.InputMapping {
+		value, err := engine.RenderData(tmpl, ev)
+		if err != nil {
+			return nil, fmt.Errorf("%w: input_mapping.%s: %v", ErrEventRejected, name, err)
+		}
+		inputs[name] = value
+	}
+	return inputs, nil
+}
+
+// IdempotencyKey возвращает idempotency key run для события триггера.
+func IdempotencyKey(t *domain.Trigger, ev *Event) string {
+	return fmt.Sprintf("trigger:%s:%s", t.ID, ev.ID)
+}
@@ -0,0 +1,100 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/mq"
+)
+
+// validateFlow проверяет параметры flow триггера.
+func validateFlow(t *domain.Trigger) error {
+	if t.Config.UpstreamFlowID == nil {
+		return fmt.Errorf("%w: flow trigger requires config.upstream_flow_id", ErrInvalidTrigger)
+	}
+	if *t.Config.UpstreamFlowID == t.FlowID {
+		return fmt.Errorf("%w: flow trigger cannot start its own upstream flow", ErrInvalidTrigger)
+	}
+	for _, status := range t.Config.Statuses {
+		if !status.IsTerminal() {
+			return fmt.Errorf("%w: statuses: %q is not a terminal run status", ErrInvalidTrigger, status)
+		}
+	}
+	return nil
+}
+
+// RunStatuses возвращает статусы upstream run, при которых срабатывает flow триггер.
+func RunStatuses(cfg *domain.TriggerConfig) []domain.RunStatus {
+	if len(cfg.Statuses) == 0 {
+		return []domain.RunStatus{domain.RunStatusSucceeded}
+	}
+	return cfg.Statuses
+}
+
+// Source возвращает exchange и binding key очереди триггера.
+//
+// Flow триггер читает события run.completed upstream flow из automata.events,
+// статусы проверяются при обработке события.
+func Source(t *domain.Trigger) (mq.Exchange, mq.RoutingKey) {
+	if t.Type == domain.TriggerTypeFlow && t.Config.UpstreamFlowID != nil {
+		return mq.ExchangeEvents, mq.RunCompletedKey(t.Config.UpstreamFlowID.String(), "*")
+	}
+	return mq.Exchange(t.Config.Exchange), mq.RoutingKey(t.Config.BindingKey)
+}
+
+// EventFromRunCompleted создаёт Event из сообщения run.completed.
+//
+// Body — payload события (run_id, flow_id, status, error, inputs, steps ...),
+// ID — ID upstream run: повторная публикация не создаст второй downstream run.
+func EventFromRunCompleted(d amqp.Delivery) (Event, error) {
+	var msg struct {
+		Type    mq.MessageType `json:"type"`
+		Payload map[string]any `json:"payload"`
+	}
+	if err := json.Unmarshal(d.Body, &msg); err != nil {
+		return Event{}, fmt.Errorf("%w: invalid run.completed message: %v", ErrEventRejected, err)
+	}
+	if msg.Type != mq.MessageTypeRunCompleted {
+		return Event{}, fmt.Errorf("%w: unexpected message type %q", ErrEventRejected, msg.Type)
+	}
+
+	runID, _ := msg.Payload["run_id"].(string)
+	if runID == "" {
+		return Event{}, fmt.Errorf("%w: run.completed without run_id", ErrEventRejected)
+	}
+
+	return Event{
+		ID:         runID,
+		Body:       msg.Payload,
+		Headers:    convertTable(d.Headers),
+		Exchange:   d.Exchange,
+		RoutingKey: d.RoutingKey,
+		Timestamp:  d.Timestamp,
+	}, nil
+}
+
+// matchRun проверяет статус upstream run. Sandbox runs не запускают цепочки.
+func matchRun(t *domain.Trigger, ev *Event) bool {
+	body, ok := ev.Body.(map[string]any)
+	if !ok {
+		return false
+	}
+	if sandbox, _ := body["is_sandbox"].(bool); sandbox {
+		return false
+	}
+	status, _ := body["status"].(string)
+	return slices.Contains(RunStatuses(&t.Config), domain.RunStatus(status))
+}
+
+// upstreamInputs возвращает inputs upstream run (inputs по умолчанию для flow триггера).
+func upstreamInputs(ev *Event) map[string]any {
+	if body, ok := ev.Body.(map[string]any); ok {
+		if inputs, ok := body["inputs"].(map[string]any); ok {
+			return inputs
+		}
+	}
+	return map[string]any{}
+}
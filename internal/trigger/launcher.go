@@ -0,0 +1,158 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/mq"
+	"github.com/shaiso/Automata/internal/repo"
+)
+
+// Launcher создаёт runs по событиям триггеров.
+type Launcher struct {
+	triggerRepo *repo.TriggerRepo
+	runRepo     *repo.RunRepo
+	flowRepo    *repo.FlowRepo
+	publisher   *mq.Publisher
+	logger      *slog.Logger
+}
+
+// LauncherConfig — конфигурация Launcher.
+type LauncherConfig struct {
+	TriggerRepo *repo.TriggerRepo
+	RunRepo     *repo.RunRepo
+	FlowRepo    *repo.FlowRepo
+	Publisher   *mq.Publisher // опционально
+	Logger      *slog.Logger
+}
+
+// NewLauncher создаёт новый Launcher.
+func NewLauncher(cfg LauncherConfig) *Launcher {
+	return &Launcher{
+		triggerRepo: cfg.TriggerRepo,
+		runRepo:     cfg.RunRepo,
+		flowRepo:    cfg.FlowRepo,
+		publisher:   cfg.Publisher,
+		logger:      cfg.Logger,
+	}
+}
+
+// Fire обрабатывает событие триггера.
+//
+// 1. Проверяет фильтр (событие не подходит — run не создаётся, nil, false)
+// 2. Ищет run с тем же idempotency key (повторная доставка — существующий run, false);
+// одновременные доставки разрешает уникальность ключа в БД: проигравшая
+// вставка возвращает run победившей
+// 3. Создаёт run последней версии flow с inputs из input_mapping
+// 4. Публикует run.pending в RabbitMQ
+//
+// Ошибки, оборачивающие ErrEventRejected, постоянные: повтор не поможет.
+func (l *Launcher) Fire(ctx context.Context, t *domain.Trigger, ev *Event) (*domain.Run, bool, error) {
+	// 1. Фильтр
+	ok, err := Match(t, ev)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	// 2. Idempotency
+	idempKey := IdempotencyKey(t, ev)
+	existingRun, err := l.findExistingRun(ctx, t, idempKey)
+	if err != nil {
+		return nil, false, err
+	}
+	if existingRun != nil {
+		return existingRun, false, nil
+	}
+
+	// 3. Создаём run
+	version, err := l.flowRepo.GetLatestVersion(ctx, t.FlowID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, false, fmt.Errorf("%w: flow %s has no versions", ErrEventRejected, t.FlowID)
+		}
+		return nil, false, fmt.Errorf("get latest flow version: %w", err)
+	}
+
+	inputs, err := MapInputs(t, ev)
+	if err != nil {
+		return nil, false, err
+	}
+
+	run := &domain.Run{
+		ID:             uuid.New(),
+		FlowID:         t.FlowID,
+		Version:        version.Version,
+		Status:         domain.RunStatusPending,
+		Inputs:         inputs,
+		IdempotencyKey: idempKey,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := l.runRepo.Create(ctx, run); err != nil {
+		// Параллельная доставка того же события успела создать run
+		// (unique flow_id + idempotency_key) — возвращаем его
+		if errors.Is(err, repo.ErrAlreadyExists) {
+			existingRun, findErr := l.findExistingRun(ctx, t, idempKey)
+			if findErr != nil {
+				return nil, false, findErr
+			}
+			if existingRun != nil {
+				return existingRun, false, nil
+			}
+		}
+		return nil, false, fmt.Errorf("create run: %w", err)
+	}
+
+	l.logger.Info("created run from trigger",
+		"run_id", run.ID,
+		"trigger_id", t.ID,
+		"trigger_name", t.Name,
+		"flow_id", t.FlowID,
+		"event_id", ev.ID,
+	)
+
+	t.RecordRun(run.ID)
+	if err := l.triggerRepo.RecordRun(ctx, t); err != nil {
+		// Не фатально — run уже создан
+		l.logger.Warn("failed to record trigger run", "trigger_id", t.ID, "error", err)
+	}
+
+	// 4. Публикуем событие (Orchestrator может забрать run и через polling)
+	if l.publisher != nil {
+		if err := l.publisher.PublishRunPending(ctx, run.ID); err != nil {
+			l.logger.Warn("failed to publish run.pending",
+				"run_id", run.ID,
+				"error", err,
+			)
+		}
+	}
+
+	return run, true, nil
+}
+
+// findExistingRun возвращает run flow с тем же idempotency key
+// (повторная доставка события) или nil, если его нет.
+func (l *Launcher) findExistingRun(ctx context.Context, t *domain.Trigger, idempKey string) (*domain.Run, error) {
+	run, err := l.runRepo.GetByIdempotencyKey(ctx, t.FlowID, idempKey)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("check idempotency: %w", err)
+	}
+
+	l.logger.Debug("run already exists (idempotency)",
+		"trigger_id", t.ID,
+		"run_id", run.ID,
+		"idempotency_key", idempKey,
+	)
+	return run, nil
+}
@@ -0,0 +1,250 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/mq"
+	"github.com/shaiso/Automata/internal/repo"
+)
+
+// Service — сервис AMQP и flow триггеров.
+//
+// Для каждого включённого amqp триггера Service держит очередь
+// triggers.<trigger_id>, привязанную к внешнему exchange с binding key
+// триггера, и consumer на ней. Очередь flow триггера привязана к
+// automata.events и получает события run.completed upstream flow. Определения триггеров периодически
+// перечитываются из БД: новые запускаются, удалённые и выключенные
+// останавливаются (их очереди удаляются), изменённые перезапускаются.
+type Service struct {
+	triggerRepo    *repo.TriggerRepo
+	launcher       *Launcher
+	conn           *mq.Connection
+	logger         *slog.Logger
+	reloadInterval time.Duration
+	prefetch       int
+
+	mu        sync.Mutex
+	consumers map[uuid.UUID]*triggerConsumer
+	wg        sync.WaitGroup
+}
+
+// triggerConsumer — запущенный consumer триггера.
+type triggerConsumer struct {
+	trigger domain.Trigger
+	cancel  context.CancelFunc
+}
+
+// Config — конфигурация Service.
+type Config struct {
+	TriggerRepo    *repo.TriggerRepo
+	Launcher       *Launcher
+	Conn           *mq.Connection
+	Logger         *slog.Logger
+	ReloadInterval time.Duration // интервал перечитывания триггеров (default: 10s)
+	Prefetch       int           // prefetch каждого consumer (default: 10)
+}
+
+// New создаёт новый Service.
+func New(cfg Config) *Service {
+	reloadInterval := cfg.ReloadInterval
+	if reloadInterval <= 0 {
+		reloadInterval = 10 * time.Second
+	}
+
+	prefetch := cfg.Prefetch
+	if prefetch <= 0 {
+		prefetch = 10
+	}
+
+	return &Service{
+		triggerRepo:    cfg.TriggerRepo,
+		launcher:       cfg.Launcher,
+		conn:           cfg.Conn,
+		logger:         cfg.Logger,
+		reloadInterval: reloadInterval,
+		prefetch:       prefetch,
+		consumers:      make(map[uuid.UUID]*triggerConsumer),
+	}
+}
+
+// Run синхронизирует consumers с БД до отмены контекста.
+// При остановке дожидается завершения всех consumers.
+func (s *Service) Run(ctx context.Context) error {
+	defer s.stopAll()
+
+	tk := time.NewTicker(s.reloadInterval)
+	defer tk.Stop()
+
+	for {
+		if err := s.Reload(ctx); err != nil {
+			s.logger.Error("failed to reload triggers", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tk.C:
+		}
+	}
+}
+
+// Reload приводит запущенные consumers в соответствие с триггерами в БД.
+func (s *Service) Reload(ctx context.Context) error {
+	triggers, err := s.triggerRepo.ListEnabled(ctx, domain.TriggerTypeAMQP, domain.TriggerTypeFlow)
+	if err != nil {
+		return fmt.Errorf("list triggers: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := make(map[uuid.UUID]bool, len(triggers))
+	for i := range triggers {
+		t := triggers[i]
+		active[t.ID] = true
+
+		if current, ok := s.consumers[t.ID]; ok {
+			if current.trigger.UpdatedAt.Equal(t.UpdatedAt) {
+				continue
+			}
+			// Триггер изменён: при смене источника очередь пересоздаётся
+			s.stopConsumer(current, !reflect.DeepEqual(current.trigger.Config, t.Config))
+		}
+
+		if err := s.startConsumer(ctx, t); err != nil {
+			exchange, _ := Source(&t)
+			s.logger.Error("failed to start trigger consumer",
+				"trigger_id", t.ID,
+				"trigger_name", t.Name,
+				"exchange", exchange,
+				"error", err,
+			)
+		}
+	}
+
+	// Удалённые и выключенные триггеры
+	for id, current := range s.consumers {
+		if !active[id] {
+			s.stopConsumer(current, true)
+		}
+	}
+
+	return nil
+}
+
+// startConsumer создаёт очередь триггера и запускает consumer.
+func (s *Service) startConsumer(ctx context.Context, t domain.Trigger) error {
+	queue := mq.TriggerQueue(t.ID.String())
+	exchange, bindingKey := Source(&t)
+	if err := mq.DeclareTriggerQueue(s.conn, queue, exchange, bindingKey); err != nil {
+		return err
+	}
+
+	consumer := mq.NewConsumer(s.conn, s.logger, mq.ConsumerConfig{
+		Queue:    string(queue),
+		Handler:  s.handler(t),
+		Prefetch: s.prefetch,
+		Raw:      true,
+	})
+
+	// Контекст consumer'а отменяется в stopConsumer
+	consumerCtx, cancel := context.WithCancel(ctx)
+	s.consumers[t.ID] = &triggerConsumer{trigger: t, cancel: cancel}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := consumer.Start(consumerCtx); err != nil && !errors.Is(err, context.Canceled) {
+			s.logger.Error("trigger consumer stopped", "trigger_id", t.ID, "error", err)
+		}
+	}()
+
+	s.logger.Info("trigger consumer started",
+		"trigger_id", t.ID,
+		"trigger_name", t.Name,
+		"exchange", exchange,
+		"binding_key", bindingKey,
+	)
+	return nil
+}
+
+// stopConsumer останавливает consumer триггера.
+// deleteQueue — удалить очередь вместе с накопленными сообщениями.
+func (s *Service) stopConsumer(tc *triggerConsumer, deleteQueue bool) {
+	tc.cancel()
+	delete(s.consumers, tc.trigger.ID)
+
+	if deleteQueue {
+		if err := mq.DeleteQueue(s.conn, mq.TriggerQueue(tc.trigger.ID.String())); err != nil {
+			s.logger.Warn("failed to delete trigger queue", "trigger_id", tc.trigger.ID, "error", err)
+		}
+	}
+
+	s.logger.Info("trigger consumer stopped", "trigger_id", tc.trigger.ID, "trigger_name", tc.trigger.Name)
+}
+
+// stopAll останавливает все consumers (очереди сохраняются).
+func (s *Service) stopAll() {
+	s.mu.Lock()
+	for _, tc := range s.consumers {
+		tc.cancel()
+	}
+	s.consumers = make(map[uuid.UUID]*triggerConsumer)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// handler возвращает обработчик сообщений триггера.
+//
+// Постоянные ошибки (ErrEventRejected) логируются, сообщение подтверждается.
+// Остальные ошибки (БД недоступна) возвращают сообщение в очередь.
+func (s *Service) handler(def domain.Trigger) mq.Handler {
+	return func(ctx context.Context, d *mq.Delivery) error {
+		t := def
+
+		ev, err := eventFromDelivery(&t, d.Raw)
+		if err != nil {
+			s.logger.Warn("trigger event rejected", "trigger_id", t.ID, "error", err)
+			return nil
+		}
+
+		run, created, err := s.launcher.Fire(ctx, &t, &ev)
+		if err != nil {
+			if errors.Is(err, ErrEventRejected) {
+				s.logger.Warn("trigger event rejected",
+					"trigger_id", t.ID,
+					"event_id", ev.ID,
+					"error", err,
+				)
+				return nil
+			}
+			return err
+		}
+
+		if run == nil {
+			s.logger.Debug("trigger event filtered out", "trigger_id", t.ID, "event_id", ev.ID)
+		} else if !created {
+			s.logger.Debug("trigger event already processed", "trigger_id", t.ID, "run_id", run.ID)
+		}
+		return nil
+	}
+}
+
+// eventFromDelivery создаёт Event из сообщения очереди триггера.
+// Ошибка (некорректное событие run.completed) оборачивает ErrEventRejected.
+func eventFromDelivery(t *domain.Trigger, d amqp.Delivery) (Event, error) {
+	if t.Type == domain.TriggerTypeFlow {
+		return EventFromRunCompleted(d)
+	}
+	return EventFromDelivery(d), nil
+}
@@ -0,0 +1,355 @@
+package trigger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/mq"
+)
+
+func sign(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidate(t *testing.T) {
+	upstreamFlow := uuid.New()
+	downstreamFlow := uuid.New()
+
+	tests := []struct {
+		name    string
+		trigger domain.Trigger
+		wantErr bool
+	}{
+		{
+			name: "valid amqp",
+			trigger: domain.Trigger{
+				Name: "orders", Type: domain.TriggerTypeAMQP,
+				Config: domain.TriggerConfig{Exchange: "shop", BindingKey: "orders.*"},
+				Filter: `eq .Body.status "paid"`,
+			},
+		},
+		{
+			name: "amqp without exchange",
+			trigger: domain.Trigger{
+				Name: "orders", Type: domain.TriggerTypeAMQP,
+				Config: domain.TriggerConfig{BindingKey: "orders.*"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid webhook",
+			trigger: domain.Trigger{
+				Name: "github", Type: domain.TriggerTypeWebhook,
+				Config: domain.TriggerConfig{
+					SignatureScheme: SignatureGitHub, Secret: "s",
+					AllowedIPs: []string{"10.0.0.0/8", "192.168.1.10"},
+				},
+			},
+		},
+		{
+			name: "webhook signature without secret",
+			trigger: domain.Trigger{
+				Name: "github", Type: domain.TriggerTypeWebhook,
+				Config: domain.TriggerConfig{SignatureScheme: SignatureGitHub},
+			},
+			wantErr: true,
+		},
+		{
+			name: "webhook invalid ip",
+			trigger: domain.Trigger{
+				Name: "hook", Type: domain.TriggerTypeWebhook,
+				Config: domain.TriggerConfig{AllowedIPs: []string{"not-an-ip"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid filter",
+			trigger: domain.Trigger{
+				Name: "hook", Type: domain.TriggerTypeWebhook,
+				Filter: `eq .Body.status "paid`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid flow",
+			trigger: domain.Trigger{
+				FlowID: downstreamFlow, Name: "load", Type: domain.TriggerTypeFlow,
+				Config: domain.TriggerConfig{
+					UpstreamFlowID: &upstreamFlow,
+					Statuses:       []domain.RunStatus{domain.RunStatusSucceeded, domain.RunStatusFailed},
+				},
+			},
+		},
+		{
+			name: "flow without upstream",
+			trigger: domain.Trigger{
+				FlowID: downstreamFlow, Name: "load", Type: domain.TriggerTypeFlow,
+			},
+			wantErr: true,
+		},
+		{
+			name: "flow chained to itself",
+			trigger: domain.Trigger{
+				FlowID: upstreamFlow, Name: "loop", Type: domain.TriggerTypeFlow,
+				Config: domain.TriggerConfig{UpstreamFlowID: &upstreamFlow},
+			},
+			wantErr: true,
+		},
+		{
+			name: "flow non-terminal status",
+			trigger: domain.Trigger{
+				FlowID: downstreamFlow, Name: "load", Type: domain.TriggerTypeFlow,
+				Config: domain.TriggerConfig{
+					UpstreamFlowID: &upstreamFlow,
+					Statuses:       []domain.RunStatus{domain.RunStatusRunning},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			trigger: domain.Trigger{Name: "x", Type: "kafka"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.trigger)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTrigger) {
+					t.Errorf("expected ErrInvalidTrigger, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMatchAndMapInputs(t *testing.T) {
+	trig := &domain.Trigger{
+		ID:     uuid.New(),
+		Filter: `eq .Body.status "paid"`,
+		InputMapping: map[string]string{
+			"order_id": "{{ .Body.id }}",
+			"tenant":   `{{ index .Headers "x-tenant" }}`,
+		},
+	}
+	ev := &Event{
+		ID:      "msg-1",
+		Body:    map[string]any{"id": "o-1", "status": "paid"},
+		Headers: map[string]any{"x-tenant": "acme"},
+	}
+
+	ok, err := Match(trig, ev)
+	if err != nil || !ok {
+		t.Fatalf("expected match, got %v (err: %v)", ok, err)
+	}
+
+	inputs, err := MapInputs(trig, ev)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inputs["order_id"] != "o-1" || inputs["tenant"] != "acme" {
+		t.Errorf("unexpected inputs: %v", inputs)
+	}
+
+	if key := IdempotencyKey(trig, ev); key != "trigger:"+trig.ID.String()+":msg-1" {
+		t.Errorf("unexpected idempotency key: %s", key)
+	}
+
+	// Без маппинга inputs — тело события
+	trig.InputMapping = nil
+	inputs, _ = MapInputs(trig, ev)
+	if inputs["status"] != "paid" {
+		t.Errorf("expected body as inputs, got %v", inputs)
+	}
+
+	ev.Body = map[string]any{"status": "new"}
+	if ok, _ := Match(trig, ev); ok {
+		t.Error("expected event to be filtered out")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	now := time.Now()
+
+	github := &domain.TriggerConfig{SignatureScheme: SignatureGitHub, Secret: "s3cret"}
+	headers := http.Header{}
+	headers.Set("X-Hub-Signature-256", "sha256="+sign("s3cret", body))
+	if err := VerifySignature(github, headers, body, now); err != nil {
+		t.Errorf("github: unexpected error: %v", err)
+	}
+	headers.Set("X-Hub-Signature-256", "sha256="+sign("wrong", body))
+	if err := VerifySignature(github, headers, body, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("github: expected ErrInvalidSignature, got %v", err)
+	}
+	if err := VerifySignature(github, http.Header{}, body, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("github: expected ErrInvalidSignature for missing header, got %v", err)
+	}
+
+	stripe := &domain.TriggerConfig{SignatureScheme: SignatureStripe, Secret: "whsec"}
+	ts := strconv.FormatInt(now.Unix(), 10)
+	headers = http.Header{}
+	headers.Set("Stripe-Signature", "t="+ts+",v1="+sign("whsec", []byte(ts+"."+string(body))))
+	if err := VerifySignature(stripe, headers, body, now); err != nil {
+		t.Errorf("stripe: unexpected error: %v", err)
+	}
+	if err := VerifySignature(stripe, headers, body, now.Add(10*time.Minute)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("stripe: expected tolerance error, got %v", err)
+	}
+
+	custom := &domain.TriggerConfig{SignatureScheme: SignatureHMACSHA256, Secret: "k", SignatureHeader: "X-Sig"}
+	headers = http.Header{}
+	headers.Set("X-Sig", sign("k", body))
+	if err := VerifySignature(custom, headers, body, now); err != nil {
+		t.Errorf("hmac_sha256: unexpected error: %v", err)
+	}
+
+	if err := VerifySignature(&domain.TriggerConfig{}, http.Header{}, body, now); err != nil {
+		t.Errorf("no scheme: unexpected error: %v", err)
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	cfg := &domain.TriggerConfig{AllowedIPs: []string{"10.0.0.0/8", "192.168.1.10"}}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"10.1.2.3:5555", true},
+		{"192.168.1.10:80", true},
+		{"192.168.1.11:80", false},
+		{"garbage", false},
+	}
+	for _, tt := range tests {
+		if got := IPAllowed(cfg, tt.addr); got != tt.want {
+			t.Errorf("IPAllowed(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	if !IPAllowed(&domain.TriggerConfig{}, "1.2.3.4:1") {
+		t.Error("empty allow-list should allow everything")
+	}
+}
+
+func TestEventFromRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/hooks/x?env=prod", strings.NewReader(""))
+	r.Header.Set("X-GitHub-Delivery", "d-1")
+	r.Header.Set("X-GitHub-Event", "push")
+
+	cfg := &domain.TriggerConfig{DedupeHeader: "X-GitHub-Delivery"}
+	ev := EventFromRequest(cfg, r, []byte(`{"ref":"main"}`))
+
+	if ev.ID != "dedupe:d-1" {
+		t.Errorf("expected dedupe id, got %q", ev.ID)
+	}
+	if ev.Headers["x-github-event"] != "push" {
+		t.Errorf("expected lower-cased headers, got %v", ev.Headers)
+	}
+	if ev.Query["env"] != "prod" {
+		t.Errorf("expected query, got %v", ev.Query)
+	}
+	if body, ok := ev.Body.(map[string]any); !ok || body["ref"] != "main" {
+		t.Errorf("expected parsed body, got %v", ev.Body)
+	}
+
+	// Без dedupe заголовка каждый запрос — новое событие
+	other := EventFromRequest(&domain.TriggerConfig{}, r, nil)
+	again := EventFromRequest(&domain.TriggerConfig{}, r, nil)
+	if other.ID == again.ID {
+		t.Error("expected unique event ids without dedupe header")
+	}
+}
+
+func TestFlowTrigger(t *testing.T) {
+	upstreamFlow := uuid.New()
+	trig := &domain.Trigger{
+		ID:     uuid.New(),
+		FlowID: uuid.New(),
+		Type:   domain.TriggerTypeFlow,
+		Config: domain.TriggerConfig{UpstreamFlowID: &upstreamFlow},
+	}
+
+	exchange, key := Source(trig)
+	if exchange != mq.ExchangeEvents || key != mq.RoutingKey("run.completed."+upstreamFlow.String()+".*") {
+		t.Errorf("unexpected source: %s %s", exchange, key)
+	}
+
+	runID := uuid.New()
+	body, _ := json.Marshal(mq.Message{
+		ID:   runID.String(),
+		Type: mq.MessageTypeRunCompleted,
+		Payload: mq.RunCompletedPayload{
+			RunID:  runID,
+			FlowID: upstreamFlow,
+			Status: string(domain.RunStatusSucceeded),
+			Inputs: map[string]any{"date": "2026-01-01"},
+			Steps:  map[string]map[string]any{"extract": {"rows": 42}},
+		},
+	})
+
+	ev, err := EventFromRunCompleted(amqp.Delivery{Body: body})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ev.ID != runID.String() {
+		t.Errorf("expected upstream run id as event id, got %q", ev.ID)
+	}
+
+	ok, err := Match(trig, &ev)
+	if err != nil || !ok {
+		t.Fatalf("expected match, got %v (err: %v)", ok, err)
+	}
+
+	// Без маппинга передаются inputs upstream run
+	inputs, _ := MapInputs(trig, &ev)
+	if inputs["date"] != "2026-01-01" || len(inputs) != 1 {
+		t.Errorf("expected upstream inputs, got %v", inputs)
+	}
+
+	trig.InputMapping = map[string]string{"rows": "{{ .Body.steps.extract.rows }}"}
+	inputs, _ = MapInputs(trig, &ev)
+	if inputs["rows"] != "42" {
+		t.Errorf("expected mapped step output, got %v", inputs)
+	}
+
+	// FAILED не входит в статусы по умолчанию
+	ev.Body.(map[string]any)["status"] = string(domain.RunStatusFailed)
+	if ok, _ := Match(trig, &ev); ok {
+		t.Error("expected FAILED run to be filtered out")
+	}
+	trig.Config.Statuses = []domain.RunStatus{domain.RunStatusFailed}
+	if ok, _ := Match(trig, &ev); !ok {
+		t.Error("expected FAILED run to match configured statuses")
+	}
+
+	// Sandbox runs не запускают цепочки
+	ev.Body.(map[string]any)["is_sandbox"] = true
+	if ok, _ := Match(trig, &ev); ok {
+		t.Error("expected sandbox run to be filtered out")
+	}
+
+	if _, err := EventFromRunCompleted(amqp.Delivery{Body: []byte("not json")}); !errors.Is(err, ErrEventRejected) {
+		t.Errorf("expected ErrEventRejected, got %v", err)
+	}
+}
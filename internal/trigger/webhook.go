@@ -0,0 +1,217 @@
+package trigger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+// Схемы подписи webhook.
+const (
+	SignatureGitHub     = "github"
+	SignatureStripe     = "stripe"
+	SignatureHMACSHA256 = "hmac_sha256"
+)
+
+// HookPathPrefix — путь API, на который приходят webhook запросы.
+const HookPathPrefix = "/api/v1/hooks/"
+
+// stripeTolerance — допустимое расхождение времени подписи Stripe.
+const stripeTolerance = 5 * time.Minute
+
+// maxSyncTimeout — верхняя граница ожидания run в синхронном режиме.
+const maxSyncTimeout = 5 * time.Minute
+
+// defaultSyncTimeout — ожидание run в синхронном режиме по умолчанию.
+const defaultSyncTimeout = 30 * time.Second
+
+// signatureHeaders — заголовки подписи по умолчанию.
+var signatureHeaders = map[string]string{
+	SignatureGitHub:     "X-Hub-Signature-256",
+	SignatureStripe:     "Stripe-Signature",
+	SignatureHMACSHA256: "X-Signature",
+}
+
+// validateWebhook проверяет параметры webhook триггера.
+func validateWebhook(cfg *domain.TriggerConfig) error {
+	if cfg.SignatureScheme != "" {
+		if _, ok := signatureHeaders[cfg.SignatureScheme]; !ok {
+			return fmt.Errorf("%w: unknown signature_scheme %q (expected github, stripe or hmac_sha256)",
+				ErrInvalidTrigger, cfg.SignatureScheme)
+		}
+		if cfg.Secret == "" {
+			return fmt.Errorf("%w: signature_scheme requires secret", ErrInvalidTrigger)
+		}
+	}
+
+	for _, entry := range cfg.AllowedIPs {
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			return fmt.Errorf("%w: allowed_ips: invalid IP or CIDR %q", ErrInvalidTrigger, entry)
+		}
+	}
+
+	if cfg.SyncTimeoutSec < 0 || time.Duration(cfg.SyncTimeoutSec)*time.Second > maxSyncTimeout {
+		return fmt.Errorf("%w: sync_timeout_sec must be between 0 and %d",
+			ErrInvalidTrigger, int(maxSyncTimeout.Seconds()))
+	}
+
+	return nil
+}
+
+// SyncTimeout возвращает время ожидания run в синхронном режиме.
+func SyncTimeout(cfg *domain.TriggerConfig) time.Duration {
+	if cfg.SyncTimeoutSec <= 0 {
+		return defaultSyncTimeout
+	}
+	return time.Duration(cfg.SyncTimeoutSec) * time.Second
+}
+
+// IPAllowed проверяет адрес клиента по allow-list триггера.
+// remoteAddr — host:port или IP. Пустой allow-list пропускает всех.
+func IPAllowed(cfg *domain.TriggerConfig, remoteAddr string) bool {
+	if len(cfg.AllowedIPs) == 0 {
+		return true
+	}
+
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range cfg.AllowedIPs {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifySignature проверяет HMAC подпись тела запроса.
+// Без signature_scheme подпись не проверяется.
+func VerifySignature(cfg *domain.TriggerConfig, headers http.Header, body []byte, now time.Time) error {
+	if cfg.SignatureScheme == "" {
+		return nil
+	}
+
+	header := cfg.SignatureHeader
+	if header == "" {
+		header = signatureHeaders[cfg.SignatureScheme]
+	}
+	value := headers.Get(header)
+	if value == "" {
+		return fmt.Errorf("%w: missing %s header", ErrInvalidSignature, header)
+	}
+
+	switch cfg.SignatureScheme {
+	case SignatureGitHub, SignatureHMACSHA256:
+		if hmacEqual(cfg.Secret, body, strings.TrimPrefix(value, "sha256=")) {
+			return nil
+		}
+
+	case SignatureStripe:
+		return verifyStripe(cfg.Secret, value, body, now)
+	}
+
+	return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+}
+
+// verifyStripe проверяет заголовок вида t=<unix>,v1=<hex>[,v1=<hex>...].
+func verifyStripe(secret, header string, body []byte, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed signature header", ErrInvalidSignature)
+	}
+
+	signedAt := time.Unix(ts, 0)
+	if now.Sub(signedAt) > stripeTolerance || signedAt.Sub(now) > stripeTolerance {
+		return fmt.Errorf("%w: signature timestamp outside tolerance", ErrInvalidSignature)
+	}
+
+	payload := append([]byte(timestamp+"."), body...)
+	for _, sig := range signatures {
+		if hmacEqual(secret, payload, sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+}
+
+// hmacEqual сравнивает hex подпись с HMAC-SHA256 данных (за постоянное время).
+func hmacEqual(secret string, data []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// EventFromRequest создаёт Event из входящего webhook запроса.
+//
+// ID — значение заголовка dedupe_header; если он не задан или пуст,
+// каждый запрос считается новым событием. Имена заголовков приводятся
+// к нижнему регистру: {{ index .Headers "x-github-event" }}.
+func EventFromRequest(cfg *domain.TriggerConfig, r *http.Request, body []byte) Event {
+	headers := make(map[string]any, len(r.Header))
+	for name, values := range r.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ", ")
+	}
+
+	query := make(map[string]any)
+	for name, values := range r.URL.Query() {
+		query[name] = strings.Join(values, ",")
+	}
+
+	var id string
+	if cfg.DedupeHeader != "" {
+		if key := r.Header.Get(cfg.DedupeHeader); key != "" {
+			id = "dedupe:" + key
+		}
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	return Event{
+		ID:        id,
+		Body:      parseBody(body),
+		Headers:   headers,
+		Query:     query,
+		Timestamp: time.Now().UTC(),
+	}
+}
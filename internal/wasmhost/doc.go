@@ -0,0 +1,27 @@
+// Package wasmhost выполняет WebAssembly-модули шага wasm.
+//
+// Модули — программы WASI (wasi_snapshot_preview1), например собранные
+// GOOS=wasip1 GOARCH=wasm go build или cargo build --target wasm32-wasip1.
+// Модуль получает вход на stdin, пишет результат в stdout и диагностику
+// в stderr; ненулевой код выхода — ошибка.
+//
+// Модуль не имеет доступа к файловой системе, сети и переменным
+// окружения хоста: WASI-окружение пустое, часы и источник случайных
+// чисел — детерминированные заглушки wazero.
+//
+// Лимиты:
+//   - память — максимальный размер линейной памяти (страницы по 64 KiB);
+//   - время — deadline контекста, по которому wazero прерывает
+//     выполнение. Учёт «топлива» (числа инструкций) wazero не
+//     поддерживает, поэтому процессорное время ограничивается deadline.
+//
+// Скомпилированные модули кешируются по дайджесту, компиляция
+// переиспользуется между рантаймами с разными лимитами памяти.
+//
+// Использование:
+//
+//	host := wasmhost.New()
+//	defer host.Close(ctx)
+//
+//	out, err := host.Run(ctx, digest, load, stdin, wasmhost.Limits{MaxMemory: 64 << 20})
+package wasmhost
@@ -0,0 +1,252 @@
+package wasmhost
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/shaiso/Automata/internal/engine"
+)
+
+const (
+	// pageSize — размер страницы линейной памяти WebAssembly.
+	pageSize = 64 << 10
+
+	// maxStdout — ограничение размера stdout модуля (результат шага).
+	maxStdout = 4 << 20
+
+	// maxStderr — сколько stderr модуля сохраняется для сообщения об ошибке.
+	maxStderr = 64 << 10
+
+	// maxCompiledModules — число скомпилированных модулей в кеше.
+	maxCompiledModules = 64
+)
+
+// Ошибки выполнения модулей.
+var (
+	// ErrInvalidModule — модуль не компилируется или не является программой WASI.
+	ErrInvalidModule = errors.New("invalid wasm module")
+
+	// ErrTimeout — выполнение прервано по истечении времени.
+	ErrTimeout = errors.New("wasm module timed out")
+
+	// ErrOutputTooLarge — stdout модуля превысил ограничение.
+	ErrOutputTooLarge = errors.New("wasm module output too large")
+)
+
+// Limits — ограничения одного запуска модуля.
+type Limits struct {
+	// MaxMemory — максимальный размер линейной памяти, в байтах.
+	MaxMemory uint64
+
+	// Timeout — ограничение времени выполнения (0 — только deadline ctx).
+	Timeout time.Duration
+}
+
+// Output — результат запуска модуля.
+type Output struct {
+	// Stdout — вывод модуля.
+	Stdout []byte
+
+	// Stderr — начало stderr модуля (до 64 KiB).
+	Stderr []byte
+
+	// ExitCode — код выхода (0 — успех).
+	ExitCode uint32
+}
+
+// Host выполняет модули. Безопасен для конкурентного использования.
+type Host struct {
+	cache wazero.CompilationCache
+
+	mu       sync.Mutex
+	runtimes map[uint32]wazero.Runtime
+	compiled map[string]wazero.CompiledModule
+}
+
+// New создаёт Host.
+func New() *Host {
+	return &Host{
+		cache:    wazero.NewCompilationCache(),
+		runtimes: make(map[uint32]wazero.Runtime),
+		compiled: make(map[string]wazero.CompiledModule),
+	}
+}
+
+// Run выполняет модуль с дайджестом digest: stdin передаётся модулю,
+// stdout и stderr возвращаются в Output. load вызывается, только если
+// модуль ещё не скомпилирован. Ненулевой код выхода не является ошибкой
+// Run — его интерпретирует вызывающий.
+func (h *Host) Run(ctx context.Context, digest string, load func(context.Context) ([]byte, error), stdin []byte, limits Limits) (*Output, error) {
+	pages := memoryPages(limits.MaxMemory)
+
+	runtime, err := h.runtime(ctx, pages)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := h.compile(ctx, runtime, digest, pages, load)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx := ctx
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+
+	stdout := &limitedBuffer{limit: maxStdout}
+	stderr := &limitedBuffer{limit: maxStderr}
+	config := wazero.NewModuleConfig().
+		WithName(""). // анонимный экземпляр: запуски одного модуля не конфликтуют
+		WithArgs("module").
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(stdout).
+		WithStderr(stderr)
+
+	out := &Output{}
+	mod, err := runtime.InstantiateModule(runCtx, compiled, config)
+	if mod != nil {
+		mod.Close(ctx)
+	}
+	if err != nil {
+		var exitErr *sys.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidModule, err)
+		}
+		switch exitErr.ExitCode() {
+		case sys.ExitCodeDeadlineExceeded, sys.ExitCodeContextCanceled:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("%w after %s", ErrTimeout, limits.Timeout)
+		}
+		out.ExitCode = exitErr.ExitCode()
+	}
+
+	if stdout.overflow {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrOutputTooLarge, maxStdout)
+	}
+	out.Stdout = stdout.Bytes()
+	out.Stderr = stderr.Bytes()
+	return out, nil
+}
+
+// runtime возвращает рантайм с лимитом памяти pages (создаётся лениво).
+func (h *Host) runtime(ctx context.Context, pages uint32) (wazero.Runtime, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if r, ok := h.runtimes[pages]; ok {
+		return r, nil
+	}
+
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCompilationCache(h.cache).
+		WithMemoryLimitPages(pages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("instantiate wasi: %w", err)
+	}
+	h.runtimes[pages] = r
+	return r, nil
+}
+
+// compile возвращает скомпилированный модуль из кеша или загружает
+// и компилирует его. Содержимое сверяется с дайджестом.
+func (h *Host) compile(ctx context.Context, runtime wazero.Runtime, digest string, pages uint32, load func(context.Context) ([]byte, error)) (wazero.CompiledModule, error) {
+	key := fmt.Sprintf("%s/%d", digest, pages)
+
+	h.mu.Lock()
+	compiled, ok := h.compiled[key]
+	h.mu.Unlock()
+	if ok {
+		return compiled, nil
+	}
+
+	data, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if got := engine.WasmDigest(data); got != digest {
+		return nil, fmt.Errorf("%w: content digest %s does not match %s", ErrInvalidModule, got, digest)
+	}
+
+	compiled, err = runtime.CompileModule(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModule, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Параллельная компиляция того же модуля — оставляем первую
+	if existing, ok := h.compiled[key]; ok {
+		compiled.Close(ctx)
+		return existing, nil
+	}
+	if len(h.compiled) >= maxCompiledModules {
+		// Закрытие безопасно для уже запущенных экземпляров
+		for k, m := range h.compiled {
+			m.Close(ctx)
+			delete(h.compiled, k)
+			break
+		}
+	}
+	h.compiled[key] = compiled
+	return compiled, nil
+}
+
+// Close освобождает рантаймы и скомпилированные модули.
+func (h *Host) Close(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, r := range h.runtimes {
+		r.Close(ctx)
+		delete(h.runtimes, key)
+	}
+	clear(h.compiled)
+	return h.cache.Close(ctx)
+}
+
+// memoryPages переводит лимит памяти в страницы (не меньше одной).
+func memoryPages(maxMemory uint64) uint32 {
+	pages := (maxMemory + pageSize - 1) / pageSize
+	if pages < 1 {
+		pages = 1
+	}
+	if pages > 65536 {
+		pages = 65536
+	}
+	return uint32(pages)
+}
+
+// limitedBuffer — буфер, сохраняющий не больше limit байт.
+// Запись сверх лимита не возвращает ошибку (модуль не должен падать
+// на записи), а отмечается флагом overflow.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.overflow = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
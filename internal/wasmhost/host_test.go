@@ -0,0 +1,158 @@
+package wasmhost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shaiso/Automata/internal/engine"
+)
+
+var (
+	stepModuleOnce sync.Once
+	stepModule     []byte
+	stepModuleErr  error
+)
+
+// buildStepModule собирает testdata/stepmod под wasip1 (один раз на пакет).
+func buildStepModule(t *testing.T) []byte {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	stepModuleOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "stepmod")
+		if err != nil {
+			stepModuleErr = err
+			return
+		}
+		defer os.RemoveAll(dir)
+
+		out := filepath.Join(dir, "stepmod.wasm")
+		cmd := exec.Command(goBin, "build", "-o", out, "./testdata/stepmod")
+		cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			stepModuleErr = errors.New(string(output))
+			return
+		}
+		stepModule, stepModuleErr = os.ReadFile(out)
+	})
+	if stepModuleErr != nil {
+		t.Fatalf("build stepmod: %v", stepModuleErr)
+	}
+	return stepModule
+}
+
+func runStepModule(t *testing.T, host *Host, config map[string]any, limits Limits) (*Output, error) {
+	t.Helper()
+	data := buildStepModule(t)
+	stdin, _ := json.Marshal(map[string]any{"config": config, "context": map[string]any{"inputs": map[string]any{"n": 1}}})
+	load := func(context.Context) ([]byte, error) { return data, nil }
+	return host.Run(context.Background(), engine.WasmDigest(data), load, stdin, limits)
+}
+
+func TestHost_Run(t *testing.T) {
+	host := New()
+	defer host.Close(context.Background())
+
+	out, err := runStepModule(t, host, map[string]any{"mode": "echo"}, Limits{MaxMemory: 64 << 20, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d (%s)", out.ExitCode, out.Stderr)
+	}
+	var echoed map[string]any
+	if err := json.Unmarshal(out.Stdout, &echoed); err != nil {
+		t.Fatalf("stdout is not JSON: %v: %s", err, out.Stdout)
+	}
+	inputs := echoed["context"].(map[string]any)["inputs"].(map[string]any)
+	if inputs["n"] != float64(1) {
+		t.Errorf("expected context passed through, got %v", echoed)
+	}
+
+	out, err = runStepModule(t, host, map[string]any{"mode": "fail"}, Limits{MaxMemory: 64 << 20, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.ExitCode != 3 || !strings.Contains(string(out.Stderr), "boom") {
+		t.Errorf("expected exit code 3 with stderr, got %d %q", out.ExitCode, out.Stderr)
+	}
+}
+
+func TestHost_Limits(t *testing.T) {
+	host := New()
+	defer host.Close(context.Background())
+
+	_, err := runStepModule(t, host, map[string]any{"mode": "loop"}, Limits{MaxMemory: 64 << 20, Timeout: 500 * time.Millisecond})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+
+	// Рантайм Go не может вырастить память сверх лимита и завершается с ошибкой
+	out, err := runStepModule(t, host, map[string]any{"mode": "alloc", "mb": 128}, Limits{MaxMemory: 64 << 20, Timeout: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.ExitCode == 0 {
+		t.Errorf("expected allocation over the memory limit to fail, got %s", out.Stdout)
+	}
+
+	out, err = runStepModule(t, host, map[string]any{"mode": "alloc", "mb": 8}, Limits{MaxMemory: 64 << 20, Timeout: 10 * time.Second})
+	if err != nil || out.ExitCode != 0 {
+		t.Errorf("expected allocation within the limit to succeed, got %v %+v", err, out)
+	}
+}
+
+func TestHost_DigestMismatch(t *testing.T) {
+	host := New()
+	defer host.Close(context.Background())
+
+	data := buildStepModule(t)
+	load := func(context.Context) ([]byte, error) { return data, nil }
+	digest := engine.WasmDigest([]byte("other"))
+
+	_, err := host.Run(context.Background(), digest, load, nil, Limits{MaxMemory: 64 << 20})
+	if !errors.Is(err, ErrInvalidModule) {
+		t.Errorf("expected ErrInvalidModule, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+
+	if err := Validate(ctx, buildStepModule(t)); err != nil {
+		t.Errorf("expected WASI command to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not wasm", []byte("hello")},
+		// Пустой модуль: (module)
+		{"no _start", []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}},
+		// (module (import "env" "f" (func)))
+		{"foreign import", []byte{
+			0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+			0x01, 0x04, 0x01, 0x60, 0x00, 0x00,
+			0x02, 0x09, 0x01, 0x03, 'e', 'n', 'v', 0x01, 'f', 0x00, 0x00,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(ctx, tt.data); !errors.Is(err, ErrInvalidModule) {
+				t.Errorf("expected ErrInvalidModule, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Тестовый модуль для wasm шага: GOOS=wasip1 GOARCH=wasm go build.
+//
+// Поведение задаётся config.mode:
+//   - echo: выводит полученные config и context
+//   - fail: пишет в stderr и завершается с кодом 3
+//   - loop: бесконечный цикл
+//   - alloc: выделяет config.mb мегабайт
+//   - scalar: выводит число
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type input struct {
+	Config  map[string]any `json:"config"`
+	Context map[string]any `json:"context"`
+}
+
+var sink []byte
+
+func main() {
+	var in input
+	if err := json.NewDecoder(os.Stdin).Decode(&in); err != nil {
+		fmt.Fprintln(os.Stderr, "decode input:", err)
+		os.Exit(1)
+	}
+
+	switch in.Config["mode"] {
+	case "echo":
+		json.NewEncoder(os.Stdout).Encode(in)
+	case "fail":
+		fmt.Fprintln(os.Stderr, "boom")
+		os.Exit(3)
+	case "loop":
+		for n := 0; ; n++ {
+			sink = fmt.Appendf(sink[:0], "%d", n)
+		}
+	case "alloc":
+		mb, _ := in.Config["mb"].(float64)
+		sink = make([]byte, int(mb)<<20)
+		for i := range sink {
+			sink[i] = 1
+		}
+		fmt.Println(`{"allocated": true}`)
+	case "scalar":
+		fmt.Println(42)
+	default:
+		fmt.Fprintln(os.Stderr, "unknown mode")
+		os.Exit(2)
+	}
+}
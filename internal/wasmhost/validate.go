@@ -0,0 +1,37 @@
+package wasmhost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// startFunction — точка входа программы WASI.
+const startFunction = "_start"
+
+// Validate проверяет модуль при загрузке: он компилируется, импортирует
+// только функции wasi_snapshot_preview1 и экспортирует _start.
+func Validate(ctx context.Context, data []byte) error {
+	// Интерпретатор: проверка без компиляции в машинный код
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(ctx)
+
+	compiled, err := r.CompileModule(ctx, data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidModule, err)
+	}
+
+	for _, fn := range compiled.ImportedFunctions() {
+		module, name, _ := fn.Import()
+		if module != wasi_snapshot_preview1.ModuleName {
+			return fmt.Errorf("%w: import %s.%s is not available, only %s is provided",
+				ErrInvalidModule, module, name, wasi_snapshot_preview1.ModuleName)
+		}
+	}
+	if _, ok := compiled.ExportedFunctions()[startFunction]; !ok {
+		return fmt.Errorf("%w: module must export %s (a WASI command)", ErrInvalidModule, startFunction)
+	}
+	return nil
+}
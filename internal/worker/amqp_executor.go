@@ -0,0 +1,257 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/shaiso/Automata/internal/config"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+const defaultAMQPConfirmTimeout = 30 * time.Second
+
+// AMQPPublishExecutor — executor для шага типа "amqp_publish".
+//
+// Публикует сообщение в exchange брокера, заданного именованным
+// подключением (AUTOMATA_CONN_<NAME>=amqp://...). Внутреннее подключение
+// Automata (mq.Connection) не используется. Публикация идёт в режиме
+// publisher confirms: шаг успешен только после подтверждения брокером.
+//
+// Config (из task.Payload):
+//   - connection (string): имя подключения (обязательно)
+//   - exchange (string): exchange ("" — default exchange)
+//   - routing_key (string): routing key
+//   - body (any): тело сообщения; строка отправляется как есть,
+//     остальное сериализуется в JSON
+//   - content_type (string): Default: application/json (text/plain для строки)
+//   - headers (map[string]any): заголовки сообщения
+//   - message_id (string): Default: ID task (не меняется между попытками,
+//     получатели могут дедуплицировать повторы)
+//   - correlation_id (string), message_type (string): свойства сообщения
+//   - persistent (bool): сохранять сообщение на диске брокера. Default: true
+//   - mandatory (bool): ошибка, если сообщение не попало ни в одну очередь
+//   - timeout_sec (number): ожидание подтверждения. Default: 30
+//
+// Outputs:
+//   - message_id (string): ID опубликованного сообщения
+//   - exchange (string), routing_key (string): куда опубликовано
+//
+// Несуществующий exchange и unroutable сообщение при mandatory —
+// логические ошибки; обрыв соединения и nack брокера — инфраструктурные.
+type AMQPPublishExecutor struct {
+	connections *config.Connections
+
+	mu    sync.Mutex
+	conns map[string]*amqp.Connection
+}
+
+// NewAMQPPublishExecutor создаёт AMQPPublishExecutor для именованных подключений.
+func NewAMQPPublishExecutor(connections *config.Connections) *AMQPPublishExecutor {
+	return &AMQPPublishExecutor{
+		connections: connections,
+		conns:       make(map[string]*amqp.Connection),
+	}
+}
+
+// amqpPublishConfig — распарсенная конфигурация amqp_publish шага.
+type amqpPublishConfig struct {
+	connection string
+	exchange   string
+	routingKey string
+	mandatory  bool
+	timeout    time.Duration
+	msg        amqp.Publishing
+}
+
+// Execute публикует сообщение и ждёт подтверждения брокера.
+func (e *AMQPPublishExecutor) Execute(ctx context.Context, task *domain.Task) (*ExecutionResult, error) {
+	cfg, err := parseAMQPPublishConfig(task.Payload)
+	if err != nil {
+		return &ExecutionResult{Error: err.Error()}, nil
+	}
+	if cfg.msg.MessageId == "" {
+		cfg.msg.MessageId = task.ID.String()
+	}
+
+	conn, err := e.connections.Get(cfg.connection, "amqp", "amqps")
+	if err != nil {
+		return &ExecutionResult{Error: err.Error()}, nil
+	}
+
+	amqpConn, err := e.dial(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := amqpConn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("%w: open channel: %v", ErrAMQPPublish, err)
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return nil, fmt.Errorf("%w: enable confirms: %v", ErrAMQPPublish, err)
+	}
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	returned := ch.NotifyReturn(make(chan amqp.Return, 1))
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx,
+		cfg.exchange, cfg.routingKey, cfg.mandatory, false, cfg.msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: publish: %v", ErrAMQPPublish, err)
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: wait for confirm: %v", ErrAMQPPublish, err)
+	}
+	if !acked {
+		// Канал закрыт брокером (например, exchange не существует)
+		select {
+		case amqpErr := <-closed:
+			if amqpErr != nil && amqpErr.Code == amqp.NotFound {
+				return &ExecutionResult{Error: fmt.Sprintf("amqp: %s", amqpErr.Reason)}, nil
+			}
+			if amqpErr != nil {
+				return nil, fmt.Errorf("%w: %v", ErrAMQPPublish, amqpErr)
+			}
+		default:
+		}
+		return nil, fmt.Errorf("%w: message %s nacked by broker", ErrAMQPPublish, cfg.msg.MessageId)
+	}
+
+	// basic.return приходит до basic.ack: к этому моменту он уже получен
+	select {
+	case ret := <-returned:
+		return &ExecutionResult{
+			Outputs: map[string]any{"message_id": cfg.msg.MessageId},
+			Error:   fmt.Sprintf("amqp: message returned: %d %s", ret.ReplyCode, ret.ReplyText),
+		}, nil
+	default:
+	}
+
+	return &ExecutionResult{
+		Outputs: map[string]any{
+			"message_id":  cfg.msg.MessageId,
+			"exchange":    cfg.exchange,
+			"routing_key": cfg.routingKey,
+		},
+	}, nil
+}
+
+// dial возвращает соединение подключения, переподключаясь после обрыва.
+func (e *AMQPPublishExecutor) dial(conn *config.Connection) (*amqp.Connection, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if c, ok := e.conns[conn.Name]; ok && !c.IsClosed() {
+		return c, nil
+	}
+
+	c, err := amqp.Dial(conn.URL.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: connect %s: %v", ErrAMQPPublish, conn, err)
+	}
+	e.conns[conn.Name] = c
+	return c, nil
+}
+
+// Close закрывает соединения с брокерами.
+func (e *AMQPPublishExecutor) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for name, c := range e.conns {
+		c.Close()
+		delete(e.conns, name)
+	}
+}
+
+// parseAMQPPublishConfig извлекает конфигурацию amqp_publish шага из payload.
+func parseAMQPPublishConfig(payload map[string]any) (*amqpPublishConfig, error) {
+	cfg := &amqpPublishConfig{
+		connection: getString(payload, "connection", ""),
+		exchange:   getString(payload, "exchange", ""),
+		routingKey: getString(payload, "routing_key", ""),
+		mandatory:  getBool(payload, "mandatory", false),
+		timeout:    getSeconds(payload, "timeout_sec", defaultAMQPConfirmTimeout),
+		msg: amqp.Publishing{
+			MessageId:     getString(payload, "message_id", ""),
+			CorrelationId: getString(payload, "correlation_id", ""),
+			Type:          getString(payload, "message_type", ""),
+			Timestamp:     time.Now().UTC(),
+			DeliveryMode:  amqp.Persistent,
+		},
+	}
+
+	if cfg.connection == "" {
+		return nil, fmt.Errorf("%w: connection is required", ErrInvalidAMQPConfig)
+	}
+	if cfg.exchange == "" && cfg.routingKey == "" {
+		return nil, fmt.Errorf("%w: exchange or routing_key is required", ErrInvalidAMQPConfig)
+	}
+	if !getBool(payload, "persistent", true) {
+		cfg.msg.DeliveryMode = amqp.Transient
+	}
+
+	switch body := payload["body"].(type) {
+	case nil:
+		cfg.msg.ContentType = "application/json"
+	case string:
+		cfg.msg.Body = []byte(body)
+		cfg.msg.ContentType = "text/plain"
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: marshal body: %v", ErrInvalidAMQPConfig, err)
+		}
+		cfg.msg.Body = data
+		cfg.msg.ContentType = "application/json"
+	}
+	cfg.msg.ContentType = getString(payload, "content_type", cfg.msg.ContentType)
+
+	switch headers := payload["headers"].(type) {
+	case nil:
+	case map[string]any:
+		cfg.msg.Headers = amqpTable(headers)
+		if err := cfg.msg.Headers.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: headers: %v", ErrInvalidAMQPConfig, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: headers must be an object", ErrInvalidAMQPConfig)
+	}
+
+	return cfg, nil
+}
+
+// amqpTable приводит заголовки из JSON к amqp.Table: вложенные объекты —
+// к amqp.Table, целые числа — к int64.
+func amqpTable(m map[string]any) amqp.Table {
+	table := make(amqp.Table, len(m))
+	for k, v := range m {
+		table[k] = amqpValue(v)
+	}
+	return table
+}
+
+func amqpValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		return amqpTable(val)
+	case []any:
+		items := make([]any, len(val))
+		for i, item := range val {
+			items[i] = amqpValue(item)
+		}
+		return items
+	default:
+		return sqlParam(val)
+	}
+}
@@ -7,7 +7,8 @@
 //
 //   - Получение tasks из очереди RabbitMQ (event-driven)
 //   - Периодическую проверку queued tasks в БД (polling fallback)
-//   - Выполнение task в зависимости от типа шага (http, delay, transform)
+//   - Выполнение task в зависимости от типа шага (http, delay, transform, poll, sql, amqp_publish, email, grpc, script, wasm,
+//     типы плагинов)
 //   - Retry с exponential backoff при ошибках
 //   - Отправку результата обратно в очередь tasks.completed
 //
@@ -22,12 +23,13 @@
 // Создаётся через New(cfg Config) и запускается методом Start(ctx).
 //
 //	w := worker.New(worker.Config{
-//	    TaskRepo:  taskRepo,
-//	    RunRepo:   runRepo,
-//	    FlowRepo:  flowRepo,
-//	    Publisher: publisher,
-//	    Conn:      mqConn,
-//	    Logger:    logger,
+//	    TaskRepo:    taskRepo,
+//	    RunRepo:     runRepo,
+//	    FlowRepo:    flowRepo,
+//	    Publisher:   publisher,
+//	    Conn:        mqConn,
+//	    Connections: connections, // config.LoadConnections(os.Environ())
+//	    Logger:      logger,
 //	})
 //
 //	if err := w.Start(ctx); err != nil {
@@ -44,35 +46,78 @@
 //	}
 //
 // Реализации:
-//   - HTTPExecutor — HTTP-запросы (GET/POST/PUT/DELETE, headers, body, timeout)
+//   - HTTPExecutor — HTTP-запросы (GET/POST/PUT/DELETE, headers, body, timeout),
+//     тела в JSON, XML или CSV
 //   - DelayExecutor — задержка на указанное количество секунд
-//   - TransformExecutor — трансформация данных (pass-through отрендеренного payload)
+//   - TransformExecutor — трансформация данных (вычисление jq-выражений отрендеренного payload)
+//   - PollExecutor — повтор вложенного действия до выполнения условия until
+//   - SQLExecutor — параметризованный запрос к PostgreSQL через именованное
+//     подключение (config.Connections), пулы создаются лениво
+//   - AMQPPublishExecutor — публикация сообщения в RabbitMQ через именованное
+//     подключение с publisher confirms
+//   - EmailExecutor — отправка письма через SMTP-подключение (STARTTLS, AUTH,
+//     вложения); SMTP 5xx — постоянная ошибка (ExecutionResult.Permanent)
+//   - GRPCExecutor — unary-вызов gRPC по схеме из server reflection или
+//     descriptor set (см. grpcschema); соединения и схемы кешируются
+//   - ScriptExecutor — Starlark-скрипт во встроенном интерпретаторе без доступа
+//     к файлам и сети, с лимитами времени, шагов и памяти
+//   - WasmExecutor — загруженный WebAssembly-модуль (WASI) в рантайме
+//     wazero (см. wasmhost): JSON на stdin, outputs из stdout
+//   - PluginExecutor — тип шага плагина: процесс плагина по протоколу
+//     JSON поверх stdio (см. plugin), с проверками живости и перезапуском
 //
 // ## Registry
 //
 // Реестр executor'ов по типу шага. NewRegistry() создаёт реестр
-// с предустановленными executor'ами (http, delay, transform).
+// с предустановленными executor'ами (http, delay, transform, poll, script).
+// RegisterConnectionExecutors добавляет executor'ы, работающие через
+// именованные подключения (sql, amqp_publish, email); Registry.Close
+// закрывает их пулы и соединения. RegisterGRPCExecutor добавляет grpc
+// с доступом к загруженным descriptor sets, RegisterWasmExecutor — wasm
+// с доступом к загруженным модулям. RegisterPluginExecutors добавляет
+// типы шагов плагинов (Config.Plugins, plugin.Discover): один процесс
+// на плагин обслуживает все его типы.
 //
 // # Обработка task
 //
 //  1. Получение task (из очереди или polling)
 //  2. Загрузка task из БД, проверка статуса QUEUED
-//  3. Перевод в RUNNING, инкремент Attempt
+//  3. Атомарный перевод QUEUED → RUNNING (TaskRepo.Claim), инкремент Attempt
 //  4. Загрузка RetryPolicy из FlowVersion
 //  5. Выполнение через executeWithRetry
 //  6. Успех → MarkSucceeded, publish TaskCompleted(SUCCEEDED)
 //  7. Ошибка → MarkFailed, publish TaskCompleted(FAILED)
 //
+// # Poll
+//
+// PollExecutor выполняет одну итерацию за вызов и возвращает
+// ExecutionResult.Reschedule, если условие until не выполнено.
+// Task возвращается в QUEUED с next_attempt_at, промежуточное состояние
+// (история итераций) хранится в outputs. Следующую итерацию запускает
+// таймер воркера (scheduleTask: не больше defaultPrefetch итераций
+// одновременно, горутины учтены при остановке), а после рестарта —
+// polling (ListQueued учитывает next_attempt_at). Между итерациями слот
+// воркера не занят. Task забирается атомарно (TaskRepo.Claim), поэтому
+// таймер и polling не выполнят одну итерацию дважды.
+//
+// # External
+//
+// Tasks шагов external воркер не выполняет: для них нет executor'а,
+// task.ready не публикуется, а ListQueued их не возвращает. Их забирают
+// внешние воркеры через API fetch-and-lock (см. api).
+//
 // # Retry
 //
 // Retry выполняется в процессе (in-process), а не через requeue в RabbitMQ.
 // Это даёт точный контроль над backoff и подсчётом попыток.
 //
-// Стратегии backoff:
+// Backoff считает engine.RetryBackoff (общий с external шагами). Стратегии:
 //   - "exponential": delay = initialDelay * 2^(attempt-1), capped at maxDelay
 //   - "fixed": delay = initialDelay
 //
-// Для HTTP-шагов можно указать OnStatus — список HTTP-кодов, при которых retry.
+// Для HTTP-шагов можно указать OnStatus — список HTTP-кодов, при которых retry
+// (для grpc — коды gRPC-статуса). Логическая ошибка с ExecutionResult.Permanent
+// (например, SMTP 5xx или gRPC NOT_FOUND) без OnStatus не повторяется.
 //
 // # Ошибки
 //
@@ -10,6 +10,9 @@ var (
 	// ErrTaskNotQueued — task не в статусе QUEUED.
 	ErrTaskNotQueued = errors.New("task is not in QUEUED status")
 
+	// ErrTaskNotDue — время следующей попытки task ещё не наступило.
+	ErrTaskNotDue = errors.New("task is not due yet")
+
 	// ErrUnknownStepType — нет executor'а для данного типа шага.
 	ErrUnknownStepType = errors.New("unknown step type")
 
@@ -30,4 +33,40 @@ var (
 
 	// ErrHTTPRequest — HTTP-запрос завершился ошибкой.
 	ErrHTTPRequest = errors.New("http request failed")
+
+	// ErrInvalidPollConfig — некорректная конфигурация poll шага.
+	ErrInvalidPollConfig = errors.New("invalid poll config")
+
+	// ErrInvalidSQLConfig — некорректная конфигурация sql шага.
+	ErrInvalidSQLConfig = errors.New("invalid sql config")
+
+	// ErrSQLQuery — SQL-запрос не выполнен (подключение, транзакция).
+	ErrSQLQuery = errors.New("sql query failed")
+
+	// ErrInvalidAMQPConfig — некорректная конфигурация amqp_publish шага.
+	ErrInvalidAMQPConfig = errors.New("invalid amqp_publish config")
+
+	// ErrAMQPPublish — сообщение не опубликовано (подключение, подтверждение).
+	ErrAMQPPublish = errors.New("amqp publish failed")
+
+	// ErrInvalidEmailConfig — некорректная конфигурация email шага.
+	ErrInvalidEmailConfig = errors.New("invalid email config")
+
+	// ErrSMTPSend — письмо не отправлено (подключение, TLS).
+	ErrSMTPSend = errors.New("smtp send failed")
+
+	// ErrInvalidGRPCConfig — некорректная конфигурация grpc шага.
+	ErrInvalidGRPCConfig = errors.New("invalid grpc config")
+
+	// ErrGRPCCall — gRPC-вызов не выполнен (подключение, ответ).
+	ErrGRPCCall = errors.New("grpc call failed")
+
+	// ErrInvalidWasmConfig — некорректная конфигурация wasm шага.
+	ErrInvalidWasmConfig = errors.New("invalid wasm config")
+
+	// ErrWasmRun — модуль не выполнен (загрузка из хранилища, рантайм).
+	ErrWasmRun = errors.New("wasm run failed")
+
+	// ErrPluginRun — плагин не выполнил task (запуск, падение, протокол).
+	ErrPluginRun = errors.New("plugin run failed")
 )
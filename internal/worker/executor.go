@@ -3,13 +3,20 @@ package worker
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/shaiso/Automata/internal/config"
 	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/plugin"
+	"github.com/shaiso/Automata/internal/repo"
 )
 
 // Executor — интерфейс для выполнения конкретного типа шага.
 //
-// Реализации: HTTPExecutor, DelayExecutor, TransformExecutor.
+// Реализации: HTTPExecutor, DelayExecutor, TransformExecutor, PollExecutor,
+// SQLExecutor, AMQPPublishExecutor, EmailExecutor, GRPCExecutor, ScriptExecutor,
+// WasmExecutor, PluginExecutor.
 //
 // task.Payload содержит отрендеренную конфигурацию шага.
 // ctx может содержать таймаут, установленный из StepDef.TimeoutSec.
@@ -25,6 +32,15 @@ type ExecutionResult struct {
 	// Error — сообщение об ошибке (логическая ошибка выполнения).
 	// Инфраструктурные ошибки возвращаются через error в Execute().
 	Error string
+
+	// Permanent — логическую ошибку бессмысленно повторять (например,
+	// SMTP 5xx): retry по RetryPolicy не выполняется.
+	Permanent bool
+
+	// Reschedule — если > 0, task ещё не завершён: он возвращается в очередь
+	// и выполняется повторно через указанное время (poll).
+	// Outputs при этом сохраняются как промежуточное состояние.
+	Reschedule time.Duration
 }
 
 // Registry — реестр executor'ов по типу шага.
@@ -34,16 +50,58 @@ type Registry struct {
 
 // NewRegistry создаёт реестр с зарегистрированными executor'ами по умолчанию.
 //
-// Регистрирует: http, delay, transform.
-// parallel обрабатывается оркестратором, воркер получает только leaf-tasks.
+// Регистрирует: http, delay, transform, poll.
+// parallel, approval и wait_for_signal обрабатываются оркестратором,
+// воркер получает только leaf-tasks.
 func NewRegistry() *Registry {
 	r := &Registry{executors: make(map[string]Executor)}
 	r.Register("http", &HTTPExecutor{})
 	r.Register("delay", &DelayExecutor{})
 	r.Register("transform", &TransformExecutor{})
+	r.Register("poll", NewPollExecutor(r))
+	r.Register("script", &ScriptExecutor{})
 	return r
 }
 
+// RegisterConnectionExecutors регистрирует executor'ы шагов, работающих
+// с внешними системами через именованные подключения: sql, amqp_publish, email.
+func (r *Registry) RegisterConnectionExecutors(connections *config.Connections) {
+	r.Register("sql", NewSQLExecutor(connections))
+	r.Register("amqp_publish", NewAMQPPublishExecutor(connections))
+	r.Register("email", NewEmailExecutor(connections))
+}
+
+// RegisterGRPCExecutor регистрирует executor шага grpc. descriptors может
+// быть nil — тогда схемы доступны только через server reflection.
+func (r *Registry) RegisterGRPCExecutor(descriptors *repo.DescriptorSetRepo) {
+	var store DescriptorSetStore
+	if descriptors != nil {
+		store = descriptors
+	}
+	r.Register("grpc", NewGRPCExecutor(store))
+}
+
+// RegisterWasmExecutor регистрирует executor шага wasm. modules может
+// быть nil — тогда шаги wasm завершаются ошибкой конфигурации.
+func (r *Registry) RegisterWasmExecutor(modules *repo.WasmModuleRepo) {
+	var store WasmModuleStore
+	if modules != nil {
+		store = modules
+	}
+	r.Register("wasm", NewWasmExecutor(store))
+}
+
+// RegisterPluginExecutors регистрирует executor'ы типов шагов плагинов:
+// один процесс на плагин, его stderr пишется в logger.
+func (r *Registry) RegisterPluginExecutors(manifests []*plugin.Manifest, logger *slog.Logger) {
+	for _, m := range manifests {
+		executor := NewPluginExecutor(plugin.New(m, plugin.Options{Logger: logger}))
+		for _, stepType := range m.StepTypes {
+			r.Register(stepType, executor)
+		}
+	}
+}
+
 // Register добавляет executor для типа шага.
 func (r *Registry) Register(stepType string, executor Executor) {
 	r.executors[stepType] = executor
@@ -57,3 +115,12 @@ func (r *Registry) Get(stepType string) (Executor, error) {
 	}
 	return executor, nil
 }
+
+// Close освобождает ресурсы executor'ов (пулы и соединения).
+func (r *Registry) Close() {
+	for _, executor := range r.executors {
+		if c, ok := executor.(interface{ Close() }); ok {
+			c.Close()
+		}
+	}
+}
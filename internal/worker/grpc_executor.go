@@ -0,0 +1,335 @@
+package worker
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/grpcschema"
+)
+
+const (
+	defaultGRPCTimeout = 30 * time.Second
+
+	// grpcSchemaTTL — время жизни закешированной схемы (reflection или
+	// descriptor set); после него схема загружается заново.
+	grpcSchemaTTL = time.Minute
+)
+
+// DescriptorSetStore — источник загруженных descriptor sets (repo.DescriptorSetRepo).
+type DescriptorSetStore interface {
+	GetByName(ctx context.Context, name string) (*domain.DescriptorSet, error)
+}
+
+// GRPCExecutor — executor для шага типа "grpc".
+//
+// Вызывает unary-метод без сгенерированного кода: запрос собирается
+// из JSON по схеме, полученной через server reflection или из
+// загруженного descriptor set (см. grpcschema).
+//
+// Config (из task.Payload):
+//   - target (string): адрес сервера host:port (обязательно)
+//   - service (string): полное имя сервиса, например "billing.v1.Invoices" (обязательно)
+//   - method (string): имя метода (обязательно)
+//   - request (map[string]any): тело запроса в JSON-представлении protobuf
+//   - metadata (map[string]any): metadata (заголовки) вызова
+//   - descriptor_set (string): имя загруженного descriptor set;
+//     без него схема запрашивается через server reflection
+//   - tls (bool): TLS-соединение. Default: false
+//   - tls_server_name (string): имя сервера для проверки сертификата
+//   - timeout_sec (number): deadline вызова. Default: 30
+//
+// Outputs:
+//   - body (any): ответ в JSON-представлении (имена полей как в .proto,
+//     int64 — строками, как в protojson)
+//   - status_code (int): код gRPC-статуса (0 — OK)
+//   - status (string): имя кода ("OK", "UNAVAILABLE", ...)
+//   - headers (map[string]string): header metadata ответа
+//
+// Ошибочный статус — логическая ошибка с status_code и message в outputs.
+// UNAVAILABLE, DEADLINE_EXCEEDED, RESOURCE_EXHAUSTED и ABORTED повторяются
+// по RetryPolicy, остальные коды — постоянные ошибки. RetryPolicy.OnStatus
+// с кодами gRPC задаёт список повторяемых кодов явно.
+type GRPCExecutor struct {
+	descriptors DescriptorSetStore
+
+	mu      sync.Mutex
+	conns   map[string]*grpc.ClientConn
+	schemas map[string]cachedSchema
+}
+
+// cachedSchema — схема с временем загрузки.
+type cachedSchema struct {
+	schema   *grpcschema.Schema
+	loadedAt time.Time
+}
+
+// NewGRPCExecutor создаёт GRPCExecutor. descriptors может быть nil —
+// тогда доступна только server reflection.
+func NewGRPCExecutor(descriptors DescriptorSetStore) *GRPCExecutor {
+	return &GRPCExecutor{
+		descriptors: descriptors,
+		conns:       make(map[string]*grpc.ClientConn),
+		schemas:     make(map[string]cachedSchema),
+	}
+}
+
+// grpcConfig — распарсенная конфигурация grpc шага.
+type grpcConfig struct {
+	target        string
+	service       string
+	method        string
+	request       map[string]any
+	metadata      metadata.MD
+	descriptorSet string
+	tls           bool
+	tlsServerName string
+	timeout       time.Duration
+}
+
+// retryableGRPCCodes — коды, при которых повтор вызова имеет смысл.
+var retryableGRPCCodes = map[codes.Code]bool{
+	codes.Unavailable:       true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+}
+
+// Execute выполняет unary-вызов.
+func (e *GRPCExecutor) Execute(ctx context.Context, task *domain.Task) (*ExecutionResult, error) {
+	cfg, err := parseGRPCConfig(task.Payload)
+	if err != nil {
+		return &ExecutionResult{Error: err.Error()}, nil
+	}
+
+	conn, err := e.conn(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	schema, err := e.schema(ctx, conn, cfg)
+	if err != nil {
+		// Ошибка reflection со статусом сервера (UNAVAILABLE и т.п.)
+		// классифицируется так же, как ошибка самого вызова
+		if status.Code(err) != codes.Unknown {
+			return grpcStatusResult(status.Convert(err)), nil
+		}
+		return &ExecutionResult{Error: err.Error()}, nil
+	}
+
+	md, err := schema.Method(cfg.service, cfg.method)
+	if err != nil {
+		return &ExecutionResult{Error: err.Error(), Permanent: true}, nil
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	reqJSON, err := json.Marshal(cfg.request)
+	if err != nil {
+		return &ExecutionResult{Error: fmt.Sprintf("%v: marshal request: %v", ErrInvalidGRPCConfig, err)}, nil
+	}
+	if err := (protojson.UnmarshalOptions{Resolver: schema.Types()}).Unmarshal(reqJSON, req); err != nil {
+		return &ExecutionResult{
+			Error:     fmt.Sprintf("%v: request does not match %s: %v", ErrInvalidGRPCConfig, md.Input().FullName(), err),
+			Permanent: true,
+		}, nil
+	}
+
+	resp := dynamicpb.NewMessage(md.Output())
+	var header metadata.MD
+	fullMethod := fmt.Sprintf("/%s/%s", cfg.service, cfg.method)
+	callCtx := metadata.NewOutgoingContext(ctx, cfg.metadata)
+	if err := conn.Invoke(callCtx, fullMethod, req, resp, grpc.Header(&header)); err != nil {
+		return grpcStatusResult(status.Convert(err)), nil
+	}
+
+	respJSON, err := (protojson.MarshalOptions{
+		UseProtoNames:   true,
+		EmitUnpopulated: true,
+		Resolver:        schema.Types(),
+	}).Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: marshal response: %v", ErrGRPCCall, err)
+	}
+	var body any
+	if err := json.Unmarshal(respJSON, &body); err != nil {
+		return nil, fmt.Errorf("%w: decode response: %v", ErrGRPCCall, err)
+	}
+
+	return &ExecutionResult{
+		Outputs: map[string]any{
+			"body":        body,
+			"status_code": int(codes.OK),
+			"status":      grpcCodeName(codes.OK),
+			"headers":     flattenMetadata(header),
+		},
+	}, nil
+}
+
+// grpcStatusResult преобразует ошибочный статус в логическую ошибку шага.
+func grpcStatusResult(st *status.Status) *ExecutionResult {
+	return &ExecutionResult{
+		Outputs: map[string]any{
+			"status_code": int(st.Code()),
+			"status":      grpcCodeName(st.Code()),
+			"message":     st.Message(),
+		},
+		Error:     fmt.Sprintf("grpc %s: %s", grpcCodeName(st.Code()), st.Message()),
+		Permanent: !retryableGRPCCodes[st.Code()],
+	}
+}
+
+// grpcCodeName возвращает имя кода в формате спецификации gRPC (UNAVAILABLE).
+func grpcCodeName(code codes.Code) string {
+	var b strings.Builder
+	prevLower := false
+	for _, r := range code.String() {
+		isUpper := r >= 'A' && r <= 'Z'
+		if isUpper && prevLower {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+		prevLower = !isUpper
+	}
+	return strings.ToUpper(b.String())
+}
+
+// schema возвращает схему сервиса из кеша, descriptor set или reflection.
+func (e *GRPCExecutor) schema(ctx context.Context, conn *grpc.ClientConn, cfg *grpcConfig) (*grpcschema.Schema, error) {
+	key := "reflection:" + cfg.target + "/" + cfg.service
+	if cfg.descriptorSet != "" {
+		key = "descriptor_set:" + cfg.descriptorSet
+	}
+
+	e.mu.Lock()
+	cached, ok := e.schemas[key]
+	e.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < grpcSchemaTTL {
+		return cached.schema, nil
+	}
+
+	var schema *grpcschema.Schema
+	if cfg.descriptorSet != "" {
+		if e.descriptors == nil {
+			return nil, fmt.Errorf("%w: descriptor sets are not configured", ErrInvalidGRPCConfig)
+		}
+		set, err := e.descriptors.GetByName(ctx, cfg.descriptorSet)
+		if err != nil {
+			return nil, fmt.Errorf("descriptor set %s: %w", cfg.descriptorSet, err)
+		}
+		if schema, err = grpcschema.Parse(set.Data); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if schema, err = grpcschema.FromReflection(ctx, conn, cfg.service); err != nil {
+			return nil, err
+		}
+	}
+
+	e.mu.Lock()
+	e.schemas[key] = cachedSchema{schema: schema, loadedAt: time.Now()}
+	e.mu.Unlock()
+	return schema, nil
+}
+
+// conn возвращает клиентское соединение с target (создаётся лениво
+// и переиспользуется между вызовами).
+func (e *GRPCExecutor) conn(cfg *grpcConfig) (*grpc.ClientConn, error) {
+	key := fmt.Sprintf("%s|%t|%s", cfg.target, cfg.tls, cfg.tlsServerName)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if conn, ok := e.conns[key]; ok {
+		return conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if cfg.tls {
+		creds = credentials.NewTLS(&tls.Config{ServerName: cfg.tlsServerName})
+	}
+	conn, err := grpc.NewClient(cfg.target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("%w: connect %s: %v", ErrGRPCCall, cfg.target, err)
+	}
+	e.conns[key] = conn
+	return conn, nil
+}
+
+// Close закрывает клиентские соединения.
+func (e *GRPCExecutor) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, conn := range e.conns {
+		conn.Close()
+		delete(e.conns, key)
+	}
+}
+
+// parseGRPCConfig извлекает конфигурацию grpc шага из payload.
+func parseGRPCConfig(payload map[string]any) (*grpcConfig, error) {
+	cfg := &grpcConfig{
+		target:        getString(payload, "target", ""),
+		service:       getString(payload, "service", ""),
+		method:        getString(payload, "method", ""),
+		descriptorSet: getString(payload, "descriptor_set", ""),
+		tls:           getBool(payload, "tls", false),
+		tlsServerName: getString(payload, "tls_server_name", ""),
+		timeout:       getSeconds(payload, "timeout_sec", defaultGRPCTimeout),
+		request:       map[string]any{},
+		metadata:      metadata.MD{},
+	}
+
+	for field, value := range map[string]string{"target": cfg.target, "service": cfg.service, "method": cfg.method} {
+		if value == "" {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidGRPCConfig, field)
+		}
+	}
+
+	switch request := payload["request"].(type) {
+	case nil:
+	case map[string]any:
+		cfg.request = request
+	default:
+		return nil, fmt.Errorf("%w: request must be an object", ErrInvalidGRPCConfig)
+	}
+
+	switch md := payload["metadata"].(type) {
+	case nil:
+	case map[string]any:
+		for k, v := range md {
+			cfg.metadata.Append(k, fmt.Sprint(v))
+		}
+	default:
+		return nil, fmt.Errorf("%w: metadata must be an object", ErrInvalidGRPCConfig)
+	}
+
+	return cfg, nil
+}
+
+// flattenMetadata преобразует metadata в map (несколько значений — через запятую).
+func flattenMetadata(md metadata.MD) map[string]string {
+	result := make(map[string]string, len(md))
+	for k, v := range md {
+		result[k] = strings.Join(v, ", ")
+	}
+	return result
+}
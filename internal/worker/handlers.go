@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
 	"github.com/shaiso/Automata/internal/mq"
 	"github.com/shaiso/Automata/internal/repo"
 )
@@ -29,7 +30,7 @@ func (w *Worker) handleTaskReady(ctx context.Context, delivery *mq.Delivery) err
 	// Обрабатываем task
 	if err := w.processTask(ctx, payload.TaskID); err != nil {
 		// Ожидаемые ситуации — не возвращаем ошибку (ack)
-		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskNotQueued) {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskNotQueued) || errors.Is(err, ErrTaskNotDue) {
 			w.logger.Debug("task not processed", "task_id", payload.TaskID, "reason", err)
 			return nil
 		}
@@ -55,11 +56,18 @@ func (w *Worker) processTask(ctx context.Context, taskID uuid.UUID) error {
 	if task.Status != domain.TaskStatusQueued {
 		return ErrTaskNotQueued
 	}
+	if !task.IsDue(time.Now()) {
+		return ErrTaskNotDue
+	}
 
-	// 3. Помечаем как running
+	// 3. Забираем task: QUEUED → RUNNING атомарно, чтобы событие,
+	// таймер и polling не выполнили её дважды
 	task.MarkRunning()
-	if err := w.taskRepo.Update(ctx, task); err != nil {
-		return fmt.Errorf("update task to running: %w", err)
+	if err := w.taskRepo.Claim(ctx, task); err != nil {
+		if errors.Is(err, repo.ErrInvalidState) {
+			return ErrTaskNotQueued
+		}
+		return fmt.Errorf("claim task: %w", err)
 	}
 
 	w.logger.Info("task started",
@@ -71,12 +79,35 @@ func (w *Worker) processTask(ctx context.Context, taskID uuid.UUID) error {
 	)
 
 	// 4. Загружаем RetryPolicy
-	retryPolicy := w.getRetryPolicy(ctx, task)
+	// poll сам управляет повторами итераций, retry к нему не применяется
+	var retryPolicy *domain.RetryPolicy
+	if task.Type != "poll" {
+		retryPolicy = w.getRetryPolicy(ctx, task)
+	}
 
 	// 5. Выполняем с retry
 	result, execErr := w.executeWithRetry(ctx, task, retryPolicy)
 
 	// 6. Обрабатываем результат
+	if execErr == nil && result.Error == "" && result.Reschedule > 0 {
+		// Шаг не завершён — возвращаем task в очередь до следующей итерации
+		nextAt := time.Now().Add(result.Reschedule)
+		task.Reschedule(result.Outputs, nextAt)
+		if err := w.taskRepo.Update(ctx, task); err != nil {
+			return fmt.Errorf("update task to queued: %w", err)
+		}
+
+		w.logger.Debug("task rescheduled",
+			"task_id", task.ID,
+			"run_id", task.RunID,
+			"step_id", task.StepID,
+			"next_attempt_at", nextAt,
+		)
+
+		w.scheduleTask(ctx, task.ID, result.Reschedule)
+		return nil
+	}
+
 	if execErr == nil && result.Error == "" {
 		// Успех
 		task.MarkSucceeded(result.Outputs)
@@ -102,6 +133,10 @@ func (w *Worker) processTask(ctx context.Context, taskID uuid.UUID) error {
 		errMsg = result.Error
 	}
 
+	// Сохраняем outputs неуспешного выполнения (status_code, история poll)
+	if result != nil && result.Outputs != nil {
+		task.Outputs = result.Outputs
+	}
 	task.MarkFailed(errMsg)
 	if err := w.taskRepo.Update(ctx, task); err != nil {
 		return fmt.Errorf("update task to failed: %w", err)
@@ -118,6 +153,43 @@ func (w *Worker) processTask(ctx context.Context, taskID uuid.UUID) error {
 	return w.publishCompletion(ctx, task, errMsg)
 }
 
+// scheduleTask запускает обработку отложенной task через delay.
+//
+// Ожидание не занимает слот воркера; обработка — занимает один из
+// слотов отложенных tasks (defaultPrefetch), как и tasks из очереди.
+// Горутина учитывается в w.wg и завершается при остановке воркера;
+// task тогда подхватит polling: ListQueued учитывает next_attempt_at.
+// Вызывается только из горутин, уже учтённых в w.wg.
+func (w *Worker) scheduleTask(ctx context.Context, taskID uuid.UUID, delay time.Duration) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case w.slots <- struct{}{}:
+		}
+		defer func() { <-w.slots }()
+
+		if err := w.processTask(ctx, taskID); err != nil &&
+			!errors.Is(err, ErrTaskNotFound) && !errors.Is(err, ErrTaskNotQueued) && !errors.Is(err, ErrTaskNotDue) {
+			w.logger.Error("failed to process rescheduled task",
+				"task_id", taskID,
+				"error", err,
+			)
+		}
+	}()
+}
+
 // publishCompletion публикует событие task.completed.
 func (w *Worker) publishCompletion(ctx context.Context, task *domain.Task, errMsg string) error {
 	if w.publisher == nil {
@@ -183,7 +255,7 @@ func (w *Worker) executeWithRetry(ctx context.Context, task *domain.Task, policy
 		}
 
 		// Считаем backoff
-		delay := calculateBackoff(task.Attempt, policy)
+		delay := engine.RetryBackoff(task.Attempt, policy)
 
 		w.logger.Debug("retrying task",
 			"task_id", task.ID,
@@ -221,7 +293,7 @@ func (w *Worker) shouldRetry(result *ExecutionResult, execErr error, policy *dom
 		return false
 	}
 
-	// Для HTTP: проверяем OnStatus
+	// Для HTTP (и кодов gRPC): проверяем OnStatus
 	if result != nil && result.Outputs != nil && len(policy.OnStatus) > 0 {
 		if statusCode, ok := result.Outputs["status_code"]; ok {
 			if code, ok := statusCode.(int); ok {
@@ -232,6 +304,11 @@ func (w *Worker) shouldRetry(result *ExecutionResult, execErr error, policy *dom
 		return false
 	}
 
+	// Постоянная ошибка (например, SMTP 5xx) — повтор не поможет
+	if result != nil && result.Permanent {
+		return false
+	}
+
 	// Логическая ошибка без OnStatus — retry
 	return true
 }
@@ -246,46 +323,6 @@ func shouldRetryHTTPStatus(statusCode int, onStatus []int) bool {
 	return false
 }
 
-// calculateBackoff вычисляет задержку перед retry.
-func calculateBackoff(attempt int, policy *domain.RetryPolicy) time.Duration {
-	if policy == nil {
-		return time.Second
-	}
-
-	initialDelay := time.Duration(policy.InitialDelayMs) * time.Millisecond
-	if initialDelay <= 0 {
-		initialDelay = time.Second
-	}
-
-	maxDelay := time.Duration(policy.MaxDelayMs) * time.Millisecond
-	if maxDelay <= 0 {
-		maxDelay = 30 * time.Second
-	}
-
-	var delay time.Duration
-	switch policy.Backoff {
-	case "exponential":
-		// delay = initialDelay * 2^(attempt-1)
-		delay = initialDelay
-		for i := 1; i < attempt; i++ {
-			delay *= 2
-			if delay > maxDelay {
-				delay = maxDelay
-				break
-			}
-		}
-	default:
-		// "fixed" или неизвестный — используем initialDelay
-		delay = initialDelay
-	}
-
-	if delay > maxDelay {
-		delay = maxDelay
-	}
-
-	return delay
-}
-
 // getRetryPolicy загружает RetryPolicy для task из FlowVersion.
 func (w *Worker) getRetryPolicy(ctx context.Context, task *domain.Task) *domain.RetryPolicy {
 	// Загружаем run для FlowID и Version
@@ -302,41 +339,5 @@ func (w *Worker) getRetryPolicy(ctx context.Context, task *domain.Task) *domain.
 		return nil
 	}
 
-	// Ищем StepDef по StepID
-	stepDef := findStepDef(version.Spec.Steps, task.StepID)
-	if stepDef != nil && stepDef.Retry != nil {
-		return stepDef.Retry
-	}
-
-	// Fallback на defaults
-	if version.Spec.Defaults != nil && version.Spec.Defaults.Retry != nil {
-		return version.Spec.Defaults.Retry
-	}
-
-	return nil
-}
-
-// findStepDef ищет StepDef по ID, включая шаги внутри parallel-веток.
-func findStepDef(steps []domain.StepDef, stepID string) *domain.StepDef {
-	for i := range steps {
-		step := &steps[i]
-		if step.ID == stepID {
-			return step
-		}
-
-		// Для parallel — ищем в ветках (с учётом prefix: parallel_id.branch_id.step_id)
-		if step.Type == "parallel" {
-			for _, branch := range step.Branches {
-				for j := range branch.Steps {
-					branchStep := &branch.Steps[j]
-					// Проверяем как прямой ID, так и prefixed
-					prefixedID := fmt.Sprintf("%s.%s.%s", step.ID, branch.ID, branchStep.ID)
-					if branchStep.ID == stepID || prefixedID == stepID {
-						return branchStep
-					}
-				}
-			}
-		}
-	}
-	return nil
+	return engine.StepRetryPolicy(&version.Spec, task.StepID)
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
 )
 
 const defaultHTTPTimeout = 30 * time.Second
@@ -22,13 +23,18 @@ const defaultHTTPTimeout = 30 * time.Second
 //   - method (string): HTTP-метод (GET, POST, PUT, DELETE). Default: GET
 //   - url (string): URL для запроса (обязательно)
 //   - headers (map[string]any): HTTP-заголовки
-//   - body (any): тело запроса (сериализуется в JSON)
+//   - body (any): тело запроса (сериализуется в body_format)
+//   - body_format (string): json, xml (корень — xml_root), csv (опции — csv)
+//     или text. Default: json
+//   - response_format (string): auto (по Content-Type), json, xml, csv
+//     или text. Default: auto
 //   - timeout_sec (number): таймаут запроса в секундах. Default: 30
 //
 // Outputs:
 //   - status_code (int): HTTP-код ответа
 //   - headers (map[string]string): заголовки ответа
-//   - body (any): тело ответа (JSON или строка)
+//   - body (any): тело ответа (JSON, XML, CSV — см. engine.DecodeBody —
+//     или строка)
 type HTTPExecutor struct{}
 
 // Execute выполняет HTTP-запрос.
@@ -40,6 +46,11 @@ func (e *HTTPExecutor) Execute(ctx context.Context, task *domain.Task) (*Executi
 		return nil, fmt.Errorf("%w: url is required", ErrHTTPRequest)
 	}
 
+	formats, err := engine.ParseBodyOptions(task.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHTTPRequest, err)
+	}
+
 	timeout := getTimeout(task.Payload)
 
 	// Таймаут
@@ -48,12 +59,14 @@ func (e *HTTPExecutor) Execute(ctx context.Context, task *domain.Task) (*Executi
 
 	// Подготавливаем body
 	var bodyReader io.Reader
+	var contentType string
 	if body, ok := task.Payload["body"]; ok && body != nil {
-		bodyBytes, err := json.Marshal(body)
+		bodyBytes, ct, err := engine.EncodeBody(body, formats)
 		if err != nil {
 			return nil, fmt.Errorf("%w: marshal body: %v", ErrHTTPRequest, err)
 		}
 		bodyReader = bytes.NewReader(bodyBytes)
+		contentType = ct
 	}
 
 	// Создаём запрос
@@ -67,7 +80,7 @@ func (e *HTTPExecutor) Execute(ctx context.Context, task *domain.Task) (*Executi
 
 	// Content-Type по умолчанию для запросов с body
 	if bodyReader != nil && req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", contentType)
 	}
 
 	// Выполняем запрос
@@ -86,7 +99,7 @@ func (e *HTTPExecutor) Execute(ctx context.Context, task *domain.Task) (*Executi
 
 	// Формируем outputs
 	includeHeaders := getBool(task.Payload, "include_headers", false)
-	outputs := buildOutputs(resp, respBody, includeHeaders)
+	outputs := buildOutputs(resp, respBody, formats, includeHeaders)
 
 	// HTTP >= 400 — логическая ошибка (outputs сохраняются для retry по status_code)
 	if resp.StatusCode >= 400 {
@@ -101,10 +114,20 @@ func (e *HTTPExecutor) Execute(ctx context.Context, task *domain.Task) (*Executi
 }
 
 // buildOutputs формирует outputs из HTTP-ответа.
-func buildOutputs(resp *http.Response, body []byte, includeHeaders bool) map[string]any {
-	// Парсим body: пробуем JSON, иначе строка
+func buildOutputs(resp *http.Response, body []byte, formats engine.BodyOptions, includeHeaders bool) map[string]any {
+	// Парсим body по response_format или Content-Type (XML, CSV);
+	// для прочих ответов пробуем JSON, иначе строка
+	contentType := resp.Header.Get("Content-Type")
+	detected := engine.DetectBodyFormat(contentType)
+
 	var parsedBody any
-	if err := json.Unmarshal(body, &parsedBody); err != nil {
+	var err error
+	if formats.ResponseFormat == engine.BodyFormatAuto && (detected == "" || detected == engine.BodyFormatText) {
+		err = json.Unmarshal(body, &parsedBody)
+	} else {
+		parsedBody, err = engine.DecodeBody(body, contentType, formats)
+	}
+	if err != nil {
 		parsedBody = string(body)
 	}
 
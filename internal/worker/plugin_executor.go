@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/plugin"
+)
+
+// PluginExecutor — executor для типа шага, реализованного плагином.
+//
+// Передаёт task процессу плагина по протоколу JSON поверх stdio
+// (см. plugin). Один процесс обслуживает все типы шагов плагина.
+//
+// Config (из task.Payload) передаётся плагину как есть. Результат:
+//   - outputs и error плагина — outputs и логическая ошибка шага,
+//     permanent отключает повторы;
+//   - падение, зависание, нарушение протокола или error ответа —
+//     инфраструктурная ошибка (повтор по RetryPolicy).
+type PluginExecutor struct {
+	plugin *plugin.Plugin
+}
+
+// NewPluginExecutor создаёт PluginExecutor для плагина p.
+func NewPluginExecutor(p *plugin.Plugin) *PluginExecutor {
+	return &PluginExecutor{plugin: p}
+}
+
+// Execute выполняет task в процессе плагина.
+func (e *PluginExecutor) Execute(ctx context.Context, task *domain.Task) (*ExecutionResult, error) {
+	result, err := e.plugin.Execute(ctx, &plugin.ExecuteRequest{
+		TaskID:  task.ID.String(),
+		RunID:   task.RunID.String(),
+		StepID:  task.StepID,
+		Type:    task.Type,
+		Attempt: task.Attempt,
+		Config:  task.Payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPluginRun, err)
+	}
+
+	return &ExecutionResult{
+		Outputs:   result.Outputs,
+		Error:     result.Error,
+		Permanent: result.Permanent,
+	}, nil
+}
+
+// Close останавливает процесс плагина. Плагин общий для его типов шагов,
+// повторный вызов безопасен.
+func (e *PluginExecutor) Close() {
+	e.plugin.Close()
+}
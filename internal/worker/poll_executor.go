@@ -0,0 +1,244 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+)
+
+// Значения по умолчанию для poll.
+const (
+	defaultPollStepInterval    = 5 * time.Second
+	defaultPollStepMaxInterval = 5 * time.Minute
+	defaultPollStepMaxDuration = time.Hour
+
+	// maxPollHistory — сколько последних итераций хранится в outputs.
+	maxPollHistory = 100
+)
+
+// PollExecutor — executor для шага типа "poll".
+//
+// Повторяет вложенное действие (по умолчанию http), пока условие until
+// над его outputs не станет истинным. За один вызов Execute выполняется
+// одна итерация: если условие не выполнено, возвращается результат
+// с Reschedule — task возвращается в очередь и не занимает слот воркера
+// до следующей итерации.
+//
+// Config (из task.Payload):
+//   - action (string): тип вложенного действия (default: "http")
+//   - request (object): config вложенного действия
+//   - until (string): условие в синтаксисе condition, например
+//     eq .Outputs.body.status "ready"
+//   - interval_sec (number): интервал между итерациями (default: 5)
+//   - backoff (string): "fixed" или "exponential" (default: "fixed")
+//   - max_interval_sec (number): максимальный интервал (default: 300)
+//   - max_attempts (number): максимальное количество итераций (default: без ограничения)
+//   - max_duration_sec (number): максимальная длительность ожидания (default: 3600)
+//
+// Ошибка вложенного действия не прерывает poll — итерация записывается
+// с ошибкой, и ожидание продолжается до исчерпания лимитов.
+//
+// Outputs: outputs последней итерации, а также:
+//   - attempts (number): количество выполненных итераций
+//   - iterations (array): история итераций (iteration, at, done, error, status_code)
+//   - started_at (string): время первой итерации (RFC3339)
+type PollExecutor struct {
+	registry *Registry
+}
+
+// NewPollExecutor создаёт PollExecutor, который берёт вложенные действия из registry.
+func NewPollExecutor(registry *Registry) *PollExecutor {
+	return &PollExecutor{registry: registry}
+}
+
+// pollConfig — разобранная конфигурация poll.
+type pollConfig struct {
+	action      string
+	request     map[string]any
+	until       string
+	interval    time.Duration
+	backoff     string
+	maxInterval time.Duration
+	maxAttempts int
+	maxDuration time.Duration
+}
+
+// Execute выполняет одну итерацию poll.
+func (e *PollExecutor) Execute(ctx context.Context, task *domain.Task) (*ExecutionResult, error) {
+	cfg, err := parsePollConfig(task.Payload)
+	if err != nil {
+		return &ExecutionResult{Error: err.Error()}, nil
+	}
+
+	executor, err := e.registry.Get(cfg.action)
+	if err != nil {
+		return &ExecutionResult{Error: err.Error()}, nil
+	}
+
+	now := time.Now()
+
+	// Состояние предыдущих итераций хранится в outputs task
+	history, _ := task.Outputs["iterations"].([]any)
+	startedAt := now
+	if s, ok := task.Outputs["started_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			startedAt = t
+		}
+	}
+	iteration := 1
+	if n, ok := toInt(task.Outputs["attempts"]); ok {
+		iteration = n + 1
+	}
+
+	// Выполняем вложенное действие
+	inner := *task
+	inner.Type = cfg.action
+	inner.Payload = cfg.request
+	inner.Outputs = nil
+
+	result, execErr := executor.Execute(ctx, &inner)
+
+	record := map[string]any{
+		"iteration": iteration,
+		"at":        now.UTC().Format(time.RFC3339Nano),
+		"done":      false,
+	}
+
+	var outputs map[string]any
+	switch {
+	case execErr != nil:
+		record["error"] = execErr.Error()
+	case result != nil && result.Error != "":
+		record["error"] = result.Error
+		outputs = result.Outputs
+	case result != nil:
+		outputs = result.Outputs
+	}
+	if code, ok := outputs["status_code"]; ok {
+		record["status_code"] = code
+	}
+
+	// Вычисляем until только для успешной итерации
+	done := false
+	if record["error"] == nil {
+		condCtx := engine.NewContext(nil)
+		condCtx.Outputs = outputs
+		done, err = engine.RenderCondition(cfg.until, condCtx)
+		if err != nil {
+			return &ExecutionResult{Error: fmt.Sprintf("evaluate until: %v", err)}, nil
+		}
+	}
+	record["done"] = done
+
+	history = append(history, record)
+	if len(history) > maxPollHistory {
+		history = history[len(history)-maxPollHistory:]
+	}
+
+	state := make(map[string]any, len(outputs)+3)
+	for k, v := range outputs {
+		state[k] = v
+	}
+	state["attempts"] = iteration
+	state["iterations"] = history
+	state["started_at"] = startedAt.UTC().Format(time.RFC3339Nano)
+
+	if done {
+		return &ExecutionResult{Outputs: state}, nil
+	}
+
+	// Проверяем лимиты
+	if cfg.maxAttempts > 0 && iteration >= cfg.maxAttempts {
+		return &ExecutionResult{
+			Outputs: state,
+			Error:   fmt.Sprintf("poll condition not met after %d attempts", iteration),
+		}, nil
+	}
+
+	delay := pollDelay(iteration, cfg)
+	if now.Add(delay).Sub(startedAt) > cfg.maxDuration {
+		return &ExecutionResult{
+			Outputs: state,
+			Error:   fmt.Sprintf("poll condition not met within %s", cfg.maxDuration),
+		}, nil
+	}
+
+	return &ExecutionResult{Outputs: state, Reschedule: delay}, nil
+}
+
+// parsePollConfig извлекает конфигурацию poll из payload.
+func parsePollConfig(payload map[string]any) (*pollConfig, error) {
+	cfg := &pollConfig{
+		action:      getString(payload, "action", "http"),
+		until:       getString(payload, "until", ""),
+		interval:    getSeconds(payload, "interval_sec", defaultPollStepInterval),
+		backoff:     getString(payload, "backoff", "fixed"),
+		maxInterval: getSeconds(payload, "max_interval_sec", defaultPollStepMaxInterval),
+		maxDuration: getSeconds(payload, "max_duration_sec", defaultPollStepMaxDuration),
+	}
+
+	if cfg.until == "" {
+		return nil, fmt.Errorf("%w: until is required", ErrInvalidPollConfig)
+	}
+	if cfg.action == "poll" {
+		return nil, fmt.Errorf("%w: action cannot be poll", ErrInvalidPollConfig)
+	}
+
+	request, ok := payload["request"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: request is required", ErrInvalidPollConfig)
+	}
+	cfg.request = request
+
+	if n, ok := toInt(payload["max_attempts"]); ok {
+		cfg.maxAttempts = n
+	}
+
+	return cfg, nil
+}
+
+// pollDelay вычисляет задержку перед следующей итерацией.
+func pollDelay(iteration int, cfg *pollConfig) time.Duration {
+	delay := cfg.interval
+	if cfg.backoff == "exponential" {
+		for i := 1; i < iteration; i++ {
+			delay *= 2
+			if delay >= cfg.maxInterval {
+				break
+			}
+		}
+	}
+	if delay > cfg.maxInterval {
+		delay = cfg.maxInterval
+	}
+	return delay
+}
+
+// getSeconds извлекает длительность в секундах из map с default значением.
+func getSeconds(m map[string]any, key string, defaultVal time.Duration) time.Duration {
+	switch v := m[key].(type) {
+	case float64:
+		if v > 0 {
+			return time.Duration(v * float64(time.Second))
+		}
+	case int:
+		if v > 0 {
+			return time.Duration(v) * time.Second
+		}
+	}
+	return defaultVal
+}
+
+// toInt приводит число из JSON (float64) или int к int.
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	}
+	return 0, false
+}
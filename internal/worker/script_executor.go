@@ -0,0 +1,327 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime/metrics"
+	"sort"
+	"time"
+
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+)
+
+// scriptHeapCheckInterval — период проверки роста heap во время выполнения скрипта.
+const scriptHeapCheckInterval = 10 * time.Millisecond
+
+// scriptHeapLimitFactor — во сколько раз рост heap процесса может
+// превысить max_memory_mb, прежде чем скрипт будет остановлен.
+const scriptHeapLimitFactor = 2
+
+// heapObjectsMetric — байты, занятые объектами heap (живыми и ещё не собранными).
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// ScriptExecutor — executor для шага типа "script".
+//
+// Выполняет Starlark-скрипт (диалект Python) во встроенном интерпретаторе.
+// Скрипт не имеет доступа к файловой системе, сети и окружению: load()
+// недоступен, из модулей предопределены только json и math.
+//
+// Config (из task.Payload):
+//   - source (string): текст скрипта с функцией main() (обязательно)
+//   - timeout_sec (number): ограничение времени. Default: 5, максимум 60
+//   - max_steps (number): ограничение шагов интерпретатора. Default: 10 000 000
+//   - max_memory_mb (number): ограничение памяти данных скрипта. Default: 64, максимум 512
+//   - globals (map[string]any): inputs и steps run — заполняет Orchestrator
+//
+// Глобальные переменные inputs и steps доступны только для чтения
+// (заморожены). Результат main() становится outputs: словарь — как есть,
+// None — пустые outputs, другое значение — {"result": значение}.
+//
+// Память контролируется внутри интерпретатора: периодически (по числу
+// шагов) оценивается размер значений, достижимых из переменных скрипта
+// (scriptMemoryMeter), а также размер результата main(). Это и есть
+// лимит max_memory_mb.
+//
+// Ошибка скрипта и превышение лимитов времени, шагов и памяти —
+// постоянные ошибки (ExecutionResult.Permanent): повтор даст тот же
+// результат.
+//
+// Дополнительно, на best-effort основе, скрипт останавливается, если heap
+// процесса вырос больше чем в scriptHeapLimitFactor раз от max_memory_mb:
+// это ловит промежуточные значения, которые оценка не видит. Рост heap
+// общий для всех tasks воркера, поэтому такая ошибка не постоянная
+// и повторяется по RetryPolicy.
+type ScriptExecutor struct{}
+
+// Execute выполняет скрипт и возвращает результат main().
+func (e *ScriptExecutor) Execute(ctx context.Context, task *domain.Task) (*ExecutionResult, error) {
+	cfg, err := engine.ParseScriptConfig(task.Payload)
+	if err != nil {
+		return &ExecutionResult{Error: err.Error(), Permanent: true}, nil
+	}
+
+	predeclared := starlark.StringDict{
+		"json": starlarkjson.Module,
+		"math": starlarkmath.Module,
+	}
+	globals, _ := task.Payload[engine.StepGlobalsKey].(map[string]any)
+	for _, name := range []string{"inputs", "steps", "outputs"} {
+		value, ok := globals[name]
+		if !ok && name == "outputs" {
+			continue
+		}
+		sv, err := toStarlark(value)
+		if err != nil {
+			return &ExecutionResult{Error: fmt.Sprintf("%v: %s: %v", engine.ErrInvalidScriptConfig, name, err)}, nil
+		}
+		sv.Freeze()
+		predeclared[name] = sv
+	}
+
+	meter := newScriptMemoryMeter(predeclared)
+	memoryExceeded := false
+	thread := &starlark.Thread{
+		Name:  task.StepID,
+		Print: func(*starlark.Thread, string) {},
+	}
+	// Шаги интерпретатора отмеряются порциями: на границе порции
+	// проверяется лимит шагов и оценивается память скрипта
+	thread.OnMaxSteps = func(thread *starlark.Thread) {
+		steps := thread.ExecutionSteps()
+		if steps >= cfg.MaxSteps {
+			thread.Cancel("too many steps")
+			return
+		}
+		size, values := meter.measure(thread)
+		if size > cfg.MaxMemory {
+			memoryExceeded = true
+			thread.Cancel("memory limit exceeded")
+			return
+		}
+		thread.SetMaxExecutionSteps(min(cfg.MaxSteps, steps+uint64(max(scriptMemoryCheckSteps, values*scriptMemoryCheckRatio))))
+	}
+	thread.SetMaxExecutionSteps(min(cfg.MaxSteps, scriptMemoryCheckSteps))
+
+	runCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	heapExceeded := watchScriptHeap(runCtx, thread, cfg.MaxMemory*scriptHeapLimitFactor)
+	go func() {
+		<-runCtx.Done()
+		thread.Cancel(runCtx.Err().Error())
+	}()
+
+	value, err := runScript(thread, cfg.Source, predeclared)
+	cancel()
+	heapLimitHit := <-heapExceeded
+	if err == nil && meter.measureValue(value) > cfg.MaxMemory {
+		memoryExceeded = true
+	}
+	if err != nil || memoryExceeded {
+		// Отмена task (остановка воркера) — не ошибка скрипта
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		switch {
+		case memoryExceeded:
+			return &ExecutionResult{
+				Error:     fmt.Sprintf("script: memory limit of %d MB exceeded", cfg.MaxMemory>>20),
+				Permanent: true,
+			}, nil
+		case heapLimitHit:
+			return &ExecutionResult{
+				Error: fmt.Sprintf("script: worker heap grew by more than %d MB during execution",
+					cfg.MaxMemory*scriptHeapLimitFactor>>20),
+			}, nil
+		}
+		return &ExecutionResult{Error: scriptError(err), Permanent: true}, nil
+	}
+
+	result, err := fromStarlark(value)
+	if err != nil {
+		return &ExecutionResult{Error: fmt.Sprintf("script: %s() result: %v", engine.ScriptEntrypoint, err), Permanent: true}, nil
+	}
+
+	outputs := map[string]any{}
+	switch r := result.(type) {
+	case nil:
+	case map[string]any:
+		outputs = r
+	default:
+		outputs["result"] = r
+	}
+
+	return &ExecutionResult{Outputs: outputs}, nil
+}
+
+// runScript исполняет файл скрипта и вызывает main().
+func runScript(thread *starlark.Thread, source string, predeclared starlark.StringDict) (starlark.Value, error) {
+	module, err := starlark.ExecFileOptions(engine.ScriptFileOptions(), thread, "script.star", source, predeclared)
+	if err != nil {
+		return nil, err
+	}
+	main, ok := module[engine.ScriptEntrypoint].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%w: script must define %s()", engine.ErrInvalidScriptConfig, engine.ScriptEntrypoint)
+	}
+	return starlark.Call(thread, main, nil, nil)
+}
+
+// scriptError форматирует ошибку скрипта с трассировкой вызовов.
+func scriptError(err error) string {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return "script: " + evalErr.Backtrace()
+	}
+	return "script: " + err.Error()
+}
+
+// watchScriptHeap отменяет выполнение скрипта, если heap процесса
+// вырос больше чем на limit байт. Канал получает одно значение после
+// завершения ctx: был ли превышен лимит.
+//
+// Проверка best-effort: heap общий для всех горутин процесса, поэтому
+// рост может быть вызван соседними tasks.
+func watchScriptHeap(ctx context.Context, thread *starlark.Thread, limit uint64) <-chan bool {
+	exceeded := make(chan bool, 1)
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+	baseline := sample[0].Value.Uint64()
+
+	go func() {
+		ticker := time.NewTicker(scriptHeapCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				exceeded <- false
+				return
+			case <-ticker.C:
+				metrics.Read(sample)
+				if current := sample[0].Value.Uint64(); current > baseline && current-baseline > limit {
+					thread.Cancel("worker heap limit exceeded")
+					exceeded <- true
+					return
+				}
+			}
+		}
+	}()
+
+	return exceeded
+}
+
+// toStarlark преобразует значение из JSON в значение Starlark.
+// Целые числа (в JSON — float64) становятся int.
+func toStarlark(v any) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case int:
+		return starlark.MakeInt(val), nil
+	case int64:
+		return starlark.MakeInt64(val), nil
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1<<53 {
+			return starlark.MakeInt64(int64(val)), nil
+		}
+		return starlark.Float(val), nil
+	case []any:
+		items := make([]starlark.Value, len(val))
+		for i, item := range val {
+			sv, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = sv
+		}
+		return starlark.NewList(items), nil
+	case map[string]any:
+		// Порядок ключей детерминирован: итерация по dict в скрипте
+		// не зависит от порядка map
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		dict := starlark.NewDict(len(val))
+		for _, k := range keys {
+			sv, err := toStarlark(val[k])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(k), sv); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// fromStarlark преобразует результат скрипта в значение для outputs.
+func fromStarlark(v starlark.Value) (any, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.String:
+		return string(val), nil
+	case starlark.Int:
+		if n, ok := val.Int64(); ok {
+			return n, nil
+		}
+		return val.String(), nil
+	case starlark.Float:
+		return float64(val), nil
+	case *starlark.List:
+		items := make([]any, val.Len())
+		for i := range items {
+			item, err := fromStarlark(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case starlark.Tuple:
+		items := make([]any, len(val))
+		for i, elem := range val {
+			item, err := fromStarlark(elem)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case *starlark.Dict:
+		result := make(map[string]any, val.Len())
+		for _, kv := range val.Items() {
+			key, ok := kv[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict key %s is not a string", kv[0])
+			}
+			item, err := fromStarlark(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			result[string(key)] = item
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %s", v.Type())
+	}
+}
@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"go.starlark.net/starlark"
+)
+
+// scriptMemoryCheckSteps — минимальный интервал (в шагах интерпретатора)
+// между оценками памяти скрипта.
+const scriptMemoryCheckSteps = 1000
+
+// scriptMemoryCheckRatio — во сколько раз интервал между оценками
+// больше числа обойдённых значений: обход значения дороже шага,
+// и без запаса оценка памяти стоила бы больше самого выполнения.
+const scriptMemoryCheckRatio = 8
+
+// Оценка размера значений Starlark в байтах: заголовок значения
+// и запись в list, dict или set. Оценка приблизительная — она ограничивает
+// данные скрипта, а не точный расход heap.
+const (
+	scriptValueSize = 16
+	scriptEntrySize = 16
+)
+
+// scriptMemoryMeter оценивает память, занятую значениями скрипта.
+//
+// Учитываются значения, достижимые из локальных переменных активных
+// функций и глобальных переменных модуля. Предопределённые inputs, steps
+// и outputs не учитываются: их создал не скрипт. Промежуточные значения
+// на стеке интерпретатора (например, список, который строит comprehension)
+// не видны до присваивания переменной.
+type scriptMemoryMeter struct {
+	// shared — контейнеры предопределённых значений.
+	shared map[starlark.Value]bool
+}
+
+// newScriptMemoryMeter создаёт оценщик, исключающий predeclared.
+func newScriptMemoryMeter(predeclared starlark.StringDict) *scriptMemoryMeter {
+	m := &scriptMemoryMeter{shared: make(map[starlark.Value]bool)}
+	w := &scriptMemoryWalk{shared: map[starlark.Value]bool{}, seen: m.shared}
+	for _, v := range predeclared {
+		w.size(v)
+	}
+	return m
+}
+
+// measure оценивает память значений, достижимых из стека thread.
+// Возвращает оценку в байтах и число обойдённых значений.
+// Вызывается только из горутины интерпретатора (OnMaxSteps).
+func (m *scriptMemoryMeter) measure(thread *starlark.Thread) (uint64, int) {
+	w := m.walk()
+	var total uint64
+
+	depth := thread.CallStackDepth()
+	for i := 0; i < depth; i++ {
+		fr := thread.DebugFrame(i)
+		for j := 0; j < fr.NumLocals(); j++ {
+			if _, v := fr.Local(j); v != nil {
+				total += w.size(v)
+			}
+		}
+		// Глобальные переменные модуля — через самую внешнюю функцию
+		if fn, ok := fr.Callable().(*starlark.Function); ok && i == depth-1 {
+			for _, v := range fn.Globals() {
+				total += w.size(v)
+			}
+		}
+	}
+	return total, w.count
+}
+
+// measureValue оценивает память одного значения (результата main()).
+func (m *scriptMemoryMeter) measureValue(v starlark.Value) uint64 {
+	return m.walk().size(v)
+}
+
+// walk начинает обход значений.
+func (m *scriptMemoryMeter) walk() *scriptMemoryWalk {
+	return &scriptMemoryWalk{shared: m.shared, seen: make(map[starlark.Value]bool)}
+}
+
+// scriptMemoryWalk — состояние одного обхода значений.
+type scriptMemoryWalk struct {
+	shared map[starlark.Value]bool
+	seen   map[starlark.Value]bool
+	count  int
+}
+
+// size оценивает память v и вложенных значений. Контейнеры из seen
+// и shared не учитываются повторно (общие ссылки, циклы).
+func (w *scriptMemoryWalk) size(v starlark.Value) uint64 {
+	w.count++
+	switch val := v.(type) {
+	case starlark.String:
+		return scriptValueSize + uint64(len(val))
+	case starlark.Bytes:
+		return scriptValueSize + uint64(len(val))
+	case starlark.Int:
+		if _, ok := val.Int64(); ok {
+			return scriptValueSize
+		}
+		return scriptValueSize + uint64(val.BigInt().BitLen()/8)
+	case starlark.Tuple:
+		total := uint64(scriptValueSize)
+		for _, item := range val {
+			total += scriptEntrySize + w.size(item)
+		}
+		return total
+	case *starlark.List:
+		if w.shared[val] || w.seen[val] {
+			return 0
+		}
+		w.seen[val] = true
+		total := uint64(scriptValueSize)
+		for i := 0; i < val.Len(); i++ {
+			total += scriptEntrySize + w.size(val.Index(i))
+		}
+		return total
+	case *starlark.Dict:
+		if w.shared[val] || w.seen[val] {
+			return 0
+		}
+		w.seen[val] = true
+		total := uint64(scriptValueSize)
+		for _, item := range val.Items() {
+			total += 2*scriptEntrySize + w.size(item[0]) + w.size(item[1])
+		}
+		return total
+	case *starlark.Set:
+		if w.shared[val] || w.seen[val] {
+			return 0
+		}
+		w.seen[val] = true
+		total := uint64(scriptValueSize)
+		iter := val.Iterate()
+		defer iter.Done()
+		var item starlark.Value
+		for iter.Next(&item) {
+			total += scriptEntrySize + w.size(item)
+		}
+		return total
+	default:
+		return scriptValueSize
+	}
+}
@@ -0,0 +1,313 @@
+package worker
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shaiso/Automata/internal/config"
+	"github.com/shaiso/Automata/internal/domain"
+)
+
+const (
+	defaultSQLTimeout = 30 * time.Second
+	defaultSQLMaxRows = 1000
+)
+
+// SQLExecutor — executor для шага типа "sql".
+//
+// Выполняет параметризованный запрос к PostgreSQL через именованное
+// подключение (AUTOMATA_CONN_<NAME>=postgres://...). Собственный пул
+// Automata не используется. Значения из шаблонов передаются только
+// через params и привязываются как параметры $1, $2, ... — в текст
+// запроса они не подставляются.
+//
+// Config (из task.Payload):
+//   - connection (string): имя подключения (обязательно)
+//   - query (string): SQL с плейсхолдерами $1, $2, ... (обязательно)
+//   - params ([]any): значения параметров
+//   - mode (string): "query" (по умолчанию) — строки результата,
+//     "exec" — количество затронутых строк
+//   - max_rows (number): максимум строк в outputs. Default: 1000
+//   - timeout_sec (number): statement_timeout запроса. Default: 30
+//   - read_only (bool): выполнять в READ ONLY транзакции
+//
+// Outputs (query):
+//   - rows ([]map[string]any): строки результата (колонка → значение)
+//   - columns ([]string): имена колонок
+//   - row_count (int): количество строк в rows
+//   - truncated (bool): строк было больше max_rows
+//
+// Outputs (exec):
+//   - rows_affected (int): количество затронутых строк
+//   - command (string): тег команды (например, "UPDATE 3")
+//
+// Ошибка Postgres (синтаксис, ограничение, таймаут) — логическая ошибка
+// с sqlstate в outputs, как и некорректный config или ненастроенное
+// подключение; ошибка соединения с БД — инфраструктурная.
+type SQLExecutor struct {
+	connections *config.Connections
+
+	mu    sync.Mutex
+	pools map[string]*pgxpool.Pool
+}
+
+// NewSQLExecutor создаёт SQLExecutor для именованных подключений.
+func NewSQLExecutor(connections *config.Connections) *SQLExecutor {
+	return &SQLExecutor{
+		connections: connections,
+		pools:       make(map[string]*pgxpool.Pool),
+	}
+}
+
+// sqlConfig — распарсенная конфигурация sql шага.
+type sqlConfig struct {
+	connection string
+	query      string
+	params     []any
+	exec       bool
+	maxRows    int
+	timeout    time.Duration
+	readOnly   bool
+}
+
+// Execute выполняет SQL запрос.
+func (e *SQLExecutor) Execute(ctx context.Context, task *domain.Task) (*ExecutionResult, error) {
+	cfg, err := parseSQLConfig(task.Payload)
+	if err != nil {
+		return &ExecutionResult{Error: err.Error()}, nil
+	}
+
+	conn, err := e.connections.Get(cfg.connection, "postgres", "postgresql")
+	if err != nil {
+		return &ExecutionResult{Error: err.Error()}, nil
+	}
+
+	pool, err := e.pool(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout+5*time.Second)
+	defer cancel()
+
+	accessMode := pgx.ReadWrite
+	if cfg.readOnly {
+		accessMode = pgx.ReadOnly
+	}
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: accessMode})
+	if err != nil {
+		return nil, fmt.Errorf("%w: begin: %v", ErrSQLQuery, err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit — no-op
+
+	// Таймаут на стороне сервера: запрос отменяется, даже если воркер упадёт
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", cfg.timeout.Milliseconds())); err != nil {
+		return nil, fmt.Errorf("%w: set statement_timeout: %v", ErrSQLQuery, err)
+	}
+
+	var outputs map[string]any
+	if cfg.exec {
+		outputs, err = execSQL(ctx, tx, cfg)
+	} else {
+		outputs, err = querySQL(ctx, tx, cfg)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			return &ExecutionResult{
+				Outputs: map[string]any{"sqlstate": pgErr.Code},
+				Error:   fmt.Sprintf("SQL %s: %s", pgErr.Code, pgErr.Message),
+			}, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrSQLQuery, err)
+	}
+
+	return &ExecutionResult{Outputs: outputs}, nil
+}
+
+// querySQL выполняет запрос и собирает не более maxRows строк.
+func querySQL(ctx context.Context, tx pgx.Tx, cfg *sqlConfig) (map[string]any, error) {
+	rows, err := tx.Query(ctx, cfg.query, cfg.params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+	}
+
+	result := make([]any, 0)
+	truncated := false
+	for rows.Next() {
+		if len(result) >= cfg.maxRows {
+			truncated = true
+			break
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			row[col] = sqlValue(values[i])
+		}
+		result = append(result, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"rows":      result,
+		"columns":   columns,
+		"row_count": len(result),
+		"truncated": truncated,
+	}, nil
+}
+
+// execSQL выполняет команду и возвращает количество затронутых строк.
+func execSQL(ctx context.Context, tx pgx.Tx, cfg *sqlConfig) (map[string]any, error) {
+	tag, err := tx.Exec(ctx, cfg.query, cfg.params...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"rows_affected": tag.RowsAffected(),
+		"command":       tag.String(),
+	}, nil
+}
+
+// pool возвращает пул подключения, создавая его при первом обращении.
+func (e *SQLExecutor) pool(ctx context.Context, conn *config.Connection) (*pgxpool.Pool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if pool, ok := e.pools[conn.Name]; ok {
+		return pool, nil
+	}
+
+	pool, err := pgxpool.New(ctx, conn.URL.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: connect %s: %v", ErrSQLQuery, conn, err)
+	}
+	e.pools[conn.Name] = pool
+	return pool, nil
+}
+
+// Close закрывает пулы подключений.
+func (e *SQLExecutor) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for name, pool := range e.pools {
+		pool.Close()
+		delete(e.pools, name)
+	}
+}
+
+// parseSQLConfig извлекает конфигурацию sql шага из payload.
+func parseSQLConfig(payload map[string]any) (*sqlConfig, error) {
+	cfg := &sqlConfig{
+		connection: getString(payload, "connection", ""),
+		query:      getString(payload, "query", ""),
+		maxRows:    defaultSQLMaxRows,
+		timeout:    getSeconds(payload, "timeout_sec", defaultSQLTimeout),
+		readOnly:   getBool(payload, "read_only", false),
+	}
+
+	if cfg.connection == "" {
+		return nil, fmt.Errorf("%w: connection is required", ErrInvalidSQLConfig)
+	}
+	if cfg.query == "" {
+		return nil, fmt.Errorf("%w: query is required", ErrInvalidSQLConfig)
+	}
+
+	switch mode := getString(payload, "mode", "query"); mode {
+	case "query":
+	case "exec":
+		cfg.exec = true
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidSQLConfig, mode)
+	}
+
+	if n, ok := toInt(payload["max_rows"]); ok {
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: max_rows must be positive", ErrInvalidSQLConfig)
+		}
+		cfg.maxRows = n
+	}
+
+	switch params := payload["params"].(type) {
+	case nil:
+	case []any:
+		cfg.params = make([]any, len(params))
+		for i, p := range params {
+			cfg.params[i] = sqlParam(p)
+		}
+	default:
+		return nil, fmt.Errorf("%w: params must be a list", ErrInvalidSQLConfig)
+	}
+
+	return cfg, nil
+}
+
+// sqlParam приводит значение из JSON к типу для привязки параметра:
+// целые числа JSON (float64) передаются как int64, чтобы подходить
+// к integer колонкам.
+func sqlParam(v any) any {
+	if f, ok := v.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int64(f)
+	}
+	return v
+}
+
+// sqlValue приводит значение колонки к JSON-совместимому виду для outputs.
+func sqlValue(v any) any {
+	switch val := v.(type) {
+	case nil, bool, string, int16, int32, int64, float32, float64:
+		return val
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case []byte:
+		return string(val)
+	case [16]byte:
+		return uuid.UUID(val).String()
+	case pgtype.Numeric:
+		if f, err := val.Float64Value(); err == nil && f.Valid {
+			return f.Float64
+		}
+		return nil
+	case map[string]any:
+		return val
+	case []any:
+		items := make([]any, len(val))
+		for i, item := range val {
+			items[i] = sqlValue(item)
+		}
+		return items
+	case driver.Valuer:
+		if dv, err := val.Value(); err == nil {
+			return sqlValue(dv)
+		}
+	case fmt.Stringer:
+		return val.String()
+	}
+	return fmt.Sprint(v)
+}
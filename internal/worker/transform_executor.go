@@ -4,23 +4,33 @@ import (
 	"context"
 
 	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
 )
 
 // TransformExecutor — executor для шага типа "transform".
 //
-// Оркестратор уже отрендерил config через engine.RenderConfig()
-// при создании task (handlers.go:dispatchStep). Поэтому task.Payload
-// уже содержит готовые значения после подстановки шаблонов.
+// Оркестратор уже отрендерил шаблоны config через engine.RenderTransform()
+// при создании task (handlers.go:dispatchStep) и передал контекст run
+// в payload под ключом engine.StepGlobalsKey. Значения {"jq": "..."}
+// (верхнего уровня и объекта mappings) вычисляются здесь, в Worker:
+// тяжёлое выражение занимает слот воркера, а не цикл Orchestrator.
 //
-// Transform просто возвращает payload как outputs —
-// это "pass-through с template expansion" для трансформации данных между шагами.
+// Остальные значения payload возвращаются как outputs без изменений.
 type TransformExecutor struct{}
 
-// Execute возвращает payload как outputs.
-func (e *TransformExecutor) Execute(_ context.Context, task *domain.Task) (*ExecutionResult, error) {
-	outputs := task.Payload
-	if outputs == nil {
-		outputs = make(map[string]any)
+// Execute вычисляет jq-выражения payload и возвращает его как outputs.
+func (e *TransformExecutor) Execute(ctx context.Context, task *domain.Task) (*ExecutionResult, error) {
+	config := make(map[string]any, len(task.Payload))
+	for key, value := range task.Payload {
+		if key != engine.StepGlobalsKey {
+			config[key] = value
+		}
+	}
+
+	outputs, err := engine.EvalTransform(ctx, config, task.Payload[engine.StepGlobalsKey])
+	if err != nil {
+		// Ошибка выражения повторится при retry; отмена контекста — нет
+		return &ExecutionResult{Error: err.Error(), Permanent: ctx.Err() == nil}, nil
 	}
 
 	return &ExecutionResult{
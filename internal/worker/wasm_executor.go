@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/repo"
+	"github.com/shaiso/Automata/internal/wasmhost"
+)
+
+// wasmStderrTail — сколько последних байт stderr модуля попадает в ошибку шага.
+const wasmStderrTail = 2 << 10
+
+// WasmModuleStore — источник загруженных модулей (repo.WasmModuleRepo).
+type WasmModuleStore interface {
+	GetByDigest(ctx context.Context, digest string) (*domain.WasmModule, error)
+}
+
+// WasmExecutor — executor для шага типа "wasm".
+//
+// Выполняет загруженный WebAssembly-модуль (программу WASI) в рантайме
+// wazero (см. wasmhost). Модуль не имеет доступа к файловой системе,
+// сети и окружению воркера.
+//
+// Config (из task.Payload):
+//   - module (string): дайджест модуля sha256:<hex> (обязательно)
+//   - timeout_sec (number): ограничение времени. Default: 10, максимум 300
+//   - max_memory_mb (number): ограничение линейной памяти. Default: 64, максимум 1024
+//   - globals (map[string]any): inputs и steps run — заполняет Orchestrator
+//   - остальные ключи — конфигурация модуля
+//
+// Модуль получает на stdin JSON:
+//
+//	{"config": {...}, "context": {"inputs": {...}, "steps": {...}}}
+//
+// и пишет результат в stdout: JSON-объект становится outputs, другое
+// значение JSON — {"result": значение}, пустой вывод — пустые outputs.
+//
+// Ненулевой код выхода — логическая ошибка с exit_code и stderr в outputs.
+// Ошибки модуля и превышение лимитов — постоянные ошибки
+// (ExecutionResult.Permanent): повтор даст тот же результат.
+type WasmExecutor struct {
+	modules WasmModuleStore
+	host    *wasmhost.Host
+}
+
+// NewWasmExecutor создаёт WasmExecutor.
+func NewWasmExecutor(modules WasmModuleStore) *WasmExecutor {
+	return &WasmExecutor{
+		modules: modules,
+		host:    wasmhost.New(),
+	}
+}
+
+// Execute выполняет модуль и возвращает его вывод как outputs.
+func (e *WasmExecutor) Execute(ctx context.Context, task *domain.Task) (*ExecutionResult, error) {
+	cfg, err := engine.ParseWasmConfig(task.Payload)
+	if err != nil {
+		return &ExecutionResult{Error: err.Error(), Permanent: true}, nil
+	}
+	if e.modules == nil {
+		return &ExecutionResult{Error: fmt.Sprintf("%v: wasm modules are not configured", ErrInvalidWasmConfig), Permanent: true}, nil
+	}
+
+	globals, _ := task.Payload[engine.StepGlobalsKey].(map[string]any)
+	stdin, err := json.Marshal(map[string]any{
+		"config":  cfg.Input,
+		"context": globals,
+	})
+	if err != nil {
+		return &ExecutionResult{Error: fmt.Sprintf("%v: encode input: %v", ErrInvalidWasmConfig, err), Permanent: true}, nil
+	}
+
+	load := func(ctx context.Context) ([]byte, error) {
+		mod, err := e.modules.GetByDigest(ctx, cfg.Module)
+		if err != nil {
+			return nil, err
+		}
+		return mod.Data, nil
+	}
+
+	out, err := e.host.Run(ctx, cfg.Module, load, stdin, wasmhost.Limits{
+		MaxMemory: cfg.MaxMemory,
+		Timeout:   cfg.Timeout,
+	})
+	if err != nil {
+		// Отмена task (остановка воркера) — не ошибка модуля
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		switch {
+		case errors.Is(err, repo.ErrNotFound):
+			return &ExecutionResult{Error: fmt.Sprintf("wasm: module %s not found", cfg.Module), Permanent: true}, nil
+		case errors.Is(err, wasmhost.ErrInvalidModule),
+			errors.Is(err, wasmhost.ErrTimeout),
+			errors.Is(err, wasmhost.ErrOutputTooLarge):
+			return &ExecutionResult{Error: "wasm: " + err.Error(), Permanent: true}, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrWasmRun, err)
+	}
+
+	if out.ExitCode != 0 {
+		stderr := stderrTail(out.Stderr)
+		return &ExecutionResult{
+			Outputs: map[string]any{
+				"exit_code": int(out.ExitCode),
+				"stderr":    stderr,
+			},
+			Error:     fmt.Sprintf("wasm: module exited with code %d: %s", out.ExitCode, stderr),
+			Permanent: true,
+		}, nil
+	}
+
+	outputs, err := wasmOutputs(out.Stdout)
+	if err != nil {
+		return &ExecutionResult{Error: fmt.Sprintf("wasm: module output: %v", err), Permanent: true}, nil
+	}
+	return &ExecutionResult{Outputs: outputs}, nil
+}
+
+// Close освобождает скомпилированные модули.
+func (e *WasmExecutor) Close() {
+	e.host.Close(context.Background())
+}
+
+// wasmOutputs разбирает stdout модуля в outputs.
+func wasmOutputs(stdout []byte) (map[string]any, error) {
+	stdout = bytes.TrimSpace(stdout)
+	if len(stdout) == 0 {
+		return map[string]any{}, nil
+	}
+
+	var result any
+	if err := json.Unmarshal(stdout, &result); err != nil {
+		return nil, fmt.Errorf("stdout is not JSON: %v", err)
+	}
+	if m, ok := result.(map[string]any); ok {
+		return m, nil
+	}
+	return map[string]any{"result": result}, nil
+}
+
+// stderrTail возвращает конец stderr модуля (последние строки обычно
+// содержат причину ошибки).
+func stderrTail(stderr []byte) string {
+	stderr = bytes.TrimSpace(stderr)
+	if len(stderr) > wasmStderrTail {
+		stderr = stderr[len(stderr)-wasmStderrTail:]
+	}
+	return string(stderr)
+}
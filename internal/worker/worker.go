@@ -7,7 +7,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/shaiso/Automata/internal/config"
 	"github.com/shaiso/Automata/internal/mq"
+	"github.com/shaiso/Automata/internal/plugin"
 	"github.com/shaiso/Automata/internal/repo"
 )
 
@@ -49,6 +51,10 @@ type Worker struct {
 	pollInterval time.Duration
 	batchSize    int
 
+	// slots ограничивает число одновременно выполняемых отложенных tasks
+	// (scheduleTask)
+	slots chan struct{}
+
 	// Lifecycle
 	logger     *slog.Logger
 	cancelFunc context.CancelFunc
@@ -68,9 +74,24 @@ type Config struct {
 	Publisher *mq.Publisher
 	Conn      *mq.Connection
 
-	// Executor registry (опционально; если nil — используется NewRegistry())
+	// Executor registry (опционально; если nil — используется NewRegistry()
+	// с executor'ами для Connections)
 	Registry *Registry
 
+	// Connections — именованные подключения для шагов sql и т.п. (опционально)
+	Connections *config.Connections
+
+	// DescriptorSetRepo — загруженные descriptor sets для шага grpc
+	// (опционально; без него — только server reflection)
+	DescriptorSetRepo *repo.DescriptorSetRepo
+
+	// WasmModuleRepo — загруженные модули для шага wasm (опционально)
+	WasmModuleRepo *repo.WasmModuleRepo
+
+	// Plugins — плагины с дополнительными типами шагов (опционально,
+	// plugin.Discover)
+	Plugins []*plugin.Manifest
+
 	// Polling configuration
 	PollInterval time.Duration // интервал polling (default: 10s)
 	BatchSize    int           // количество tasks за один poll (default: 50)
@@ -99,6 +120,10 @@ func New(cfg Config) *Worker {
 	registry := cfg.Registry
 	if registry == nil {
 		registry = NewRegistry()
+		registry.RegisterConnectionExecutors(cfg.Connections)
+		registry.RegisterGRPCExecutor(cfg.DescriptorSetRepo)
+		registry.RegisterWasmExecutor(cfg.WasmModuleRepo)
+		registry.RegisterPluginExecutors(cfg.Plugins, logger)
 	}
 
 	return &Worker{
@@ -110,6 +135,7 @@ func New(cfg Config) *Worker {
 		registry:     registry,
 		pollInterval: pollInterval,
 		batchSize:    batchSize,
+		slots:        make(chan struct{}, defaultPrefetch),
 		logger:       logger,
 	}
 }
@@ -174,6 +200,9 @@ func (w *Worker) Stop() {
 	// Ждём завершения горутин
 	w.wg.Wait()
 
+	// Закрываем пулы подключений executor'ов
+	w.registry.Close()
+
 	w.logger.Info("worker stopped")
 }
 
@@ -3,13 +3,35 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/shaiso/Automata/internal/config"
 	"github.com/shaiso/Automata/internal/domain"
+	"github.com/shaiso/Automata/internal/engine"
+	"github.com/shaiso/Automata/internal/plugin"
+	"github.com/shaiso/Automata/internal/repo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // --- HTTPExecutor Tests ---
@@ -203,6 +225,104 @@ func TestHTTPExecutor_DefaultMethod(t *testing.T) {
 	}
 }
 
+func TestHTTPExecutor_XML(t *testing.T) {
+	var receivedBody, receivedContentType string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		receivedBody, receivedContentType = string(b), r.Header.Get("Content-Type")
+		w.Header().Set("Content-Type", "application/soap+xml")
+		w.Write([]byte(`<soap:Envelope xmlns:soap="urn:s"><soap:Body><Rate currency="EUR">1.08</Rate></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	executor := &HTTPExecutor{}
+	task := &domain.Task{
+		ID: uuid.New(),
+		Payload: map[string]any{
+			"method":      "POST",
+			"url":         server.URL,
+			"body":        map[string]any{"GetRate": map[string]any{"currency": "EUR"}},
+			"body_format": "xml",
+		},
+	}
+
+	result, err := executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedBody != "<GetRate><currency>EUR</currency></GetRate>" {
+		t.Errorf("unexpected request body %s", receivedBody)
+	}
+	if receivedContentType != "application/xml; charset=utf-8" {
+		t.Errorf("unexpected Content-Type %s", receivedContentType)
+	}
+
+	body, _ := result.Outputs["body"].(map[string]any)
+	envelope, _ := body["Envelope"].(map[string]any)
+	soapBody, _ := envelope["Body"].(map[string]any)
+	rate, _ := soapBody["Rate"].(map[string]any)
+	if rate["@currency"] != "EUR" || rate["#text"] != "1.08" {
+		t.Errorf("unexpected parsed body %v", result.Outputs["body"])
+	}
+}
+
+func TestHTTPExecutor_CSV(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("sku,qty\nA1,3\nB2,5\n"))
+	}))
+	defer server.Close()
+
+	executor := &HTTPExecutor{}
+	task := &domain.Task{
+		ID:      uuid.New(),
+		Payload: map[string]any{"url": server.URL},
+	}
+
+	result, err := executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, ok := result.Outputs["body"].([]any)
+	if !ok || len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", result.Outputs["body"])
+	}
+	if row, _ := rows[1].(map[string]any); row["sku"] != "B2" || row["qty"] != "5" {
+		t.Errorf("unexpected row %v", rows[1])
+	}
+}
+
+func TestHTTPExecutor_TextJSONFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(`{"id": "123"}`))
+	}))
+	defer server.Close()
+
+	executor := &HTTPExecutor{}
+	task := &domain.Task{ID: uuid.New(), Payload: map[string]any{"url": server.URL}}
+	result, err := executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body, _ := result.Outputs["body"].(map[string]any); body["id"] != "123" {
+		t.Errorf("expected JSON body, got %v", result.Outputs["body"])
+	}
+
+	// response_format text отключает разбор
+	task.Payload["response_format"] = "text"
+	result, err = executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Outputs["body"] != `{"id": "123"}` {
+		t.Errorf("expected string body, got %v", result.Outputs["body"])
+	}
+}
+
 // --- DelayExecutor Tests ---
 
 func TestDelayExecutor_Success(t *testing.T) {
@@ -310,108 +430,1022 @@ func TestTransformExecutor_NilPayload(t *testing.T) {
 	}
 }
 
-// --- Registry Tests ---
+func TestTransformExecutor_JQ(t *testing.T) {
+	executor := &TransformExecutor{}
+	task := &domain.Task{
+		ID: uuid.New(),
+		Payload: map[string]any{
+			"label": "orders",
+			"total": map[string]any{"jq": "[.steps.fetch.outputs.orders[].amount] | add"},
+			"mappings": map[string]any{
+				"count": map[string]any{"jq": ".steps.fetch.outputs.orders | length"},
+			},
+			engine.StepGlobalsKey: map[string]any{
+				"inputs": map[string]any{},
+				"steps": map[string]any{
+					"fetch": map[string]any{
+						"outputs": map[string]any{"orders": []any{
+							map[string]any{"amount": 12},
+							map[string]any{"amount": 30},
+						}},
+						"status": "SUCCEEDED",
+					},
+				},
+			},
+		},
+	}
 
-func TestNewRegistry_DefaultExecutors(t *testing.T) {
-	r := NewRegistry()
+	result, err := executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Error != "" {
+		t.Fatalf("unexpected execution error: %s", result.Error)
+	}
+	if result.Outputs["label"] != "orders" || result.Outputs["total"] != float64(42) {
+		t.Errorf("unexpected outputs: %v", result.Outputs)
+	}
+	if mappings, _ := result.Outputs["mappings"].(map[string]any); mappings["count"] != 2 {
+		t.Errorf("unexpected mappings: %v", result.Outputs["mappings"])
+	}
+	// Контекст run не попадает в outputs
+	if _, ok := result.Outputs[engine.StepGlobalsKey]; ok {
+		t.Error("globals should not be in outputs")
+	}
 
-	// Должны быть зарегистрированы http, delay, transform
-	for _, stepType := range []string{"http", "delay", "transform"} {
-		executor, err := r.Get(stepType)
+	task.Payload["total"] = map[string]any{"jq": "error(\"boom\")"}
+	result, err = executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Permanent || !strings.Contains(result.Error, "transform total") {
+		t.Errorf("expected permanent jq error, got %+v", result)
+	}
+}
+
+// --- PollExecutor Tests ---
+
+func TestPollExecutor_UntilCondition(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		status := "pending"
+		if calls >= 2 {
+			status = "ready"
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": status})
+	}))
+	defer server.Close()
+
+	executor := NewPollExecutor(NewRegistry())
+	task := &domain.Task{
+		ID: uuid.New(),
+		Payload: map[string]any{
+			"request":      map[string]any{"url": server.URL},
+			"until":        `eq .Outputs.body.status "ready"`,
+			"interval_sec": float64(2),
+			"backoff":      "exponential",
+		},
+	}
+
+	// Первая итерация — условие не выполнено, task откладывается
+	result, err := executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Error != "" {
+		t.Fatalf("unexpected execution error: %s", result.Error)
+	}
+	if result.Reschedule != 2*time.Second {
+		t.Errorf("expected reschedule in 2s, got %v", result.Reschedule)
+	}
+
+	// Вторая итерация — условие выполнено
+	task.Outputs = result.Outputs
+	result, err = executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Reschedule != 0 {
+		t.Errorf("expected poll to finish, got reschedule %v", result.Reschedule)
+	}
+	if result.Outputs["attempts"] != 2 {
+		t.Errorf("expected 2 attempts, got %v", result.Outputs["attempts"])
+	}
+
+	iterations, ok := result.Outputs["iterations"].([]any)
+	if !ok || len(iterations) != 2 {
+		t.Fatalf("expected 2 recorded iterations, got %v", result.Outputs["iterations"])
+	}
+	last := iterations[1].(map[string]any)
+	if last["done"] != true || last["status_code"] != http.StatusOK {
+		t.Errorf("unexpected last iteration: %v", last)
+	}
+
+	body, _ := result.Outputs["body"].(map[string]any)
+	if body["status"] != "ready" {
+		t.Errorf("expected outputs of last iteration, got %v", result.Outputs["body"])
+	}
+}
+
+func TestPollExecutor_MaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"status": "pending"})
+	}))
+	defer server.Close()
+
+	executor := NewPollExecutor(NewRegistry())
+	task := &domain.Task{
+		ID: uuid.New(),
+		Payload: map[string]any{
+			"request":      map[string]any{"url": server.URL},
+			"until":        `eq .Outputs.body.status "ready"`,
+			"max_attempts": float64(2),
+		},
+	}
+
+	result, _ := executor.Execute(context.Background(), task)
+	if result.Reschedule == 0 {
+		t.Fatal("first iteration should be rescheduled")
+	}
+
+	task.Outputs = result.Outputs
+	result, _ = executor.Execute(context.Background(), task)
+	if result.Error == "" {
+		t.Error("expected error after max attempts")
+	}
+	if result.Reschedule != 0 {
+		t.Error("exhausted poll should not be rescheduled")
+	}
+}
+
+func TestPollExecutor_MaxDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	executor := NewPollExecutor(NewRegistry())
+	task := &domain.Task{
+		ID: uuid.New(),
+		Payload: map[string]any{
+			"request":          map[string]any{"url": server.URL},
+			"until":            "true",
+			"interval_sec":     float64(10),
+			"max_duration_sec": float64(5),
+		},
+	}
+
+	// Ошибка вложенного действия не завершает poll, но следующая
+	// итерация вышла бы за max_duration
+	result, _ := executor.Execute(context.Background(), task)
+	if result.Error == "" {
+		t.Error("expected timeout error")
+	}
+
+	iterations := result.Outputs["iterations"].([]any)
+	if iterations[0].(map[string]any)["error"] == nil {
+		t.Error("iteration error should be recorded")
+	}
+}
+
+func TestPollExecutor_InvalidConfig(t *testing.T) {
+	executor := NewPollExecutor(NewRegistry())
+
+	result, err := executor.Execute(context.Background(), &domain.Task{
+		Payload: map[string]any{"request": map[string]any{"url": "http://example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Error == "" {
+		t.Error("expected error for missing until")
+	}
+}
+
+func TestPollDelay(t *testing.T) {
+	cfg := &pollConfig{interval: time.Second, backoff: "exponential", maxInterval: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := pollDelay(i+1, cfg); got != want {
+			t.Errorf("iteration %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+
+	cfg.backoff = "fixed"
+	if got := pollDelay(5, cfg); got != time.Second {
+		t.Errorf("fixed backoff: expected 1s, got %v", got)
+	}
+}
+
+// --- SQLExecutor Tests ---
+
+func TestParseSQLConfig(t *testing.T) {
+	cfg, err := parseSQLConfig(map[string]any{
+		"connection":  "reporting",
+		"query":       "SELECT * FROM orders WHERE id = $1 AND total > $2",
+		"params":      []any{float64(42), 9.5},
+		"max_rows":    float64(10),
+		"timeout_sec": float64(5),
+		"read_only":   true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.exec {
+		t.Error("expected query mode by default")
+	}
+	if cfg.maxRows != 10 || cfg.timeout != 5*time.Second || !cfg.readOnly {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.params[0] != int64(42) || cfg.params[1] != 9.5 {
+		t.Errorf("unexpected params: %#v", cfg.params)
+	}
+
+	invalid := []map[string]any{
+		{"query": "SELECT 1"},
+		{"connection": "reporting"},
+		{"connection": "reporting", "query": "SELECT 1", "mode": "batch"},
+		{"connection": "reporting", "query": "SELECT 1", "params": "x"},
+		{"connection": "reporting", "query": "SELECT 1", "max_rows": float64(0)},
+	}
+	for _, payload := range invalid {
+		if _, err := parseSQLConfig(payload); !errors.Is(err, ErrInvalidSQLConfig) {
+			t.Errorf("payload %v: expected ErrInvalidSQLConfig, got %v", payload, err)
+		}
+	}
+}
+
+func TestSQLExecutor_UnknownConnection(t *testing.T) {
+	connections, err := config.NewConnections(map[string]string{"queue": "amqp://localhost"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	executor := NewSQLExecutor(connections)
+
+	for _, name := range []string{"reporting", "queue"} {
+		result, err := executor.Execute(context.Background(), &domain.Task{
+			Payload: map[string]any{"connection": name, "query": "SELECT 1"},
+		})
 		if err != nil {
-			t.Errorf("expected executor for %s, got error: %v", stepType, err)
+			t.Fatalf("unexpected error: %v", err)
 		}
-		if executor == nil {
-			t.Errorf("executor for %s should not be nil", stepType)
+		if result.Error == "" {
+			t.Errorf("expected error for connection %s", name)
 		}
 	}
 }
 
-func TestRegistry_UnknownType(t *testing.T) {
-	r := NewRegistry()
+func TestSQLValue(t *testing.T) {
+	ts := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	id := uuid.New()
 
-	_, err := r.Get("unknown")
-	if err == nil {
-		t.Error("expected error for unknown step type")
+	tests := []struct {
+		in   any
+		want any
+	}{
+		{nil, nil},
+		{int32(7), int32(7)},
+		{"text", "text"},
+		{ts, "2024-01-15T10:00:00Z"},
+		{[16]byte(id), id.String()},
+		{[]byte("raw"), "raw"},
+	}
+	for _, tt := range tests {
+		if got := sqlValue(tt.in); got != tt.want {
+			t.Errorf("sqlValue(%#v) = %#v, want %#v", tt.in, got, tt.want)
+		}
 	}
 }
 
-func TestRegistry_Register(t *testing.T) {
-	r := NewRegistry()
+// --- AMQPPublishExecutor Tests ---
 
-	// Регистрируем кастомный executor
-	r.Register("custom", &DelayExecutor{})
+func TestParseAMQPPublishConfig(t *testing.T) {
+	cfg, err := parseAMQPPublishConfig(map[string]any{
+		"connection":  "events",
+		"exchange":    "orders",
+		"routing_key": "order.paid",
+		"body":        map[string]any{"id": "42"},
+		"headers":     map[string]any{"attempt": float64(2), "meta": map[string]any{"source": "automata"}},
+		"persistent":  false,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(cfg.msg.Body) != `{"id":"42"}` || cfg.msg.ContentType != "application/json" {
+		t.Errorf("unexpected body: %s (%s)", cfg.msg.Body, cfg.msg.ContentType)
+	}
+	if cfg.msg.Headers["attempt"] != int64(2) {
+		t.Errorf("expected integer header, got %#v", cfg.msg.Headers["attempt"])
+	}
+	if cfg.msg.DeliveryMode != amqp.Transient {
+		t.Errorf("expected transient delivery mode, got %d", cfg.msg.DeliveryMode)
+	}
 
-	executor, err := r.Get("custom")
+	cfg, err = parseAMQPPublishConfig(map[string]any{"connection": "events", "routing_key": "audit", "body": "hello"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if executor == nil {
-		t.Error("custom executor should be registered")
+	if string(cfg.msg.Body) != "hello" || cfg.msg.ContentType != "text/plain" || cfg.msg.DeliveryMode != amqp.Persistent {
+		t.Errorf("unexpected message: %+v", cfg.msg)
+	}
+
+	invalid := []map[string]any{
+		{"exchange": "orders"},
+		{"connection": "events"},
+		{"connection": "events", "exchange": "orders", "headers": "x"},
+	}
+	for _, payload := range invalid {
+		if _, err := parseAMQPPublishConfig(payload); !errors.Is(err, ErrInvalidAMQPConfig) {
+			t.Errorf("payload %v: expected ErrInvalidAMQPConfig, got %v", payload, err)
+		}
+	}
+}
+
+func TestAMQPPublishExecutor_UnknownConnection(t *testing.T) {
+	connections, err := config.NewConnections(map[string]string{"reporting": "postgres://localhost/reports"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	executor := NewAMQPPublishExecutor(connections)
+
+	for _, name := range []string{"events", "reporting"} {
+		result, err := executor.Execute(context.Background(), &domain.Task{
+			ID:      uuid.New(),
+			Payload: map[string]any{"connection": name, "exchange": "orders"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Error == "" {
+			t.Errorf("expected error for connection %s", name)
+		}
 	}
 }
 
-// --- Backoff Tests ---
+// --- EmailExecutor Tests ---
+
+// smtpStub — локальный SMTP-сервер для тестов email шага.
+// reply возвращает ответ на команду (пусто — ответ по умолчанию).
+type smtpStub struct {
+	addr  string
+	data  chan string
+	reply func(cmd string) string
+}
+
+func startSMTPStub(t *testing.T, reply func(cmd string) string) *smtpStub {
+	t.Helper()
 
-func TestCalculateBackoff_Exponential(t *testing.T) {
-	policy := &domain.RetryPolicy{
-		Backoff:        "exponential",
-		InitialDelayMs: 1000,
-		MaxDelayMs:     10000,
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	stub := &smtpStub{addr: ln.Addr().String(), data: make(chan string, 1), reply: reply}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+	return stub
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 stub ready")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		if r := s.reply(line); r != "" {
+			tp.PrintfLine("%s", r)
+			continue
+		}
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO":
+			tp.PrintfLine("250-stub")
+			tp.PrintfLine("250 8BITMIME")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data <- string(data)
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
 	}
+}
+
+func emailConnections(t *testing.T, addr string) *config.Connections {
+	t.Helper()
+	connections, err := config.NewConnections(map[string]string{
+		"mail": "smtp://" + addr + "?from=robot@example.com&starttls=disabled",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return connections
+}
 
+func TestEmailExecutor_Send(t *testing.T) {
+	stub := startSMTPStub(t, func(cmd string) string {
+		if strings.Contains(cmd, "unknown@example.com") {
+			return "550 no such user"
+		}
+		return ""
+	})
+	executor := NewEmailExecutor(emailConnections(t, stub.addr))
+
+	task := &domain.Task{
+		ID: uuid.New(),
+		Payload: map[string]any{
+			"connection": "mail",
+			"to":         []any{"Ops <ops@example.com>", "unknown@example.com"},
+			"bcc":        "audit@example.com",
+			"subject":    "Отчёт за день",
+			"text":       "Отчёт во вложении",
+			"html":       "<p>Отчёт во вложении</p>",
+			"attachments": []any{
+				map[string]any{"filename": "report.json", "content": map[string]any{"total": float64(42)}},
+			},
+		},
+	}
+
+	result, err := executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+
+	accepted, _ := result.Outputs["accepted"].([]string)
+	rejected, _ := result.Outputs["rejected"].([]string)
+	if len(accepted) != 2 || len(rejected) != 1 || rejected[0] != "unknown@example.com" {
+		t.Errorf("unexpected recipients: accepted=%v rejected=%v", accepted, rejected)
+	}
+	if want := "<" + task.ID.String() + "@example.com>"; result.Outputs["message_id"] != want {
+		t.Errorf("expected message_id %s, got %v", want, result.Outputs["message_id"])
+	}
+
+	data := <-stub.data
+	for _, want := range []string{"Message-Id: <" + task.ID.String(), "multipart/mixed", "multipart/alternative", "filename=report.json"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("message should contain %q", want)
+		}
+	}
+	if strings.Contains(data, "audit@example.com") {
+		t.Error("bcc recipient should not appear in headers")
+	}
+}
+
+func TestEmailExecutor_SMTPErrors(t *testing.T) {
 	tests := []struct {
-		attempt  int
-		expected time.Duration
+		name      string
+		reply     string
+		permanent bool
 	}{
-		{1, 1 * time.Second},
-		{2, 2 * time.Second},
-		{3, 4 * time.Second},
-		{4, 8 * time.Second},
-		{5, 10 * time.Second}, // capped at max
-		{6, 10 * time.Second}, // stays at max
+		{"transient", "451 try again later", false},
+		{"permanent", "553 sender rejected", true},
 	}
 
 	for _, tt := range tests {
-		got := calculateBackoff(tt.attempt, policy)
-		if got != tt.expected {
-			t.Errorf("attempt %d: expected %v, got %v", tt.attempt, tt.expected, got)
+		t.Run(tt.name, func(t *testing.T) {
+			stub := startSMTPStub(t, func(cmd string) string {
+				if strings.HasPrefix(cmd, "MAIL FROM") {
+					return tt.reply
+				}
+				return ""
+			})
+			executor := NewEmailExecutor(emailConnections(t, stub.addr))
+
+			result, err := executor.Execute(context.Background(), &domain.Task{
+				ID:      uuid.New(),
+				Payload: map[string]any{"connection": "mail", "to": "ops@example.com", "text": "hi"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Error == "" || result.Permanent != tt.permanent {
+				t.Errorf("expected error with permanent=%v, got %+v", tt.permanent, result)
+			}
+
+			w := &Worker{}
+			retry := w.shouldRetry(result, nil, &domain.RetryPolicy{MaxAttempts: 3})
+			if retry == tt.permanent {
+				t.Errorf("expected retry=%v", !tt.permanent)
+			}
+		})
+	}
+}
+
+func TestParseEmailConfig_Invalid(t *testing.T) {
+	invalid := []map[string]any{
+		{"to": "ops@example.com", "text": "hi"},
+		{"connection": "mail", "text": "hi"},
+		{"connection": "mail", "to": "ops@example.com"},
+		{"connection": "mail", "to": "not an address", "text": "hi"},
+		{"connection": "mail", "to": "ops@example.com", "text": "hi", "attachments": []any{map[string]any{"content": "x"}}},
+	}
+	for _, payload := range invalid {
+		if _, err := parseEmailConfig(payload); !errors.Is(err, ErrInvalidEmailConfig) {
+			t.Errorf("payload %v: expected ErrInvalidEmailConfig, got %v", payload, err)
 		}
 	}
 }
 
-func TestCalculateBackoff_Fixed(t *testing.T) {
-	policy := &domain.RetryPolicy{
-		Backoff:        "fixed",
-		InitialDelayMs: 2000,
-		MaxDelayMs:     10000,
+// --- gRPC Tests ---
+
+// startGRPCServer запускает сервер с grpc.health.v1 и server reflection.
+func startGRPCServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("billing", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	return ln.Addr().String()
+}
+
+// descriptorSets — DescriptorSetStore в памяти.
+type descriptorSets map[string][]byte
+
+func (d descriptorSets) GetByName(_ context.Context, name string) (*domain.DescriptorSet, error) {
+	data, ok := d[name]
+	if !ok {
+		return nil, errors.New("descriptor set not found")
 	}
+	return &domain.DescriptorSet{Name: name, Data: data}, nil
+}
 
-	// Все попытки — одинаковая задержка
-	for attempt := 1; attempt <= 5; attempt++ {
-		got := calculateBackoff(attempt, policy)
-		if got != 2*time.Second {
-			t.Errorf("attempt %d: expected 2s, got %v", attempt, got)
+func TestGRPCExecutor_Call(t *testing.T) {
+	addr := startGRPCServer(t)
+
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto),
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal descriptor set: %v", err)
+	}
+	executor := NewGRPCExecutor(descriptorSets{"health": set})
+	defer executor.Close()
+
+	tests := []struct {
+		name       string
+		payload    map[string]any
+		wantStatus string
+	}{
+		{"reflection", map[string]any{"service": "grpc.health.v1.Health"}, "SERVING"},
+		{"descriptor set", map[string]any{"service": "grpc.health.v1.Health", "descriptor_set": "health"}, "SERVING"},
+		{"request body", map[string]any{"service": "grpc.health.v1.Health", "request": map[string]any{"service": "billing"}}, "NOT_SERVING"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.payload["target"] = addr
+			tt.payload["method"] = "Check"
+			result, err := executor.Execute(context.Background(), &domain.Task{ID: uuid.New(), Payload: tt.payload})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Error != "" {
+				t.Fatalf("unexpected logical error: %s", result.Error)
+			}
+			body, _ := result.Outputs["body"].(map[string]any)
+			if body["status"] != tt.wantStatus {
+				t.Errorf("expected status %s, got %v", tt.wantStatus, body["status"])
+			}
+			if result.Outputs["status"] != "OK" {
+				t.Errorf("expected OK, got %v", result.Outputs["status"])
+			}
+		})
+	}
+}
+
+func TestGRPCExecutor_StatusErrors(t *testing.T) {
+	addr := startGRPCServer(t)
+	executor := NewGRPCExecutor(nil)
+	defer executor.Close()
+
+	tests := []struct {
+		name          string
+		payload       map[string]any
+		wantPermanent bool
+	}{
+		// health.Server отвечает NOT_FOUND на неизвестный сервис
+		{"not found", map[string]any{"target": addr, "service": "grpc.health.v1.Health", "method": "Check",
+			"request": map[string]any{"service": "missing"}}, true},
+		{"unknown method", map[string]any{"target": addr, "service": "grpc.health.v1.Health", "method": "Missing"}, true},
+		{"request mismatch", map[string]any{"target": addr, "service": "grpc.health.v1.Health", "method": "Check",
+			"request": map[string]any{"unknown_field": 1}}, true},
+		{"descriptor sets not configured", map[string]any{"target": addr, "service": "grpc.health.v1.Health", "method": "Check",
+			"descriptor_set": "health"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Execute(context.Background(), &domain.Task{ID: uuid.New(), Payload: tt.payload})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Error == "" {
+				t.Fatal("expected logical error")
+			}
+			if result.Permanent != tt.wantPermanent {
+				t.Errorf("expected permanent=%t, got %t (%s)", tt.wantPermanent, result.Permanent, result.Error)
+			}
+		})
+	}
+
+	result := grpcStatusResult(status.New(codes.Unavailable, "connection refused"))
+	if result.Permanent {
+		t.Error("UNAVAILABLE should be retriable")
+	}
+	if result.Outputs["status_code"] != 14 || result.Outputs["status"] != "UNAVAILABLE" {
+		t.Errorf("unexpected outputs: %v", result.Outputs)
+	}
+}
+
+func TestGRPCCodeName(t *testing.T) {
+	tests := map[codes.Code]string{
+		codes.OK:                "OK",
+		codes.NotFound:          "NOT_FOUND",
+		codes.DeadlineExceeded:  "DEADLINE_EXCEEDED",
+		codes.ResourceExhausted: "RESOURCE_EXHAUSTED",
+		codes.Unavailable:       "UNAVAILABLE",
+	}
+	for code, want := range tests {
+		if got := grpcCodeName(code); got != want {
+			t.Errorf("grpcCodeName(%d) = %s, want %s", code, got, want)
 		}
 	}
 }
 
-func TestCalculateBackoff_NilPolicy(t *testing.T) {
-	got := calculateBackoff(1, nil)
-	if got != time.Second {
-		t.Errorf("expected 1s default, got %v", got)
+func TestParseGRPCConfig_Invalid(t *testing.T) {
+	invalid := []map[string]any{
+		{"service": "grpc.health.v1.Health", "method": "Check"},
+		{"target": "localhost:50051", "method": "Check"},
+		{"target": "localhost:50051", "service": "grpc.health.v1.Health"},
+		{"target": "localhost:50051", "service": "grpc.health.v1.Health", "method": "Check", "request": "{}"},
+		{"target": "localhost:50051", "service": "grpc.health.v1.Health", "method": "Check", "metadata": []any{"x"}},
+	}
+	for _, payload := range invalid {
+		if _, err := parseGRPCConfig(payload); !errors.Is(err, ErrInvalidGRPCConfig) {
+			t.Errorf("payload %v: expected ErrInvalidGRPCConfig, got %v", payload, err)
+		}
+	}
+}
+
+// --- Script Tests ---
+
+func runScriptTask(t *testing.T, payload map[string]any) *ExecutionResult {
+	t.Helper()
+	result, err := (&ScriptExecutor{}).Execute(context.Background(), &domain.Task{ID: uuid.New(), StepID: "reshape", Payload: payload})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result
+}
+
+func TestScriptExecutor_Outputs(t *testing.T) {
+	source := `
+def main():
+    totals = {}
+    for order in inputs["orders"]:
+        totals[order["customer"]] = totals.get(order["customer"], 0) + order["amount"]
+    top = sorted(totals.items(), key = lambda kv: -kv[1])
+    return {
+        "totals": totals,
+        "top": top[0][0],
+        "count": steps["fetch"]["outputs"]["count"],
+        "encoded": json.encode({"n": len(totals)}),
+    }
+`
+	ctx := engine.NewContext(map[string]any{"orders": []any{
+		map[string]any{"customer": "a", "amount": float64(10)},
+		map[string]any{"customer": "b", "amount": float64(25)},
+		map[string]any{"customer": "a", "amount": float64(5)},
+	}})
+	ctx.AddStepResult("fetch", map[string]any{"count": float64(3)}, "SUCCEEDED")
+
+	result := runScriptTask(t, map[string]any{"source": source, engine.StepGlobalsKey: engine.StepGlobals(ctx)})
+	if result.Error != "" {
+		t.Fatalf("unexpected logical error: %s", result.Error)
+	}
+
+	totals, _ := result.Outputs["totals"].(map[string]any)
+	if totals["a"] != int64(15) || totals["b"] != int64(25) {
+		t.Errorf("unexpected totals: %v", totals)
+	}
+	if result.Outputs["top"] != "b" || result.Outputs["count"] != int64(3) {
+		t.Errorf("unexpected outputs: %v", result.Outputs)
+	}
+	if result.Outputs["encoded"] != `{"n":2}` {
+		t.Errorf("unexpected encoded: %v", result.Outputs["encoded"])
+	}
+
+	// Не словарь — оборачивается в result
+	result = runScriptTask(t, map[string]any{"source": "def main():\n    return [1, 2.5]\n"})
+	if items, _ := result.Outputs["result"].([]any); len(items) != 2 || items[1] != 2.5 {
+		t.Errorf("unexpected result: %v", result.Outputs)
 	}
 }
 
-func TestCalculateBackoff_ZeroValues(t *testing.T) {
-	policy := &domain.RetryPolicy{
-		Backoff: "exponential",
-		// InitialDelayMs и MaxDelayMs = 0
+func TestScriptExecutor_Errors(t *testing.T) {
+	globals := map[string]any{"inputs": map[string]any{"items": []any{"a"}}, "steps": map[string]any{}}
+
+	tests := []struct {
+		name          string
+		payload       map[string]any
+		wantError     string
+		wantPermanent bool
+	}{
+		{"runtime error", map[string]any{"source": "def main():\n    return {\"x\": 1 // 0}\n"}, "division by zero", true},
+		{"read-only inputs", map[string]any{"source": "def main():\n    inputs[\"items\"].append(\"b\")\n    return {}\n", engine.StepGlobalsKey: globals}, "frozen", true},
+		{"no load", map[string]any{"source": "load(\"os.star\", \"os\")\ndef main():\n    return {}\n"}, "load not implemented", true},
+		{"max steps", map[string]any{"source": "def main():\n    n = 0\n    while True:\n        n += 1\n", "max_steps": 10000}, "too many steps", true},
+		// sorted — один шаг интерпретатора: время кончается раньше шагов
+		{"timeout", map[string]any{"source": "def main():\n    items = list(range(100000))\n    while True:\n        sorted(items, reverse = True)\n", "timeout_sec": 1}, "deadline exceeded", true},
+		{"memory", map[string]any{"source": "def main():\n    data = []\n    while True:\n        data.append(\"x\" * 16 * 1024)\n", "max_memory_mb": 8}, "memory limit of 8 MB", true},
+		{"memory in globals", map[string]any{"source": "cache = [\"x\" * 1024 * 1024 for _ in range(10)]\ndef main():\n    n = 0\n    while True:\n        n += 1\n", "max_memory_mb": 8}, "memory limit of 8 MB", true},
+		{"memory in result", map[string]any{"source": "def main():\n    return {\"data\": [\"x\" * 1024 * 1024 for _ in range(10)]}\n", "max_memory_mb": 8}, "memory limit of 8 MB", true},
+		{"non-string key", map[string]any{"source": "def main():\n    return {1: 2}\n"}, "not a string", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runScriptTask(t, tt.payload)
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("expected error containing %q, got %q", tt.wantError, result.Error)
+			}
+			if result.Permanent != tt.wantPermanent {
+				t.Errorf("expected permanent=%t, got %t", tt.wantPermanent, result.Permanent)
+			}
+		})
 	}
+}
 
-	got := calculateBackoff(1, policy)
-	if got != time.Second {
-		t.Errorf("expected 1s default for zero InitialDelayMs, got %v", got)
+func TestScriptExecutor_MemoryExcludesGlobals(t *testing.T) {
+	// inputs больше лимита: их создал не скрипт, они не учитываются
+	items := make([]any, 0, 10)
+	for range 10 {
+		items = append(items, strings.Repeat("x", 1<<20))
+	}
+	result := runScriptTask(t, map[string]any{
+		"source":        "def main():\n    data = inputs[\"items\"]\n    return {\"count\": len(data)}\n",
+		"max_memory_mb": 8,
+		engine.StepGlobalsKey: map[string]any{
+			"inputs": map[string]any{"items": items},
+			"steps":  map[string]any{},
+		},
+	})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	if result.Outputs["count"] != int64(10) {
+		t.Errorf("unexpected outputs: %v", result.Outputs)
+	}
+}
+
+// --- Wasm Tests ---
+
+// wasmModules — WasmModuleStore в памяти.
+type wasmModules map[string][]byte
+
+func (m wasmModules) GetByDigest(_ context.Context, digest string) (*domain.WasmModule, error) {
+	data, ok := m[digest]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return &domain.WasmModule{Digest: digest, Data: data}, nil
+}
+
+// buildWasmModule собирает тестовый модуль wasmhost/testdata/stepmod.
+func buildWasmModule(t *testing.T) []byte {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	out := filepath.Join(t.TempDir(), "stepmod.wasm")
+	cmd := exec.Command(goBin, "build", "-o", out, "../wasmhost/testdata/stepmod")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build stepmod: %v: %s", err, output)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWasmExecutor_Execute(t *testing.T) {
+	data := buildWasmModule(t)
+	digest := engine.WasmDigest(data)
+	executor := NewWasmExecutor(wasmModules{digest: data})
+	defer executor.Close()
+
+	run := func(config map[string]any) *ExecutionResult {
+		t.Helper()
+		payload := map[string]any{"module": digest, engine.StepGlobalsKey: map[string]any{"inputs": map[string]any{"user": "ann"}}}
+		for k, v := range config {
+			payload[k] = v
+		}
+		result, err := executor.Execute(context.Background(), &domain.Task{ID: uuid.New(), Payload: payload})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	result := run(map[string]any{"mode": "echo", "threshold": 5})
+	if result.Error != "" {
+		t.Fatalf("unexpected logical error: %s", result.Error)
+	}
+	config, _ := result.Outputs["config"].(map[string]any)
+	if config["threshold"] != float64(5) || config["module"] != nil {
+		t.Errorf("expected only module config on stdin, got %v", config)
+	}
+	inputs, _ := result.Outputs["context"].(map[string]any)["inputs"].(map[string]any)
+	if inputs["user"] != "ann" {
+		t.Errorf("expected run context on stdin, got %v", result.Outputs["context"])
+	}
+
+	// Не объект — оборачивается в result
+	result = run(map[string]any{"mode": "scalar"})
+	if result.Outputs["result"] != float64(42) {
+		t.Errorf("unexpected outputs: %v", result.Outputs)
+	}
+
+	result = run(map[string]any{"mode": "fail"})
+	if !result.Permanent || result.Outputs["exit_code"] != 3 || !strings.Contains(result.Error, "boom") {
+		t.Errorf("expected permanent exit error with stderr, got %+v", result)
+	}
+
+	result = run(map[string]any{"mode": "loop", "timeout_sec": 1})
+	if !result.Permanent || !strings.Contains(result.Error, "timed out") {
+		t.Errorf("expected permanent timeout, got %+v", result)
+	}
+}
+
+func TestWasmExecutor_Errors(t *testing.T) {
+	digest := engine.WasmDigest([]byte("module"))
+
+	tests := []struct {
+		name      string
+		executor  *WasmExecutor
+		payload   map[string]any
+		wantError string
+	}{
+		{"no module", NewWasmExecutor(wasmModules{}), map[string]any{}, "module is required"},
+		{"not a digest", NewWasmExecutor(wasmModules{}), map[string]any{"module": "score@1.0"}, "must be a digest"},
+		{"not found", NewWasmExecutor(wasmModules{}), map[string]any{"module": digest}, "not found"},
+		{"not configured", NewWasmExecutor(nil), map[string]any{"module": digest}, "not configured"},
+		{"digest mismatch", NewWasmExecutor(wasmModules{digest: []byte("other")}), map[string]any{"module": digest}, "does not match"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.executor.Close()
+			result, err := tt.executor.Execute(context.Background(), &domain.Task{ID: uuid.New(), Payload: tt.payload})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.Permanent || !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("expected permanent error containing %q, got %+v", tt.wantError, result)
+			}
+		})
+	}
+}
+
+// --- Plugin Tests ---
+
+// buildTestPlugin собирает тестовый плагин plugin/testdata/testplugin.
+func buildTestPlugin(t *testing.T) string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	out := filepath.Join(t.TempDir(), "testplugin")
+	cmd := exec.Command(goBin, "build", "-o", out, "../plugin/testdata/testplugin")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build testplugin: %v: %s", err, output)
+	}
+	return out
+}
+
+func TestPluginExecutor_Execute(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterPluginExecutors([]*plugin.Manifest{{
+		Name:      "test",
+		Protocol:  plugin.ProtocolVersion,
+		Command:   buildTestPlugin(t),
+		Args:      []string{"serve", "slack_post", "slack_upload"},
+		StepTypes: []string{"slack_post", "slack_upload"},
+		Dir:       t.TempDir(),
+	}}, nil)
+	defer r.Close()
+
+	run := func(stepType string, payload map[string]any) (*ExecutionResult, error) {
+		t.Helper()
+		executor, err := r.Get(stepType)
+		if err != nil {
+			t.Fatalf("expected executor for %s: %v", stepType, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return executor.Execute(ctx, &domain.Task{ID: uuid.New(), RunID: uuid.New(), StepID: "notify", Type: stepType, Attempt: 2, Payload: payload})
+	}
+
+	result, err := run("slack_upload", map[string]any{"channel": "#ops"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config, _ := result.Outputs["config"].(map[string]any)
+	if result.Error != "" || result.Outputs["type"] != "slack_upload" || result.Outputs["attempt"] != float64(2) || config["channel"] != "#ops" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	result, err = run("slack_post", map[string]any{"error": "channel_not_found", "permanent": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Error != "channel_not_found" || !result.Permanent {
+		t.Errorf("expected permanent logical error, got %+v", result)
+	}
+
+	// Падение процесса — инфраструктурная ошибка (повтор по RetryPolicy)
+	_, err = run("slack_post", map[string]any{"crash": true})
+	if !errors.Is(err, ErrPluginRun) || !strings.Contains(err.Error(), "exit status 2") {
+		t.Errorf("expected ErrPluginRun with exit status, got %v", err)
+	}
+}
+
+// --- Registry Tests ---
+
+func TestNewRegistry_DefaultExecutors(t *testing.T) {
+	r := NewRegistry()
+
+	// Должны быть зарегистрированы http, delay, transform, poll, script
+	for _, stepType := range []string{"http", "delay", "transform", "poll", "script"} {
+		executor, err := r.Get(stepType)
+		if err != nil {
+			t.Errorf("expected executor for %s, got error: %v", stepType, err)
+		}
+		if executor == nil {
+			t.Errorf("executor for %s should not be nil", stepType)
+		}
+	}
+}
+
+func TestRegistry_UnknownType(t *testing.T) {
+	r := NewRegistry()
+
+	_, err := r.Get("unknown")
+	if err == nil {
+		t.Error("expected error for unknown step type")
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+
+	// Регистрируем кастомный executor
+	r.Register("custom", &DelayExecutor{})
+
+	executor, err := r.Get("custom")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if executor == nil {
+		t.Error("custom executor should be registered")
 	}
 }
 
@@ -432,6 +1466,23 @@ func TestShouldRetryHTTPStatus(t *testing.T) {
 	}
 }
 
+func TestShouldRetry_Permanent(t *testing.T) {
+	w := &Worker{}
+	permanent := &ExecutionResult{
+		Outputs:   map[string]any{"status_code": int(codes.NotFound)},
+		Error:     "grpc NOT_FOUND: unknown service",
+		Permanent: true,
+	}
+
+	if w.shouldRetry(permanent, nil, &domain.RetryPolicy{MaxAttempts: 3}) {
+		t.Error("permanent error should not be retried")
+	}
+	// OnStatus явно задаёт повторяемые коды и имеет приоритет
+	if !w.shouldRetry(permanent, nil, &domain.RetryPolicy{MaxAttempts: 3, OnStatus: []int{int(codes.NotFound)}}) {
+		t.Error("code from OnStatus should be retried")
+	}
+}
+
 // --- Worker Tests ---
 
 func TestNew_DefaultConfig(t *testing.T) {
@@ -478,6 +1529,28 @@ func TestWorker_IsStopped(t *testing.T) {
 	}
 }
 
+func TestWorker_ScheduleTaskStops(t *testing.T) {
+	w := New(Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Отложенная task учитывается в wg и не держит остановку до таймера
+	w.wg.Add(1)
+	w.scheduleTask(ctx, uuid.New(), time.Hour)
+	w.wg.Done()
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduled task was not stopped with the worker")
+	}
+}
+
 func TestNew_CustomRegistry(t *testing.T) {
 	r := NewRegistry()
 	r.Register("custom", &DelayExecutor{})
@@ -0,0 +1,9 @@
+-- Миграция 0004: Отложенные попытки tasks
+-- next_attempt_at — время, раньше которого QUEUED task не берётся в работу.
+-- Используется poll шагами: между итерациями task возвращается в QUEUED
+-- и не занимает слот воркера.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS next_attempt_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_queued_next_attempt
+    ON tasks(next_attempt_at) WHERE status = 'QUEUED';
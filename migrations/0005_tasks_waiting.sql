@@ -0,0 +1,8 @@
+-- Миграция 0005: Ожидание внешнего сигнала
+-- WAITING — task шага approval / wait_for_signal ждёт сигнала через API
+-- и не занимает слот воркера. Дедлайн ожидания хранится в next_attempt_at.
+
+ALTER TYPE task_status ADD VALUE IF NOT EXISTS 'WAITING';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_waiting_deadline
+    ON tasks(next_attempt_at) WHERE status = 'WAITING';
@@ -0,0 +1,8 @@
+-- Миграция 0006: Компенсации (saga)
+-- COMPENSATING — run упал, выполняются компенсации завершённых шагов.
+-- COMPENSATED / COMPENSATION_FAILED — итог компенсации упавшего run.
+-- Tasks компенсаций хранятся в tasks со step_id <step_id>.compensate.
+
+ALTER TYPE run_status ADD VALUE IF NOT EXISTS 'COMPENSATING';
+ALTER TYPE run_status ADD VALUE IF NOT EXISTS 'COMPENSATED';
+ALTER TYPE run_status ADD VALUE IF NOT EXISTS 'COMPENSATION_FAILED';
@@ -0,0 +1,21 @@
+-- Миграция 0007: Триггеры — запуск flow по внешним событиям
+-- type — источник событий (amqp), config — его параметры (exchange, binding_key).
+-- filter — условие над событием, input_mapping — шаблоны inputs run.
+
+CREATE TABLE IF NOT EXISTS triggers (
+    id uuid PRIMARY KEY,
+    flow_id uuid NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    type text NOT NULL,
+    config jsonb NOT NULL DEFAULT '{}',
+    filter text,
+    input_mapping jsonb,
+    enabled boolean NOT NULL DEFAULT true,
+    last_fired_at timestamptz,
+    last_run_id uuid REFERENCES runs(id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_triggers_flow ON triggers(flow_id);
+CREATE INDEX IF NOT EXISTS idx_triggers_type_enabled ON triggers(type) WHERE enabled = true;
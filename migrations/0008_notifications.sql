@@ -0,0 +1,40 @@
+-- Миграция 0008: Уведомления о завершении runs
+-- notification_rules — правила (событие, канал, шаблоны сообщения).
+-- notification_deliveries — история доставки с повторами: Orchestrator создаёт
+-- PENDING запись при завершении run и отправляет её, пока не исчерпаны попытки.
+
+CREATE TABLE IF NOT EXISTS notification_rules (
+    id uuid PRIMARY KEY,
+    flow_id uuid NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    event text NOT NULL,
+    threshold integer,
+    channel text NOT NULL,
+    config jsonb NOT NULL DEFAULT '{}',
+    enabled boolean NOT NULL DEFAULT true,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_rules_flow ON notification_rules(flow_id);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id uuid PRIMARY KEY,
+    rule_id uuid NOT NULL REFERENCES notification_rules(id) ON DELETE CASCADE,
+    run_id uuid NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+    status text NOT NULL DEFAULT 'PENDING',
+    subject text,
+    body text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamptz,
+    sent_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    -- одно уведомление на run по правилу
+    UNIQUE (rule_id, run_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due
+    ON notification_deliveries(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_rule
+    ON notification_deliveries(rule_id, created_at DESC);
@@ -0,0 +1,4 @@
+-- Миграция 0009: Результат run
+-- outputs — значения FlowSpec.outputs, вычисленные при успешном завершении run.
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS outputs jsonb;
@@ -0,0 +1,9 @@
+-- Миграция 0010: Повторный запуск runs
+-- retry_of — исходный run, из которого повторный run переиспользует
+-- результаты успешных шагов (их tasks копируются в новый run).
+-- retry_from_step — шаг, с которого перезапущен run (NULL — повтор упавших шагов).
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS retry_of uuid REFERENCES runs(id) ON DELETE SET NULL;
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS retry_from_step text;
+
+CREATE INDEX IF NOT EXISTS idx_runs_retry_of ON runs(retry_of) WHERE retry_of IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Миграция 0011: Дедлайн и SLA runs
+-- deadline_at — started_at + FlowSpec.timeout_sec: просроченный RUNNING run
+-- Orchestrator переводит в FAILED, его незавершённые tasks — в CANCELLED.
+-- sla_at — started_at + FlowSpec.sla_sec: после него создаются уведомления
+-- sla_breach, sla_breached_at защищает от повторных уведомлений.
+
+ALTER TYPE task_status ADD VALUE IF NOT EXISTS 'CANCELLED';
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS deadline_at timestamptz;
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS sla_at timestamptz;
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS sla_breached_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_runs_running_deadline
+    ON runs(deadline_at) WHERE status = 'RUNNING';
+CREATE INDEX IF NOT EXISTS idx_runs_running_sla
+    ON runs(sla_at) WHERE status = 'RUNNING' AND sla_breached_at IS NULL;
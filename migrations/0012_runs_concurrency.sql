@@ -0,0 +1,10 @@
+-- Миграция 0012: Ограничение параллельных runs flow
+-- concurrency_key — значение FlowSpec.concurrency.key для inputs run:
+-- лимит max_concurrent_runs считается по RUNNING runs flow с тем же ключом.
+-- waiting_reason — почему PENDING run ещё не запущен (лимит concurrency).
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS concurrency_key text;
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS waiting_reason text;
+
+CREATE INDEX IF NOT EXISTS idx_runs_flow_running
+    ON runs(flow_id, concurrency_key) WHERE status = 'RUNNING';
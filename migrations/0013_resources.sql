@@ -0,0 +1,34 @@
+-- Миграция 0013: Именованные ресурсы (мьютексы / семафоры) для шагов
+-- resources — ресурсы с ёмкостью, управляются через API.
+-- resource_leases — занятые слоты: шаг run держит слот от dispatch до
+-- завершения task. Хранятся в БД, чтобы рестарт Orchestrator не терял слоты.
+-- resource_waiters — очередь шагов, ожидающих слота (FIFO по created_at).
+
+CREATE TABLE IF NOT EXISTS resources (
+    name text PRIMARY KEY,
+    capacity integer NOT NULL CHECK (capacity > 0),
+    description text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS resource_leases (
+    resource text NOT NULL REFERENCES resources(name) ON DELETE CASCADE,
+    run_id uuid NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+    step_id text NOT NULL,
+    acquired_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (resource, run_id, step_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_resource_leases_run ON resource_leases(run_id, step_id);
+
+CREATE TABLE IF NOT EXISTS resource_waiters (
+    resource text NOT NULL REFERENCES resources(name) ON DELETE CASCADE,
+    run_id uuid NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+    step_id text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (resource, run_id, step_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_resource_waiters_queue ON resource_waiters(resource, created_at);
+CREATE INDEX IF NOT EXISTS idx_resource_waiters_run ON resource_waiters(run_id, step_id);
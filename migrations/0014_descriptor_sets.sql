@@ -0,0 +1,14 @@
+-- Миграция 0014: Загруженные protobuf descriptor sets для шага grpc
+-- descriptor_sets — FileDescriptorSet (protoc --descriptor_set_out
+-- --include_imports), на который шаг ссылается по имени
+-- (config.descriptor_set). Повторная загрузка под тем же именем заменяет
+-- набор; sha256 позволяет воркерам кешировать разобранную схему.
+
+CREATE TABLE IF NOT EXISTS descriptor_sets (
+    name text PRIMARY KEY,
+    data bytea NOT NULL,
+    sha256 text NOT NULL,
+    services text[] NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
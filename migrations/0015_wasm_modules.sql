@@ -0,0 +1,17 @@
+-- Миграция 0015: WebAssembly-модули для шага wasm
+-- wasm_modules — загруженные модули, адресуемые по содержимому: шаг
+-- ссылается на модуль дайджестом (config.module = "sha256:<hex>"),
+-- поэтому загрузка новой сборки не меняет уже опубликованные версии flow.
+-- name и version — человекочитаемая метка сборки; пара уникальна.
+
+CREATE TABLE IF NOT EXISTS wasm_modules (
+    digest text PRIMARY KEY,
+    name text NOT NULL,
+    version text NOT NULL,
+    size bigint NOT NULL,
+    data bytea NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (name, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wasm_modules_name ON wasm_modules(name, created_at DESC);
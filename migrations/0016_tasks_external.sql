@@ -0,0 +1,13 @@
+-- Миграция 0016: External tasks
+-- Tasks шага external выполняются внешними воркерами через API
+-- (fetch-and-lock). worker_id — воркер, заблокировавший task; дедлайн
+-- блокировки хранится в next_attempt_at RUNNING task, после него task
+-- считается неудачной попыткой и повторяется по RetryPolicy шага.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS worker_id text;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_external_topic
+    ON tasks((payload->>'topic'), created_at) WHERE type = 'external' AND status = 'QUEUED';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_external_lock
+    ON tasks(next_attempt_at) WHERE type = 'external' AND status = 'RUNNING';